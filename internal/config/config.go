@@ -23,7 +23,7 @@ func Load() *Config {
         DBUser:     getEnv("DB_USER", "postgres"),
         DBPassword: getEnv("DB_PASSWORD", "password"),
         DBName:     getEnv("DB_NAME", "paydeya"),
-        RedisURL:   getEnv("REDIS_URL", "localhost:6379"),
+        RedisURL:   getEnv("REDIS_URL", ""), // пусто: Redis не используется
         JWTSecret:  getEnv("JWT_SECRET", "dev-secret-key"),
         Port:       getEnv("PORT", "8080"),
     }
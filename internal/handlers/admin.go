@@ -1,6 +1,7 @@
 package handlers
 
 import (
+    "errors"
     "net/http"
     "strconv"
 
@@ -45,7 +46,7 @@ func (h *AdminHandler) GetStatistics(c *gin.Context) {
 // @Tags admin
 // @Produce json
 // @Security ApiKeyAuth
-// @Param role query string false "Фильтр по роли" Enums(student, teacher, admin)
+// @Param role query string false "Фильтр по роли" Enums(student, teacher, moderator, admin)
 // @Param page query int false "Номер страницы" default(1)
 // @Param limit query int false "Количество записей на странице" default(20)
 // @Success 200 {object} UsersListResponse "Список пользователей"
@@ -79,6 +80,52 @@ func (h *AdminHandler) GetUsers(c *gin.Context) {
     })
 }
 
+// GetUserAudit godoc
+// @Summary Журнал аудита пользователя
+// @Description Возвращает действия с аккаунтом пользователя и действия, выполненные им самим, в том числе запросы при входе под другими пользователями
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID пользователя"
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество записей на странице" default(50)
+// @Success 200 {object} AuditListResponse "Записи журнала"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 401 {object} AuthErrorResponse "Требуется авторизация"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 500 {object} InternalErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/users/{id}/audit [get]
+func (h *AdminHandler) GetUserAudit(c *gin.Context) {
+    userID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+        return
+    }
+
+    page, _ := strconv.Atoi(c.Query("page"))
+    limit, _ := strconv.Atoi(c.Query("limit"))
+
+    if page <= 0 {
+        page = 1
+    }
+    if limit <= 0 || limit > 200 {
+        limit = 50
+    }
+
+    entries, total, err := h.adminService.GetUserAudit(c.Request.Context(), userID, page, limit)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get audit log"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "entries": entries,
+        "total":   total,
+        "page":    page,
+        "limit":   limit,
+    })
+}
+
 // BlockUser godoc
 // @Summary Заблокировать пользователя
 // @Description Блокирует пользователя по ID с указанием причины
@@ -152,6 +199,56 @@ func (h *AdminHandler) CreateSubject(c *gin.Context) {
         "subject": req,
     })
 }
+// ChangeUserRole godoc
+// @Summary Изменить роль пользователя
+// @Description Назначает пользователю одну из существующих ролей. Изменить собственную роль нельзя. Роль записана в access токене, поэтому новые права пользователь получит после обновления токена (access токен живет 15 минут)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID пользователя"
+// @Param input body models.ChangeUserRoleRequest true "Новая роль"
+// @Success 200 {object} SuccessResponse "Роль изменена"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 401 {object} AuthErrorResponse "Требуется авторизация"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} UserNotFoundErrorResponse "Пользователь не найден"
+// @Failure 500 {object} InternalErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/users/{id}/role [put]
+func (h *AdminHandler) ChangeUserRole(c *gin.Context) {
+    adminID := c.GetInt("userID")
+    userID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+        return
+    }
+
+    var req models.ChangeUserRoleRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    err = h.adminService.ChangeUserRole(c.Request.Context(), adminID, userID, req.Role)
+    switch {
+    case errors.Is(err, services.ErrUnknownRole), errors.Is(err, services.ErrCannotChangeOwnRole):
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    case errors.Is(err, services.ErrUserNotFound):
+        c.JSON(http.StatusNotFound, gin.H{"error": "No such user"})
+        return
+    case err != nil:
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change role"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Role changed successfully",
+        "userId":  userID,
+        "role":    req.Role,
+    })
+}
+
 // Response models for Swagger
 
 // ErrorResponse represents error response
@@ -203,4 +300,13 @@ type UsersListResponse struct {
     Total int                     `json:"total" example:"150"`
     Page  int                     `json:"page" example:"1"`
     Limit int                     `json:"limit" example:"20"`
-}
\ No newline at end of file
+}
+
+// AuditListResponse represents audit log page
+// @Description Страница журнала аудита
+type AuditListResponse struct {
+    Entries []models.AuditEntry `json:"entries"`
+    Total   int                 `json:"total" example:"120"`
+    Page    int                 `json:"page" example:"1"`
+    Limit   int                 `json:"limit" example:"50"`
+}
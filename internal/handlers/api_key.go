@@ -0,0 +1,124 @@
+package handlers
+
+import (
+    "errors"
+    "log"
+    "net/http"
+    "strconv"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+type APIKeyHandler struct {
+    apiKeyService *services.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService *services.APIKeyService) *APIKeyHandler {
+    return &APIKeyHandler{apiKeyService: apiKeyService}
+}
+
+// ListKeys godoc
+// @Summary API ключи
+// @Description Возвращает персональные API ключи пользователя (без самих значений ключей)
+// @Tags profile
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} APIKeysListResponse "Список ключей"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /profile/api-keys [get]
+func (h *APIKeyHandler) ListKeys(c *gin.Context) {
+    keys, err := h.apiKeyService.ListKeys(c.Request.Context(), c.GetInt("userID"))
+    if err != nil {
+        log.Printf("Failed to list api keys: %v", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get API keys"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "apiKeys": keys,
+        "total":   len(keys),
+        "scopes":  models.APIKeyScopes,
+    })
+}
+
+// CreateKey godoc
+// @Summary Создать API ключ
+// @Description Создает ключ для интеграций и скриптов. Ключ передается в заголовке X-API-Key и действует от имени пользователя только в пределах выбранных областей (materials:read, materials:write, progress:read, progress:write, profile:read, profile:write, admin). Значение ключа показывается один раз
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param input body models.CreateAPIKeyRequest true "Название, области доступа и срок действия"
+// @Success 201 {object} models.CreateAPIKeyResponse "Ключ создан"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 409 {object} ErrorResponse "Достигнут лимит ключей"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /profile/api-keys [post]
+func (h *APIKeyHandler) CreateKey(c *gin.Context) {
+    var req models.CreateAPIKeyRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    response, err := h.apiKeyService.CreateKey(c.Request.Context(), c.GetInt("userID"), &req, clientInfo(c))
+    if errors.Is(err, services.ErrTooManyAPIKeys) {
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+        return
+    }
+    if err != nil {
+        log.Printf("Failed to create api key: %v", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+        return
+    }
+
+    c.JSON(http.StatusCreated, response)
+}
+
+// RevokeKey godoc
+// @Summary Отозвать API ключ
+// @Description Ключ перестает действовать сразу
+// @Tags profile
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID ключа"
+// @Success 200 {object} SuccessResponse "Ключ отозван"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 404 {object} ErrorResponse "Ключ не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /profile/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeKey(c *gin.Context) {
+    keyID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+        return
+    }
+
+    err = h.apiKeyService.RevokeKey(c.Request.Context(), c.GetInt("userID"), keyID, clientInfo(c))
+    if errors.Is(err, services.ErrAPIKeyNotFound) {
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        return
+    }
+    if err != nil {
+        log.Printf("Failed to revoke api key: %v", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "API key revoked",
+    })
+}
+
+// Response models for Swagger
+
+// APIKeysListResponse represents API keys list
+// @Description Ответ со списком API ключей
+type APIKeysListResponse struct {
+    APIKeys []models.APIKey `json:"apiKeys"`
+    Total   int             `json:"total" example:"2"`
+    Scopes  []string        `json:"scopes" example:"materials:read,materials:write"`
+}
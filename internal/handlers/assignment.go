@@ -0,0 +1,215 @@
+package handlers
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+type AssignmentHandler struct {
+    assignmentService *services.AssignmentService
+}
+
+func NewAssignmentHandler(assignmentService *services.AssignmentService) *AssignmentHandler {
+    return &AssignmentHandler{assignmentService: assignmentService}
+}
+
+// SubmitWork godoc
+// @Summary Сдать задание
+// @Description Отправляет ответ на задание с ручной проверкой: текст и/или файл (pdf, jpg, jpeg, png, до 20MB) в зависимости от настроек задания. Повторная отправка заменяет работу и снова ставит ее в очередь, пока преподаватель не выставил оценку
+// @Tags progress
+// @Accept multipart/form-data
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param blockId path string true "ID блока задания"
+// @Param text formData string false "Текстовый ответ"
+// @Param file formData file false "Файл с решением"
+// @Success 201 {object} models.AssignmentSubmission "Работа отправлена"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 404 {object} ErrorResponse "Материал или задание не найдено"
+// @Failure 409 {object} ErrorResponse "Работа уже оценена"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /student/materials/{id}/assignments/{blockId}/submission [post]
+func (h *AssignmentHandler) SubmitWork(c *gin.Context) {
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    file, err := c.FormFile("file")
+    if errors.Is(err, http.ErrMissingFile) || errors.Is(err, http.ErrNotMultipart) {
+        file, err = nil, nil
+    }
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid form data"})
+        return
+    }
+
+    submission, err := h.assignmentService.Submit(c.Request.Context(), c.GetInt("userID"), materialID, c.Param("blockId"), c.PostForm("text"), file)
+    if err != nil {
+        respondMaterialError(c, err, "Failed to submit assignment")
+        return
+    }
+
+    c.JSON(http.StatusCreated, submission)
+}
+
+// GetMySubmission godoc
+// @Summary Моя работа по заданию
+// @Description Возвращает работу текущего ученика по заданию со статусом проверки, баллами и отзывом преподавателя
+// @Tags progress
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param blockId path string true "ID блока задания"
+// @Success 200 {object} models.AssignmentSubmission "Работа"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 404 {object} ErrorResponse "Работа не найдена"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /student/materials/{id}/assignments/{blockId}/submission [get]
+func (h *AssignmentHandler) GetMySubmission(c *gin.Context) {
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    submission, err := h.assignmentService.GetMySubmission(c.Request.Context(), c.GetInt("userID"), materialID, c.Param("blockId"))
+    if err != nil {
+        respondMaterialError(c, err, "Failed to get submission")
+        return
+    }
+
+    c.JSON(http.StatusOK, submission)
+}
+
+// ListMySubmissions godoc
+// @Summary Мои задания
+// @Description Возвращает все работы текущего ученика со статусами проверки (submitted - на проверке, graded - оценена, returned - возвращена на доработку), последние первыми
+// @Tags progress
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} SubmissionsListResponse "Работы"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /student/assignments [get]
+func (h *AssignmentHandler) ListMySubmissions(c *gin.Context) {
+    submissions, err := h.assignmentService.ListMySubmissions(c.Request.Context(), c.GetInt("userID"))
+    if err != nil {
+        respondMaterialError(c, err, "Failed to get submissions")
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "submissions": submissions,
+        "total":       len(submissions),
+    })
+}
+
+// ListQueue godoc
+// @Summary Очередь проверки заданий
+// @Description Возвращает работы учеников по материалу materialId (нужно право редактировать его) или по всем материалам текущего преподавателя. Старые работы первыми
+// @Tags teacher
+// @Produce json
+// @Security ApiKeyAuth
+// @Param materialId query int false "ID материала"
+// @Param status query string false "Фильтр по статусу" Enums(submitted, graded, returned)
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество записей на странице" default(20)
+// @Success 200 {object} SubmissionsListResponse "Очередь проверки"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Материал не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /teacher/assignments/submissions [get]
+func (h *AssignmentHandler) ListQueue(c *gin.Context) {
+    var materialID int
+    if value := c.Query("materialId"); value != "" {
+        id, err := strconv.Atoi(value)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+            return
+        }
+        materialID = id
+    }
+
+    page, _ := strconv.Atoi(c.Query("page"))
+    limit, _ := strconv.Atoi(c.Query("limit"))
+
+    if page == 0 {
+        page = 1
+    }
+    if limit == 0 {
+        limit = 20
+    }
+
+    submissions, total, err := h.assignmentService.ListQueue(
+        c.Request.Context(), c.GetInt("userID"), c.GetString("userRole"), materialID, c.Query("status"), page, limit,
+    )
+    if err != nil {
+        respondMaterialError(c, err, "Failed to get submissions")
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "submissions": submissions,
+        "total":       total,
+        "page":        page,
+        "limit":       limit,
+    })
+}
+
+// ReviewSubmission godoc
+// @Summary Проверить работу
+// @Description Выставляет баллы (status=graded, от 0 до maxScore задания) или возвращает работу на доработку (status=returned, нужен отзыв). Оценку можно исправить повторной проверкой. Оценка ученика за материал пересчитывается
+// @Tags teacher
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID работы"
+// @Param input body models.ReviewSubmissionRequest true "Решение преподавателя"
+// @Success 200 {object} models.AssignmentSubmission "Работа проверена"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Работа не найдена"
+// @Failure 409 {object} ErrorResponse "Работа возвращена на доработку"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /teacher/assignments/submissions/{id}/review [post]
+func (h *AssignmentHandler) ReviewSubmission(c *gin.Context) {
+    submissionID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid submission ID"})
+        return
+    }
+
+    var req models.ReviewSubmissionRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    submission, err := h.assignmentService.Review(c.Request.Context(), c.GetInt("userID"), c.GetString("userRole"), submissionID, &req)
+    if err != nil {
+        respondMaterialError(c, err, "Failed to review submission")
+        return
+    }
+
+    c.JSON(http.StatusOK, submission)
+}
+
+// Response models for Swagger
+
+// SubmissionsListResponse represents assignment submissions list
+// @Description Ответ со списком работ по заданиям
+type SubmissionsListResponse struct {
+    Submissions []models.AssignmentSubmission `json:"submissions"`
+    Total       int                           `json:"total" example:"5"`
+    Page        int                           `json:"page,omitempty" example:"1"`
+    Limit       int                           `json:"limit,omitempty" example:"20"`
+}
@@ -1,25 +1,41 @@
 package handlers
 
 import (
+    "errors"
+    "log"
     "net/http"
+    "strconv"
 
     "paydeya-backend/internal/models"
     "paydeya-backend/internal/services"
+    "paydeya-backend/internal/utils"
 
     "github.com/gin-gonic/gin"
 )
 
 type AuthHandler struct {
-    authService *services.AuthService
+    authService      *services.AuthService
+    twoFactorService *services.TwoFactorService
 }
 
-func NewAuthHandler(authService *services.AuthService) *AuthHandler {
-    return &AuthHandler{authService: authService}
+func NewAuthHandler(authService *services.AuthService, twoFactorService *services.TwoFactorService) *AuthHandler {
+    return &AuthHandler{
+        authService:      authService,
+        twoFactorService: twoFactorService,
+    }
+}
+
+// clientInfo описывает устройство, с которого пришел запрос
+func clientInfo(c *gin.Context) models.ClientInfo {
+    return models.ClientInfo{
+        UserAgent: c.Request.UserAgent(),
+        IP:        c.ClientIP(),
+    }
 }
 
 // Register godoc
 // @Summary Регистрация пользователя
-// @Description Создает нового пользователя и возвращает токены
+// @Description Создает нового пользователя и возвращает токены. Доступны роли student и teacher; администратор регистрируется по приглашению (inviteToken)
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -43,7 +59,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
     }
 
     // Генерируем токены
-    accessToken, refreshToken, err := h.authService.GenerateTokens(user)
+    accessToken, refreshToken, err := h.authService.GenerateTokens(c.Request.Context(), user, clientInfo(c))
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate tokens"})
         return
@@ -59,14 +75,15 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 // Login godoc
 // @Summary Вход в систему
-// @Description Аутентифицирует пользователя и возвращает токены
+// @Description Аутентифицирует пользователя и возвращает токены. Если включена двухфакторная аутентификация, вместо токенов возвращается challengeToken для /auth/2fa/verify
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param input body models.LoginRequest true "Данные для входа"
-// @Success 200 {object} models.AuthResponse "Успешный вход"
+// @Success 200 {object} models.AuthResponse "Успешный вход или требуется код второго фактора"
 // @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
 // @Failure 401 {object} InvalidDataErrorResponse "Неверные учетные данные"
+// @Failure 429 {object} ErrorResponse "Слишком много неудачных попыток, см. заголовок Retry-After"
 // @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
@@ -77,14 +94,98 @@ func (h *AuthHandler) Login(c *gin.Context) {
         return
     }
 
-    user, err := h.authService.Login(c.Request.Context(), &req)
+    user, err := h.authService.Login(c.Request.Context(), &req, c.ClientIP())
+    var throttled *services.LoginThrottledError
+    if errors.As(err, &throttled) {
+        c.Header("Retry-After", strconv.Itoa(throttled.RetryAfterSeconds()))
+        c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+        return
+    }
     if err != nil {
         c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
         return
     }
 
+    respondLogin(c, h.authService, h.twoFactorService, user)
+}
+
+// respondLogin завершает вход пользователя, личность которого уже подтверждена
+// (паролем или внешним провайдером): при включенной 2FA возвращает challenge
+// токен, иначе выдает токены
+func respondLogin(c *gin.Context, authService *services.AuthService, twoFactorService *services.TwoFactorService, user *models.User) {
+    twoFactorEnabled, err := twoFactorService.IsEnabled(c.Request.Context(), user.ID)
+    if err != nil {
+        log.Printf("Failed to check two-factor status for user %d: %v", user.ID, err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+        return
+    }
+    if twoFactorEnabled {
+        challengeToken, err := twoFactorService.CreateChallenge(c.Request.Context(), user.ID)
+        if err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+            return
+        }
+
+        c.JSON(http.StatusOK, models.AuthResponse{
+            Message:           "Two-factor authentication required",
+            TwoFactorRequired: true,
+            ChallengeToken:    challengeToken,
+            ExpiresIn:         int(utils.TwoFactorChallengeTTL.Seconds()),
+        })
+        return
+    }
+
     // Генерируем токены
-    accessToken, refreshToken, err := h.authService.GenerateTokens(user)
+    accessToken, refreshToken, err := authService.GenerateTokens(c.Request.Context(), user, clientInfo(c))
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate tokens"})
+        return
+    }
+
+    c.JSON(http.StatusOK, models.AuthResponse{
+        Message:      "Login successful",
+        AccessToken:  accessToken,
+        RefreshToken: refreshToken,
+        User:         user,
+    })
+}
+
+// VerifyTwoFactor godoc
+// @Summary Второй шаг входа
+// @Description Обменивает challenge токен и код из приложения-аутентификатора (или код восстановления) на токены. Challenge токен одноразовый
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body models.VerifyTwoFactorRequest true "Challenge токен и код"
+// @Success 200 {object} models.AuthResponse "Успешный вход"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 401 {object} ErrorResponse "Неверный код, истекший или уже использованный challenge токен"
+// @Failure 429 {object} ErrorResponse "Слишком много неверных кодов"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /auth/2fa/verify [post]
+func (h *AuthHandler) VerifyTwoFactor(c *gin.Context) {
+    var req models.VerifyTwoFactorRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    user, err := h.twoFactorService.VerifyChallenge(c.Request.Context(), req.ChallengeToken, req.Code)
+    switch {
+    case errors.Is(err, services.ErrTwoFactorLocked):
+        c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+        return
+    case errors.Is(err, services.ErrInvalidChallengeToken), errors.Is(err, services.ErrInvalidTwoFactorCode),
+        errors.Is(err, services.ErrTwoFactorNotEnabled):
+        c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+        return
+    case err != nil:
+        log.Printf("Failed to verify two-factor code: %v", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
+        return
+    }
+
+    accessToken, refreshToken, err := h.authService.GenerateTokens(c.Request.Context(), user, clientInfo(c))
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate tokens"})
         return
@@ -108,6 +209,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 // @Success 200 {object} models.AuthResponse "Токены обновлены"
 // @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
 // @Failure 401 {object} InvalidTokenErrorResponse "Невалидный токен"
+// @Failure 403 {object} ForbiddenErrorResponse "Аккаунт заблокирован"
 // @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
 // @Router /auth/refresh [post]
 func (h *AuthHandler) Refresh(c *gin.Context) {
@@ -118,10 +220,18 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
         return
     }
 
-    accessToken, refreshToken, err := h.authService.RefreshTokens(req.RefreshToken)
-    if err != nil {
+    accessToken, refreshToken, err := h.authService.RefreshTokens(c.Request.Context(), req.RefreshToken, clientInfo(c))
+    switch {
+    case errors.Is(err, services.ErrAccountBlocked):
+        c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+        return
+    case errors.Is(err, services.ErrInvalidRefreshToken), errors.Is(err, services.ErrRefreshTokenReused):
         c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
         return
+    case err != nil:
+        log.Printf("Failed to refresh tokens: %v", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh tokens"})
+        return
     }
 
     c.JSON(http.StatusOK, models.AuthResponse{
@@ -133,24 +243,65 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 
 // Logout godoc
 // @Summary Выход из системы
-// @Description Завершает сеанс пользователя
+// @Description Завершает сеанс: отзывает refresh токен и все токены, полученные из него ротацией
 // @Tags auth
 // @Accept json
 // @Produce json
-// @Security ApiKeyAuth
+// @Param input body RefreshTokenRequest true "Refresh токен текущего сеанса"
 // @Success 200 {object} SuccessResponse "Успешный выход"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 401 {object} InvalidTokenErrorResponse "Невалидный токен"
 // @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
 // @Router /auth/logout [post]
 func (h *AuthHandler) Logout(c *gin.Context) {
-    // Пока просто возвращаем успех - в будущем можно добавить blacklist токенов
+    var req RefreshTokenRequest
+
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    err := h.authService.Logout(c.Request.Context(), req.RefreshToken)
+    if errors.Is(err, services.ErrInvalidRefreshToken) {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+        return
+    }
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout"})
+        return
+    }
+
     c.JSON(http.StatusOK, gin.H{
         "message": "Successfully logged out",
     })
 }
 
+// LogoutAll godoc
+// @Summary Выход со всех устройств
+// @Description Отзывает все refresh токены текущего пользователя
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} SuccessResponse "Все сеансы завершены"
+// @Failure 401 {object} AuthErrorResponse "Требуется авторизация"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /auth/logout-all [post]
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+    userID := c.GetInt("userID")
+
+    if err := h.authService.LogoutAll(c.Request.Context(), userID); err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Successfully logged out from all devices",
+    })
+}
+
 // ForgotPassword godoc
 // @Summary Запрос сброса пароля
-// @Description Отправляет инструкции по сбросу пароля на email
+// @Description Отправляет на email одноразовую ссылку для сброса пароля (действует 1 час)
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -169,7 +320,8 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 
     err := h.authService.ForgotPassword(c.Request.Context(), req.Email)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        log.Printf("Failed to send password reset for %s: %v", req.Email, err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send reset instructions"})
         return
     }
 
@@ -180,7 +332,7 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 
 // ResetPassword godoc
 // @Summary Сброс пароля
-// @Description Устанавливает новый пароль по токену сброса
+// @Description Устанавливает новый пароль по токену сброса и завершает все сеансы пользователя
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -198,16 +350,86 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
     }
 
     err := h.authService.ResetPassword(c.Request.Context(), req.Token, req.NewPassword)
-    if err != nil {
+    if errors.Is(err, services.ErrInvalidResetToken) {
         c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
         return
     }
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+        return
+    }
 
     c.JSON(http.StatusOK, gin.H{
         "message": "Password successfully changed",
     })
 }
 
+// VerifyEmail godoc
+// @Summary Подтверждение email
+// @Description Подтверждает email по токену из письма
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body models.VerifyEmailRequest true "Токен подтверждения"
+// @Success 200 {object} SuccessResponse "Email подтвержден"
+// @Failure 400 {object} InvalidDataOrTokenErrorResponse "Неверный или просроченный токен"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /auth/verify-email [post]
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+    var req models.VerifyEmailRequest
+
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    err := h.authService.VerifyEmail(c.Request.Context(), req.Token)
+    if errors.Is(err, services.ErrInvalidVerificationToken) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Email successfully verified",
+    })
+}
+
+// ResendVerification godoc
+// @Summary Повторная отправка письма подтверждения
+// @Description Отправляет новое письмо подтверждения email (не чаще раза в минуту и не более 5 в час)
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} SuccessResponse "Письмо отправлено"
+// @Failure 400 {object} ErrorResponse "Email уже подтвержден"
+// @Failure 429 {object} ErrorResponse "Слишком частые запросы"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /auth/resend-verification [post]
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+    userID := c.GetInt("userID")
+
+    err := h.authService.ResendVerificationEmail(c.Request.Context(), userID)
+    switch {
+    case errors.Is(err, services.ErrEmailAlreadyVerified):
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    case errors.Is(err, services.ErrVerificationThrottled):
+        c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+        return
+    case err != nil:
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Verification email sent",
+    })
+}
+
 // RefreshTokenRequest represents refresh token request
 // @Description Запрос на обновление токенов
 type RefreshTokenRequest struct {
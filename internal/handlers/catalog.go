@@ -93,6 +93,7 @@ func (h *CatalogHandler) GetSubjects(c *gin.Context) {
 // @Produce json
 // @Param search query string false "Поисковый запрос"
 // @Param subject query string false "Фильтр по предмету"
+// @Param verifiedOnly query bool false "Только верифицированные преподаватели"
 // @Success 200 {object} TeachersResponse "Список преподавателей"
 // @Failure 400 {object} ErrorResponse "Неверные параметры запроса"
 // @Failure 500 {object} ErrorResponse "Ошибка сервера"
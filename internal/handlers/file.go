@@ -0,0 +1,62 @@
+package handlers
+
+import (
+    "errors"
+    "log"
+    "mime"
+    "net/http"
+
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+type FileHandler struct {
+    privateFileService *services.PrivateFileService
+}
+
+func NewFileHandler(privateFileService *services.PrivateFileService) *FileHandler {
+    return &FileHandler{privateFileService: privateFileService}
+}
+
+// DownloadFile godoc
+// @Summary Скачать закрытый файл
+// @Description Отдает документ заявки на верификацию или файл сданного задания. Документ заявки доступен владельцу и администраторам с правом teacher.verify, файл работы - ученику и тем, кто может редактировать материал
+// @Tags files
+// @Produce octet-stream
+// @Security ApiKeyAuth
+// @Param id path string true "ID файла"
+// @Success 200 {file} file "Содержимое файла"
+// @Failure 403 {object} ForbiddenErrorResponse "Нет доступа к файлу"
+// @Failure 404 {object} ErrorResponse "Файл не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /files/{id} [get]
+func (h *FileHandler) DownloadFile(c *gin.Context) {
+    file, reader, err := h.privateFileService.Open(c.Request.Context(), c.GetInt("userID"), c.GetString("userRole"), c.Param("id"))
+    switch {
+    case errors.Is(err, services.ErrFileNotFound):
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        return
+    case errors.Is(err, services.ErrAccessDenied):
+        c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+        return
+    case err != nil:
+        log.Printf("Failed to open file %s: %v", c.Param("id"), err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open file"})
+        return
+    }
+    defer reader.Close()
+
+    disposition := mime.FormatMediaType("attachment", map[string]string{"filename": file.FileName})
+    if disposition == "" {
+        disposition = "attachment"
+    }
+
+    // Файл всегда скачивается, а не открывается в браузере: содержимое
+    // загружено пользователем и не должно исполняться на нашем домене
+    c.DataFromReader(http.StatusOK, file.Size, file.ContentType, reader, map[string]string{
+        "Content-Disposition":    disposition,
+        "Cache-Control":          "private, no-store",
+        "X-Content-Type-Options": "nosniff",
+    })
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+    "errors"
+    "log"
+    "net/http"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+type FormulaHandler struct {
+    formulaService *services.FormulaService
+}
+
+func NewFormulaHandler(formulaService *services.FormulaService) *FormulaHandler {
+    return &FormulaHandler{formulaService: formulaService}
+}
+
+// RenderFormula godoc
+// @Summary Отрисовать формулу
+// @Description Проверяет формулу LaTeX (поддерживаемое подмножество описано в схеме блока formula) и возвращает ее в MathML и SVG. Отрисовка выполняется на сервере, результаты кэшируются. При ошибке разбора возвращается позиция символа (с 0) и длина фрагмента с ошибкой
+// @Tags materials
+// @Accept json
+// @Produce json
+// @Param input body models.RenderFormulaRequest true "Формула"
+// @Success 200 {object} models.RenderedFormula "Отрисованная формула"
+// @Failure 400 {object} FormulaErrorResponse "Ошибка в формуле"
+// @Failure 429 {object} ErrorResponse "Слишком много запросов"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /formulas/render [post]
+func (h *FormulaHandler) RenderFormula(c *gin.Context) {
+    var req models.RenderFormulaRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    formula, err := h.formulaService.Render(req.LaTeX, req.Display)
+    if err != nil {
+        respondFormulaError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, formula)
+}
+
+// RenderFormulaSVG godoc
+// @Summary Формула в SVG
+// @Description Возвращает формулу LaTeX картинкой SVG, например для экспорта и клиентов без отрисовки формул. Цвет берется из currentColor
+// @Tags materials
+// @Produce image/svg+xml
+// @Param latex query string true "Формула LaTeX"
+// @Param display query string false "Строчная или выключная формула" Enums(inline, block) default(block)
+// @Success 200 {string} string "SVG"
+// @Failure 400 {object} FormulaErrorResponse "Ошибка в формуле"
+// @Failure 429 {object} ErrorResponse "Слишком много запросов"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /formulas/render.svg [get]
+func (h *FormulaHandler) RenderFormulaSVG(c *gin.Context) {
+    latex := c.Query("latex")
+    display := c.DefaultQuery("display", models.FormulaDisplayBlock)
+    if latex == "" || len(latex) > 5000 ||
+        (display != models.FormulaDisplayInline && display != models.FormulaDisplayBlock) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters"})
+        return
+    }
+
+    formula, err := h.formulaService.Render(latex, display)
+    if err != nil {
+        respondFormulaError(c, err)
+        return
+    }
+
+    // Результат зависит только от параметров запроса
+    c.Header("Cache-Control", "public, max-age=86400")
+    c.Data(http.StatusOK, "image/svg+xml; charset=utf-8", []byte(formula.SVG))
+}
+
+func respondFormulaError(c *gin.Context, err error) {
+    var formulaErr *services.FormulaError
+    if errors.As(err, &formulaErr) {
+        c.JSON(http.StatusBadRequest, gin.H{
+            "error":    formulaErr.Message,
+            "position": formulaErr.Position,
+            "length":   formulaErr.Length,
+        })
+        return
+    }
+    log.Printf("Failed to render formula: %v", err)
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render formula"})
+}
+
+// Response models for Swagger
+
+// FormulaErrorResponse represents formula parse error
+// @Description Ошибка разбора формулы с местом ошибки
+type FormulaErrorResponse struct {
+    Error    string `json:"error" example:"unknown command \\fracc"`
+    Position int    `json:"position" example:"0"` // номер символа, с 0
+    Length   int    `json:"length" example:"6"`
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+    "errors"
+    "log"
+    "net/http"
+    "strconv"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+type ImpersonationHandler struct {
+    impersonationService *services.ImpersonationService
+}
+
+func NewImpersonationHandler(impersonationService *services.ImpersonationService) *ImpersonationHandler {
+    return &ImpersonationHandler{impersonationService: impersonationService}
+}
+
+// Impersonate godoc
+// @Summary Войти под пользователем
+// @Description Выдает access токен пользователя на 15 минут (без refresh токена), чтобы поддержка увидела то же, что и он. В токене есть claim act с администратором, каждый запрос с токеном пишется в журнал аудита. Смена пароля, email, 2FA, удаление аккаунта и выгрузка данных с таким токеном запрещены. Входить под пользователями с правами админки нельзя
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID пользователя"
+// @Param input body models.ImpersonateRequest true "Причина входа (например, номер обращения)"
+// @Success 200 {object} models.ImpersonationResponse "Токен пользователя"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 401 {object} AuthErrorResponse "Требуется авторизация"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен или пользователь заблокирован"
+// @Failure 404 {object} UserNotFoundErrorResponse "Пользователь не найден"
+// @Failure 500 {object} InternalErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/users/{id}/impersonate [post]
+func (h *ImpersonationHandler) Impersonate(c *gin.Context) {
+    userID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+        return
+    }
+
+    var req models.ImpersonateRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    response, err := h.impersonationService.Impersonate(c.Request.Context(), c.GetInt("userID"), userID, req.Reason, clientInfo(c))
+    switch {
+    case errors.Is(err, services.ErrCannotImpersonateSelf):
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    case errors.Is(err, services.ErrCannotImpersonatePrivileged), errors.Is(err, services.ErrAccountBlocked):
+        c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+        return
+    case errors.Is(err, services.ErrUserNotFound):
+        c.JSON(http.StatusNotFound, gin.H{"error": "No such user"})
+        return
+    case err != nil:
+        log.Printf("Failed to impersonate user %d: %v", userID, err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to impersonate user"})
+        return
+    }
+
+    c.JSON(http.StatusOK, response)
+}
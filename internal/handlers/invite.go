@@ -0,0 +1,135 @@
+package handlers
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+type InviteHandler struct {
+    inviteService *services.InviteService
+}
+
+func NewInviteHandler(inviteService *services.InviteService) *InviteHandler {
+    return &InviteHandler{inviteService: inviteService}
+}
+
+// CreateInvite godoc
+// @Summary Создать приглашение администратора
+// @Description Выпускает одноразовое приглашение. Токен показывается только в этом ответе; по нему регистрируются через /auth/register с полем inviteToken
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param input body models.CreateInviteRequest false "Параметры приглашения"
+// @Success 201 {object} CreateInviteResponse "Приглашение создано"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 401 {object} AuthErrorResponse "Требуется авторизация"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 500 {object} InternalErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/invites [post]
+func (h *InviteHandler) CreateInvite(c *gin.Context) {
+    adminID := c.GetInt("userID")
+
+    var req models.CreateInviteRequest
+    if c.Request.ContentLength > 0 {
+        if err := c.ShouldBindJSON(&req); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+            return
+        }
+    }
+
+    invite, token, link, err := h.inviteService.CreateInvite(c.Request.Context(), adminID, &req)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invite"})
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{
+        "message": "Invite created successfully",
+        "invite":  invite,
+        "token":   token,
+        "link":    link,
+    })
+}
+
+// ListInvites godoc
+// @Summary Получить приглашения
+// @Description Возвращает все выпущенные приглашения администраторов
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} InvitesListResponse "Список приглашений"
+// @Failure 401 {object} AuthErrorResponse "Требуется авторизация"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 500 {object} InternalErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/invites [get]
+func (h *InviteHandler) ListInvites(c *gin.Context) {
+    invites, err := h.inviteService.ListInvites(c.Request.Context())
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get invites"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "invites": invites,
+        "total":   len(invites),
+    })
+}
+
+// RevokeInvite godoc
+// @Summary Отозвать приглашение
+// @Description Отзывает еще не использованное приглашение
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID приглашения"
+// @Success 200 {object} SuccessResponse "Приглашение отозвано"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 404 {object} ErrorResponse "Приглашение не найдено или уже использовано"
+// @Failure 500 {object} InternalErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/invites/{id} [delete]
+func (h *InviteHandler) RevokeInvite(c *gin.Context) {
+    inviteID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invite ID"})
+        return
+    }
+
+    err = h.inviteService.RevokeInvite(c.Request.Context(), inviteID)
+    if errors.Is(err, services.ErrInviteNotFound) {
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        return
+    }
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke invite"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Invite revoked successfully",
+    })
+}
+
+// Response models for Swagger
+
+// CreateInviteResponse represents created invite response
+// @Description Ответ с созданным приглашением
+type CreateInviteResponse struct {
+    Message string             `json:"message" example:"Invite created successfully"`
+    Invite  models.AdminInvite `json:"invite"`
+    Token   string             `json:"token" example:"3f9a1c..."`
+    Link    string             `json:"link" example:"https://paydeya.ru/register?invite=3f9a1c..."`
+}
+
+// InvitesListResponse represents invites list response
+// @Description Ответ со списком приглашений
+type InvitesListResponse struct {
+    Invites []models.AdminInvite `json:"invites"`
+    Total   int                  `json:"total" example:"2"`
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+    "net/http"
+
+    "paydeya-backend/internal/utils"
+
+    "github.com/gin-gonic/gin"
+)
+
+type JWKSHandler struct {
+    keys *utils.KeySet
+}
+
+func NewJWKSHandler(keys *utils.KeySet) *JWKSHandler {
+    return &JWKSHandler{keys: keys}
+}
+
+// GetJWKS godoc
+// @Summary Публичные ключи JWT
+// @Description Возвращает JWK Set с публичными ключами, которыми подписываются токены Пайдеи. Другие сервисы проверяют по ним access токены (kid из заголовка токена, аудитория paydeya-access)
+// @Tags auth
+// @Produce json
+// @Success 200 {object} utils.JWKS "Набор ключей"
+// @Router /.well-known/jwks.json [get]
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+    c.Header("Cache-Control", "public, max-age=300")
+    c.JSON(http.StatusOK, h.keys.JWKS())
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+    "errors"
+    "net/http"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+type LockoutHandler struct {
+    throttler *services.LoginThrottler
+}
+
+func NewLockoutHandler(throttler *services.LoginThrottler) *LockoutHandler {
+    return &LockoutHandler{throttler: throttler}
+}
+
+// ListLockouts godoc
+// @Summary Получить блокировки входа
+// @Description Возвращает аккаунты и IP-адреса с неудачными попытками входа; заблокированные идут первыми
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} LockoutsListResponse "Список блокировок"
+// @Failure 401 {object} AuthErrorResponse "Требуется авторизация"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 500 {object} InternalErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/lockouts [get]
+func (h *LockoutHandler) ListLockouts(c *gin.Context) {
+    lockouts, err := h.throttler.ListLockouts(c.Request.Context())
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get lockouts"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "lockouts": lockouts,
+        "total":    len(lockouts),
+    })
+}
+
+// ClearLockout godoc
+// @Summary Снять блокировку входа
+// @Description Обнуляет счетчик неудачных попыток и снимает блокировку для аккаунта или IP
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param key path string true "Ключ блокировки, например account:user@example.com или ip:203.0.113.7"
+// @Success 200 {object} SuccessResponse "Блокировка снята"
+// @Failure 401 {object} AuthErrorResponse "Требуется авторизация"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Блокировка не найдена"
+// @Failure 500 {object} InternalErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/lockouts/{key} [delete]
+func (h *LockoutHandler) ClearLockout(c *gin.Context) {
+    key := c.Param("key")
+
+    err := h.throttler.ClearLockout(c.Request.Context(), key)
+    if errors.Is(err, services.ErrLockoutNotFound) {
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        return
+    }
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear lockout"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Lockout cleared",
+        "key":     key,
+    })
+}
+
+// Response models for Swagger
+
+// LockoutsListResponse represents login lockouts list
+// @Description Ответ со списком блокировок входа
+type LockoutsListResponse struct {
+    Lockouts []models.LoginLockout `json:"lockouts"`
+    Total    int                   `json:"total" example:"2"`
+}
@@ -5,6 +5,9 @@ import (
     "strconv"
     "crypto/rand"
     "encoding/hex"
+    "errors"
+    "log"
+    "strings"
 
     "paydeya-backend/internal/models"
     "paydeya-backend/internal/services"
@@ -20,6 +23,68 @@ func NewMaterialHandler(materialService *services.MaterialService) *MaterialHand
     return &MaterialHandler{materialService: materialService}
 }
 
+// respondMaterialError переводит ошибки сервиса материалов в HTTP ответы
+func respondMaterialError(c *gin.Context, err error, message string) {
+    var conflict *services.VersionConflictError
+    var invalid *services.BlockValidationError
+    switch {
+    case errors.As(err, &invalid):
+        c.JSON(http.StatusBadRequest, gin.H{
+            "error":  "Invalid block content",
+            "fields": invalid.Errors,
+        })
+    case errors.As(err, &conflict):
+        c.Header("ETag", materialETag(conflict.Current.EditVersion))
+        c.JSON(http.StatusPreconditionFailed, gin.H{
+            "error":          "Material was modified by someone else",
+            "currentVersion": conflict.Current.EditVersion,
+            "material":       conflict.Current,
+        })
+    case errors.Is(err, services.ErrAccessDenied):
+        c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+    case errors.Is(err, services.ErrMaterialNotFound):
+        c.JSON(http.StatusNotFound, gin.H{"error": "Material not found"})
+    case errors.Is(err, services.ErrBlockNotFound), errors.Is(err, services.ErrRevisionNotFound),
+        errors.Is(err, services.ErrMaterialNotPublished), errors.Is(err, services.ErrSubmissionNotFound),
+        errors.Is(err, services.ErrTemplateNotInGallery):
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+    case errors.Is(err, services.ErrInvalidBlockOrder), errors.Is(err, services.ErrInvalidQuizAnswers),
+        errors.Is(err, services.ErrInvalidSubmission), errors.Is(err, services.ErrNotTemplate):
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    case errors.Is(err, services.ErrSubmissionGraded), errors.Is(err, services.ErrSubmissionReturned),
+        errors.Is(err, services.ErrQuizAttemptsExhausted):
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+    default:
+        log.Printf("%s: %v", message, err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+    }
+}
+
+// materialETag формирует ETag черновика по его версии
+func materialETag(version int) string {
+    return `"` + strconv.Itoa(version) + `"`
+}
+
+// parseIfMatch разбирает заголовок If-Match. Без него изменять материал нельзя (428),
+// "*" отключает проверку версии (возвращается 0).
+func parseIfMatch(c *gin.Context) (int, bool) {
+    value := strings.TrimSpace(c.GetHeader("If-Match"))
+    if value == "" {
+        c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header is required"})
+        return 0, false
+    }
+    if value == "*" {
+        return 0, true
+    }
+
+    version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(value, "W/"), `"`))
+    if err != nil || version <= 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid If-Match header"})
+        return 0, false
+    }
+    return version, true
+}
+
 // CreateMaterial godoc
 // @Summary Создать материал
 // @Description Создает новый учебный материал
@@ -30,6 +95,7 @@ func NewMaterialHandler(materialService *services.MaterialService) *MaterialHand
 // @Param input body models.CreateMaterialRequest true "Данные материала"
 // @Success 201 {object} CreateMaterialResponse "Материал создан"
 // @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Нет права material.create"
 // @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
 // @Router /materials [post]
 func (h *MaterialHandler) CreateMaterial(c *gin.Context) {
@@ -47,6 +113,7 @@ func (h *MaterialHandler) CreateMaterial(c *gin.Context) {
         return
     }
 
+    c.Header("ETag", materialETag(material.EditVersion))
     c.JSON(http.StatusCreated, gin.H{
         "message":  "Material created successfully",
         "material": material,
@@ -54,15 +121,136 @@ func (h *MaterialHandler) CreateMaterial(c *gin.Context) {
     })
 }
 
+// DuplicateMaterial godoc
+// @Summary Копировать материал
+// @Description Создает копию материала с блоками в черновиках текущего пользователя. Материал, который пользователь может редактировать, копируется из черновика целиком. Чужой материал можно скопировать (форк), только если он опубликован в открытом доступе: копируется опубликованная версия без скрытых полей (ключей ответов, критериев оценки), а у копии указываются источник и автор оригинала
+// @Tags materials
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param input body models.CopyMaterialRequest false "Заголовок копии"
+// @Success 201 {object} models.Material "Копия материала"
+// @Header 201 {string} ETag "Версия черновика копии"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Материал доступен только по ссылке или нет права material.create"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /materials/{id}/duplicate [post]
+func (h *MaterialHandler) DuplicateMaterial(c *gin.Context) {
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    var req models.CopyMaterialRequest
+    if c.Request.ContentLength > 0 {
+        if err := c.ShouldBindJSON(&req); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+            return
+        }
+    }
+
+    material, err := h.materialService.DuplicateMaterial(c.Request.Context(), c.GetInt("userID"), c.GetString("userRole"), materialID, &req)
+    if err != nil {
+        respondMaterialError(c, err, "Failed to duplicate material")
+        return
+    }
+
+    c.Header("ETag", materialETag(material.EditVersion))
+    c.JSON(http.StatusCreated, material)
+}
+
+// SetTemplate godoc
+// @Summary Отметить материал шаблоном
+// @Description Включает или выключает признак шаблона. По шаблону можно создавать новые материалы (POST /materials/{id}/instantiate): автору доступен черновик, остальным - опубликованная версия, если материал открыт или добавлен в галерею шаблонов
+// @Tags materials
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param input body models.SetTemplateRequest true "Признак шаблона"
+// @Success 200 {object} models.Material "Материал"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /materials/{id}/template [put]
+func (h *MaterialHandler) SetTemplate(c *gin.Context) {
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    var req models.SetTemplateRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    material, err := h.materialService.SetTemplate(c.Request.Context(), c.GetInt("userID"), c.GetString("userRole"), materialID, *req.IsTemplate)
+    if err != nil {
+        respondMaterialError(c, err, "Failed to update template flag")
+        return
+    }
+
+    c.JSON(http.StatusOK, material)
+}
+
+// InstantiateTemplate godoc
+// @Summary Создать материал по шаблону
+// @Description Создает в черновиках текущего пользователя материал по шаблону. Шаблон копируется целиком, включая ключи ответов, у нового материала указываются шаблон и его автор. Чужой шаблон доступен, если он опубликован в открытом доступе или добавлен в галерею
+// @Tags materials
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID шаблона"
+// @Param input body models.CopyMaterialRequest false "Заголовок нового материала"
+// @Success 201 {object} models.Material "Новый материал"
+// @Header 201 {string} ETag "Версия черновика"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса или материал не шаблон"
+// @Failure 403 {object} ForbiddenErrorResponse "Шаблон недоступен или нет права material.create"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Шаблон не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /materials/{id}/instantiate [post]
+func (h *MaterialHandler) InstantiateTemplate(c *gin.Context) {
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    var req models.CopyMaterialRequest
+    if c.Request.ContentLength > 0 {
+        if err := c.ShouldBindJSON(&req); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+            return
+        }
+    }
+
+    material, err := h.materialService.InstantiateTemplate(c.Request.Context(), c.GetInt("userID"), c.GetString("userRole"), materialID, &req)
+    if err != nil {
+        respondMaterialError(c, err, "Failed to create material from template")
+        return
+    }
+
+    c.Header("ETag", materialETag(material.EditVersion))
+    c.JSON(http.StatusCreated, material)
+}
+
 // GetMaterial godoc
 // @Summary Получить материал
-// @Description Возвращает материал по ID
+// @Description Возвращает материал по ID. Автору и редакторам по умолчанию отдается черновик, остальным - опубликованная версия (черновые правки им не видны). Поле version показывает, какая версия в ответе. Для черновика возвращается ETag, который нужно передавать в If-Match при изменении материала
 // @Tags materials
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
 // @Param id path int true "ID материала"
+// @Param version query string false "Версия для автора: черновик или опубликованная" Enums(draft, published)
 // @Success 200 {object} models.Material "Материал"
+// @Header 200 {string} ETag "Версия черновика"
 // @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
 // @Failure 401 {object} InvalidIDErrorResponse "Неверный ID"
 // @Failure 404 {object} MaterialNotFoundErrorResponse "Материал не найден"
@@ -75,32 +263,41 @@ func (h *MaterialHandler) GetMaterial(c *gin.Context) {
         return
     }
 
-    material, err := h.materialService.GetMaterial(c.Request.Context(), materialID)
-    if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    version := c.Query("version")
+    if version != "" && version != models.MaterialVersionDraft && version != models.MaterialVersionPublished {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version"})
         return
     }
 
-    if material == nil {
-        c.JSON(http.StatusNotFound, gin.H{"error": "Material not found"})
+    material, err := h.materialService.ViewMaterial(c.Request.Context(), c.GetInt("userID"), c.GetString("userRole"), materialID, version)
+    if err != nil {
+        respondMaterialError(c, err, "Failed to get material")
         return
     }
 
+    if material.Version == models.MaterialVersionDraft {
+        c.Header("ETag", materialETag(material.EditVersion))
+    }
     c.JSON(http.StatusOK, material)
 }
 
 // UpdateMaterial godoc
 // @Summary Обновить материал
-// @Description Обновляет материал (только для автора)
+// @Description Обновляет материал (автор с правом material.edit.own или пользователь с правом material.edit.any)
 // @Tags materials
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
+// @Param If-Match header string true "ETag черновика из GET /materials/{id} (или *)"
 // @Param id path int true "ID материала"
 // @Param input body models.UpdateMaterialRequest true "Данные для обновления"
 // @Success 200 {object} SuccessResponse "Материал обновлен"
-// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Header 200 {string} ETag "Новая версия черновика"
+// @Failure 400 {object} BlockValidationErrorResponse "Неверные параметры запроса или содержимое блоков"
 // @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал не найден"
+// @Failure 412 {object} VersionConflictResponse "Материал изменен другим пользователем"
+// @Failure 428 {object} ErrorResponse "Не передан If-Match"
 // @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
 // @Router /materials/{id} [put]
 func (h *MaterialHandler) UpdateMaterial(c *gin.Context) {
@@ -111,22 +308,24 @@ func (h *MaterialHandler) UpdateMaterial(c *gin.Context) {
         return
     }
 
+    version, ok := parseIfMatch(c)
+    if !ok {
+        return
+    }
+
     var req models.UpdateMaterialRequest
     if err := c.ShouldBindJSON(&req); err != nil {
         c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
         return
     }
 
-    err = h.materialService.UpdateMaterial(c.Request.Context(), userID, materialID, &req)
+    newVersion, err := h.materialService.UpdateMaterial(c.Request.Context(), userID, c.GetString("userRole"), materialID, &req, version)
     if err != nil {
-        if err.Error() == "access denied" {
-            c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
-        } else {
-            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-        }
+        respondMaterialError(c, err, "Failed to update material")
         return
     }
 
+    c.Header("ETag", materialETag(newVersion))
     c.JSON(http.StatusOK, gin.H{
         "message": "Material updated successfully",
     })
@@ -161,17 +360,65 @@ func (h *MaterialHandler) GetUserMaterials(c *gin.Context) {
         })
 }
 
+// GetBlockSchemas godoc
+// @Summary Схемы блоков
+// @Description Возвращает схемы содержимого всех типов блоков. По ним сервер проверяет блоки при добавлении и изменении материала
+// @Tags materials
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} BlockSchemasResponse "Схемы блоков"
+// @Router /materials/block-schemas [get]
+func (h *MaterialHandler) GetBlockSchemas(c *gin.Context) {
+    c.JSON(http.StatusOK, gin.H{
+        "schemas": h.materialService.BlockSchemas(),
+    })
+}
+
+// PreviewAnimation godoc
+// @Summary Предпросмотр анимации блока
+// @Description Проверяет блок с анимацией (элементы шагов должны быть в содержимом блока, запуск и действия - из известных) и возвращает шаги с абсолютным временем начала и конца от запуска анимации. По этому расписанию анимацию проигрывают веб- и мобильный клиенты
+// @Tags materials
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param input body models.Block true "Блок с анимацией"
+// @Success 200 {object} models.AnimationTimeline "Расписание анимации"
+// @Failure 400 {object} BlockValidationErrorResponse "Неверные параметры запроса или анимация"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /materials/animations/preview [post]
+func (h *MaterialHandler) PreviewAnimation(c *gin.Context) {
+    var block models.Block
+    if err := c.ShouldBindJSON(&block); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    timeline, err := h.materialService.PreviewAnimation(&block)
+    if err != nil {
+        respondMaterialError(c, err, "Failed to preview animation")
+        return
+    }
+
+    c.JSON(http.StatusOK, timeline)
+}
+
 // PublishMaterial godoc
 // @Summary Опубликовать материал
-// @Description Публикует материал с указанными настройками видимости
+// @Description Публикует материал с указанными настройками видимости. При публикации текущий черновик замораживается в неизменяемую версию: ученики видят ее, пока автор не опубликует материал снова
 // @Tags materials
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
+// @Param If-Match header string true "ETag черновика из GET /materials/{id} (или *)"
 // @Param id path int true "ID материала"
 // @Param input body models.PublishMaterialRequest true "Настройки публикации"
 // @Success 200 {object} PublishMaterialResponse "Материал опубликован"
+// @Header 200 {string} ETag "Новая версия черновика"
 // @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Email не подтвержден или доступ запрещен"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал не найден"
+// @Failure 412 {object} VersionConflictResponse "Материал изменен другим пользователем"
+// @Failure 428 {object} ErrorResponse "Не передан If-Match"
 // @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
 // @Router /materials/{id}/publish [post]
 func (h *MaterialHandler) PublishMaterial(c *gin.Context) {
@@ -182,6 +429,11 @@ func (h *MaterialHandler) PublishMaterial(c *gin.Context) {
         return
     }
 
+    version, ok := parseIfMatch(c)
+    if !ok {
+        return
+    }
+
     var req models.PublishMaterialRequest
     if err := c.ShouldBindJSON(&req); err != nil {
         c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -197,12 +449,13 @@ func (h *MaterialHandler) PublishMaterial(c *gin.Context) {
     }
 
     // Вызываем настоящую логику публикации
-    material, err := h.materialService.PublishMaterial(c.Request.Context(), userID, materialID, &req)
+    material, err := h.materialService.PublishMaterial(c.Request.Context(), userID, c.GetString("userRole"), materialID, &req, version)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        respondMaterialError(c, err, "Failed to publish material")
         return
     }
 
+    c.Header("ETag", materialETag(material.EditVersion))
     c.JSON(http.StatusOK, gin.H{
         "message": "Material published successfully",
         "material": material,
@@ -217,10 +470,16 @@ func (h *MaterialHandler) PublishMaterial(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
+// @Param If-Match header string true "ETag черновика из GET /materials/{id} (или *)"
 // @Param id path int true "ID материала"
 // @Param input body models.Block true "Данные блока"
 // @Success 200 {object} AddBlockResponse "Блок добавлен"
-// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Header 200 {string} ETag "Новая версия черновика"
+// @Failure 400 {object} BlockValidationErrorResponse "Неверные параметры запроса или содержимое блоков"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал или блок не найден"
+// @Failure 412 {object} VersionConflictResponse "Материал изменен другим пользователем"
+// @Failure 428 {object} ErrorResponse "Не передан If-Match"
 // @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
 // @Router /materials/{id}/blocks [post]
 func (h *MaterialHandler) AddBlock(c *gin.Context) {
@@ -231,18 +490,24 @@ func (h *MaterialHandler) AddBlock(c *gin.Context) {
         return
     }
 
+    version, ok := parseIfMatch(c)
+    if !ok {
+        return
+    }
+
     var block models.Block
     if err := c.ShouldBindJSON(&block); err != nil {
         c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
         return
     }
 
-    err = h.materialService.AddBlock(c.Request.Context(), userID, materialID, &block)
+    newVersion, err := h.materialService.AddBlock(c.Request.Context(), userID, c.GetString("userRole"), materialID, &block, version)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        respondMaterialError(c, err, "Failed to add block")
         return
     }
 
+    c.Header("ETag", materialETag(newVersion))
     c.JSON(http.StatusOK, gin.H{
         "message": "Block added successfully",
         "blockId": block.ID,
@@ -256,11 +521,17 @@ func (h *MaterialHandler) AddBlock(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
+// @Param If-Match header string true "ETag черновика из GET /materials/{id} (или *)"
 // @Param id path int true "ID материала"
 // @Param blockId path string true "ID блока"
 // @Param input body models.Block true "Данные блока"
 // @Success 200 {object} SuccessResponse "Блок обновлен"
-// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Header 200 {string} ETag "Новая версия черновика"
+// @Failure 400 {object} BlockValidationErrorResponse "Неверные параметры запроса или содержимое блоков"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал или блок не найден"
+// @Failure 412 {object} VersionConflictResponse "Материал изменен другим пользователем"
+// @Failure 428 {object} ErrorResponse "Не передан If-Match"
 // @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
 // @Router /materials/{id}/blocks/{blockId} [put]
 func (h *MaterialHandler) UpdateBlock(c *gin.Context) {
@@ -273,6 +544,11 @@ func (h *MaterialHandler) UpdateBlock(c *gin.Context) {
 
     blockID := c.Param("blockId")
 
+    version, ok := parseIfMatch(c)
+    if !ok {
+        return
+    }
+
     var block models.Block
     if err := c.ShouldBindJSON(&block); err != nil {
         c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -281,12 +557,13 @@ func (h *MaterialHandler) UpdateBlock(c *gin.Context) {
 
     block.ID = blockID
 
-    err = h.materialService.UpdateBlock(c.Request.Context(), userID, materialID, blockID, &block)
+    newVersion, err := h.materialService.UpdateBlock(c.Request.Context(), userID, c.GetString("userRole"), materialID, blockID, &block, version)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        respondMaterialError(c, err, "Failed to update block")
         return
     }
 
+    c.Header("ETag", materialETag(newVersion))
     c.JSON(http.StatusOK, gin.H{
         "message": "Block updated successfully",
     })
@@ -299,10 +576,16 @@ func (h *MaterialHandler) UpdateBlock(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
+// @Param If-Match header string true "ETag черновика из GET /materials/{id} (или *)"
 // @Param id path int true "ID материала"
 // @Param blockId path string true "ID блока"
 // @Success 200 {object} SuccessResponse "Блок удален"
+// @Header 200 {string} ETag "Новая версия черновика"
 // @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал или блок не найден"
+// @Failure 412 {object} VersionConflictResponse "Материал изменен другим пользователем"
+// @Failure 428 {object} ErrorResponse "Не передан If-Match"
 // @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
 // @Router /materials/{id}/blocks/{blockId} [delete]
 func (h *MaterialHandler) DeleteBlock(c *gin.Context) {
@@ -315,12 +598,18 @@ func (h *MaterialHandler) DeleteBlock(c *gin.Context) {
 
     blockID := c.Param("blockId")
 
-    err = h.materialService.DeleteBlock(c.Request.Context(), userID, materialID, blockID)
+    version, ok := parseIfMatch(c)
+    if !ok {
+        return
+    }
+
+    newVersion, err := h.materialService.DeleteBlock(c.Request.Context(), userID, c.GetString("userRole"), materialID, blockID, version)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        respondMaterialError(c, err, "Failed to delete block")
         return
     }
 
+    c.Header("ETag", materialETag(newVersion))
     c.JSON(http.StatusOK, gin.H{
         "message": "Block deleted successfully",
     })
@@ -333,10 +622,16 @@ func (h *MaterialHandler) DeleteBlock(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
+// @Param If-Match header string true "ETag черновика из GET /materials/{id} (или *)"
 // @Param id path int true "ID материала"
 // @Param input body ReorderBlocksRequest true "Новый порядок блоков"
 // @Success 200 {object} ReorderBlocksResponse "Порядок изменен"
+// @Header 200 {string} ETag "Новая версия черновика"
 // @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал или блок не найден"
+// @Failure 412 {object} VersionConflictResponse "Материал изменен другим пользователем"
+// @Failure 428 {object} ErrorResponse "Не передан If-Match"
 // @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
 // @Router /materials/{id}/blocks/reorder [post]
 func (h *MaterialHandler) ReorderBlocks(c *gin.Context) {
@@ -347,18 +642,24 @@ func (h *MaterialHandler) ReorderBlocks(c *gin.Context) {
         return
     }
 
+    version, ok := parseIfMatch(c)
+    if !ok {
+        return
+    }
+
     var req ReorderBlocksRequest
     if err := c.ShouldBindJSON(&req); err != nil {
         c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
         return
     }
 
-    err = h.materialService.ReorderBlocks(c.Request.Context(), userID, materialID, req.Blocks)
+    newVersion, err := h.materialService.ReorderBlocks(c.Request.Context(), userID, c.GetString("userRole"), materialID, req.Blocks, version)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        respondMaterialError(c, err, "Failed to reorder blocks")
         return
     }
 
+    c.Header("ETag", materialETag(newVersion))
     c.JSON(http.StatusOK, gin.H{
         "message": "Blocks reordered successfully",
         "newOrder": req.Blocks,
@@ -383,6 +684,27 @@ type CreateMaterialResponse struct {
     EditorURL string          `json:"editorUrl" example:"/editor/1"`
 }
 
+// BlockSchemasResponse represents block schemas list
+// @Description Ответ со схемами типов блоков
+type BlockSchemasResponse struct {
+    Schemas []models.BlockSchema `json:"schemas"`
+}
+
+// BlockValidationErrorResponse represents block validation error
+// @Description Ошибки проверки блоков по полям
+type BlockValidationErrorResponse struct {
+    Error  string              `json:"error" example:"Invalid block content"`
+    Fields []models.FieldError `json:"fields"`
+}
+
+// VersionConflictResponse represents stale write response
+// @Description Ответ на запись с устаревшей версией: текущий черновик для слияния правок
+type VersionConflictResponse struct {
+    Error          string          `json:"error" example:"Material was modified by someone else"`
+    CurrentVersion int             `json:"currentVersion" example:"8"`
+    Material       models.Material `json:"material"`
+}
+
 // PublishMaterialResponse represents publish material response
 // @Description Ответ на публикацию материала
 type PublishMaterialResponse struct {
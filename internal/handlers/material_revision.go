@@ -0,0 +1,213 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+)
+
+// ListRevisions godoc
+// @Summary История материала
+// @Description Возвращает ревизии материала (без содержимого блоков), новые сверху. Ревизия сохраняется при каждом изменении заголовка или блоков
+// @Tags materials
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество записей на странице" default(20)
+// @Success 200 {object} RevisionsListResponse "Список ревизий"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /materials/{id}/revisions [get]
+func (h *MaterialHandler) ListRevisions(c *gin.Context) {
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    page, _ := strconv.Atoi(c.Query("page"))
+    limit, _ := strconv.Atoi(c.Query("limit"))
+
+    if page <= 0 {
+        page = 1
+    }
+    if limit <= 0 || limit > 100 {
+        limit = 20
+    }
+
+    revisions, total, err := h.materialService.ListRevisions(c.Request.Context(), c.GetInt("userID"), c.GetString("userRole"), materialID, page, limit)
+    if err != nil {
+        respondMaterialError(c, err, "Failed to get revisions")
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "revisions": revisions,
+        "total":     total,
+        "page":      page,
+        "limit":     limit,
+    })
+}
+
+// GetRevision godoc
+// @Summary Ревизия материала
+// @Description Возвращает снимок материала (заголовок и блоки) на момент ревизии
+// @Tags materials
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param rev path int true "Номер ревизии"
+// @Success 200 {object} models.MaterialRevision "Ревизия"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Материал или ревизия не найдены"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /materials/{id}/revisions/{rev} [get]
+func (h *MaterialHandler) GetRevision(c *gin.Context) {
+    materialID, number, ok := parseRevisionParams(c)
+    if !ok {
+        return
+    }
+
+    revision, err := h.materialService.GetRevision(c.Request.Context(), c.GetInt("userID"), c.GetString("userRole"), materialID, number)
+    if err != nil {
+        respondMaterialError(c, err, "Failed to get revision")
+        return
+    }
+
+    c.JSON(http.StatusOK, revision)
+}
+
+// DiffRevisions godoc
+// @Summary Сравнить ревизии
+// @Description Структурное сравнение ревизии с более ранней на уровне блоков: добавленные, удаленные, измененные (с перечнем полей) и перемещенные блоки. По умолчанию сравнивается с предыдущей ревизией
+// @Tags materials
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param rev path int true "Номер ревизии"
+// @Param from query int false "С какой ревизией сравнивать (0 - с пустым материалом)"
+// @Success 200 {object} models.RevisionDiff "Различия"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Материал или ревизия не найдены"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /materials/{id}/revisions/{rev}/diff [get]
+func (h *MaterialHandler) DiffRevisions(c *gin.Context) {
+    materialID, number, ok := parseRevisionParams(c)
+    if !ok {
+        return
+    }
+
+    from := number - 1
+    if value := c.Query("from"); value != "" {
+        parsed, err := strconv.Atoi(value)
+        if err != nil || parsed < 0 {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from revision"})
+            return
+        }
+        from = parsed
+    }
+
+    diff, err := h.materialService.DiffRevisions(c.Request.Context(), c.GetInt("userID"), c.GetString("userRole"), materialID, from, number)
+    if err != nil {
+        respondMaterialError(c, err, "Failed to compare revisions")
+        return
+    }
+
+    c.JSON(http.StatusOK, diff)
+}
+
+// RestoreRevision godoc
+// @Summary Восстановить ревизию
+// @Description Возвращает материалу заголовок и блоки из ревизии. Восстановление сохраняется как новая ревизия, история не теряется
+// @Tags materials
+// @Produce json
+// @Security ApiKeyAuth
+// @Param If-Match header string true "ETag черновика из GET /materials/{id} (или *)"
+// @Param id path int true "ID материала"
+// @Param rev path int true "Номер ревизии"
+// @Success 200 {object} RestoreRevisionResponse "Материал восстановлен"
+// @Header 200 {string} ETag "Новая версия черновика"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Материал или ревизия не найдены"
+// @Failure 412 {object} VersionConflictResponse "Материал изменен другим пользователем"
+// @Failure 428 {object} ErrorResponse "Не передан If-Match"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /materials/{id}/revisions/{rev}/restore [post]
+func (h *MaterialHandler) RestoreRevision(c *gin.Context) {
+    materialID, number, ok := parseRevisionParams(c)
+    if !ok {
+        return
+    }
+
+    version, ok := parseIfMatch(c)
+    if !ok {
+        return
+    }
+
+    revision, newVersion, err := h.materialService.RestoreRevision(c.Request.Context(), c.GetInt("userID"), c.GetString("userRole"), materialID, number, version)
+    if err != nil {
+        respondMaterialError(c, err, "Failed to restore revision")
+        return
+    }
+
+    c.Header("ETag", materialETag(newVersion))
+    c.JSON(http.StatusOK, gin.H{
+        "message":  "Material restored successfully",
+        "revision": revision.Revision,
+    })
+}
+
+// parseRevisionParams разбирает ID материала и номер ревизии из пути
+func parseRevisionParams(c *gin.Context) (int, int, bool) {
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return 0, 0, false
+    }
+
+    number, err := strconv.Atoi(c.Param("rev"))
+    if err != nil || number <= 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision number"})
+        return 0, 0, false
+    }
+
+    return materialID, number, true
+}
+
+// Response models for Swagger
+
+// RevisionsListResponse represents material revisions page
+// @Description Ответ со списком ревизий материала
+type RevisionsListResponse struct {
+    Revisions []RevisionSummary `json:"revisions"`
+    Total     int               `json:"total" example:"12"`
+    Page      int               `json:"page" example:"1"`
+    Limit     int               `json:"limit" example:"20"`
+}
+
+// RevisionSummary represents revision without blocks
+// @Description Ревизия без содержимого блоков
+type RevisionSummary struct {
+    Revision    int    `json:"revision" example:"3"`
+    MaterialID  int    `json:"materialId" example:"1"`
+    Title       string `json:"title" example:"Основы алгебры"`
+    BlocksCount int    `json:"blocksCount" example:"5"`
+    AuthorID    int    `json:"authorId" example:"123"`
+    AuthorName  string `json:"authorName" example:"Иван Иванов"`
+    Action      string `json:"action" example:"block_updated"`
+    CreatedAt   string `json:"createdAt" example:"2023-01-15T10:30:00Z"`
+}
+
+// RestoreRevisionResponse represents restore result
+// @Description Ответ на восстановление ревизии
+type RestoreRevisionResponse struct {
+    Message  string `json:"message" example:"Material restored successfully"`
+    Revision int    `json:"revision" example:"13"`
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+    "errors"
+    "log"
+    "net/http"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+type OAuthHandler struct {
+    oauthService     *services.OAuthService
+    authService      *services.AuthService
+    twoFactorService *services.TwoFactorService
+}
+
+func NewOAuthHandler(oauthService *services.OAuthService, authService *services.AuthService, twoFactorService *services.TwoFactorService) *OAuthHandler {
+    return &OAuthHandler{
+        oauthService:     oauthService,
+        authService:      authService,
+        twoFactorService: twoFactorService,
+    }
+}
+
+// ListProviders godoc
+// @Summary Провайдеры входа
+// @Description Возвращает внешних провайдеров (Яндекс ID, VK ID, Google и др.), через которых можно войти
+// @Tags auth
+// @Produce json
+// @Success 200 {object} OAuthProvidersResponse "Список провайдеров"
+// @Router /auth/oauth/providers [get]
+func (h *OAuthHandler) ListProviders(c *gin.Context) {
+    c.JSON(http.StatusOK, gin.H{
+        "providers": h.oauthService.ListProviders(),
+    })
+}
+
+// Authorize godoc
+// @Summary Начать вход через провайдера
+// @Description Возвращает ссылку на страницу входа провайдера (authorization code flow с PKCE). После входа провайдер вернет пользователя на {APP_URL}/oauth/{provider}/callback с параметрами code и state
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Провайдер" example(yandex)
+// @Param role query string false "Роль, если по итогам входа будет создан аккаунт" Enums(student, teacher) default(student)
+// @Success 200 {object} models.OAuthAuthorizeResponse "Ссылка на вход"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 404 {object} ErrorResponse "Провайдер не подключен"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /auth/oauth/{provider}/authorize [get]
+func (h *OAuthHandler) Authorize(c *gin.Context) {
+    var query struct {
+        Role string `form:"role" binding:"omitempty,oneof=student teacher"`
+    }
+    if err := c.ShouldBindQuery(&query); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    response, err := h.oauthService.StartAuthorization(c.Request.Context(), c.Param("provider"), query.Role)
+    if errors.Is(err, services.ErrUnknownOAuthProvider) {
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        return
+    }
+    if err != nil {
+        log.Printf("Failed to start oauth authorization: %v", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start authorization"})
+        return
+    }
+
+    c.JSON(http.StatusOK, response)
+}
+
+// Callback godoc
+// @Summary Завершить вход через провайдера
+// @Description Принимает code и state, с которыми провайдер вернул пользователя на фронтенд. Внешний аккаунт привязывается к пользователю с тем же email, если владелец аккаунта подтвердил этот email (иначе 409), или создается новый аккаунт. Если включена 2FA, возвращается challengeToken для /auth/2fa/verify
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param provider path string true "Провайдер" example(yandex)
+// @Param input body models.OAuthCallbackRequest true "Параметры от провайдера"
+// @Success 200 {object} models.AuthResponse "Успешный вход или требуется код второго фактора"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры или истекший state"
+// @Failure 401 {object} ErrorResponse "Провайдер отклонил вход или аккаунт заблокирован"
+// @Failure 404 {object} ErrorResponse "Провайдер не подключен"
+// @Failure 409 {object} ErrorResponse "Аккаунт с этим email не подтвердил адрес"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /auth/oauth/{provider}/callback [post]
+func (h *OAuthHandler) Callback(c *gin.Context) {
+    var req models.OAuthCallbackRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    user, err := h.oauthService.CompleteAuthorization(c.Request.Context(), c.Param("provider"), &req)
+    switch {
+    case errors.Is(err, services.ErrUnknownOAuthProvider):
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        return
+    case errors.Is(err, services.ErrInvalidOAuthState), errors.Is(err, services.ErrOAuthEmailRequired),
+        errors.Is(err, services.ErrOAuthEmailNotVerified):
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    case errors.Is(err, services.ErrOAuthAccountNotVerified):
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+        return
+    case errors.Is(err, services.ErrOAuthExchangeFailed), errors.Is(err, services.ErrAccountBlocked):
+        c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+        return
+    case err != nil:
+        log.Printf("Failed to complete oauth authorization: %v", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+        return
+    }
+
+    respondLogin(c, h.authService, h.twoFactorService, user)
+}
+
+// Response models for Swagger
+
+// OAuthProvidersResponse represents enabled login providers
+// @Description Ответ со списком провайдеров входа
+type OAuthProvidersResponse struct {
+    Providers []models.OAuthProviderInfo `json:"providers"`
+}
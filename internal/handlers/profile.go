@@ -1,9 +1,15 @@
 package handlers
 
 import (
+    "archive/zip"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
     "net/http"
     "log"
 
+    "paydeya-backend/internal/models"
     "paydeya-backend/internal/repositories"
     "paydeya-backend/internal/services"
 
@@ -11,16 +17,18 @@ import (
 )
 
 type ProfileHandler struct {
-    authService *services.AuthService
-    userRepo    *repositories.UserRepository
-    fileService *services.FileService
+    authService    *services.AuthService
+    accountService *services.AccountService
+    userRepo       *repositories.UserRepository
+    fileService    *services.FileService
 }
 
-func NewProfileHandler(authService *services.AuthService, userRepo *repositories.UserRepository, fileService *services.FileService) *ProfileHandler {
+func NewProfileHandler(authService *services.AuthService, accountService *services.AccountService, userRepo *repositories.UserRepository, fileService *services.FileService) *ProfileHandler {
     return &ProfileHandler{
-        authService: authService,
-        userRepo:    userRepo,
-        fileService: fileService,
+        authService:    authService,
+        accountService: accountService,
+        userRepo:       userRepo,
+        fileService:    fileService,
     }
 }
 
@@ -65,6 +73,7 @@ func (h *ProfileHandler) GetProfile(c *gin.Context) {
         "role":             user.Role,
         "avatarUrl":        user.AvatarURL,
         "isVerified":       user.IsVerified,
+        "emailVerified":    user.EmailVerified,
         "specializations":  specializations,
         "createdAt":        user.CreatedAt,
         "updatedAt":        user.UpdatedAt,
@@ -166,6 +175,264 @@ func (h *ProfileHandler) UploadAvatar(c *gin.Context) {
     })
 }
 
+// respondAccountError переводит ошибки смены учетных данных в HTTP ответы
+func respondAccountError(c *gin.Context, err error, message string) {
+    switch {
+    case errors.Is(err, services.ErrInvalidPassword), errors.Is(err, services.ErrSameEmail),
+        errors.Is(err, services.ErrInvalidEmailChangeToken):
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    case errors.Is(err, services.ErrInvalidTransferTarget):
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    case errors.Is(err, services.ErrEmailTaken):
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+    case errors.Is(err, services.ErrUserNotFound), errors.Is(err, services.ErrDeletionNotScheduled):
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+    default:
+        log.Printf("%s: %v", message, err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+    }
+}
+
+// ChangePassword godoc
+// @Summary Сменить пароль
+// @Description Меняет пароль после проверки текущего. Все сессии, кроме текущей, завершаются
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param input body models.ChangePasswordRequest true "Текущий и новый пароль"
+// @Success 200 {object} SuccessResponse "Пароль изменен"
+// @Failure 400 {object} ErrorResponse "Неверный текущий пароль или данные"
+// @Failure 500 {object} ErrorResponse "Ошибка сервера"
+// @Router /profile/password [post]
+func (h *ProfileHandler) ChangePassword(c *gin.Context) {
+    var req models.ChangePasswordRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    err := h.accountService.ChangePassword(c.Request.Context(), c.GetInt("userID"), c.GetString("sessionID"), &req, clientInfo(c))
+    if err != nil {
+        respondAccountError(c, err, "Failed to change password")
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Password successfully changed",
+    })
+}
+
+// RequestEmailChange godoc
+// @Summary Сменить email
+// @Description Отправляет ссылку подтверждения на новый адрес (действует 24 часа). Email меняется после подтверждения через /profile/email/confirm
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param input body models.ChangeEmailRequest true "Новый email и текущий пароль"
+// @Success 200 {object} SuccessResponse "Письмо отправлено"
+// @Failure 400 {object} ErrorResponse "Неверный пароль или данные"
+// @Failure 409 {object} ErrorResponse "Email уже занят"
+// @Failure 500 {object} ErrorResponse "Ошибка сервера"
+// @Router /profile/email [post]
+func (h *ProfileHandler) RequestEmailChange(c *gin.Context) {
+    var req models.ChangeEmailRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    err := h.accountService.RequestEmailChange(c.Request.Context(), c.GetInt("userID"), &req, clientInfo(c))
+    if err != nil {
+        respondAccountError(c, err, "Failed to request email change")
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Confirmation link sent to the new email",
+    })
+}
+
+// ConfirmEmailChange godoc
+// @Summary Подтвердить смену email
+// @Description Меняет email по токену из письма. Подтверждать нужно из аккаунта, запросившего смену; все остальные сессии завершаются
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param input body models.ConfirmEmailChangeRequest true "Токен из письма"
+// @Success 200 {object} ConfirmEmailChangeResponse "Email изменен"
+// @Failure 400 {object} ErrorResponse "Неверный или просроченный токен"
+// @Failure 409 {object} ErrorResponse "Email уже занят"
+// @Failure 500 {object} ErrorResponse "Ошибка сервера"
+// @Router /profile/email/confirm [post]
+func (h *ProfileHandler) ConfirmEmailChange(c *gin.Context) {
+    var req models.ConfirmEmailChangeRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    email, err := h.accountService.ConfirmEmailChange(c.Request.Context(), c.GetInt("userID"), c.GetString("sessionID"), req.Token, clientInfo(c))
+    if err != nil {
+        respondAccountError(c, err, "Failed to change email")
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Email successfully changed",
+        "email":   email,
+    })
+}
+
+// ExportData godoc
+// @Summary Выгрузить мои данные
+// @Description Возвращает все персональные данные пользователя: профиль, материалы с блоками, завершения, оценки, избранное и ссылки на загруженные файлы. format=zip отдает архив с отдельным JSON файлом на каждый раздел
+// @Tags profile
+// @Produce json
+// @Produce application/zip
+// @Security ApiKeyAuth
+// @Param format query string false "Формат выгрузки" Enums(json, zip) default(json)
+// @Success 200 {object} models.UserDataExport "Данные пользователя"
+// @Failure 400 {object} ErrorResponse "Неизвестный формат"
+// @Failure 500 {object} ErrorResponse "Ошибка сервера"
+// @Router /profile/export [get]
+func (h *ProfileHandler) ExportData(c *gin.Context) {
+    format := c.DefaultQuery("format", "json")
+    if format != "json" && format != "zip" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Format must be json or zip"})
+        return
+    }
+
+    export, err := h.accountService.ExportData(c.Request.Context(), c.GetInt("userID"), clientInfo(c))
+    if err != nil {
+        respondAccountError(c, err, "Failed to export data")
+        return
+    }
+
+    fileName := fmt.Sprintf("paydeya-export-%d-%s", export.Profile.ID, export.ExportedAt.Format("20060102"))
+    if format == "json" {
+        c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, fileName))
+        c.JSON(http.StatusOK, export)
+        return
+    }
+
+    c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, fileName))
+    c.Header("Content-Type", "application/zip")
+    c.Status(http.StatusOK)
+    if err := writeExportZip(c.Writer, export); err != nil {
+        // Заголовки уже отправлены, остается только оборвать архив
+        log.Printf("Failed to write export archive for user %d: %v", export.Profile.ID, err)
+    }
+}
+
+// writeExportZip пишет выгрузку архивом: по JSON файлу на раздел
+func writeExportZip(w io.Writer, export *models.UserDataExport) error {
+    archive := zip.NewWriter(w)
+
+    files := []struct {
+        name string
+        data interface{}
+    }{
+        {"profile.json", gin.H{
+            "exportedAt":      export.ExportedAt,
+            "profile":         export.Profile,
+            "specializations": export.Specializations,
+            "identities":      export.Identities,
+        }},
+        {"materials.json", export.Materials},
+        {"completions.json", export.Completions},
+        {"ratings.json", export.Ratings},
+        {"favorites.json", export.Favorites},
+        {"verification_applications.json", export.Applications},
+        {"media.json", export.Media},
+    }
+
+    for _, file := range files {
+        fw, err := archive.Create(file.name)
+        if err != nil {
+            return err
+        }
+        encoder := json.NewEncoder(fw)
+        encoder.SetIndent("", "  ")
+        if err := encoder.Encode(file.data); err != nil {
+            return err
+        }
+    }
+
+    return archive.Close()
+}
+
+// DeleteAccount godoc
+// @Summary Удалить аккаунт
+// @Description Планирует удаление аккаунта через 14 дней; до этого его можно отменить через DELETE /profile/deletion. Остальные сессии завершаются. Материалы преподавателя архивируются (аккаунт тогда обезличивается, а не удаляется) или передаются другому преподавателю
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param input body models.DeleteAccountRequest true "Пароль и судьба материалов"
+// @Success 202 {object} models.AccountDeletion "Удаление запланировано"
+// @Failure 400 {object} ErrorResponse "Неверный пароль или получатель материалов"
+// @Failure 500 {object} ErrorResponse "Ошибка сервера"
+// @Router /profile [delete]
+func (h *ProfileHandler) DeleteAccount(c *gin.Context) {
+    var req models.DeleteAccountRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    deletion, err := h.accountService.ScheduleDeletion(c.Request.Context(), c.GetInt("userID"), c.GetString("sessionID"), &req, clientInfo(c))
+    if err != nil {
+        respondAccountError(c, err, "Failed to schedule account deletion")
+        return
+    }
+
+    c.JSON(http.StatusAccepted, deletion)
+}
+
+// GetDeletion godoc
+// @Summary Запланированное удаление аккаунта
+// @Description Показывает, когда аккаунт будет удален
+// @Tags profile
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.AccountDeletion "Удаление запланировано"
+// @Failure 404 {object} ErrorResponse "Удаление не запланировано"
+// @Failure 500 {object} ErrorResponse "Ошибка сервера"
+// @Router /profile/deletion [get]
+func (h *ProfileHandler) GetDeletion(c *gin.Context) {
+    deletion, err := h.accountService.GetDeletion(c.Request.Context(), c.GetInt("userID"))
+    if err != nil {
+        respondAccountError(c, err, "Failed to get account deletion")
+        return
+    }
+
+    c.JSON(http.StatusOK, deletion)
+}
+
+// CancelDeletion godoc
+// @Summary Отменить удаление аккаунта
+// @Description Отменяет запланированное удаление, пока не истек период ожидания
+// @Tags profile
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} SuccessResponse "Удаление отменено"
+// @Failure 404 {object} ErrorResponse "Удаление не запланировано"
+// @Failure 500 {object} ErrorResponse "Ошибка сервера"
+// @Router /profile/deletion [delete]
+func (h *ProfileHandler) CancelDeletion(c *gin.Context) {
+    if err := h.accountService.CancelDeletion(c.Request.Context(), c.GetInt("userID"), clientInfo(c)); err != nil {
+        respondAccountError(c, err, "Failed to cancel account deletion")
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Account deletion cancelled",
+    })
+}
+
 // Request/Response models for Swagger
 
 // ProfileResponse represents user profile response
@@ -177,6 +444,7 @@ type ProfileResponse struct {
     Role            string    `json:"role" example:"teacher"`
     AvatarURL       string    `json:"avatarUrl" example:"https://example.com/avatars/123.jpg"`
     IsVerified      bool      `json:"isVerified" example:"true"`
+    EmailVerified   bool      `json:"emailVerified" example:"true"`
     Specializations []string  `json:"specializations" example:"math,physics"`
     CreatedAt       string    `json:"createdAt" example:"2023-01-15T10:30:00Z"`
     UpdatedAt       string    `json:"updatedAt" example:"2023-01-15T10:30:00Z"`
@@ -204,3 +472,10 @@ type UploadAvatarResponse struct {
     AvatarURL string `json:"avatarUrl" example:"https://example.com/avatars/123.jpg"`
 }
 
+
+// ConfirmEmailChangeResponse represents email change result
+// @Description Ответ на подтверждение смены email
+type ConfirmEmailChangeResponse struct {
+    Message string `json:"message" example:"Email successfully changed"`
+    Email   string `json:"email" example:"new@example.com"`
+}
@@ -41,7 +41,7 @@ func (h *ProgressHandler) GetProgress(c *gin.Context) {
 
 // MarkMaterialComplete godoc
 // @Summary Отметить материал как завершенный
-// @Description Отмечает материал как завершенный с оценкой и временем изучения
+// @Description Отмечает материал как завершенный со временем изучения. Запоминается опубликованная версия материала, которую изучал ученик. Оценка выставляется сервером по последним попыткам в тестах материала (нет тестов - нет оценки). Тест, по которому у ученика остались попытки и результаты не открыты, в оценку не входит
 // @Tags progress
 // @Accept json
 // @Produce json
@@ -66,7 +66,7 @@ func (h *ProgressHandler) MarkMaterialComplete(c *gin.Context) {
         return
     }
 
-    err = h.progressService.MarkMaterialComplete(c.Request.Context(), userID, materialID, req.TimeSpent, req.Grade)
+    grade, err := h.progressService.MarkMaterialComplete(c.Request.Context(), userID, materialID, req.TimeSpent)
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark material as complete"})
         return
@@ -75,6 +75,7 @@ func (h *ProgressHandler) MarkMaterialComplete(c *gin.Context) {
     c.JSON(http.StatusOK, gin.H{
         "message": "Material marked as completed",
         "materialID": materialID,
+        "grade": grade,
     })
 }
 
@@ -148,15 +149,15 @@ func (h *ProgressHandler) ToggleFavorite(c *gin.Context) {
 // MarkCompleteRequest represents mark material complete request
 // @Description Запрос на отметку материала как завершенного
 type MarkCompleteRequest struct {
-    TimeSpent int     `json:"timeSpent" binding:"required" example:"3600"`
-    Grade     float64 `json:"grade" binding:"required,min=1,max=5" example:"4.5"`
+    TimeSpent int `json:"timeSpent" binding:"required" example:"3600"`
 }
 
 // MarkCompleteResponse represents mark material complete response
 // @Description Ответ на отметку материала как завершенного
 type MarkCompleteResponse struct {
     Message    string `json:"message" example:"Material marked as completed"`
-    MaterialID int    `json:"materialID" example:"1"`
+    MaterialID int      `json:"materialID" example:"1"`
+    Grade      *float64 `json:"grade" example:"4.5"` // оценка по тестам материала, null если тестов нет
 }
 
 // ToggleFavoriteRequest represents toggle favorite request
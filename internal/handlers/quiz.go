@@ -0,0 +1,156 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+type QuizHandler struct {
+    quizService *services.QuizService
+}
+
+func NewQuizHandler(quizService *services.QuizService) *QuizHandler {
+    return &QuizHandler{quizService: quizService}
+}
+
+// SubmitAttempt godoc
+// @Summary Отправить ответы на тест
+// @Description Проверяет ответы по опубликованной версии теста и сохраняет попытку. Single - id варианта, multiple - массив id, numeric - число (с допуском), text - строка (без учета регистра и лишних пробелов). Число попыток ограничено maxAttempts теста (по умолчанию 3). Результаты проверки скрыты (resultsHidden), пока остаются попытки и преподаватель не открыл результаты. Оценка за завершенный материал пересчитывается по последним попыткам; пока результаты скрыты, тест в нее не входит
+// @Tags progress
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param blockId path string true "ID блока теста"
+// @Param input body models.SubmitQuizRequest true "Ответы"
+// @Success 201 {object} models.QuizAttempt "Попытка проверена"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса или ответы"
+// @Failure 404 {object} ErrorResponse "Материал или тест не найден"
+// @Failure 409 {object} ErrorResponse "Попытки закончились"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /student/materials/{id}/quizzes/{blockId}/attempts [post]
+func (h *QuizHandler) SubmitAttempt(c *gin.Context) {
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    var req models.SubmitQuizRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    attempt, err := h.quizService.SubmitAttempt(c.Request.Context(), c.GetInt("userID"), materialID, c.Param("blockId"), req.Answers)
+    if err != nil {
+        respondMaterialError(c, err, "Failed to submit quiz attempt")
+        return
+    }
+
+    c.JSON(http.StatusCreated, attempt)
+}
+
+// ListAttempts godoc
+// @Summary Мои попытки теста
+// @Description Возвращает попытки текущего пользователя по тесту, последние первыми. Результаты скрыты, пока остаются попытки и преподаватель не открыл результаты
+// @Tags progress
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param blockId path string true "ID блока теста"
+// @Success 200 {object} QuizAttemptsResponse "Попытки"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /student/materials/{id}/quizzes/{blockId}/attempts [get]
+func (h *QuizHandler) ListAttempts(c *gin.Context) {
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    attempts, err := h.quizService.ListAttempts(c.Request.Context(), c.GetInt("userID"), materialID, c.Param("blockId"))
+    if err != nil {
+        respondMaterialError(c, err, "Failed to get quiz attempts")
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "attempts": attempts,
+        "total":    len(attempts),
+    })
+}
+
+// GetStats godoc
+// @Summary Статистика теста
+// @Description Статистика по вопросам теста для автора материала: доля верных ответов, средний балл, сколько раз выбирали каждый вариант. Вопросы берутся из опубликованной версии
+// @Tags materials
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param blockId path string true "ID блока теста"
+// @Success 200 {object} models.QuizStats "Статистика"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Материал или тест не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /materials/{id}/quizzes/{blockId}/stats [get]
+func (h *QuizHandler) GetStats(c *gin.Context) {
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    stats, err := h.quizService.GetStats(c.Request.Context(), c.GetInt("userID"), c.GetString("userRole"), materialID, c.Param("blockId"))
+    if err != nil {
+        respondMaterialError(c, err, "Failed to get quiz stats")
+        return
+    }
+
+    c.JSON(http.StatusOK, stats)
+}
+
+// ReleaseResults godoc
+// @Summary Открыть результаты теста
+// @Description Показывает ученикам результаты проверки их попыток (правильность ответов, баллы, пояснения), не дожидаясь, пока они израсходуют попытки, и включает тест в их оценки за материал. Отменить нельзя
+// @Tags materials
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param blockId path string true "ID блока теста"
+// @Success 200 {object} SuccessResponse "Результаты открыты"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Материал или тест не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /materials/{id}/quizzes/{blockId}/release [post]
+func (h *QuizHandler) ReleaseResults(c *gin.Context) {
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    if err := h.quizService.ReleaseResults(c.Request.Context(), c.GetInt("userID"), c.GetString("userRole"), materialID, c.Param("blockId")); err != nil {
+        respondMaterialError(c, err, "Failed to release quiz results")
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Quiz results released"})
+}
+
+// Response models for Swagger
+
+// QuizAttemptsResponse represents quiz attempts list
+// @Description Ответ со списком попыток теста
+type QuizAttemptsResponse struct {
+    Attempts []models.QuizAttempt `json:"attempts"`
+    Total    int                  `json:"total" example:"3"`
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+    "errors"
+    "log"
+    "net/http"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+type RoleHandler struct {
+    authorizer *services.Authorizer
+}
+
+func NewRoleHandler(authorizer *services.Authorizer) *RoleHandler {
+    return &RoleHandler{authorizer: authorizer}
+}
+
+// ListRoles godoc
+// @Summary Получить роли
+// @Description Возвращает роли платформы и назначенные им права
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} RolesListResponse "Список ролей"
+// @Failure 401 {object} AuthErrorResponse "Требуется авторизация"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 500 {object} InternalErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/roles [get]
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+    roles, err := h.authorizer.ListRoles(c.Request.Context())
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get roles"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "roles": roles,
+        "total": len(roles),
+    })
+}
+
+// SaveRole godoc
+// @Summary Создать или изменить роль
+// @Description Создает роль или полностью заменяет набор ее прав. Позволяет завести, например, модератора без изменения кода. Новые права роли начинают действовать в течение минуты (права ролей кэшируются), перевыпускать токены не нужно. Нельзя убрать право role.manage, если после этого ни у одного действующего пользователя его не останется
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param input body models.SaveRoleRequest true "Роль и ее права"
+// @Success 200 {object} models.Role "Роль сохранена"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса или неизвестное право"
+// @Failure 401 {object} AuthErrorResponse "Требуется авторизация"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 409 {object} ErrorResponse "Управлять ролями стало бы некому"
+// @Failure 500 {object} InternalErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/roles [post]
+func (h *RoleHandler) SaveRole(c *gin.Context) {
+    var req models.SaveRoleRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    role, err := h.authorizer.SaveRole(c.Request.Context(), &req)
+    if errors.Is(err, services.ErrUnknownPermission) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if errors.Is(err, services.ErrLastRoleManager) {
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+        return
+    }
+    if err != nil {
+        log.Printf("Failed to save role %s: %v", req.Name, err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save role"})
+        return
+    }
+
+    c.JSON(http.StatusOK, role)
+}
+
+// Response models for Swagger
+
+// RolesListResponse represents roles list
+// @Description Ответ со списком ролей
+type RolesListResponse struct {
+    Roles []models.Role `json:"roles"`
+    Total int           `json:"total" example:"4"`
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+type SessionHandler struct {
+    sessionService *services.SessionService
+}
+
+func NewSessionHandler(sessionService *services.SessionService) *SessionHandler {
+    return &SessionHandler{sessionService: sessionService}
+}
+
+// GetMySessions godoc
+// @Summary Активные сессии
+// @Description Возвращает устройства, на которых выполнен вход; текущая сессия отмечена current
+// @Tags profile
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} SessionsListResponse "Список сессий"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /profile/sessions [get]
+func (h *SessionHandler) GetMySessions(c *gin.Context) {
+    h.listSessions(c, c.GetInt("userID"), c.GetString("sessionID"))
+}
+
+// RevokeMySession godoc
+// @Summary Завершить сессию
+// @Description Выходит из аккаунта на выбранном устройстве
+// @Tags profile
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ID сессии"
+// @Success 200 {object} SuccessResponse "Сессия завершена"
+// @Failure 404 {object} ErrorResponse "Сессия не найдена"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /profile/sessions/{id} [delete]
+func (h *SessionHandler) RevokeMySession(c *gin.Context) {
+    h.revokeSession(c, c.GetInt("userID"), c.Param("id"))
+}
+
+// GetUserSessions godoc
+// @Summary Сессии пользователя
+// @Description Возвращает активные сессии любого пользователя
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} SessionsListResponse "Список сессий"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 401 {object} AuthErrorResponse "Требуется авторизация"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 500 {object} InternalErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/users/{id}/sessions [get]
+func (h *SessionHandler) GetUserSessions(c *gin.Context) {
+    userID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+        return
+    }
+
+    h.listSessions(c, userID, "")
+}
+
+// RevokeUserSession godoc
+// @Summary Завершить сессию пользователя
+// @Description Принудительно завершает сессию пользователя на одном устройстве
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID пользователя"
+// @Param sessionId path string true "ID сессии"
+// @Success 200 {object} SuccessResponse "Сессия завершена"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 401 {object} AuthErrorResponse "Требуется авторизация"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Сессия не найдена"
+// @Failure 500 {object} InternalErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/users/{id}/sessions/{sessionId} [delete]
+func (h *SessionHandler) RevokeUserSession(c *gin.Context) {
+    userID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+        return
+    }
+
+    h.revokeSession(c, userID, c.Param("sessionId"))
+}
+
+func (h *SessionHandler) listSessions(c *gin.Context, userID int, currentSessionID string) {
+    sessions, err := h.sessionService.ListSessions(c.Request.Context(), userID, currentSessionID)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get sessions"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "sessions": sessions,
+        "total":    len(sessions),
+    })
+}
+
+func (h *SessionHandler) revokeSession(c *gin.Context, userID int, sessionID string) {
+    err := h.sessionService.RevokeSession(c.Request.Context(), userID, sessionID)
+    if errors.Is(err, services.ErrSessionNotFound) {
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        return
+    }
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// Response models for Swagger
+
+// SessionsListResponse represents active sessions list
+// @Description Ответ со списком активных сессий
+type SessionsListResponse struct {
+    Sessions []models.Session `json:"sessions"`
+    Total    int              `json:"total" example:"2"`
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+type TemplateHandler struct {
+    templateService *services.TemplateService
+}
+
+func NewTemplateHandler(templateService *services.TemplateService) *TemplateHandler {
+    return &TemplateHandler{templateService: templateService}
+}
+
+// GetGallery godoc
+// @Summary Галерея шаблонов
+// @Description Возвращает шаблоны, отобранные администраторами. Материал по шаблону создается через POST /materials/{id}/instantiate
+// @Tags materials
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} TemplateGalleryResponse "Шаблоны"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /templates [get]
+func (h *TemplateHandler) GetGallery(c *gin.Context) {
+    templates, err := h.templateService.GetGallery(c.Request.Context())
+    if err != nil {
+        respondMaterialError(c, err, "Failed to get templates")
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "templates": templates,
+        "total":     len(templates),
+    })
+}
+
+// SaveGalleryTemplate godoc
+// @Summary Добавить шаблон в галерею
+// @Description Добавляет опубликованный шаблон в галерею или меняет его описание и место. Шаблоны, которые автор снял с публикации или перестал считать шаблонами, в галерее не показываются
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param input body models.GalleryTemplateRequest true "Шаблон"
+// @Success 200 {object} SuccessResponse "Галерея обновлена"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса или материал не шаблон"
+// @Failure 403 {object} ForbiddenErrorResponse "Нет права template.manage"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал не найден или не опубликован"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /admin/templates [post]
+func (h *TemplateHandler) SaveGalleryTemplate(c *gin.Context) {
+    var req models.GalleryTemplateRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    if err := h.templateService.SaveToGallery(c.Request.Context(), c.GetInt("userID"), &req); err != nil {
+        respondMaterialError(c, err, "Failed to save template")
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Template saved to gallery"})
+}
+
+// RemoveGalleryTemplate godoc
+// @Summary Убрать шаблон из галереи
+// @Description Убирает шаблон из галереи. Сам материал и признак шаблона не меняются
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Success 200 {object} SuccessResponse "Шаблон убран"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Нет права template.manage"
+// @Failure 404 {object} ErrorResponse "Шаблона нет в галерее"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /admin/templates/{id} [delete]
+func (h *TemplateHandler) RemoveGalleryTemplate(c *gin.Context) {
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    if err := h.templateService.RemoveFromGallery(c.Request.Context(), materialID); err != nil {
+        respondMaterialError(c, err, "Failed to remove template")
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Template removed from gallery"})
+}
+
+// Response models for Swagger
+
+// TemplateGalleryResponse represents template gallery
+// @Description Ответ с галереей шаблонов
+type TemplateGalleryResponse struct {
+    Templates []models.GalleryTemplate `json:"templates"`
+    Total     int                      `json:"total" example:"8"`
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+    "errors"
+    "log"
+    "net/http"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+type TwoFactorHandler struct {
+    twoFactorService *services.TwoFactorService
+}
+
+func NewTwoFactorHandler(twoFactorService *services.TwoFactorService) *TwoFactorHandler {
+    return &TwoFactorHandler{twoFactorService: twoFactorService}
+}
+
+// respondTwoFactorError переводит ошибки 2FA в HTTP ответы
+func respondTwoFactorError(c *gin.Context, err error, message string) {
+    switch {
+    case errors.Is(err, services.ErrTwoFactorAlreadyEnabled), errors.Is(err, services.ErrTwoFactorNotEnabled),
+        errors.Is(err, services.ErrTwoFactorNotSetUp):
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+    case errors.Is(err, services.ErrInvalidTwoFactorCode), errors.Is(err, services.ErrInvalidPassword):
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    case errors.Is(err, services.ErrTwoFactorLocked):
+        c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+    default:
+        log.Printf("%s: %v", message, err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+    }
+}
+
+// GetStatus godoc
+// @Summary Состояние двухфакторной аутентификации
+// @Description Показывает, включена ли 2FA, и сколько осталось кодов восстановления
+// @Tags profile
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.TwoFactorStatus "Состояние 2FA"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /profile/2fa [get]
+func (h *TwoFactorHandler) GetStatus(c *gin.Context) {
+    status, err := h.twoFactorService.GetStatus(c.Request.Context(), c.GetInt("userID"))
+    if err != nil {
+        respondTwoFactorError(c, err, "Failed to get two-factor status")
+        return
+    }
+
+    c.JSON(http.StatusOK, status)
+}
+
+// Setup godoc
+// @Summary Начать подключение 2FA
+// @Description Генерирует TOTP секрет и otpauth ссылку для QR-кода. 2FA включается после подтверждения кодом
+// @Tags profile
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.TwoFactorSetupResponse "Секрет и ссылка"
+// @Failure 409 {object} ErrorResponse "2FA уже включена"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /profile/2fa/setup [post]
+func (h *TwoFactorHandler) Setup(c *gin.Context) {
+    setup, err := h.twoFactorService.Setup(c.Request.Context(), c.GetInt("userID"))
+    if err != nil {
+        respondTwoFactorError(c, err, "Failed to set up two-factor authentication")
+        return
+    }
+
+    c.JSON(http.StatusOK, setup)
+}
+
+// Confirm godoc
+// @Summary Подтвердить подключение 2FA
+// @Description Проверяет первый код из приложения, включает 2FA и возвращает коды восстановления (показываются один раз)
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param input body models.TwoFactorCodeRequest true "Код из приложения"
+// @Success 200 {object} models.RecoveryCodesResponse "2FA включена"
+// @Failure 400 {object} ErrorResponse "Неверный код"
+// @Failure 409 {object} ErrorResponse "Подключение не начато или 2FA уже включена"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /profile/2fa/confirm [post]
+func (h *TwoFactorHandler) Confirm(c *gin.Context) {
+    var req models.TwoFactorCodeRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    codes, err := h.twoFactorService.Confirm(c.Request.Context(), c.GetInt("userID"), req.Code)
+    if err != nil {
+        respondTwoFactorError(c, err, "Failed to enable two-factor authentication")
+        return
+    }
+
+    c.JSON(http.StatusOK, models.RecoveryCodesResponse{
+        Message:       "Two-factor authentication enabled",
+        RecoveryCodes: codes,
+    })
+}
+
+// Disable godoc
+// @Summary Отключить 2FA
+// @Description Отключает двухфакторную аутентификацию. Требует пароль и код из приложения или код восстановления
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param input body models.DisableTwoFactorRequest true "Пароль и код"
+// @Success 200 {object} SuccessResponse "2FA отключена"
+// @Failure 400 {object} ErrorResponse "Неверный пароль или код"
+// @Failure 409 {object} ErrorResponse "2FA не включена"
+// @Failure 429 {object} ErrorResponse "Слишком много неверных кодов"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /profile/2fa/disable [post]
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+    var req models.DisableTwoFactorRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    if err := h.twoFactorService.Disable(c.Request.Context(), c.GetInt("userID"), req.Password, req.Code); err != nil {
+        respondTwoFactorError(c, err, "Failed to disable two-factor authentication")
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Перевыпустить коды восстановления
+// @Description Выдает новые коды восстановления, старые перестают действовать
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param input body models.TwoFactorCodeRequest true "Код из приложения или код восстановления"
+// @Success 200 {object} models.RecoveryCodesResponse "Новые коды"
+// @Failure 400 {object} ErrorResponse "Неверный код"
+// @Failure 409 {object} ErrorResponse "2FA не включена"
+// @Failure 429 {object} ErrorResponse "Слишком много неверных кодов"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /profile/2fa/recovery-codes [post]
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+    var req models.TwoFactorCodeRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    codes, err := h.twoFactorService.RegenerateRecoveryCodes(c.Request.Context(), c.GetInt("userID"), req.Code)
+    if err != nil {
+        respondTwoFactorError(c, err, "Failed to regenerate recovery codes")
+        return
+    }
+
+    c.JSON(http.StatusOK, models.RecoveryCodesResponse{
+        Message:       "Recovery codes regenerated",
+        RecoveryCodes: codes,
+    })
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "strconv"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+type VerificationHandler struct {
+    verificationService *services.VerificationService
+}
+
+func NewVerificationHandler(verificationService *services.VerificationService) *VerificationHandler {
+    return &VerificationHandler{verificationService: verificationService}
+}
+
+// SubmitApplication godoc
+// @Summary Подать заявку на верификацию
+// @Description Преподаватель загружает документы (pdf, jpg, png до 10MB, не более 5 файлов) для подтверждения квалификации
+// @Tags teacher
+// @Accept multipart/form-data
+// @Produce json
+// @Security ApiKeyAuth
+// @Param documents formData file true "Документы"
+// @Param message formData string false "Комментарий к заявке"
+// @Success 201 {object} models.TeacherApplication "Заявка создана"
+// @Failure 400 {object} InvalidFileErrorResponse "Неверные файлы"
+// @Failure 403 {object} ForbiddenErrorResponse "Только для преподавателей"
+// @Failure 409 {object} ErrorResponse "Заявка уже на рассмотрении"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /teacher/verification/applications [post]
+func (h *VerificationHandler) SubmitApplication(c *gin.Context) {
+    userID := c.GetInt("userID")
+
+    form, err := c.MultipartForm()
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Multipart form with documents is required"})
+        return
+    }
+
+    application, err := h.verificationService.SubmitApplication(
+        c.Request.Context(), userID, c.PostForm("message"), form.File["documents"],
+    )
+    switch {
+    case errors.Is(err, services.ErrNotTeacher):
+        c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+        return
+    case errors.Is(err, services.ErrTeacherAlreadyVerified), errors.Is(err, services.ErrApplicationPending):
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+        return
+    case err != nil:
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusCreated, application)
+}
+
+// GetMyApplications godoc
+// @Summary Мои заявки на верификацию
+// @Description Возвращает заявки текущего преподавателя с решениями администраторов
+// @Tags teacher
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} ApplicationsListResponse "Список заявок"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /teacher/verification/applications [get]
+func (h *VerificationHandler) GetMyApplications(c *gin.Context) {
+    userID := c.GetInt("userID")
+
+    applications, err := h.verificationService.GetUserApplications(c.Request.Context(), userID)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get applications"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "applications": applications,
+        "total":        len(applications),
+    })
+}
+
+// ListApplications godoc
+// @Summary Получить заявки преподавателей
+// @Description Возвращает заявки на верификацию с фильтрацией по статусу
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param status query string false "Фильтр по статусу" Enums(pending, approved, rejected)
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество записей на странице" default(20)
+// @Success 200 {object} ApplicationsListResponse "Список заявок"
+// @Failure 401 {object} AuthErrorResponse "Требуется авторизация"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 500 {object} InternalErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/teachers/applications [get]
+func (h *VerificationHandler) ListApplications(c *gin.Context) {
+    status := c.Query("status")
+    page, _ := strconv.Atoi(c.Query("page"))
+    limit, _ := strconv.Atoi(c.Query("limit"))
+
+    if page <= 0 {
+        page = 1
+    }
+    if limit <= 0 || limit > 100 {
+        limit = 20
+    }
+
+    applications, total, err := h.verificationService.ListApplications(c.Request.Context(), status, page, limit)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get applications"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "applications": applications,
+        "total":        total,
+        "page":         page,
+        "limit":        limit,
+    })
+}
+
+// ApproveApplication godoc
+// @Summary Одобрить заявку преподавателя
+// @Description Одобряет заявку и отмечает преподавателя как верифицированного
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID заявки"
+// @Param input body models.ReviewApplicationRequest false "Комментарий"
+// @Success 200 {object} models.TeacherApplication "Заявка одобрена"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 404 {object} ErrorResponse "Заявка не найдена"
+// @Failure 409 {object} ErrorResponse "Заявка уже рассмотрена"
+// @Failure 500 {object} InternalErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/teachers/applications/{id}/approve [post]
+func (h *VerificationHandler) ApproveApplication(c *gin.Context) {
+    h.review(c, h.verificationService.ApproveApplication)
+}
+
+// RejectApplication godoc
+// @Summary Отклонить заявку преподавателя
+// @Description Отклоняет заявку с обязательным комментарием
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID заявки"
+// @Param input body models.ReviewApplicationRequest true "Причина отказа"
+// @Success 200 {object} models.TeacherApplication "Заявка отклонена"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 404 {object} ErrorResponse "Заявка не найдена"
+// @Failure 409 {object} ErrorResponse "Заявка уже рассмотрена"
+// @Failure 500 {object} InternalErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/teachers/applications/{id}/reject [post]
+func (h *VerificationHandler) RejectApplication(c *gin.Context) {
+    h.review(c, h.verificationService.RejectApplication)
+}
+
+func (h *VerificationHandler) review(c *gin.Context, decide func(ctx context.Context, adminID, applicationID int, comment string) (*models.TeacherApplication, error)) {
+    adminID := c.GetInt("userID")
+    applicationID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid application ID"})
+        return
+    }
+
+    var req models.ReviewApplicationRequest
+    if c.Request.ContentLength > 0 {
+        if err := c.ShouldBindJSON(&req); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+            return
+        }
+    }
+
+    application, err := decide(c.Request.Context(), adminID, applicationID, req.Comment)
+    switch {
+    case errors.Is(err, services.ErrApplicationNotFound):
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        return
+    case errors.Is(err, services.ErrApplicationReviewed):
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+        return
+    case errors.Is(err, services.ErrRejectCommentRequired):
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    case err != nil:
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to review application"})
+        return
+    }
+
+    c.JSON(http.StatusOK, application)
+}
+
+// Response models for Swagger
+
+// ApplicationsListResponse represents verification applications list
+// @Description Ответ со списком заявок на верификацию
+type ApplicationsListResponse struct {
+    Applications []models.TeacherApplication `json:"applications"`
+    Total        int                         `json:"total" example:"3"`
+    Page         int                         `json:"page,omitempty" example:"1"`
+    Limit        int                         `json:"limit,omitempty" example:"20"`
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+    "net/http"
+    "strings"
+
+    "paydeya-backend/internal/models"
+)
+
+// apiKeyRoute связывает маршруты с областями доступа API ключей.
+// read - область для GET запросов, write - для остальных (пусто = недоступно).
+type apiKeyRoute struct {
+    method string // если задан, правило действует только для этого метода
+    path   string
+    prefix bool // правило действует и на все вложенные маршруты
+    read   string
+    write  string
+}
+
+// apiKeyRoutes перечисляет все маршруты, доступные по API ключу. Остальные
+// (пароль, email, 2FA, сессии, сами ключи, удаление аккаунта и т.п.) требуют
+// входа по паролю, чтобы утечка ключа не давала захватить аккаунт.
+var apiKeyRoutes = []apiKeyRoute{
+    {method: http.MethodGet, path: "/api/v1/profile", read: models.ScopeProfileRead},
+    {method: http.MethodPatch, path: "/api/v1/profile", write: models.ScopeProfileWrite},
+    {method: http.MethodPost, path: "/api/v1/profile/avatar", write: models.ScopeProfileWrite},
+    {path: "/api/v1/materials", prefix: true, read: models.ScopeMaterialsRead, write: models.ScopeMaterialsWrite},
+    {path: "/api/v1/upload", prefix: true, write: models.ScopeMaterialsWrite},
+    {path: "/api/v1/embed", prefix: true, write: models.ScopeMaterialsWrite},
+    {path: "/api/v1/student", prefix: true, read: models.ScopeProgressRead, write: models.ScopeProgressWrite},
+    // Вход под пользователем выдает новые токены - только после входа по паролю
+    {path: "/api/v1/admin/users/:id/impersonate"},
+    {path: "/api/v1/admin", prefix: true, read: models.ScopeAdmin, write: models.ScopeAdmin},
+}
+
+// apiKeyScope возвращает область, нужную для вызова маршрута по API ключу.
+// false означает, что маршрут по ключу недоступен.
+func apiKeyScope(method, fullPath string) (string, bool) {
+    for _, route := range apiKeyRoutes {
+        matches := fullPath == route.path ||
+            (route.prefix && strings.HasPrefix(fullPath, route.path+"/"))
+        if !matches || (route.method != "" && route.method != method) {
+            continue
+        }
+
+        scope := route.write
+        if method == http.MethodGet || method == http.MethodHead {
+            scope = route.read
+        }
+        return scope, scope != ""
+    }
+    return "", false
+}
@@ -1,6 +1,8 @@
 package middleware
 
 import (
+    "errors"
+    "log"
     "net/http"
     "strings"
 
@@ -9,8 +11,16 @@ import (
     "github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
+// AuthMiddleware пускает запросы с access токеном (Authorization: Bearer) или
+// с персональным API ключом (X-API-Key). В обоих случаях в контекст попадают
+// одни и те же данные пользователя, поэтому обработчики не различают способ входа.
+func AuthMiddleware(authService *services.AuthService, apiKeyService *services.APIKeyService) gin.HandlerFunc {
     return func(c *gin.Context) {
+        if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+            authenticateAPIKey(c, apiKeyService, apiKey)
+            return
+        }
+
         // Получаем токен из заголовка
         authHeader := c.GetHeader("Authorization")
         if authHeader == "" {
@@ -41,7 +51,64 @@ func AuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
         c.Set("userID", claims.UserID)
         c.Set("userEmail", claims.Email)
         c.Set("userRole", claims.Role)
+        c.Set("sessionID", claims.SessionID)
+
+        // Токен входа под пользователем: запоминаем, кто действует на самом деле
+        if claims.Actor != nil {
+            actorID := claims.ActorID()
+            if actorID == 0 {
+                c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+                c.Abort()
+                return
+            }
+            c.Set("actorID", actorID)
+            c.Set("actorEmail", claims.Actor.Email)
+            c.Set("impersonationID", claims.ID)
+        }
 
         c.Next()
     }
-}
\ No newline at end of file
+}
+
+// authenticateAPIKey проверяет API ключ и область доступа маршрута
+func authenticateAPIKey(c *gin.Context, apiKeyService *services.APIKeyService, apiKey string) {
+    owner, err := apiKeyService.Authenticate(c.Request.Context(), apiKey)
+    switch {
+    case errors.Is(err, services.ErrInvalidAPIKey):
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+        c.Abort()
+        return
+    case errors.Is(err, services.ErrAccountBlocked):
+        c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+        c.Abort()
+        return
+    case err != nil:
+        log.Printf("API key authentication failed: %v", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check API key"})
+        c.Abort()
+        return
+    }
+
+    scope, ok := apiKeyScope(c.Request.Method, c.FullPath())
+    if !ok {
+        c.JSON(http.StatusForbidden, gin.H{"error": "This endpoint is not available with an API key"})
+        c.Abort()
+        return
+    }
+    if !owner.Key.HasScope(scope) {
+        c.JSON(http.StatusForbidden, gin.H{
+            "error":         "API key does not have the required scope",
+            "requiredScope": scope,
+        })
+        c.Abort()
+        return
+    }
+
+    c.Set("userID", owner.Key.UserID)
+    c.Set("userEmail", owner.Email)
+    c.Set("userRole", owner.Role)
+    c.Set("sessionID", "")
+    c.Set("apiKeyID", owner.Key.ID)
+
+    c.Next()
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+    "log"
+    "net/http"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+// AuditImpersonation записывает в журнал аудита каждый запрос, выполненный
+// администратором от имени пользователя. Подключается после AuthMiddleware.
+func AuditImpersonation(impersonationService *services.ImpersonationService) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        actorID := c.GetInt("actorID")
+        if actorID == 0 {
+            c.Next()
+            return
+        }
+
+        c.Next()
+
+        err := impersonationService.RecordRequest(c.Request.Context(), &models.ImpersonatedRequest{
+            UserID:          c.GetInt("userID"),
+            ActorID:         actorID,
+            ImpersonationID: c.GetString("impersonationID"),
+            Method:          c.Request.Method,
+            Path:            c.Request.URL.RequestURI(),
+            Status:          c.Writer.Status(),
+            Client: models.ClientInfo{
+                UserAgent: c.Request.UserAgent(),
+                IP:        c.ClientIP(),
+            },
+        })
+        if err != nil {
+            log.Printf("⚠️ Failed to audit impersonated request %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+        }
+    }
+}
+
+// DenyImpersonation запрещает действие при входе под пользователем. Подключается
+// к маршрутам, которые меняют учетные данные или судьбу аккаунта (пароль, email,
+// 2FA, удаление и т.п.): их пользователь должен выполнять сам.
+func DenyImpersonation() gin.HandlerFunc {
+    return func(c *gin.Context) {
+        if c.GetInt("actorID") != 0 {
+            c.JSON(http.StatusForbidden, gin.H{
+                "error": "This action is not allowed while impersonating a user",
+            })
+            c.Abort()
+            return
+        }
+
+        c.Next()
+    }
+}
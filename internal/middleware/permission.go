@@ -0,0 +1,34 @@
+package middleware
+
+import (
+    "log"
+    "net/http"
+
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+// RequirePermission пропускает запрос, только если у роли пользователя есть
+// все перечисленные права. Используется после AuthMiddleware.
+func RequirePermission(authorizer *services.Authorizer, permissions ...string) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        allowed, err := authorizer.HasPermission(c.Request.Context(), c.GetString("userRole"), permissions...)
+        if err != nil {
+            log.Printf("Permission check failed: %v", err)
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+            c.Abort()
+            return
+        }
+
+        if !allowed {
+            c.JSON(http.StatusForbidden, gin.H{
+                "error": "Access denied. Insufficient permissions",
+            })
+            c.Abort()
+            return
+        }
+
+        c.Next()
+    }
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+    "net/http"
+    "strconv"
+    "sync"
+    "time"
+
+    "github.com/gin-gonic/gin"
+)
+
+// maxRateLimitClients ограничивает число адресов, которые учитываются в одном окне
+const maxRateLimitClients = 100000
+
+// rateLimiter считает запросы с каждого адреса в окнах фиксированной длины.
+// Счетчики сбрасываются целиком в начале окна, поэтому память ограничена
+// числом адресов за одно окно.
+type rateLimiter struct {
+    mu          sync.Mutex
+    limit       int
+    window      time.Duration
+    windowStart time.Time
+    counts      map[string]int
+}
+
+// allow учитывает запрос и возвращает, сколько ждать, если лимит исчерпан
+func (l *rateLimiter) allow(client string, now time.Time) (bool, time.Duration) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    if now.Sub(l.windowStart) >= l.window {
+        l.windowStart = now
+        l.counts = make(map[string]int)
+    }
+    retryAfter := l.windowStart.Add(l.window).Sub(now)
+
+    count, ok := l.counts[client]
+    if !ok && len(l.counts) >= maxRateLimitClients {
+        return false, retryAfter
+    }
+    if count >= l.limit {
+        return false, retryAfter
+    }
+    l.counts[client] = count + 1
+    return true, 0
+}
+
+// RateLimit ограничивает число запросов с одного адреса (c.ClientIP) до limit за window.
+// Подключается к открытым маршрутам, которые выполняют заметную работу на сервере.
+func RateLimit(limit int, window time.Duration) gin.HandlerFunc {
+    limiter := &rateLimiter{limit: limit, window: window}
+
+    return func(c *gin.Context) {
+        allowed, retryAfter := limiter.allow(c.ClientIP(), time.Now())
+        if !allowed {
+            seconds := int(retryAfter.Seconds())
+            if retryAfter > time.Duration(seconds)*time.Second {
+                seconds++
+            }
+            c.Header("Retry-After", strconv.Itoa(seconds))
+            c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests, try again later"})
+            c.Abort()
+            return
+        }
+
+        c.Next()
+    }
+}
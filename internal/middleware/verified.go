@@ -0,0 +1,32 @@
+package middleware
+
+import (
+    "net/http"
+
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+// RequireVerifiedEmail пропускает только пользователей с подтвержденным email.
+// Подключается к отдельным маршрутам (например, публикации материалов) после AuthMiddleware.
+func RequireVerifiedEmail(authService *services.AuthService) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        verified, err := authService.IsEmailVerified(c.Request.Context(), c.GetInt("userID"))
+        if err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check email verification"})
+            c.Abort()
+            return
+        }
+
+        if !verified {
+            c.JSON(http.StatusForbidden, gin.H{
+                "error": "Email verification required",
+            })
+            c.Abort()
+            return
+        }
+
+        c.Next()
+    }
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Что сделать с материалами преподавателя при удалении аккаунта
+const (
+    MaterialsActionArchive  = "archive"
+    MaterialsActionTransfer = "transfer"
+)
+
+// AccountDeletion represents scheduled account deletion
+// @Description Запланированное удаление аккаунта
+type AccountDeletion struct {
+    UserID          int       `json:"userId" example:"123"`
+    MaterialsAction string    `json:"materialsAction" example:"archive"` // archive, transfer
+    TransferToID    *int      `json:"transferToId,omitempty" example:"45"`
+    RequestedAt     time.Time `json:"requestedAt" example:"2023-01-15T10:30:00Z"`
+    ScheduledFor    time.Time `json:"scheduledFor" example:"2023-01-29T10:30:00Z"`
+}
+
+// DeleteAccountRequest represents account deletion request
+// @Description Запрос на удаление аккаунта
+type DeleteAccountRequest struct {
+    Password string `json:"password" binding:"required"`
+    // Авторские материалы: archive - снять с публикации (аккаунт будет обезличен,
+    // а не удален, чтобы материалы сохранились), transfer - передать другому преподавателю
+    MaterialsAction string `json:"materialsAction" binding:"omitempty,oneof=archive transfer" example:"archive"`
+    TransferToEmail string `json:"transferToEmail" binding:"required_if=MaterialsAction transfer,omitempty,email" example:"colleague@school.ru"`
+}
+
+// MaterialRating represents user rating of material
+// @Description Оценка материала пользователем
+type MaterialRating struct {
+    MaterialID int       `json:"materialId" example:"1"`
+    Rating     int       `json:"rating" example:"5"`
+    CreatedAt  time.Time `json:"createdAt" example:"2023-01-15T10:30:00Z"`
+}
+
+// UserDataExport represents all personal data of user
+// @Description Выгрузка персональных данных пользователя
+type UserDataExport struct {
+    ExportedAt      time.Time              `json:"exportedAt"`
+    Profile         *User                  `json:"profile"`
+    Specializations []string               `json:"specializations"`
+    Identities      []UserIdentity         `json:"identities"`
+    Materials       []*Material            `json:"materials"`
+    Completions     []MaterialCompletion   `json:"completions"`
+    QuizAttempts    []QuizAttempt          `json:"quizAttempts"`
+    Submissions     []AssignmentSubmission `json:"assignmentSubmissions"`
+    Ratings         []MaterialRating       `json:"ratings"`
+    Favorites       []FavoriteMaterial     `json:"favorites"`
+    Applications    []TeacherApplication   `json:"verificationApplications"`
+    Media           []string               `json:"media"` // ссылки на загруженные файлы
+}
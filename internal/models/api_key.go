@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// Области доступа API ключей. Ключ действует от имени владельца,
+// но только в пределах выданных областей.
+const (
+    ScopeMaterialsRead  = "materials:read"
+    ScopeMaterialsWrite = "materials:write"
+    ScopeProgressRead   = "progress:read"
+    ScopeProgressWrite  = "progress:write"
+    ScopeProfileRead    = "profile:read"
+    ScopeProfileWrite   = "profile:write"
+    ScopeAdmin          = "admin"
+)
+
+// APIKeyScopes перечисляет все области, которые можно выдать ключу
+var APIKeyScopes = []string{
+    ScopeMaterialsRead, ScopeMaterialsWrite,
+    ScopeProgressRead, ScopeProgressWrite,
+    ScopeProfileRead, ScopeProfileWrite,
+    ScopeAdmin,
+}
+
+// APIKey represents personal API key
+// @Description Персональный API ключ (сам ключ показывается только при создании)
+type APIKey struct {
+    ID         int        `json:"id" example:"1"`
+    UserID     int        `json:"-"`
+    Name       string     `json:"name" example:"Импорт из LMS"`
+    Prefix     string     `json:"prefix" example:"pdk_3f2b8c1e"`
+    Scopes     []string   `json:"scopes" example:"materials:read,progress:read"`
+    ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+    LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+    RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+    CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// HasScope проверяет, выдана ли ключу область
+func (k *APIKey) HasScope(scope string) bool {
+    for _, s := range k.Scopes {
+        if s == scope {
+            return true
+        }
+    }
+    return false
+}
+
+// APIKeyOwner - владелец ключа, от имени которого выполняется запрос
+type APIKeyOwner struct {
+    Key   *APIKey
+    Email string
+    Role  string
+}
+
+// CreateAPIKeyRequest represents API key creation request
+// @Description Запрос на создание API ключа
+type CreateAPIKeyRequest struct {
+    Name          string   `json:"name" binding:"required,max=100" example:"Импорт из LMS"`
+    Scopes        []string `json:"scopes" binding:"required,min=1,dive,oneof=materials:read materials:write progress:read progress:write profile:read profile:write admin" example:"materials:read,progress:read"`
+    ExpiresInDays int      `json:"expiresInDays,omitempty" binding:"omitempty,min=1,max=365" example:"90"`
+}
+
+// CreateAPIKeyResponse represents created API key
+// @Description Созданный API ключ. Значение key больше нигде не показывается
+type CreateAPIKeyResponse struct {
+    Key    string  `json:"key" example:"pdk_3f2b8c1e..."`
+    APIKey *APIKey `json:"apiKey"`
+}
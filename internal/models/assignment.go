@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Способы сдачи задания
+const (
+    SubmissionTypeText = "text" // только текстовый ответ
+    SubmissionTypeFile = "file" // только файл
+    SubmissionTypeAny  = "any"  // текст и/или файл
+)
+
+// Статусы работы ученика
+const (
+    SubmissionStatusSubmitted = "submitted" // ожидает проверки
+    SubmissionStatusGraded    = "graded"    // оценена
+    SubmissionStatusReturned  = "returned"  // возвращена на доработку
+)
+
+// AssignmentSubmission represents student work on assignment block
+// @Description Работа ученика по заданию с ручной проверкой
+type AssignmentSubmission struct {
+    ID            int        `json:"id" example:"1"`
+    MaterialID    int        `json:"materialId" example:"1"`
+    MaterialTitle string     `json:"materialTitle,omitempty" example:"Квадратные уравнения"`
+    BlockID       string     `json:"blockId" example:"task1"`
+    UserID        int        `json:"userId" example:"12"`
+    StudentName   string     `json:"studentName,omitempty" example:"Иван Петров"`
+    Revision      int        `json:"revision" example:"4"` // версия материала, по которой выполнялось задание
+    Text          string     `json:"text,omitempty" example:"Ответ: x = 2"`
+    FileID        string     `json:"fileId,omitempty" example:"3f8c1a2e-5b7d-4e9f-a1c3-d5e7f9a1b3c5"` // скачивается через /files/{fileId}
+    FileURL       string     `json:"fileUrl,omitempty"`                                              // только у работ, сданных до закрытого хранения файлов
+    FileName      string     `json:"fileName,omitempty" example:"solution.pdf"`
+    Status        string     `json:"status" example:"submitted"` // submitted, graded, returned
+    Score         *float64   `json:"score,omitempty" example:"8"`
+    MaxScore      float64    `json:"maxScore" example:"10"`
+    Percent       *float64   `json:"percent,omitempty" example:"80"`
+    Feedback      string     `json:"feedback,omitempty" example:"Хорошее решение, но не хватает проверки корней"`
+    GradedBy      *int       `json:"gradedBy,omitempty" example:"3"`
+    GradedAt      *time.Time `json:"gradedAt,omitempty"`
+    SubmittedAt   time.Time  `json:"submittedAt"`
+}
+
+// ReviewSubmissionRequest represents teacher review of submission
+// @Description Проверка работы: оценка (graded) или возврат на доработку (returned)
+type ReviewSubmissionRequest struct {
+    Status   string   `json:"status" binding:"required,oneof=graded returned" example:"graded"`
+    Score    *float64 `json:"score" binding:"omitempty,min=0" example:"8"`
+    Feedback string   `json:"feedback" binding:"max=5000" example:"Хорошее решение"`
+}
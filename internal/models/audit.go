@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Действия, которые записываются в журнал аудита
+const (
+    AuditPasswordChanged      = "password_changed"
+    AuditEmailChangeRequested = "email_change_requested"
+    AuditEmailChanged         = "email_changed"
+    AuditDataExported         = "data_exported"
+    AuditDeletionRequested    = "account_deletion_requested"
+    AuditDeletionCancelled    = "account_deletion_cancelled"
+    AuditAccountDeleted       = "account_deleted"
+    AuditAPIKeyCreated        = "api_key_created"
+    AuditAPIKeyRevoked        = "api_key_revoked"
+    AuditImpersonationStarted = "impersonation_started"
+    AuditImpersonatedRequest  = "impersonated_request"
+)
+
+// AuditEntry represents account audit record
+// @Description Запись журнала аудита
+type AuditEntry struct {
+    ID        int64                  `json:"id"`
+    UserID    int                    `json:"userId"`
+    ActorID   int                    `json:"actorId"`
+    Action    string                 `json:"action" example:"password_changed"`
+    Details   map[string]interface{} `json:"details,omitempty"`
+    IP        string                 `json:"ip,omitempty" example:"203.0.113.7"`
+    UserAgent string                 `json:"userAgent,omitempty"`
+    CreatedAt time.Time              `json:"createdAt"`
+}
@@ -0,0 +1,64 @@
+package models
+
+// Типы блоков
+const (
+    BlockTypeText       = "text"
+    BlockTypeImage      = "image"
+    BlockTypeVideo      = "video"
+    BlockTypeFormula    = "formula"
+    BlockTypeQuiz       = "quiz"
+    BlockTypeAssignment = "assignment"
+)
+
+// Типы полей схемы
+const (
+    FieldTypeString  = "string"
+    FieldTypeNumber  = "number"
+    FieldTypeInteger = "integer"
+    FieldTypeBoolean = "boolean"
+    FieldTypeArray   = "array"
+    FieldTypeObject  = "object"
+)
+
+// Форматы строковых полей
+const (
+    FieldFormatImageURL = "image-url" // файл из нашего хранилища (images)
+    FieldFormatVideoURL = "video-url" // файл из нашего хранилища (videos) или ссылка видеосервиса
+    FieldFormatLinkURL  = "link-url"  // http(s) или mailto ссылка
+)
+
+// BlockSchema represents content schema of a block type
+// @Description Схема содержимого блока определенного типа
+type BlockSchema struct {
+    Type        string        `json:"type" example:"image"`
+    Description string        `json:"description" example:"Изображение из хранилища"`
+    Fields      []SchemaField `json:"fields"`
+}
+
+// SchemaField represents a field of block content
+// @Description Поле содержимого блока
+type SchemaField struct {
+    Name        string        `json:"name,omitempty" example:"url"`
+    Type        string        `json:"type" example:"string"` // string, number, integer, boolean, array, object
+    Required    bool          `json:"required,omitempty"`
+    Hidden      bool          `json:"hidden,omitempty"` // не отдается ученикам (ключи ответов и т.п.)
+    Description string        `json:"description,omitempty"`
+    Enum        []string      `json:"enum,omitempty"`
+    Format      string        `json:"format,omitempty" example:"image-url"`
+    MaxLength   int           `json:"maxLength,omitempty" example:"500"`
+    Min         *float64      `json:"min,omitempty"`
+    Max         *float64      `json:"max,omitempty"`
+    MinItems    int           `json:"minItems,omitempty"`
+    MaxItems    int           `json:"maxItems,omitempty"`
+    Items       *SchemaField  `json:"items,omitempty"`  // элементы массива
+    Fields      []SchemaField `json:"fields,omitempty"` // поля объекта
+}
+
+// FieldError represents validation error of a single field
+// @Description Ошибка проверки поля
+type FieldError struct {
+    Field    string `json:"field" example:"blocks[2].content.url"`
+    Message  string `json:"message" example:"must point to uploaded image"`
+    Position *int   `json:"position,omitempty" example:"7"` // позиция ошибки в строке (символ от 0), например в формуле
+    Length   int    `json:"length,omitempty" example:"5"`
+}
@@ -29,6 +29,7 @@ type Teacher struct {
     Specializations []string `json:"specializations" example:"math,physics"`
     Rating          float64  `json:"rating" example:"4.9"`
     MaterialsCount  int      `json:"materialsCount" example:"25"`
+    IsVerified      bool     `json:"isVerified" example:"true"`
     AvatarURL       *string   `json:"avatarUrl,omitempty" example:"https://example.com/avatar.jpg"`
 }
 
@@ -53,6 +54,7 @@ type CatalogFilters struct {
 // TeacherFilters represents filters for teachers search
 // @Description Фильтры для поиска преподавателей
 type TeacherFilters struct {
-    Search  string `form:"search" example:"математика"`
-    Subject string `form:"subject" example:"math"`
+    Search       string `form:"search" example:"математика"`
+    Subject      string `form:"subject" example:"math"`
+    VerifiedOnly bool   `form:"verifiedOnly" example:"true"`
 }
\ No newline at end of file
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Виды закрытых файлов
+const (
+    PrivateFileVerification = "verification" // документ заявки на верификацию
+    PrivateFileSubmission   = "submission"   // файл сданного задания
+)
+
+// Хранилища закрытых файлов
+const (
+    FileStorageLocal = "local"
+    FileStorageS3    = "s3"
+)
+
+// PrivateFile represents file available only through authorized download
+// @Description Закрытый файл. Скачивается через /files/{id} владельцем и теми, кому он нужен для работы
+type PrivateFile struct {
+    ID          string    `json:"id" example:"3f8c1a2e-5b7d-4e9f-a1c3-d5e7f9a1b3c5"`
+    UserID      int       `json:"userId" example:"123"`
+    Kind        string    `json:"kind" example:"verification"`
+    Storage     string    `json:"-"`
+    StorageKey  string    `json:"-"`
+    FileName    string    `json:"fileName" example:"diploma.pdf"`
+    ContentType string    `json:"contentType" example:"application/pdf"`
+    Size        int64     `json:"size" example:"204800"`
+    CreatedAt   time.Time `json:"createdAt"`
+}
@@ -0,0 +1,26 @@
+package models
+
+// Режимы вывода формулы
+const (
+    FormulaDisplayInline = "inline" // строчная формула
+    FormulaDisplayBlock  = "block"  // выключная формула
+)
+
+// RenderFormulaRequest represents formula rendering request
+// @Description Запрос на отрисовку формулы
+type RenderFormulaRequest struct {
+    LaTeX   string `json:"latex" binding:"required,max=5000" example:"\\frac{a}{b} + \\sqrt{x^2 + 1}"`
+    Display string `json:"display" binding:"omitempty,oneof=inline block" example:"block"` // по умолчанию block
+}
+
+// RenderedFormula represents formula rendered on the server
+// @Description Формула, отрисованная в MathML и SVG. Размеры SVG - в em: высота над базовой линией и глубина под ней нужны для выравнивания строчных формул
+type RenderedFormula struct {
+    LaTeX   string  `json:"latex" example:"x^2"`
+    Display string  `json:"display" example:"block"`
+    MathML  string  `json:"mathml"`
+    SVG     string  `json:"svg"`
+    Width   float64 `json:"width" example:"1.02"`
+    Height  float64 `json:"height" example:"0.86"`
+    Depth   float64 `json:"depth" example:"0"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// ImpersonateRequest represents request to act on behalf of a user
+// @Description Запрос на вход под пользователем
+type ImpersonateRequest struct {
+    Reason string `json:"reason" binding:"required,max=500" example:"Обращение #1234: не открывается редактор"`
+}
+
+// ImpersonationResponse represents impersonation token
+// @Description Короткоживущий токен для работы от имени пользователя
+type ImpersonationResponse struct {
+    AccessToken     string    `json:"accessToken"`
+    ExpiresIn       int       `json:"expiresIn" example:"900"`
+    ExpiresAt       time.Time `json:"expiresAt"`
+    ImpersonationID string    `json:"impersonationId" example:"3f2b8c1e-8a4d-4c5e-9f1a-2b3c4d5e6f70"`
+    User            *User     `json:"user"`
+}
+
+// ImpersonatedRequest describes request made with impersonation token
+type ImpersonatedRequest struct {
+    UserID          int
+    ActorID         int
+    ImpersonationID string
+    Method          string
+    Path            string
+    Status          int
+    Client          ClientInfo
+}
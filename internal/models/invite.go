@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// AdminInvite represents invite for privileged registration
+// @Description Приглашение для регистрации администратора
+type AdminInvite struct {
+    ID        int        `json:"id" example:"1"`
+    Email     *string    `json:"email,omitempty" example:"new.admin@paydeya.ru"`
+    Role      string     `json:"role" example:"admin"`
+    CreatedBy *int       `json:"createdBy,omitempty" example:"1"`
+    ExpiresAt time.Time  `json:"expiresAt" example:"2023-01-22T10:30:00Z"`
+    UsedAt    *time.Time `json:"usedAt,omitempty"`
+    UsedBy    *int       `json:"usedBy,omitempty"`
+    RevokedAt *time.Time `json:"revokedAt,omitempty"`
+    CreatedAt time.Time  `json:"createdAt" example:"2023-01-15T10:30:00Z"`
+}
+
+// CreateInviteRequest represents request to create admin invite
+// @Description Запрос на создание приглашения
+type CreateInviteRequest struct {
+    Email          string `json:"email" binding:"omitempty,email" example:"new.admin@paydeya.ru"`
+    ExpiresInHours int    `json:"expiresInHours" binding:"omitempty,min=1,max=720" example:"72"`
+}
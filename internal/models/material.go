@@ -15,6 +15,18 @@ type Material struct {
     Status      string    `json:"status" example:"published"` // draft, published, archived
     Access      string    `json:"access" example:"open"` // open, link
     ShareURL    string    `json:"shareUrl,omitempty" example:"https://paydeya.com/share/abc123"`
+    // Опубликованная версия - номер неизменяемой ревизии, которую видят ученики
+    PublishedRevision     *int       `json:"publishedRevision,omitempty" example:"4"`
+    PublishedAt           *time.Time `json:"publishedAt,omitempty"`
+    HasUnpublishedChanges bool       `json:"hasUnpublishedChanges,omitempty"` // черновик отличается от опубликованной версии
+    Version               string     `json:"version,omitempty" example:"draft"` // какая версия в ответе: draft или published
+    EditVersion           int        `json:"editVersion,omitempty" example:"7"` // версия черновика, она же ETag для If-Match
+    IsTemplate            bool       `json:"isTemplate" example:"false"` // по материалу можно создавать новые материалы
+    // Копия другого материала: источник и автор оригинала
+    ForkedFrom            *int       `json:"forkedFrom,omitempty" example:"12"`
+    ForkedRevision        *int       `json:"forkedRevision,omitempty" example:"3"` // версия источника, с которой снята копия
+    OriginalAuthorID      *int       `json:"originalAuthorId,omitempty" example:"45"`
+    OriginalAuthorName    string     `json:"originalAuthorName,omitempty" example:"Петр Петров"`
     Blocks      []Block   `json:"blocks,omitempty"`
     CreatedAt   time.Time `json:"createdAt" example:"2023-01-15T10:30:00Z"`
     UpdatedAt   time.Time `json:"updatedAt" example:"2023-01-15T10:30:00Z"`
@@ -24,27 +36,63 @@ type Material struct {
 // @Description Блок контента в материале
 type Block struct {
     ID        string                 `json:"id" example:"block_123"`
-    Type      string                 `json:"type" example:"text"` // text, image, video, formula, quiz
+    Type      string                 `json:"type" example:"text"` // text, image, video, formula, quiz, assignment
     Content   map[string]interface{} `json:"content"`
     Styles    map[string]interface{} `json:"styles,omitempty"`
     Position  int                    `json:"position" example:"1"`
     Animation *BlockAnimation        `json:"animation,omitempty"`
 }
 
+// Запуск и действия анимаций блоков
+const (
+    AnimationTriggerClick = "click" // по нажатию на блок
+    AnimationTriggerAuto  = "auto"  // при появлении блока на экране
+
+    AnimationActionShow      = "show"
+    AnimationActionHide      = "hide"
+    AnimationActionHighlight = "highlight"
+
+    // AnimationElementBlock - элемент шага, означающий весь блок
+    AnimationElementBlock = "block"
+    // AnimationDefaultDuration - длительность шага по умолчанию, мс
+    AnimationDefaultDuration = 300
+)
+
 // BlockAnimation represents block animation
-// @Description Анимация блока
+// @Description Анимация блока. Шаги выполняются по очереди после запуска, перед каждым шагом - пауза delay
 type BlockAnimation struct {
     Steps     []AnimationStep `json:"steps,omitempty"`
-    Trigger   string          `json:"trigger" example:"click"` // click, auto
-    Delay     int             `json:"delay,omitempty" example:"1000"`
+    Trigger   string          `json:"trigger" example:"click"` // click, auto; по умолчанию click
+    Delay     int             `json:"delay,omitempty" example:"1000"` // пауза перед каждым шагом, мс
 }
 
 // AnimationStep represents animation step
-// @Description Шаг анимации
+// @Description Шаг анимации. element - id элемента из содержимого блока (фрагмента текста, вопроса, варианта ответа) или block для всего блока
 type AnimationStep struct {
     Element   string                 `json:"element" example:"element_1"`
     Action    string                 `json:"action" example:"show"` // show, hide, highlight
     Style     map[string]interface{} `json:"style,omitempty"`
+    Duration  *int                   `json:"duration,omitempty" example:"300"` // длительность шага, мс; по умолчанию 300
+}
+
+// AnimationTimeline represents resolved block animation
+// @Description Анимация блока с абсолютным временем шагов от момента запуска
+type AnimationTimeline struct {
+    BlockID  string         `json:"blockId" example:"block_123"`
+    Trigger  string         `json:"trigger" example:"click"`
+    Duration int            `json:"duration" example:"2600"` // время до окончания последнего шага, мс
+    Steps    []TimelineStep `json:"steps"`
+}
+
+// TimelineStep represents animation step with absolute timing
+// @Description Шаг анимации с началом и концом в миллисекундах от запуска
+type TimelineStep struct {
+    Index   int                    `json:"index" example:"0"`
+    Element string                 `json:"element" example:"element_1"`
+    Action  string                 `json:"action" example:"show"`
+    Style   map[string]interface{} `json:"style,omitempty"`
+    Start   int                    `json:"start" example:"1000"`
+    End     int                    `json:"end" example:"1300"`
 }
 
 // CreateMaterialRequest represents create material request
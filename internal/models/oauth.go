@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// OAuthProfile represents user data received from external provider
+type OAuthProfile struct {
+    Provider      string
+    Subject       string
+    Email         string
+    EmailVerified bool
+    FullName      string
+    AvatarURL     string
+}
+
+// OAuthState represents pending authorization
+type OAuthState struct {
+    Provider     string
+    CodeVerifier string
+    Role         string
+    ExpiresAt    time.Time
+}
+
+// UserIdentity represents external account linked to user
+// @Description Внешний аккаунт, привязанный к пользователю
+type UserIdentity struct {
+    ID        int       `json:"id"`
+    UserID    int       `json:"userId"`
+    Provider  string    `json:"provider" example:"yandex"`
+    Subject   string    `json:"subject" example:"1130000012345678"`
+    Email     string    `json:"email" example:"student@yandex.ru"`
+    CreatedAt time.Time `json:"createdAt"`
+}
+
+// OAuthProviderInfo represents enabled login provider
+// @Description Провайдер входа, доступный на платформе
+type OAuthProviderInfo struct {
+    Name        string `json:"name" example:"yandex"`
+    DisplayName string `json:"displayName" example:"Яндекс ID"`
+}
+
+// OAuthAuthorizeResponse represents authorization URL
+// @Description Ссылка на страницу входа провайдера
+type OAuthAuthorizeResponse struct {
+    AuthorizationURL string `json:"authorizationUrl" example:"https://oauth.yandex.ru/authorize?client_id=...&code_challenge=...&state=..."`
+    ExpiresIn        int    `json:"expiresIn" example:"600"`
+}
+
+// OAuthCallbackRequest represents data returned by provider to frontend
+// @Description Параметры, с которыми провайдер вернул пользователя на фронтенд
+type OAuthCallbackRequest struct {
+    Code     string `json:"code" binding:"required"`
+    State    string `json:"state" binding:"required"`
+    DeviceID string `json:"deviceId,omitempty"` // VK ID передает device_id вместе с code
+}
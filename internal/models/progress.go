@@ -19,6 +19,8 @@ type ProgressMaterial struct {
     Title        string    `json:"title" example:"Основы алгебры"`
     Subject      string    `json:"subject" example:"math"`
     Progress     float64   `json:"progress" example:"75.5"` // 0-100%
+    Revision     *int      `json:"revision,omitempty" example:"4"` // изученная версия материала
+    HasNewVersion bool     `json:"hasNewVersion,omitempty"` // с тех пор опубликована новая версия
     LastActivity time.Time `json:"lastActivity" example:"2023-01-15T10:30:00Z"`
 }
 
@@ -29,6 +31,7 @@ type MaterialCompletion struct {
     UserID      int       `json:"userId" example:"123"`
     TimeSpent   int       `json:"timeSpent" example:"3600"` // в секундах
     Grade       float64   `json:"grade" example:"4.5"`     // 1-5
+    Revision    *int      `json:"revision,omitempty" example:"4"` // изученная версия материала
     CompletedAt time.Time `json:"completedAt" example:"2023-01-15T10:30:00Z"`
 }
 
@@ -0,0 +1,100 @@
+package models
+
+import (
+    "math"
+    "time"
+)
+
+// Типы вопросов теста
+const (
+    QuestionTypeSingle   = "single"   // один вариант
+    QuestionTypeMultiple = "multiple" // несколько вариантов
+    QuestionTypeNumeric  = "numeric"  // число с допуском
+    QuestionTypeText     = "text"     // короткий текстовый ответ
+)
+
+// ScorePercent возвращает долю набранных баллов в процентах.
+// Тест, за который нельзя получить баллы, считается пройденным полностью.
+func ScorePercent(score, maxScore float64) float64 {
+    if maxScore <= 0 {
+        return 100
+    }
+    return math.Round(score/maxScore*10000) / 100
+}
+
+// QuizAttempt represents graded quiz attempt
+// @Description Попытка прохождения теста. Результаты (results, score, percent) скрыты (resultsHidden),
+// @Description пока у ученика остаются попытки и преподаватель не открыл результаты
+type QuizAttempt struct {
+    ID            int                    `json:"id" example:"1"`
+    MaterialID    int                    `json:"materialId" example:"1"`
+    BlockID       string                 `json:"blockId" example:"quiz1"`
+    UserID        int                    `json:"userId" example:"12"`
+    Revision      int                    `json:"revision" example:"4"` // версия материала, по которой проходился тест
+    Number        int                    `json:"number" example:"2"`   // номер попытки ученика по тесту
+    Answers       map[string]interface{} `json:"answers"`
+    Results       []QuestionResult       `json:"results,omitempty"`
+    Score         float64                `json:"score" example:"3"`
+    MaxScore      float64                `json:"maxScore" example:"4"`
+    Percent       float64                `json:"percent" example:"75"`
+    ResultsHidden bool                   `json:"resultsHidden,omitempty"`
+    AttemptsLeft  *int                   `json:"attemptsLeft,omitempty" example:"1"`
+    CreatedAt     time.Time              `json:"createdAt"`
+}
+
+// HideResults убирает из попытки все, по чему можно восстановить правильные ответы
+func (a *QuizAttempt) HideResults() {
+    a.Results = nil
+    a.Score = 0
+    a.Percent = 0
+    a.ResultsHidden = true
+}
+
+// QuestionResult represents result of a single question
+// @Description Результат проверки ответа на вопрос
+type QuestionResult struct {
+    QuestionID  string  `json:"questionId" example:"q1"`
+    Answered    bool    `json:"answered"`
+    Correct     bool    `json:"correct"`
+    Points      float64 `json:"points" example:"1"`
+    MaxPoints   float64 `json:"maxPoints" example:"1"`
+    Explanation string  `json:"explanation,omitempty"`
+}
+
+// SubmitQuizRequest represents quiz answers
+// @Description Ответы на тест: id вопроса -> id варианта (single), массив id (multiple), число (numeric) или строка (text)
+type SubmitQuizRequest struct {
+    Answers map[string]interface{} `json:"answers" binding:"required"`
+}
+
+// QuizStats represents quiz statistics for teacher
+// @Description Статистика прохождения теста
+type QuizStats struct {
+    MaterialID     int             `json:"materialId" example:"1"`
+    BlockID        string          `json:"blockId" example:"quiz1"`
+    Attempts       int             `json:"attempts" example:"42"`
+    Students       int             `json:"students" example:"17"`
+    AveragePercent float64         `json:"averagePercent" example:"68.5"`
+    Questions      []QuestionStats `json:"questions"`
+}
+
+// QuestionStats represents statistics of a single question
+// @Description Статистика ответов на вопрос
+type QuestionStats struct {
+    QuestionID    string        `json:"questionId" example:"q1"`
+    Text          string        `json:"text" example:"Сколько будет 2+2?"`
+    Type          string        `json:"type" example:"single"`
+    Answered      int           `json:"answered" example:"40"`
+    Correct       int           `json:"correct" example:"31"`
+    CorrectRate   float64       `json:"correctRate" example:"73.8"` // % правильных от всех попыток
+    AveragePoints float64       `json:"averagePoints" example:"0.74"`
+    Options       []OptionStats `json:"options,omitempty"`
+}
+
+// OptionStats represents how often an option was chosen
+// @Description Сколько раз выбирали вариант ответа
+type OptionStats struct {
+    OptionID string `json:"optionId" example:"a"`
+    Text     string `json:"text" example:"4"`
+    Picks    int    `json:"picks" example:"31"`
+}
@@ -0,0 +1,47 @@
+package models
+
+// Права доступа. Соответствие ролей и прав хранится в таблице role_permissions.
+const (
+    PermMaterialCreate  = "material.create"
+    PermMaterialEditOwn = "material.edit.own"
+    PermMaterialEditAny = "material.edit.any"
+    PermUserView        = "user.view"
+    PermUserBlock       = "user.block"
+    PermSubjectManage   = "subject.manage"
+    PermStatsView       = "stats.view"
+    PermTeacherVerify   = "teacher.verify"
+    PermInviteManage    = "invite.manage"
+    PermRoleManage      = "role.manage"
+    PermUserImpersonate = "user.impersonate"
+    PermTemplateManage  = "template.manage"
+)
+
+// AdminPermissions - права, дающие доступ к админке. Под пользователями
+// с такими правами входить нельзя: это открыло бы обход проверок прав.
+var AdminPermissions = []string{
+    PermUserView, PermUserBlock, PermSubjectManage, PermStatsView,
+    PermTeacherVerify, PermInviteManage, PermRoleManage, PermUserImpersonate,
+    PermTemplateManage,
+}
+
+// Role represents role with its permissions
+// @Description Роль и ее права
+type Role struct {
+    Name        string   `json:"name" example:"moderator"`
+    Description string   `json:"description" example:"Модератор контента"`
+    Permissions []string `json:"permissions" example:"material.edit.any,user.block"`
+}
+
+// SaveRoleRequest represents request to create or update role
+// @Description Запрос на создание или изменение роли
+type SaveRoleRequest struct {
+    Name        string   `json:"name" binding:"required,max=20" example:"moderator"`
+    Description string   `json:"description" example:"Модератор контента"`
+    Permissions []string `json:"permissions" binding:"required" example:"material.edit.any,user.block"`
+}
+
+// ChangeUserRoleRequest represents request to change user role
+// @Description Запрос на смену роли пользователя
+type ChangeUserRoleRequest struct {
+    Role string `json:"role" binding:"required" example:"moderator"`
+}
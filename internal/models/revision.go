@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// Действия, после которых сохраняется ревизия материала
+const (
+    RevisionActionCreated       = "created"
+    RevisionActionBaseline      = "baseline" // состояние материала до первой записанной правки
+    RevisionActionUpdated       = "material_updated"
+    RevisionActionBlockAdded    = "block_added"
+    RevisionActionBlockUpdated  = "block_updated"
+    RevisionActionBlockDeleted  = "block_deleted"
+    RevisionActionBlocksOrdered = "blocks_reordered"
+    RevisionActionRestored      = "restored"
+    RevisionActionPublished     = "published"
+)
+
+// Версии материала, которые можно запросить
+const (
+    MaterialVersionDraft     = "draft"
+    MaterialVersionPublished = "published"
+)
+
+// MaterialRevision represents immutable snapshot of material
+// @Description Ревизия материала: снимок заголовка и блоков после изменения
+type MaterialRevision struct {
+    Revision     int       `json:"revision" example:"3"`
+    MaterialID   int       `json:"materialId" example:"1"`
+    Title        string    `json:"title" example:"Основы алгебры"`
+    Blocks       []Block   `json:"blocks,omitempty"`
+    BlocksCount  int       `json:"blocksCount" example:"5"`
+    AuthorID     int       `json:"authorId,omitempty" example:"123"`
+    AuthorName   string    `json:"authorName,omitempty" example:"Иван Иванов"`
+    Action       string    `json:"action" example:"block_updated"`
+    RestoredFrom *int      `json:"restoredFrom,omitempty" example:"2"`
+    CreatedAt    time.Time `json:"createdAt"`
+}
+
+// Виды изменений блока между ревизиями
+const (
+    BlockChangeAdded    = "added"
+    BlockChangeRemoved  = "removed"
+    BlockChangeModified = "modified"
+    BlockChangeMoved    = "moved"
+)
+
+// BlockChange describes block difference between revisions
+// @Description Изменение блока между двумя ревизиями
+type BlockChange struct {
+    BlockID     string   `json:"blockId" example:"block_123"`
+    Type        string   `json:"type" example:"text"`
+    Change      string   `json:"change" example:"modified"` // added, removed, modified, moved
+    OldPosition *int     `json:"oldPosition,omitempty" example:"1"`
+    NewPosition *int     `json:"newPosition,omitempty" example:"2"`
+    Fields      []string `json:"fields,omitempty" example:"content,styles"` // измененные поля блока
+    Moved       bool     `json:"moved,omitempty"` // блок изменен и вдобавок перемещен
+    Old         *Block   `json:"old,omitempty"`
+    New         *Block   `json:"new,omitempty"`
+}
+
+// RevisionDiff represents block level difference between revisions
+// @Description Структурное сравнение двух ревизий на уровне блоков
+type RevisionDiff struct {
+    MaterialID   int           `json:"materialId" example:"1"`
+    From         int           `json:"from" example:"2"`
+    To           int           `json:"to" example:"3"`
+    TitleChanged bool          `json:"titleChanged"`
+    OldTitle     string        `json:"oldTitle,omitempty"`
+    NewTitle     string        `json:"newTitle,omitempty"`
+    Changes      []BlockChange `json:"changes"`
+}
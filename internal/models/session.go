@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// ClientInfo describes device that performs login or refresh
+type ClientInfo struct {
+    UserAgent string
+    IP        string
+}
+
+// Session represents user login on a device
+// @Description Активная сессия (устройство) пользователя
+type Session struct {
+    ID         string    `json:"id" example:"3f2b8c1e-8a4d-4c5e-9f1a-2b3c4d5e6f70"`
+    UserID     int       `json:"userId" example:"1"`
+    UserAgent  string    `json:"userAgent" example:"Mozilla/5.0 (Windows NT 10.0; Win64; x64)"`
+    IP         string    `json:"ip" example:"203.0.113.7"`
+    CreatedAt  time.Time `json:"createdAt"`
+    LastSeenAt time.Time `json:"lastSeenAt"`
+    Current    bool      `json:"current" example:"true"`
+}
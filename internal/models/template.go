@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// CopyMaterialRequest represents material duplication or template instantiation request
+// @Description Запрос на создание материала копированием. Без заголовка берется заголовок источника
+type CopyMaterialRequest struct {
+    Title string `json:"title" binding:"omitempty,max=1000" example:"Основы алгебры, 8 класс"`
+}
+
+// SetTemplateRequest represents request to mark material as template
+// @Description Запрос на изменение признака шаблона
+type SetTemplateRequest struct {
+    IsTemplate *bool `json:"isTemplate" binding:"required" example:"true"`
+}
+
+// GalleryTemplate represents template in curated gallery
+// @Description Шаблон из галереи
+type GalleryTemplate struct {
+    MaterialID  int       `json:"materialId" example:"12"`
+    Title       string    `json:"title" example:"Урок с тестом"`
+    Subject     string    `json:"subject" example:"math"`
+    AuthorID    int       `json:"authorId" example:"45"`
+    AuthorName  string    `json:"authorName" example:"Петр Петров"`
+    Description string    `json:"description,omitempty" example:"Теория, пример и тест на 5 вопросов"`
+    Position    int       `json:"position" example:"1"`
+    BlockCount  int       `json:"blockCount" example:"6"`
+    AddedAt     time.Time `json:"addedAt"`
+}
+
+// GalleryTemplateRequest represents request to add template to gallery
+// @Description Запрос на добавление шаблона в галерею или изменение его описания и места
+type GalleryTemplateRequest struct {
+    MaterialID  int    `json:"materialId" binding:"required" example:"12"`
+    Description string `json:"description" binding:"max=1000" example:"Теория, пример и тест на 5 вопросов"`
+    Position    int    `json:"position" example:"1"` // шаблоны упорядочены по возрастанию
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// LoginLockout represents failed login counter for account or IP
+// @Description Неудачные попытки входа для аккаунта или IP-адреса
+type LoginLockout struct {
+    Key         string     `json:"key" example:"account:user@example.com"`
+    Scope       string     `json:"scope" example:"account"`
+    Subject     string     `json:"subject" example:"user@example.com"`
+    Failures    int        `json:"failures" example:"10"`
+    LockedUntil *time.Time `json:"lockedUntil,omitempty"`
+}
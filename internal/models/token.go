@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// RefreshToken represents stored refresh token
+// @Description Запись о выданном refresh токене
+type RefreshToken struct {
+    ID        int64      `json:"id"`
+    UserID    int        `json:"userId"`
+    FamilyID  string     `json:"familyId"`
+    TokenHash string     `json:"-"`
+    ExpiresAt time.Time  `json:"expiresAt"`
+    RotatedAt *time.Time `json:"rotatedAt,omitempty"`
+    RevokedAt *time.Time `json:"revokedAt,omitempty"`
+    CreatedAt time.Time  `json:"createdAt"`
+}
+
+// Назначения одноразовых токенов
+const (
+    TokenPurposePasswordReset     = "password_reset"
+    TokenPurposeEmailVerification = "email_verification"
+    TokenPurposeEmailChange       = "email_change"
+)
+
+// UserToken represents one-time user token
+// @Description Одноразовый токен пользователя (сброс пароля и т.п.)
+type UserToken struct {
+    ID        int64      `json:"id"`
+    UserID    int        `json:"userId"`
+    Purpose   string     `json:"purpose"`
+    TokenHash string     `json:"-"`
+    NewEmail  *string    `json:"newEmail,omitempty"` // только для смены email
+    ExpiresAt time.Time  `json:"expiresAt"`
+    UsedAt    *time.Time `json:"usedAt,omitempty"`
+    CreatedAt time.Time  `json:"createdAt"`
+}
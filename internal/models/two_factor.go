@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// UserTOTP represents TOTP settings of user
+// @Description Настройки двухфакторной аутентификации пользователя
+type UserTOTP struct {
+    UserID         int        `json:"userId"`
+    Secret         string     `json:"-"`
+    Enabled        bool       `json:"enabled"`
+    LastUsedStep   int64      `json:"-"`
+    FailedAttempts int        `json:"-"`
+    LockedUntil    *time.Time `json:"-"`
+    ConfirmedAt    *time.Time `json:"confirmedAt,omitempty"`
+    CreatedAt      time.Time  `json:"createdAt"`
+}
+
+// TwoFactorStatus represents 2FA status
+// @Description Состояние двухфакторной аутентификации
+type TwoFactorStatus struct {
+    Enabled           bool       `json:"enabled" example:"true"`
+    ConfirmedAt       *time.Time `json:"confirmedAt,omitempty"`
+    RecoveryCodesLeft int        `json:"recoveryCodesLeft" example:"10"`
+}
+
+// TwoFactorSetupResponse represents 2FA enrollment data
+// @Description Секрет для приложения-аутентификатора и otpauth ссылка для QR-кода
+type TwoFactorSetupResponse struct {
+    Secret     string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+    OTPAuthURI string `json:"otpauthUri" example:"otpauth://totp/%D0%9F%D0%B0%D0%B9%D0%B4%D0%B5%D1%8F:user@example.com?secret=JBSWY3DPEHPK3PXP&issuer=%D0%9F%D0%B0%D0%B9%D0%B4%D0%B5%D1%8F"`
+}
+
+// TwoFactorCodeRequest represents request with 2FA code
+// @Description Запрос с кодом из приложения-аутентификатора или кодом восстановления
+type TwoFactorCodeRequest struct {
+    Code string `json:"code" binding:"required" example:"123456"`
+}
+
+// DisableTwoFactorRequest represents request to disable 2FA
+// @Description Запрос на отключение двухфакторной аутентификации
+type DisableTwoFactorRequest struct {
+    Password string `json:"password" binding:"required" example:"password123"`
+    Code     string `json:"code" binding:"required" example:"123456"`
+}
+
+// VerifyTwoFactorRequest represents second login step
+// @Description Второй шаг входа: challenge токен и код
+type VerifyTwoFactorRequest struct {
+    ChallengeToken string `json:"challengeToken" binding:"required"`
+    Code           string `json:"code" binding:"required" example:"123456"`
+}
+
+// RecoveryCodesResponse represents generated recovery codes
+// @Description Коды восстановления. Показываются один раз
+type RecoveryCodesResponse struct {
+    Message       string   `json:"message" example:"Two-factor authentication enabled"`
+    RecoveryCodes []string `json:"recoveryCodes" example:"k3jd9-x8c2m,p0q7a-zz41n"`
+}
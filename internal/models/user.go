@@ -13,6 +13,7 @@ type User struct {
     Role         string    `json:"role"`
     AvatarURL    string    `json:"avatarUrl,omitempty"`
     IsVerified   bool      `json:"isVerified"`
+    EmailVerified bool     `json:"emailVerified"`
     IsBlocked    bool      `json:"isBlocked"`
     BlockReason  *string    `json:"blockReason,omitempty"`
     CreatedAt    time.Time `json:"createdAt"`
@@ -24,7 +25,10 @@ type RegisterRequest struct {
     Email    string `json:"email" binding:"required,email"`
     Password string `json:"password" binding:"required,min=6"`
     FullName string `json:"fullName" binding:"required"`
-    Role     string `json:"role" binding:"required,oneof=student teacher admin"`
+    // Публично можно зарегистрироваться только учеником или преподавателем.
+    // Роль администратора выдается по приглашению (inviteToken), тогда role не нужна.
+    Role        string `json:"role" binding:"required_without=InviteToken,omitempty,oneof=student teacher"`
+    InviteToken string `json:"inviteToken,omitempty"`
 }
 // LoginRequest represents login request
 // @Description Запрос на вход
@@ -35,10 +39,13 @@ type LoginRequest struct {
 // AuthResponse represents authentication response
 // @Description Ответ с токенами и данными пользователя
 type AuthResponse struct {
-    Message      string `json:"message"`
-    AccessToken  string `json:"accessToken,omitempty"`
-    RefreshToken string `json:"refreshToken,omitempty"`
-    User         *User  `json:"user,omitempty"`
+    Message           string `json:"message"`
+    AccessToken       string `json:"accessToken,omitempty"`
+    RefreshToken      string `json:"refreshToken,omitempty"`
+    User              *User  `json:"user,omitempty"`
+    TwoFactorRequired bool   `json:"twoFactorRequired,omitempty"`
+    ChallengeToken    string `json:"challengeToken,omitempty"`
+    ExpiresIn         int    `json:"expiresIn,omitempty"`
 }
 // ForgotPasswordRequest represents forgot password request
 // @Description Запрос на сброс пароля
@@ -50,4 +57,30 @@ type ForgotPasswordRequest struct {
 type ResetPasswordRequest struct {
     Token       string `json:"token" binding:"required"`
     NewPassword string `json:"newPassword" binding:"required,min=6"`
-}
\ No newline at end of file
+}
+
+// VerifyEmailRequest represents email verification request
+// @Description Запрос на подтверждение email
+type VerifyEmailRequest struct {
+    Token string `json:"token" binding:"required"`
+}
+
+// ChangePasswordRequest represents password change request
+// @Description Запрос на смену пароля
+type ChangePasswordRequest struct {
+    CurrentPassword string `json:"currentPassword" binding:"required"`
+    NewPassword     string `json:"newPassword" binding:"required,min=6"`
+}
+
+// ChangeEmailRequest represents email change request
+// @Description Запрос на смену email
+type ChangeEmailRequest struct {
+    NewEmail        string `json:"newEmail" binding:"required,email"`
+    CurrentPassword string `json:"currentPassword" binding:"required"`
+}
+
+// ConfirmEmailChangeRequest represents email change confirmation
+// @Description Подтверждение смены email токеном из письма
+type ConfirmEmailChangeRequest struct {
+    Token string `json:"token" binding:"required"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// TeacherApplication represents teacher verification application
+// @Description Заявка преподавателя на верификацию
+type TeacherApplication struct {
+    ID            int                    `json:"id" example:"1"`
+    UserID        int                    `json:"userId" example:"123"`
+    UserEmail     string                 `json:"userEmail,omitempty" example:"teacher@school.ru"`
+    UserName      string                 `json:"userName,omitempty" example:"Мария Петрова"`
+    Status        string                 `json:"status" example:"pending"` // pending, approved, rejected
+    Message       string                 `json:"message,omitempty" example:"Преподаю математику 10 лет"`
+    Documents     []VerificationDocument `json:"documents"`
+    ReviewComment string                 `json:"reviewComment,omitempty" example:"Диплом подтвержден"`
+    ReviewedBy    *int                   `json:"reviewedBy,omitempty" example:"1"`
+    ReviewedAt    *time.Time             `json:"reviewedAt,omitempty" example:"2023-01-15T10:30:00Z"`
+    CreatedAt     time.Time              `json:"createdAt" example:"2023-01-15T10:30:00Z"`
+}
+
+// VerificationDocument represents uploaded verification document
+// @Description Документ, приложенный к заявке. Скачивается через /files/{fileId}
+type VerificationDocument struct {
+    FileID   string `json:"fileId,omitempty" example:"3f8c1a2e-5b7d-4e9f-a1c3-d5e7f9a1b3c5"`
+    URL      string `json:"url,omitempty"` // только у заявок, поданных до закрытого хранения документов
+    FileName string `json:"fileName" example:"diploma.pdf"`
+}
+
+// ReviewApplicationRequest represents admin decision on application
+// @Description Решение администратора по заявке
+type ReviewApplicationRequest struct {
+    Comment string `json:"comment" example:"Диплом подтвержден"`
+}
@@ -0,0 +1,291 @@
+package repositories
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AccountRepository struct {
+    db *pgxpool.Pool
+}
+
+func NewAccountRepository(db *pgxpool.Pool) *AccountRepository {
+    return &AccountRepository{db: db}
+}
+
+// GetCompletions возвращает завершенные пользователем материалы
+func (r *AccountRepository) GetCompletions(ctx context.Context, userID int) ([]models.MaterialCompletion, error) {
+    query := `
+        SELECT material_id, user_id, time_spent, COALESCE(grade, 0), material_revision, completed_at
+        FROM material_completions
+        WHERE user_id = $1
+        ORDER BY completed_at
+    `
+
+    rows, err := r.db.Query(ctx, query, userID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    completions := []models.MaterialCompletion{}
+    for rows.Next() {
+        var completion models.MaterialCompletion
+        if err := rows.Scan(
+            &completion.MaterialID, &completion.UserID, &completion.TimeSpent,
+            &completion.Grade, &completion.Revision, &completion.CompletedAt,
+        ); err != nil {
+            return nil, err
+        }
+        completions = append(completions, completion)
+    }
+
+    return completions, rows.Err()
+}
+
+// GetQuizAttempts возвращает попытки прохождения тестов пользователем
+func (r *AccountRepository) GetQuizAttempts(ctx context.Context, userID int) ([]models.QuizAttempt, error) {
+    query := `SELECT ` + attemptColumns + ` FROM quiz_attempts WHERE user_id = $1 ORDER BY created_at`
+
+    rows, err := r.db.Query(ctx, query, userID)
+    if err != nil {
+        return nil, err
+    }
+    return collectQuizAttempts(rows)
+}
+
+// GetAssignmentSubmissions возвращает работы пользователя по заданиям
+func (r *AccountRepository) GetAssignmentSubmissions(ctx context.Context, userID int) ([]models.AssignmentSubmission, error) {
+    query := "SELECT " + submissionColumns + submissionFrom + " WHERE s.user_id = $1 ORDER BY s.submitted_at"
+
+    rows, err := r.db.Query(ctx, query, userID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    submissions := []models.AssignmentSubmission{}
+    for rows.Next() {
+        submission, err := scanSubmission(rows)
+        if err != nil {
+            return nil, err
+        }
+        submissions = append(submissions, *submission)
+    }
+
+    return submissions, rows.Err()
+}
+
+// GetRatings возвращает оценки, выставленные пользователем
+func (r *AccountRepository) GetRatings(ctx context.Context, userID int) ([]models.MaterialRating, error) {
+    query := `
+        SELECT material_id, rating, created_at
+        FROM material_ratings
+        WHERE user_id = $1
+        ORDER BY created_at
+    `
+
+    rows, err := r.db.Query(ctx, query, userID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    ratings := []models.MaterialRating{}
+    for rows.Next() {
+        var rating models.MaterialRating
+        if err := rows.Scan(&rating.MaterialID, &rating.Rating, &rating.CreatedAt); err != nil {
+            return nil, err
+        }
+        ratings = append(ratings, rating)
+    }
+
+    return ratings, rows.Err()
+}
+
+// GetFavorites возвращает избранные материалы пользователя
+func (r *AccountRepository) GetFavorites(ctx context.Context, userID int) ([]models.FavoriteMaterial, error) {
+    query := `
+        SELECT material_id, user_id, created_at
+        FROM favorite_materials
+        WHERE user_id = $1
+        ORDER BY created_at
+    `
+
+    rows, err := r.db.Query(ctx, query, userID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    favorites := []models.FavoriteMaterial{}
+    for rows.Next() {
+        var favorite models.FavoriteMaterial
+        if err := rows.Scan(&favorite.MaterialID, &favorite.UserID, &favorite.AddedAt); err != nil {
+            return nil, err
+        }
+        favorites = append(favorites, favorite)
+    }
+
+    return favorites, rows.Err()
+}
+
+// ScheduleDeletion создает или обновляет запрос на удаление аккаунта
+func (r *AccountRepository) ScheduleDeletion(ctx context.Context, deletion *models.AccountDeletion) error {
+    query := `
+        INSERT INTO account_deletions (user_id, materials_action, transfer_to, scheduled_for)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (user_id) DO UPDATE
+        SET materials_action = EXCLUDED.materials_action,
+            transfer_to = EXCLUDED.transfer_to,
+            requested_at = CURRENT_TIMESTAMP,
+            scheduled_for = EXCLUDED.scheduled_for
+        RETURNING requested_at
+    `
+
+    return r.db.QueryRow(ctx, query,
+        deletion.UserID, deletion.MaterialsAction, deletion.TransferToID, deletion.ScheduledFor,
+    ).Scan(&deletion.RequestedAt)
+}
+
+const deletionColumns = `user_id, materials_action, transfer_to, requested_at, scheduled_for`
+
+func scanDeletion(row pgx.Row) (*models.AccountDeletion, error) {
+    var deletion models.AccountDeletion
+    err := row.Scan(
+        &deletion.UserID, &deletion.MaterialsAction, &deletion.TransferToID,
+        &deletion.RequestedAt, &deletion.ScheduledFor,
+    )
+    if err != nil {
+        return nil, err
+    }
+    return &deletion, nil
+}
+
+// GetDeletion возвращает запрос на удаление аккаунта (nil, если его нет)
+func (r *AccountRepository) GetDeletion(ctx context.Context, userID int) (*models.AccountDeletion, error) {
+    query := `SELECT ` + deletionColumns + ` FROM account_deletions WHERE user_id = $1`
+
+    deletion, err := scanDeletion(r.db.QueryRow(ctx, query, userID))
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+    return deletion, err
+}
+
+// CancelDeletion отменяет запрос на удаление. Возвращает false, если запроса не было.
+func (r *AccountRepository) CancelDeletion(ctx context.Context, userID int) (bool, error) {
+    tag, err := r.db.Exec(ctx, `DELETE FROM account_deletions WHERE user_id = $1`, userID)
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() > 0, nil
+}
+
+// GetDueDeletions возвращает запросы, у которых истек период ожидания
+func (r *AccountRepository) GetDueDeletions(ctx context.Context, now time.Time) ([]models.AccountDeletion, error) {
+    query := `SELECT ` + deletionColumns + ` FROM account_deletions WHERE scheduled_for <= $1 ORDER BY scheduled_for`
+
+    rows, err := r.db.Query(ctx, query, now)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var deletions []models.AccountDeletion
+    for rows.Next() {
+        deletion, err := scanDeletion(rows)
+        if err != nil {
+            return nil, err
+        }
+        deletions = append(deletions, *deletion)
+    }
+
+    return deletions, rows.Err()
+}
+
+// DeleteAccount удаляет аккаунт по запросу. Материалы передаются другому
+// автору или архивируются. Если после этого у пользователя остались материалы,
+// аккаунт обезличивается (иначе каскадное удаление унесло бы и материалы),
+// в остальных случаях удаляется полностью. Возвращает true, если аккаунт обезличен.
+func (r *AccountRepository) DeleteAccount(ctx context.Context, deletion *models.AccountDeletion) (bool, error) {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback(ctx)
+
+    userID := deletion.UserID
+    if deletion.MaterialsAction == models.MaterialsActionTransfer && deletion.TransferToID != nil {
+        _, err = tx.Exec(ctx,
+            "UPDATE materials SET author_id = $1, updated_at = CURRENT_TIMESTAMP WHERE author_id = $2",
+            *deletion.TransferToID, userID,
+        )
+    } else {
+        _, err = tx.Exec(ctx,
+            "UPDATE materials SET status = 'archived', updated_at = CURRENT_TIMESTAMP WHERE author_id = $1",
+            userID,
+        )
+    }
+    if err != nil {
+        return false, err
+    }
+
+    var hasMaterials bool
+    err = tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM materials WHERE author_id = $1)", userID).Scan(&hasMaterials)
+    if err != nil {
+        return false, err
+    }
+
+    if !hasMaterials {
+        if _, err := tx.Exec(ctx, "DELETE FROM users WHERE id = $1", userID); err != nil {
+            return false, err
+        }
+        return false, tx.Commit(ctx)
+    }
+
+    // Удаляем все персональные данные, кроме самой строки пользователя
+    cleanup := []string{
+        "DELETE FROM teacher_specializations WHERE user_id = $1",
+        "DELETE FROM specializations WHERE user_id = $1",
+        "DELETE FROM material_completions WHERE user_id = $1",
+        "DELETE FROM quiz_attempts WHERE user_id = $1",
+        "DELETE FROM assignment_submissions WHERE user_id = $1",
+        "DELETE FROM material_ratings WHERE user_id = $1",
+        "DELETE FROM favorite_materials WHERE user_id = $1",
+        "DELETE FROM teacher_verification_applications WHERE user_id = $1",
+        "DELETE FROM private_files WHERE user_id = $1",
+        "DELETE FROM refresh_tokens WHERE user_id = $1",
+        "DELETE FROM auth_sessions WHERE user_id = $1",
+        "DELETE FROM user_tokens WHERE user_id = $1",
+        "DELETE FROM user_totp WHERE user_id = $1",
+        "DELETE FROM user_recovery_codes WHERE user_id = $1",
+        "DELETE FROM user_identities WHERE user_id = $1",
+        "DELETE FROM api_keys WHERE user_id = $1",
+        "DELETE FROM account_deletions WHERE user_id = $1",
+    }
+    for _, query := range cleanup {
+        if _, err := tx.Exec(ctx, query, userID); err != nil {
+            return false, err
+        }
+    }
+
+    _, err = tx.Exec(ctx, `
+        UPDATE users
+        SET email = $1, password_hash = '', full_name = 'Удаленный пользователь', avatar_url = '',
+            email_verified = FALSE, is_blocked = TRUE, block_reason = 'Аккаунт удален',
+            deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $2
+    `, fmt.Sprintf("deleted-%d@deleted.invalid", userID), userID)
+    if err != nil {
+        return false, err
+    }
+
+    return true, tx.Commit(ctx)
+}
@@ -135,11 +135,30 @@ func (r *AdminRepository) GetUsers(ctx context.Context, role string, page, limit
     return users, total, nil
 }
 
-// BlockUser блокирует пользователя
+// BlockUser блокирует пользователя и отзывает все его семейства refresh токенов,
+// чтобы уже выданные токены нельзя было обменять
 func (r *AdminRepository) BlockUser(ctx context.Context, userID int, reason string) error {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
     query := `UPDATE users SET is_blocked = true, block_reason = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
-    _, err := r.db.Exec(ctx, query, reason, userID)
-    return err
+    if _, err := tx.Exec(ctx, query, reason, userID); err != nil {
+        return err
+    }
+
+    _, err = tx.Exec(ctx, `
+        UPDATE refresh_tokens
+        SET revoked_at = CURRENT_TIMESTAMP
+        WHERE user_id = $1 AND revoked_at IS NULL
+    `, userID)
+    if err != nil {
+        return err
+    }
+
+    return tx.Commit(ctx)
 }
 
 // CreateSubject создает новый предмет
@@ -147,4 +166,14 @@ func (r *AdminRepository) CreateSubject(ctx context.Context, req *models.CreateS
     query := `INSERT INTO subjects (id, name, icon) VALUES ($1, $2, $3)`
     _, err := r.db.Exec(ctx, query, req.ID, req.Name, req.Icon)
     return err
-}
\ No newline at end of file
+}
+
+// ChangeUserRole меняет роль пользователя
+func (r *AdminRepository) ChangeUserRole(ctx context.Context, userID int, role string) (bool, error) {
+    query := `UPDATE users SET role = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
+    tag, err := r.db.Exec(ctx, query, role, userID)
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,116 @@
+package repositories
+
+import (
+    "context"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type APIKeyRepository struct {
+    db *pgxpool.Pool
+}
+
+func NewAPIKeyRepository(db *pgxpool.Pool) *APIKeyRepository {
+    return &APIKeyRepository{db: db}
+}
+
+const apiKeyColumns = `id, user_id, name, key_prefix, scopes, expires_at, last_used_at, revoked_at, created_at`
+
+func scanAPIKey(row pgx.Row) (*models.APIKey, error) {
+    var key models.APIKey
+    err := row.Scan(
+        &key.ID, &key.UserID, &key.Name, &key.Prefix, &key.Scopes,
+        &key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt, &key.CreatedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+    return &key, nil
+}
+
+// CreateKey сохраняет новый ключ (только хеш и префикс)
+func (r *APIKeyRepository) CreateKey(ctx context.Context, key *models.APIKey, keyHash string) error {
+    query := `
+        INSERT INTO api_keys (user_id, name, key_prefix, key_hash, scopes, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id, created_at
+    `
+
+    return r.db.QueryRow(ctx, query,
+        key.UserID, key.Name, key.Prefix, keyHash, key.Scopes, key.ExpiresAt,
+    ).Scan(&key.ID, &key.CreatedAt)
+}
+
+// GetUserKeys возвращает неотозванные ключи пользователя
+func (r *APIKeyRepository) GetUserKeys(ctx context.Context, userID int) ([]models.APIKey, error) {
+    query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE user_id = $1 AND revoked_at IS NULL ORDER BY created_at DESC`
+
+    rows, err := r.db.Query(ctx, query, userID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    keys := []models.APIKey{}
+    for rows.Next() {
+        key, err := scanAPIKey(rows)
+        if err != nil {
+            return nil, err
+        }
+        keys = append(keys, *key)
+    }
+
+    return keys, rows.Err()
+}
+
+// CountActiveKeys считает неотозванные и неистекшие ключи пользователя
+func (r *APIKeyRepository) CountActiveKeys(ctx context.Context, userID int) (int, error) {
+    var count int
+    query := `
+        SELECT COUNT(*) FROM api_keys
+        WHERE user_id = $1 AND revoked_at IS NULL
+          AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+    `
+    err := r.db.QueryRow(ctx, query, userID).Scan(&count)
+    return count, err
+}
+
+// GetActiveKeyByHash возвращает действующий ключ по хешу или nil
+func (r *APIKeyRepository) GetActiveKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+    query := `
+        SELECT ` + apiKeyColumns + ` FROM api_keys
+        WHERE key_hash = $1 AND revoked_at IS NULL
+          AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+    `
+
+    key, err := scanAPIKey(r.db.QueryRow(ctx, query, keyHash))
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+    return key, err
+}
+
+// RevokeKey отзывает ключ пользователя. Возвращает false, если ключ не найден.
+func (r *APIKeyRepository) RevokeKey(ctx context.Context, userID, keyID int) (bool, error) {
+    tag, err := r.db.Exec(ctx,
+        "UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL",
+        keyID, userID,
+    )
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() > 0, nil
+}
+
+// TouchKey обновляет время последнего использования не чаще раза в минуту,
+// чтобы частые запросы скрипта не превращались в запись на каждый запрос
+func (r *APIKeyRepository) TouchKey(ctx context.Context, keyID int) error {
+    _, err := r.db.Exec(ctx, `
+        UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '1 minute')
+    `, keyID)
+    return err
+}
@@ -0,0 +1,203 @@
+package repositories
+
+import (
+    "context"
+    "errors"
+    "fmt"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AssignmentRepository struct {
+    db *pgxpool.Pool
+}
+
+func NewAssignmentRepository(db *pgxpool.Pool) *AssignmentRepository {
+    return &AssignmentRepository{db: db}
+}
+
+const submissionColumns = `s.id, s.material_id, COALESCE(pr.title, m.title), s.block_id, s.user_id, u.full_name,
+    s.material_revision, COALESCE(s.text, ''), COALESCE(s.file_id, ''), COALESCE(s.file_url, ''), COALESCE(s.file_name, ''),
+    s.status, s.score, s.max_score, COALESCE(s.feedback, ''), s.graded_by, s.graded_at, s.submitted_at`
+
+const submissionFrom = `
+    FROM assignment_submissions s
+    JOIN materials m ON m.id = s.material_id
+    JOIN users u ON u.id = s.user_id
+    LEFT JOIN material_revisions pr ON pr.material_id = m.id AND pr.revision = m.published_revision
+`
+
+func scanSubmission(row pgx.Row) (*models.AssignmentSubmission, error) {
+    var submission models.AssignmentSubmission
+
+    err := row.Scan(
+        &submission.ID, &submission.MaterialID, &submission.MaterialTitle, &submission.BlockID,
+        &submission.UserID, &submission.StudentName, &submission.Revision,
+        &submission.Text, &submission.FileID, &submission.FileURL, &submission.FileName,
+        &submission.Status, &submission.Score, &submission.MaxScore, &submission.Feedback,
+        &submission.GradedBy, &submission.GradedAt, &submission.SubmittedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+
+    if submission.Score != nil {
+        percent := models.ScorePercent(*submission.Score, submission.MaxScore)
+        submission.Percent = &percent
+    }
+
+    return &submission, nil
+}
+
+func (r *AssignmentRepository) querySubmissions(ctx context.Context, query string, args ...interface{}) ([]models.AssignmentSubmission, error) {
+    rows, err := r.db.Query(ctx, query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    submissions := []models.AssignmentSubmission{}
+    for rows.Next() {
+        submission, err := scanSubmission(rows)
+        if err != nil {
+            return nil, err
+        }
+        submissions = append(submissions, *submission)
+    }
+
+    return submissions, rows.Err()
+}
+
+// SaveSubmission сохраняет работу ученика. Повторная отправка заменяет
+// предыдущую работу и снова ставит ее в очередь на проверку. Оцененную
+// работу заменить нельзя - тогда возвращается false.
+func (r *AssignmentRepository) SaveSubmission(ctx context.Context, submission *models.AssignmentSubmission) (bool, error) {
+    query := `
+        INSERT INTO assignment_submissions (material_id, block_id, user_id, material_revision, text, file_id, file_name, max_score)
+        VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), $8)
+        ON CONFLICT (material_id, block_id, user_id) DO UPDATE
+        SET material_revision = EXCLUDED.material_revision, text = EXCLUDED.text,
+            file_id = EXCLUDED.file_id, file_url = NULL, file_name = EXCLUDED.file_name, max_score = EXCLUDED.max_score,
+            status = 'submitted', score = NULL, feedback = NULL, graded_by = NULL, graded_at = NULL,
+            submitted_at = CURRENT_TIMESTAMP
+        WHERE assignment_submissions.status <> 'graded'
+        RETURNING id, status, submitted_at
+    `
+
+    err := r.db.QueryRow(ctx, query,
+        submission.MaterialID, submission.BlockID, submission.UserID, submission.Revision,
+        submission.Text, submission.FileID, submission.FileName, submission.MaxScore,
+    ).Scan(&submission.ID, &submission.Status, &submission.SubmittedAt)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+    return true, nil
+}
+
+// GetFileMaterial возвращает материал, к заданию которого приложен файл, или 0
+func (r *AssignmentRepository) GetFileMaterial(ctx context.Context, fileID string) (int, error) {
+    var materialID int
+    err := r.db.QueryRow(ctx,
+        "SELECT material_id FROM assignment_submissions WHERE file_id = $1", fileID,
+    ).Scan(&materialID)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return 0, nil
+    }
+    return materialID, err
+}
+
+// GetSubmission возвращает работу по ID
+func (r *AssignmentRepository) GetSubmission(ctx context.Context, id int) (*models.AssignmentSubmission, error) {
+    query := "SELECT " + submissionColumns + submissionFrom + " WHERE s.id = $1"
+
+    submission, err := scanSubmission(r.db.QueryRow(ctx, query, id))
+    if errors.Is(err, pgx.ErrNoRows) {
+        return nil, nil
+    }
+    return submission, err
+}
+
+// GetUserSubmission возвращает работу ученика по заданию
+func (r *AssignmentRepository) GetUserSubmission(ctx context.Context, userID, materialID int, blockID string) (*models.AssignmentSubmission, error) {
+    query := "SELECT " + submissionColumns + submissionFrom +
+        " WHERE s.user_id = $1 AND s.material_id = $2 AND s.block_id = $3"
+
+    submission, err := scanSubmission(r.db.QueryRow(ctx, query, userID, materialID, blockID))
+    if errors.Is(err, pgx.ErrNoRows) {
+        return nil, nil
+    }
+    return submission, err
+}
+
+// GetUserSubmissions возвращает все работы ученика, последние первыми.
+// materialID = 0 - по всем материалам.
+func (r *AssignmentRepository) GetUserSubmissions(ctx context.Context, userID, materialID int) ([]models.AssignmentSubmission, error) {
+    query := "SELECT " + submissionColumns + submissionFrom +
+        " WHERE s.user_id = $1 AND ($2 = 0 OR s.material_id = $2) ORDER BY s.submitted_at DESC, s.id DESC"
+
+    return r.querySubmissions(ctx, query, userID, materialID)
+}
+
+// ListSubmissions возвращает очередь проверки: работы по материалу materialID
+// или, если он не задан, по всем материалам автора authorID. Старые работы первыми.
+func (r *AssignmentRepository) ListSubmissions(ctx context.Context, authorID, materialID int, status string, page, limit int) ([]models.AssignmentSubmission, int, error) {
+    baseQuery := submissionFrom
+    var args []interface{}
+    argIndex := 1
+
+    if materialID > 0 {
+        baseQuery += fmt.Sprintf(" WHERE s.material_id = $%d", argIndex)
+        args = append(args, materialID)
+    } else {
+        baseQuery += fmt.Sprintf(" WHERE m.author_id = $%d", argIndex)
+        args = append(args, authorID)
+    }
+    argIndex++
+
+    if status != "" {
+        baseQuery += fmt.Sprintf(" AND s.status = $%d", argIndex)
+        args = append(args, status)
+        argIndex++
+    }
+
+    var total int
+    if err := r.db.QueryRow(ctx, "SELECT COUNT(*) "+baseQuery, args...).Scan(&total); err != nil {
+        return nil, 0, err
+    }
+
+    query := "SELECT " + submissionColumns + baseQuery + " ORDER BY s.submitted_at, s.id"
+
+    if limit > 0 {
+        query += fmt.Sprintf(" LIMIT $%d", argIndex)
+        args = append(args, limit)
+        argIndex++
+
+        if page > 0 {
+            query += fmt.Sprintf(" OFFSET $%d", argIndex)
+            args = append(args, (page-1)*limit)
+        }
+    }
+
+    submissions, err := r.querySubmissions(ctx, query, args...)
+    return submissions, total, err
+}
+
+// ReviewSubmission сохраняет решение преподавателя. Работу, возвращенную
+// на доработку, нельзя проверить, пока ученик не отправит ее снова - тогда возвращается false.
+func (r *AssignmentRepository) ReviewSubmission(ctx context.Context, id int, status string, score *float64, feedback string, graderID int) (bool, error) {
+    tag, err := r.db.Exec(ctx, `
+        UPDATE assignment_submissions
+        SET status = $2, score = $3, feedback = NULLIF($4, ''), graded_by = $5, graded_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND status <> 'returned'
+    `, id, status, score, feedback, graderID)
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,85 @@
+package repositories
+
+import (
+    "context"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AuditRepository struct {
+    db *pgxpool.Pool
+}
+
+func NewAuditRepository(db *pgxpool.Pool) *AuditRepository {
+    return &AuditRepository{db: db}
+}
+
+// Record добавляет запись в журнал аудита
+func (r *AuditRepository) Record(ctx context.Context, entry *models.AuditEntry) error {
+    details := entry.Details
+    if details == nil {
+        details = map[string]interface{}{}
+    }
+
+    query := `
+        INSERT INTO audit_log (user_id, actor_id, action, details, ip, user_agent)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id, created_at
+    `
+
+    return r.db.QueryRow(ctx, query,
+        nullableID(entry.UserID), nullableID(entry.ActorID), entry.Action, details, entry.IP, entry.UserAgent,
+    ).Scan(&entry.ID, &entry.CreatedAt)
+}
+
+// GetUserEntries возвращает записи о пользователе и о действиях, выполненных им самим
+// (в том числе от имени других пользователей), новые сверху
+func (r *AuditRepository) GetUserEntries(ctx context.Context, userID, page, limit int) ([]models.AuditEntry, int, error) {
+    var total int
+    err := r.db.QueryRow(ctx,
+        "SELECT COUNT(*) FROM audit_log WHERE user_id = $1 OR actor_id = $1", userID,
+    ).Scan(&total)
+    if err != nil {
+        return nil, 0, err
+    }
+
+    query := `
+        SELECT id, COALESCE(user_id, 0), COALESCE(actor_id, 0), action, details,
+               COALESCE(ip, ''), COALESCE(user_agent, ''), created_at
+        FROM audit_log
+        WHERE user_id = $1 OR actor_id = $1
+        ORDER BY created_at DESC, id DESC
+        LIMIT $2 OFFSET $3
+    `
+
+    rows, err := r.db.Query(ctx, query, userID, limit, (page-1)*limit)
+    if err != nil {
+        return nil, 0, err
+    }
+    defer rows.Close()
+
+    entries := []models.AuditEntry{}
+    for rows.Next() {
+        var entry models.AuditEntry
+        if err := rows.Scan(
+            &entry.ID, &entry.UserID, &entry.ActorID, &entry.Action, &entry.Details,
+            &entry.IP, &entry.UserAgent, &entry.CreatedAt,
+        ); err != nil {
+            return nil, 0, err
+        }
+        entries = append(entries, entry)
+    }
+
+    return entries, total, rows.Err()
+}
+
+// nullableID превращает 0 в NULL: запись о полностью удаленном
+// пользователе не может ссылаться на его строку в users
+func nullableID(id int) *int {
+    if id == 0 {
+        return nil
+    }
+    return &id
+}
@@ -5,74 +5,341 @@ import (
     "encoding/json"
 
     "paydeya-backend/internal/models"
+    "paydeya-backend/internal/utils"
 
-    //"github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5"
     "github.com/jackc/pgx/v5/pgxpool"
 )
 
+// blockPositionGap - шаг между позициями соседних блоков. Пока между соседями
+// есть свободные позиции, вставка и перемещение меняют только одну строку.
+const blockPositionGap int64 = 1024
+
 type BlockRepository struct {
-    db *pgxpool.Pool
+    db DBTX
 }
 
 func NewBlockRepository(db *pgxpool.Pool) *BlockRepository {
     return &BlockRepository{db: db}
 }
 
-// SaveBlocks сохраняет блоки материала
+// WithTx возвращает репозиторий, работающий внутри транзакции tx
+func (r *BlockRepository) WithTx(tx pgx.Tx) *BlockRepository {
+    return &BlockRepository{db: tx}
+}
+
+// blockPosition - блок и его позиция в таблице
+type blockPosition struct {
+    ID       string
+    Position int64
+}
+
+// lockMaterial блокирует материал до конца транзакции, чтобы параллельные
+// правки блоков не получили одинаковые позиции
+func lockMaterial(ctx context.Context, tx pgx.Tx, materialID int) error {
+    _, err := tx.Exec(ctx, "SELECT id FROM materials WHERE id = $1 FOR UPDATE", materialID)
+    return err
+}
+
+// getPositions возвращает блоки материала в порядке следования
+func getPositions(ctx context.Context, tx pgx.Tx, materialID int) ([]blockPosition, error) {
+    rows, err := tx.Query(ctx,
+        "SELECT block_id, position FROM material_blocks WHERE material_id = $1 ORDER BY position, id",
+        materialID,
+    )
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var positions []blockPosition
+    for rows.Next() {
+        var position blockPosition
+        if err := rows.Scan(&position.ID, &position.Position); err != nil {
+            return nil, err
+        }
+        positions = append(positions, position)
+    }
+
+    return positions, rows.Err()
+}
+
+// planPositions вычисляет новые позиции для порядка order. Блоки из наибольшей
+// общей подпоследовательности старого и нового порядка остаются на месте,
+// остальные встают в промежутки между ними. Если промежуток исчерпан,
+// материал перенумеровывается целиком. Возвращает только изменившиеся позиции.
+func planPositions(current []blockPosition, order []string) map[string]int64 {
+    currentPos := make(map[string]int64, len(current))
+    for _, block := range current {
+        currentPos[block.ID] = block.Position
+    }
+    inOrder := make(map[string]bool, len(order))
+    for _, id := range order {
+        inOrder[id] = true
+    }
+
+    var currentCommon, orderCommon []string
+    for _, block := range current {
+        if inOrder[block.ID] {
+            currentCommon = append(currentCommon, block.ID)
+        }
+    }
+    for _, id := range order {
+        if _, ok := currentPos[id]; ok {
+            orderCommon = append(orderCommon, id)
+        }
+    }
+    stable := utils.LongestCommonSubsequence(currentCommon, orderCommon)
+
+    planned := make(map[string]int64)
+    prev := int64(0)
+    for i := 0; i < len(order); {
+        if stable[order[i]] {
+            prev = currentPos[order[i]]
+            i++
+            continue
+        }
+
+        // Серия блоков, которые нужно поставить между prev и следующим неподвижным блоком
+        j := i
+        for j < len(order) && !stable[order[j]] {
+            j++
+        }
+        count := int64(j - i)
+
+        step := blockPositionGap
+        if j < len(order) {
+            step = (currentPos[order[j]] - prev) / (count + 1)
+            if step < 1 {
+                return renumberPositions(currentPos, order)
+            }
+        }
+        for k := int64(0); k < count; k++ {
+            planned[order[i+int(k)]] = prev + step*(k+1)
+        }
+
+        i = j
+    }
+
+    for id, position := range planned {
+        if old, ok := currentPos[id]; ok && old == position {
+            delete(planned, id)
+        }
+    }
+    return planned
+}
+
+// renumberPositions заново расставляет позиции с шагом blockPositionGap
+func renumberPositions(currentPos map[string]int64, order []string) map[string]int64 {
+    planned := make(map[string]int64, len(order))
+    for i, id := range order {
+        position := int64(i+1) * blockPositionGap
+        if old, ok := currentPos[id]; !ok || old != position {
+            planned[id] = position
+        }
+    }
+    return planned
+}
+
+// marshalBlock готовит JSON поля блока для записи
+func marshalBlock(block *models.Block) (contentJSON, stylesJSON, animationJSON []byte, err error) {
+    if contentJSON, err = json.Marshal(block.Content); err != nil {
+        return nil, nil, nil, err
+    }
+    if stylesJSON, err = json.Marshal(block.Styles); err != nil {
+        return nil, nil, nil, err
+    }
+    if block.Animation != nil {
+        if animationJSON, err = json.Marshal(block.Animation); err != nil {
+            return nil, nil, nil, err
+        }
+    }
+    return contentJSON, stylesJSON, animationJSON, nil
+}
+
+// InsertBlock добавляет блок в конец материала
+func (r *BlockRepository) InsertBlock(ctx context.Context, materialID int, block *models.Block) error {
+    contentJSON, stylesJSON, animationJSON, err := marshalBlock(block)
+    if err != nil {
+        return err
+    }
+
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
+    if err := lockMaterial(ctx, tx, materialID); err != nil {
+        return err
+    }
+
+    var count int
+    var lastPosition int64
+    err = tx.QueryRow(ctx,
+        "SELECT COUNT(*), COALESCE(MAX(position), 0) FROM material_blocks WHERE material_id = $1",
+        materialID,
+    ).Scan(&count, &lastPosition)
+    if err != nil {
+        return err
+    }
+
+    _, err = tx.Exec(ctx, `
+        INSERT INTO material_blocks (material_id, block_id, type, content, styles, animation, position)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+    `, materialID, block.ID, block.Type, contentJSON, stylesJSON, animationJSON, lastPosition+blockPositionGap)
+    if err != nil {
+        return err
+    }
+
+    block.Position = count
+    return tx.Commit(ctx)
+}
+
+// UpdateBlock меняет содержимое блока, не трогая его позицию.
+// Возвращает false, если блока нет.
+func (r *BlockRepository) UpdateBlock(ctx context.Context, materialID int, block *models.Block) (bool, error) {
+    contentJSON, stylesJSON, animationJSON, err := marshalBlock(block)
+    if err != nil {
+        return false, err
+    }
+
+    tag, err := r.db.Exec(ctx, `
+        UPDATE material_blocks
+        SET type = $1, content = $2, styles = $3, animation = $4, updated_at = CURRENT_TIMESTAMP
+        WHERE material_id = $5 AND block_id = $6
+    `, block.Type, contentJSON, stylesJSON, animationJSON, materialID, block.ID)
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() > 0, nil
+}
+
+// DeleteBlock удаляет блок. Позиции остальных блоков не меняются.
+// Возвращает false, если блока нет.
+func (r *BlockRepository) DeleteBlock(ctx context.Context, materialID int, blockID string) (bool, error) {
+    tag, err := r.db.Exec(ctx,
+        "DELETE FROM material_blocks WHERE material_id = $1 AND block_id = $2",
+        materialID, blockID,
+    )
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() > 0, nil
+}
+
+// MoveBlocks расставляет блоки в порядке order, меняя позиции
+// только у блоков, которые действительно переместились
+func (r *BlockRepository) MoveBlocks(ctx context.Context, materialID int, order []string) error {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
+    if err := lockMaterial(ctx, tx, materialID); err != nil {
+        return err
+    }
+
+    current, err := getPositions(ctx, tx, materialID)
+    if err != nil {
+        return err
+    }
+
+    batch := &pgx.Batch{}
+    for id, position := range planPositions(current, order) {
+        batch.Queue(
+            "UPDATE material_blocks SET position = $1, updated_at = CURRENT_TIMESTAMP WHERE material_id = $2 AND block_id = $3",
+            position, materialID, id,
+        )
+    }
+    if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+        return err
+    }
+
+    return tx.Commit(ctx)
+}
+
+// SaveBlocks приводит блоки материала к переданному списку: новые блоки
+// добавляются, отсутствующие удаляются, а существующие обновляются только
+// если изменились их содержимое или позиция
 func (r *BlockRepository) SaveBlocks(ctx context.Context, materialID int, blocks []models.Block) error {
-    // Начинаем транзакцию
     tx, err := r.db.Begin(ctx)
     if err != nil {
         return err
     }
     defer tx.Rollback(ctx)
 
-    // Удаляем старые блоки
-    _, err = tx.Exec(ctx, "DELETE FROM material_blocks WHERE material_id = $1", materialID)
+    if err := lockMaterial(ctx, tx, materialID); err != nil {
+        return err
+    }
+
+    current, err := getPositions(ctx, tx, materialID)
     if err != nil {
         return err
     }
 
-    // Сохраняем новые блоки
-    for position, block := range blocks {
-        contentJSON, err := json.Marshal(block.Content)
-        if err != nil {
-            return err
-        }
+    currentPos := make(map[string]int64, len(current))
+    for _, block := range current {
+        currentPos[block.ID] = block.Position
+    }
 
-        stylesJSON, err := json.Marshal(block.Styles)
+    order := make([]string, 0, len(blocks))
+    for _, block := range blocks {
+        order = append(order, block.ID)
+    }
+    planned := planPositions(current, order)
+
+    batch := &pgx.Batch{}
+    batch.Queue(
+        "DELETE FROM material_blocks WHERE material_id = $1 AND NOT (block_id = ANY($2))",
+        materialID, order,
+    )
+
+    for i := range blocks {
+        block := &blocks[i]
+        contentJSON, stylesJSON, animationJSON, err := marshalBlock(block)
         if err != nil {
             return err
         }
 
-        var animationJSON []byte
-        if block.Animation != nil {
-            animationJSON, err = json.Marshal(block.Animation)
-            if err != nil {
-                return err
-            }
+        position, moved := planned[block.ID]
+        if _, exists := currentPos[block.ID]; !exists {
+            batch.Queue(`
+                INSERT INTO material_blocks (material_id, block_id, type, content, styles, animation, position)
+                VALUES ($1, $2, $3, $4, $5, $6, $7)
+            `, materialID, block.ID, block.Type, contentJSON, stylesJSON, animationJSON, position)
+            continue
+        }
+        if !moved {
+            position = currentPos[block.ID]
         }
 
-        _, err = tx.Exec(ctx, `
-            INSERT INTO material_blocks (material_id, block_id, type, content, styles, animation, position)
-            VALUES ($1, $2, $3, $4, $5, $6, $7)
-        `, materialID, block.ID, block.Type, contentJSON, stylesJSON, animationJSON, position)
+        // Неизмененные блоки не перезаписываются (jsonb сравнивается по значению)
+        batch.Queue(`
+            UPDATE material_blocks
+            SET type = $1, content = $2, styles = $3, animation = $4, position = $5, updated_at = CURRENT_TIMESTAMP
+            WHERE material_id = $6 AND block_id = $7
+              AND (type IS DISTINCT FROM $1 OR content IS DISTINCT FROM $2::jsonb OR styles IS DISTINCT FROM $3::jsonb
+                   OR animation IS DISTINCT FROM $4::jsonb OR position IS DISTINCT FROM $5)
+        `, block.Type, contentJSON, stylesJSON, animationJSON, position, materialID, block.ID)
+    }
 
-        if err != nil {
-            return err
-        }
+    if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+        return err
     }
 
     return tx.Commit(ctx)
 }
 
-// GetBlocks возвращает блоки материала
+// GetBlocks возвращает блоки материала. Position в ответе - порядковый номер
+// блока (с нуля), а не внутренняя позиция с промежутками.
 func (r *BlockRepository) GetBlocks(ctx context.Context, materialID int) ([]models.Block, error) {
     query := `
-        SELECT block_id, type, content, styles, animation, position
+        SELECT block_id, type, content, styles, animation
         FROM material_blocks
         WHERE material_id = $1
-        ORDER BY position
+        ORDER BY position, id
     `
 
     rows, err := r.db.Query(ctx, query, materialID)
@@ -86,7 +353,7 @@ func (r *BlockRepository) GetBlocks(ctx context.Context, materialID int) ([]mode
         var block models.Block
         var contentJSON, stylesJSON, animationJSON []byte
 
-        err := rows.Scan(&block.ID, &block.Type, &contentJSON, &stylesJSON, &animationJSON, &block.Position)
+        err := rows.Scan(&block.ID, &block.Type, &contentJSON, &stylesJSON, &animationJSON)
         if err != nil {
             return nil, err
         }
@@ -106,8 +373,9 @@ func (r *BlockRepository) GetBlocks(ctx context.Context, materialID int) ([]mode
             block.Animation = &animation
         }
 
+        block.Position = len(blocks)
         blocks = append(blocks, block)
     }
 
-    return blocks, nil
-}
\ No newline at end of file
+    return blocks, rows.Err()
+}
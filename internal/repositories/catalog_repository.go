@@ -27,12 +27,13 @@ func (r *CatalogRepository) SearchMaterials(ctx context.Context, filters models.
 
     // Базовый запрос
     baseQuery := `
-        SELECT m.id, m.title, m.subject,
+        SELECT m.id, COALESCE(pr.title, m.title) as title, m.subject,
                u.id as author_id, u.full_name as author_name,
                COALESCE(rm.rating, 0) as rating,
                COALESCE(rm.students_count, 0) as students_count
         FROM materials m
         JOIN users u ON m.author_id = u.id
+        LEFT JOIN material_revisions pr ON pr.material_id = m.id AND pr.revision = m.published_revision
         LEFT JOIN (
             SELECT material_id,
                    AVG(rating) as rating,
@@ -49,7 +50,7 @@ func (r *CatalogRepository) SearchMaterials(ctx context.Context, filters models.
 
     // Добавляем условия фильтрации
     if filters.Search != "" {
-        conditions = append(conditions, fmt.Sprintf("(m.title ILIKE $%d OR u.full_name ILIKE $%d)", argIndex, argIndex))
+        conditions = append(conditions, fmt.Sprintf("(COALESCE(pr.title, m.title) ILIKE $%d OR u.full_name ILIKE $%d)", argIndex, argIndex))
         args = append(args, "%"+filters.Search+"%")
         argIndex++
     }
@@ -145,7 +146,7 @@ func (r *CatalogRepository) GetSubjects(ctx context.Context) ([]models.Subject,
 func (r *CatalogRepository) SearchTeachers(ctx context.Context, filters models.TeacherFilters) ([]models.Teacher, error) {
     log.Printf("Building query with filters: %+v", filters)
     query := `
-        SELECT u.id, u.full_name, u.avatar_url,
+        SELECT u.id, u.full_name, u.avatar_url, u.is_verified,
                COUNT(DISTINCT m.id) as materials_count,
                COALESCE(AVG(mr.rating), 0) as rating
         FROM users u
@@ -170,11 +171,15 @@ func (r *CatalogRepository) SearchTeachers(ctx context.Context, filters models.T
         argIndex++
     }
 
+    if filters.VerifiedOnly {
+        conditions = append(conditions, "u.is_verified = TRUE")
+    }
+
     if len(conditions) > 0 {
         query += " AND " + strings.Join(conditions, " AND ")
     }
 
-    query += " GROUP BY u.id, u.full_name, u.avatar_url ORDER BY rating DESC NULLS LAST, materials_count DESC"
+    query += " GROUP BY u.id, u.full_name, u.avatar_url, u.is_verified ORDER BY rating DESC NULLS LAST, materials_count DESC"
 
     log.Printf("Final SQL query: %s", query) // ← ДОБАВЬТЕ
     log.Printf("Query args: %v", args)       // ← ДОБАВЬТЕ
@@ -189,7 +194,7 @@ func (r *CatalogRepository) SearchTeachers(ctx context.Context, filters models.T
     for rows.Next() {
         var teacher models.Teacher
         var avatarURL *string
-        if err := rows.Scan(&teacher.ID, &teacher.Name, &avatarURL, &teacher.MaterialsCount, &teacher.Rating); err != nil {
+        if err := rows.Scan(&teacher.ID, &teacher.Name, &avatarURL, &teacher.IsVerified, &teacher.MaterialsCount, &teacher.Rating); err != nil {
             return nil, err
         }
 
@@ -0,0 +1,18 @@
+package repositories
+
+import (
+    "context"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgconn"
+)
+
+// DBTX - общее у пула соединений и транзакции. Репозитории, методы которых
+// вызываются внутри транзакции сервиса, работают через него (см. WithTx).
+// Begin внутри транзакции создает точку сохранения.
+type DBTX interface {
+    Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+    Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+    QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+    Begin(ctx context.Context) (pgx.Tx, error)
+}
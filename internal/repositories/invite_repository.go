@@ -0,0 +1,112 @@
+package repositories
+
+import (
+    "context"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type InviteRepository struct {
+    db *pgxpool.Pool
+}
+
+func NewInviteRepository(db *pgxpool.Pool) *InviteRepository {
+    return &InviteRepository{db: db}
+}
+
+const inviteColumns = `id, email, role, created_by, expires_at, used_at, used_by, revoked_at, created_at`
+
+func scanInvite(row pgx.Row) (*models.AdminInvite, error) {
+    var invite models.AdminInvite
+    err := row.Scan(
+        &invite.ID, &invite.Email, &invite.Role, &invite.CreatedBy, &invite.ExpiresAt,
+        &invite.UsedAt, &invite.UsedBy, &invite.RevokedAt, &invite.CreatedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+    return &invite, nil
+}
+
+// CreateInvite сохраняет приглашение
+func (r *InviteRepository) CreateInvite(ctx context.Context, invite *models.AdminInvite, tokenHash string) error {
+    query := `
+        INSERT INTO admin_invites (token_hash, email, role, created_by, expires_at)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at
+    `
+
+    return r.db.QueryRow(ctx, query,
+        tokenHash, invite.Email, invite.Role, invite.CreatedBy, invite.ExpiresAt,
+    ).Scan(&invite.ID, &invite.CreatedAt)
+}
+
+// ListInvites возвращает все приглашения, новые первыми
+func (r *InviteRepository) ListInvites(ctx context.Context) ([]models.AdminInvite, error) {
+    query := `SELECT ` + inviteColumns + ` FROM admin_invites ORDER BY created_at DESC`
+
+    rows, err := r.db.Query(ctx, query)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    invites := []models.AdminInvite{}
+    for rows.Next() {
+        invite, err := scanInvite(rows)
+        if err != nil {
+            return nil, err
+        }
+        invites = append(invites, *invite)
+    }
+
+    return invites, rows.Err()
+}
+
+// RevokeInvite отзывает неиспользованное приглашение. Возвращает false, если отзывать нечего.
+func (r *InviteRepository) RevokeInvite(ctx context.Context, id int) (bool, error) {
+    query := `
+        UPDATE admin_invites
+        SET revoked_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND used_at IS NULL AND revoked_at IS NULL
+    `
+
+    tag, err := r.db.Exec(ctx, query, id)
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() > 0, nil
+}
+
+// ClaimInvite атомарно занимает действующее приглашение, чтобы его нельзя
+// было использовать дважды. Возвращает nil, если приглашение недействительно.
+func (r *InviteRepository) ClaimInvite(ctx context.Context, tokenHash string) (*models.AdminInvite, error) {
+    query := `
+        UPDATE admin_invites
+        SET used_at = CURRENT_TIMESTAMP
+        WHERE token_hash = $1 AND used_at IS NULL AND revoked_at IS NULL
+          AND expires_at > CURRENT_TIMESTAMP
+        RETURNING ` + inviteColumns
+
+    invite, err := scanInvite(r.db.QueryRow(ctx, query, tokenHash))
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+
+    return invite, err
+}
+
+// CompleteInvite записывает пользователя, зарегистрированного по приглашению
+func (r *InviteRepository) CompleteInvite(ctx context.Context, id, userID int) error {
+    _, err := r.db.Exec(ctx, `UPDATE admin_invites SET used_by = $1 WHERE id = $2`, userID, id)
+    return err
+}
+
+// ReleaseInvite возвращает приглашение в действующие, если регистрация не удалась
+func (r *InviteRepository) ReleaseInvite(ctx context.Context, id int) error {
+    _, err := r.db.Exec(ctx, `UPDATE admin_invites SET used_at = NULL WHERE id = $1 AND used_by IS NULL`, id)
+    return err
+}
@@ -10,25 +10,38 @@ import (
 )
 
 type MaterialRepository struct {
-    db *pgxpool.Pool
+    db DBTX
 }
 
 func NewMaterialRepository(db *pgxpool.Pool) *MaterialRepository {
     return &MaterialRepository{db: db}
 }
 
+// Begin начинает транзакцию, в которой сервис материалов выполняет
+// многошаговые изменения
+func (r *MaterialRepository) Begin(ctx context.Context) (pgx.Tx, error) {
+    return r.db.Begin(ctx)
+}
+
+// WithTx возвращает репозиторий, работающий внутри транзакции tx
+func (r *MaterialRepository) WithTx(tx pgx.Tx) *MaterialRepository {
+    return &MaterialRepository{db: tx}
+}
+
 // CreateMaterial создает новый материал
 func (r *MaterialRepository) CreateMaterial(ctx context.Context, material *models.Material) error {
     query := `
-        INSERT INTO materials (title, subject, author_id, status, access, share_url)
-        VALUES ($1, $2, $3, $4, $5, $6)
-        RETURNING id, created_at, updated_at
+        INSERT INTO materials (title, subject, author_id, status, access, share_url,
+                               is_template, forked_from, forked_revision, original_author_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+        RETURNING id, edit_version, created_at, updated_at
     `
 
     err := r.db.QueryRow(ctx, query,
         material.Title, material.Subject, material.AuthorID,
         material.Status, material.Access, material.ShareURL,
-    ).Scan(&material.ID, &material.CreatedAt, &material.UpdatedAt)
+        material.IsTemplate, material.ForkedFrom, material.ForkedRevision, material.OriginalAuthorID,
+    ).Scan(&material.ID, &material.EditVersion, &material.CreatedAt, &material.UpdatedAt)
 
     return err
 }
@@ -37,15 +50,23 @@ func (r *MaterialRepository) CreateMaterial(ctx context.Context, material *model
 func (r *MaterialRepository) GetMaterial(ctx context.Context, id int) (*models.Material, error) {
     var material models.Material
 
+    var latestRevision int
+
     query := `
-        SELECT id, title, subject, author_id, status, access, share_url, created_at, updated_at
-        FROM materials
-        WHERE id = $1
+        SELECT m.id, m.title, m.subject, m.author_id, m.status, m.access, m.share_url, m.published_revision, m.published_at, m.edit_version,
+               COALESCE((SELECT MAX(revision) FROM material_revisions WHERE material_id = m.id), 0),
+               m.is_template, m.forked_from, m.forked_revision, m.original_author_id, COALESCE(oa.full_name, ''),
+               m.created_at, m.updated_at
+        FROM materials m
+        LEFT JOIN users oa ON oa.id = m.original_author_id
+        WHERE m.id = $1
     `
 
     err := r.db.QueryRow(ctx, query, id).Scan(
         &material.ID, &material.Title, &material.Subject, &material.AuthorID,
         &material.Status, &material.Access, &material.ShareURL,
+        &material.PublishedRevision, &material.PublishedAt, &material.EditVersion, &latestRevision,
+        &material.IsTemplate, &material.ForkedFrom, &material.ForkedRevision, &material.OriginalAuthorID, &material.OriginalAuthorName,
         &material.CreatedAt, &material.UpdatedAt,
     )
 
@@ -53,6 +74,12 @@ func (r *MaterialRepository) GetMaterial(ctx context.Context, id int) (*models.M
         return nil, nil
     }
 
+    // Каждая правка черновика сохраняет ревизию, поэтому более поздняя ревизия
+    // означает, что черновик ушел вперед от опубликованной версии
+    if material.PublishedRevision != nil {
+        material.HasUnpublishedChanges = latestRevision > *material.PublishedRevision
+    }
+
     return &material, err
 }
 
@@ -63,11 +90,11 @@ func (r *MaterialRepository) GetUserMaterials(ctx context.Context, userID int, s
     var err error
 
     if status == "" {
-        query = `SELECT id, title, subject, status, access, created_at, updated_at
+        query = `SELECT id, title, subject, status, access, is_template, created_at, updated_at
                  FROM materials WHERE author_id = $1 ORDER BY updated_at DESC`
         rows, err = r.db.Query(ctx, query, userID)
     } else {
-        query = `SELECT id, title, subject, status, access, created_at, updated_at
+        query = `SELECT id, title, subject, status, access, is_template, created_at, updated_at
                  FROM materials WHERE author_id = $1 AND status = $2 ORDER BY updated_at DESC`
         rows, err = r.db.Query(ctx, query, userID, status)
     }
@@ -82,7 +109,7 @@ func (r *MaterialRepository) GetUserMaterials(ctx context.Context, userID int, s
         var material models.Material
         if err := rows.Scan(
             &material.ID, &material.Title, &material.Subject,
-            &material.Status, &material.Access, &material.CreatedAt, &material.UpdatedAt,
+            &material.Status, &material.Access, &material.IsTemplate, &material.CreatedAt, &material.UpdatedAt,
         ); err != nil {
             return nil, err
         }
@@ -93,18 +120,46 @@ func (r *MaterialRepository) GetUserMaterials(ctx context.Context, userID int, s
     return materials, nil
 }
 
+// BumpEditVersion увеличивает версию черновика, если текущая равна expected
+// (0 - без проверки). Возвращает новую версию или 0, если версия не совпала.
+func (r *MaterialRepository) BumpEditVersion(ctx context.Context, materialID, expected int) (int, error) {
+    query := `
+        UPDATE materials
+        SET edit_version = edit_version + 1
+        WHERE id = $1 AND ($2::int = 0 OR edit_version = $2)
+        RETURNING edit_version
+    `
+
+    var version int
+    err := r.db.QueryRow(ctx, query, materialID, expected).Scan(&version)
+    if err == pgx.ErrNoRows {
+        return 0, nil
+    }
+    return version, err
+}
+
 // UpdateMaterial обновляет материал
 func (r *MaterialRepository) UpdateMaterial(ctx context.Context, material *models.Material) error {
     query := `
         UPDATE materials
-        SET title = $1, subject = $2, status = $3, access = $4, share_url = $5, updated_at = CURRENT_TIMESTAMP
-        WHERE id = $6 AND author_id = $7
+        SET title = $1, subject = $2, status = $3, access = $4, share_url = $5,
+            published_revision = $6, published_at = $7, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $8 AND author_id = $9
     `
 
     _, err := r.db.Exec(ctx, query,
         material.Title, material.Subject, material.Status, material.Access,
-        material.ShareURL, material.ID, material.AuthorID,
+        material.ShareURL, material.PublishedRevision, material.PublishedAt, material.ID, material.AuthorID,
     )
     return err
 }
 
+
+// SetTemplate включает или выключает признак шаблона
+func (r *MaterialRepository) SetTemplate(ctx context.Context, materialID int, isTemplate bool) error {
+    _, err := r.db.Exec(ctx,
+        "UPDATE materials SET is_template = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2",
+        isTemplate, materialID,
+    )
+    return err
+}
@@ -0,0 +1,155 @@
+package repositories
+
+import (
+    "context"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type OAuthRepository struct {
+    db *pgxpool.Pool
+}
+
+func NewOAuthRepository(db *pgxpool.Pool) *OAuthRepository {
+    return &OAuthRepository{db: db}
+}
+
+// CreateState сохраняет незавершенную авторизацию и заодно удаляет просроченные
+func (r *OAuthRepository) CreateState(ctx context.Context, stateHash string, state *models.OAuthState) error {
+    if _, err := r.db.Exec(ctx, `DELETE FROM oauth_states WHERE expires_at < CURRENT_TIMESTAMP`); err != nil {
+        return err
+    }
+
+    query := `
+        INSERT INTO oauth_states (state_hash, provider, code_verifier, role, expires_at)
+        VALUES ($1, $2, $3, $4, $5)
+    `
+
+    _, err := r.db.Exec(ctx, query, stateHash, state.Provider, state.CodeVerifier, state.Role, state.ExpiresAt)
+    return err
+}
+
+// ConsumeState удаляет state и возвращает его. State одноразовый:
+// если он не найден, истек или уже использован - возвращает nil.
+func (r *OAuthRepository) ConsumeState(ctx context.Context, stateHash string) (*models.OAuthState, error) {
+    var state models.OAuthState
+
+    query := `
+        DELETE FROM oauth_states
+        WHERE state_hash = $1
+        RETURNING provider, code_verifier, role, expires_at
+    `
+
+    err := r.db.QueryRow(ctx, query, stateHash).Scan(
+        &state.Provider, &state.CodeVerifier, &state.Role, &state.ExpiresAt,
+    )
+
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    return &state, nil
+}
+
+// GetIdentityUserID возвращает ID пользователя, к которому привязан внешний аккаунт (0, если не привязан)
+func (r *OAuthRepository) GetIdentityUserID(ctx context.Context, provider, subject string) (int, error) {
+    var userID int
+    query := `SELECT user_id FROM user_identities WHERE provider = $1 AND subject = $2`
+    err := r.db.QueryRow(ctx, query, provider, subject).Scan(&userID)
+    if err == pgx.ErrNoRows {
+        return 0, nil
+    }
+    return userID, err
+}
+
+// GetUserIdentities возвращает внешние аккаунты, привязанные к пользователю
+func (r *OAuthRepository) GetUserIdentities(ctx context.Context, userID int) ([]models.UserIdentity, error) {
+    query := `
+        SELECT id, user_id, provider, subject, COALESCE(email, ''), created_at
+        FROM user_identities
+        WHERE user_id = $1
+        ORDER BY created_at
+    `
+
+    rows, err := r.db.Query(ctx, query, userID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    identities := []models.UserIdentity{}
+    for rows.Next() {
+        var identity models.UserIdentity
+        if err := rows.Scan(
+            &identity.ID, &identity.UserID, &identity.Provider, &identity.Subject,
+            &identity.Email, &identity.CreatedAt,
+        ); err != nil {
+            return nil, err
+        }
+        identities = append(identities, identity)
+    }
+
+    return identities, rows.Err()
+}
+
+// CreateIdentity привязывает внешний аккаунт к существующему пользователю
+func (r *OAuthRepository) CreateIdentity(ctx context.Context, identity *models.UserIdentity) error {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
+    if err := insertIdentity(ctx, tx, identity); err != nil {
+        return err
+    }
+
+    return tx.Commit(ctx)
+}
+
+// CreateUserWithIdentity создает пользователя вместе с привязанным внешним аккаунтом
+func (r *OAuthRepository) CreateUserWithIdentity(ctx context.Context, user *models.User, identity *models.UserIdentity) error {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
+    query := `
+        INSERT INTO users (email, password_hash, full_name, role, avatar_url, is_verified, email_verified)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id, created_at, updated_at
+    `
+
+    err = tx.QueryRow(ctx, query,
+        user.Email, user.PasswordHash, user.FullName, user.Role, user.AvatarURL, user.IsVerified, user.EmailVerified,
+    ).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+    if err != nil {
+        return err
+    }
+
+    identity.UserID = user.ID
+    if err := insertIdentity(ctx, tx, identity); err != nil {
+        return err
+    }
+
+    return tx.Commit(ctx)
+}
+
+func insertIdentity(ctx context.Context, tx pgx.Tx, identity *models.UserIdentity) error {
+    query := `
+        INSERT INTO user_identities (user_id, provider, subject, email)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id, created_at
+    `
+
+    return tx.QueryRow(ctx, query,
+        identity.UserID, identity.Provider, identity.Subject, identity.Email,
+    ).Scan(&identity.ID, &identity.CreatedAt)
+}
@@ -0,0 +1,84 @@
+package repositories
+
+import (
+    "context"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PrivateFileRepository struct {
+    db *pgxpool.Pool
+}
+
+func NewPrivateFileRepository(db *pgxpool.Pool) *PrivateFileRepository {
+    return &PrivateFileRepository{db: db}
+}
+
+const privateFileColumns = `id, user_id, kind, storage, storage_key, file_name, content_type, size, created_at`
+
+func scanPrivateFile(row pgx.Row) (*models.PrivateFile, error) {
+    var file models.PrivateFile
+    err := row.Scan(
+        &file.ID, &file.UserID, &file.Kind, &file.Storage, &file.StorageKey,
+        &file.FileName, &file.ContentType, &file.Size, &file.CreatedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+    return &file, nil
+}
+
+// CreateFile сохраняет сведения о загруженном закрытом файле
+func (r *PrivateFileRepository) CreateFile(ctx context.Context, file *models.PrivateFile) error {
+    query := `
+        INSERT INTO private_files (id, user_id, kind, storage, storage_key, file_name, content_type, size)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING created_at
+    `
+
+    return r.db.QueryRow(ctx, query,
+        file.ID, file.UserID, file.Kind, file.Storage, file.StorageKey, file.FileName, file.ContentType, file.Size,
+    ).Scan(&file.CreatedAt)
+}
+
+// GetFile возвращает файл по идентификатору или nil, если его нет
+func (r *PrivateFileRepository) GetFile(ctx context.Context, id string) (*models.PrivateFile, error) {
+    query := `SELECT ` + privateFileColumns + ` FROM private_files WHERE id = $1`
+
+    file, err := scanPrivateFile(r.db.QueryRow(ctx, query, id))
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+    return file, err
+}
+
+// GetUserFiles возвращает все закрытые файлы пользователя
+func (r *PrivateFileRepository) GetUserFiles(ctx context.Context, userID int) ([]models.PrivateFile, error) {
+    query := `SELECT ` + privateFileColumns + ` FROM private_files WHERE user_id = $1 ORDER BY created_at`
+
+    rows, err := r.db.Query(ctx, query, userID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    files := []models.PrivateFile{}
+    for rows.Next() {
+        file, err := scanPrivateFile(rows)
+        if err != nil {
+            return nil, err
+        }
+        files = append(files, *file)
+    }
+
+    return files, rows.Err()
+}
+
+// DeleteFile удаляет сведения о файле
+func (r *PrivateFileRepository) DeleteFile(ctx context.Context, id string) error {
+    _, err := r.db.Exec(ctx, "DELETE FROM private_files WHERE id = $1", id)
+    return err
+}
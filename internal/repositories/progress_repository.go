@@ -49,10 +49,12 @@ func (r *ProgressRepository) GetStudentProgress(ctx context.Context, userID int)
     }
 
     // Получаем текущие материалы (последние 5)
+    // Название берется из изученной версии материала
     query = `
-        SELECT m.id, m.title, m.subject, mc.last_activity
+        SELECT m.id, COALESCE(r.title, m.title), m.subject, mc.material_revision, m.published_revision, mc.last_activity
         FROM materials m
         JOIN material_completions mc ON m.id = mc.material_id
+        LEFT JOIN material_revisions r ON r.material_id = m.id AND r.revision = mc.material_revision
         WHERE mc.user_id = $1
         ORDER BY mc.last_activity DESC
         LIMIT 5
@@ -63,10 +65,12 @@ func (r *ProgressRepository) GetStudentProgress(ctx context.Context, userID int)
 
         for rows.Next() {
             var material models.ProgressMaterial
+            var publishedRevision *int
             var lastActivity time.Time
 
-            if err := rows.Scan(&material.ID, &material.Title, &material.Subject, &lastActivity); err == nil {
+            if err := rows.Scan(&material.ID, &material.Title, &material.Subject, &material.Revision, &publishedRevision, &lastActivity); err == nil {
                 material.LastActivity = lastActivity
+                material.HasNewVersion = material.Revision != nil && publishedRevision != nil && *publishedRevision > *material.Revision
                 material.Progress = 100.0 // если в completion, значит завершен
                 progress.CurrentMaterials = append(progress.CurrentMaterials, material)
             }
@@ -76,13 +80,15 @@ func (r *ProgressRepository) GetStudentProgress(ctx context.Context, userID int)
     return &progress, nil
 }
 
-// MarkMaterialComplete отмечает материал как завершенный
-func (r *ProgressRepository) MarkMaterialComplete(ctx context.Context, userID, materialID int, timeSpent int, grade float64) error {
+// MarkMaterialComplete отмечает материал как завершенный и запоминает
+// опубликованную версию, которую изучал ученик. grade = nil - без оценки.
+func (r *ProgressRepository) MarkMaterialComplete(ctx context.Context, userID, materialID int, timeSpent int, grade *float64) error {
     query := `
-        INSERT INTO material_completions (user_id, material_id, time_spent, grade, completed_at, last_activity)
-        VALUES ($1, $2, $3, $4, $5, $6)
+        INSERT INTO material_completions (user_id, material_id, time_spent, grade, completed_at, last_activity, material_revision)
+        VALUES ($1, $2, $3, $4, $5, $6, (SELECT published_revision FROM materials WHERE id = $2))
         ON CONFLICT (user_id, material_id)
-        DO UPDATE SET time_spent = EXCLUDED.time_spent, grade = EXCLUDED.grade, last_activity = EXCLUDED.last_activity
+        DO UPDATE SET time_spent = EXCLUDED.time_spent, grade = EXCLUDED.grade, last_activity = EXCLUDED.last_activity,
+                      material_revision = EXCLUDED.material_revision
     `
 
     now := time.Now()
@@ -90,15 +96,26 @@ func (r *ProgressRepository) MarkMaterialComplete(ctx context.Context, userID, m
     return err
 }
 
+// UpdateGrade обновляет оценку за уже завершенный материал (nil - без оценки).
+// Если материал еще не завершен, ничего не делает.
+func (r *ProgressRepository) UpdateGrade(ctx context.Context, userID, materialID int, grade *float64) error {
+    _, err := r.db.Exec(ctx,
+        "UPDATE material_completions SET grade = $1, last_activity = CURRENT_TIMESTAMP WHERE user_id = $2 AND material_id = $3",
+        grade, userID, materialID,
+    )
+    return err
+}
+
 // GetFavoriteMaterials возвращает избранные материалы
 func (r *ProgressRepository) GetFavoriteMaterials(ctx context.Context, userID int) ([]models.CatalogMaterial, error) {
     query := `
-        SELECT m.id, m.title, m.subject,
+        SELECT m.id, COALESCE(pr.title, m.title), m.subject,
                u.id as author_id, u.full_name as author_name,
                4.5 as rating, 10 as students_count
         FROM materials m
         JOIN users u ON m.author_id = u.id
         JOIN favorite_materials fm ON m.id = fm.material_id
+        LEFT JOIN material_revisions pr ON pr.material_id = m.id AND pr.revision = m.published_revision
         WHERE fm.user_id = $1 AND m.status = 'published'
         ORDER BY fm.created_at DESC
     `
@@ -0,0 +1,205 @@
+package repositories
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgconn"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type QuizRepository struct {
+    db *pgxpool.Pool
+}
+
+func NewQuizRepository(db *pgxpool.Pool) *QuizRepository {
+    return &QuizRepository{db: db}
+}
+
+const attemptColumns = `id, material_id, block_id, user_id, material_revision, attempt_number, answers, results, score, max_score, created_at`
+
+func scanQuizAttempt(row pgx.Row) (*models.QuizAttempt, error) {
+    var attempt models.QuizAttempt
+    var answersJSON, resultsJSON []byte
+
+    err := row.Scan(
+        &attempt.ID, &attempt.MaterialID, &attempt.BlockID, &attempt.UserID, &attempt.Revision, &attempt.Number,
+        &answersJSON, &resultsJSON, &attempt.Score, &attempt.MaxScore, &attempt.CreatedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+
+    if err := json.Unmarshal(answersJSON, &attempt.Answers); err != nil {
+        return nil, err
+    }
+    if err := json.Unmarshal(resultsJSON, &attempt.Results); err != nil {
+        return nil, err
+    }
+    attempt.Percent = models.ScorePercent(attempt.Score, attempt.MaxScore)
+
+    return &attempt, nil
+}
+
+func collectQuizAttempts(rows pgx.Rows) ([]models.QuizAttempt, error) {
+    defer rows.Close()
+
+    attempts := []models.QuizAttempt{}
+    for rows.Next() {
+        attempt, err := scanQuizAttempt(rows)
+        if err != nil {
+            return nil, err
+        }
+        attempts = append(attempts, *attempt)
+    }
+
+    return attempts, rows.Err()
+}
+
+// CreateAttempt сохраняет проверенную попытку со следующим номером.
+// Если ученик уже сделал maxAttempts попыток (или попытка с тем же номером
+// сохранена параллельным запросом), попытка не сохраняется и возвращается false.
+func (r *QuizRepository) CreateAttempt(ctx context.Context, attempt *models.QuizAttempt, maxAttempts int) (bool, error) {
+    answersJSON, err := json.Marshal(attempt.Answers)
+    if err != nil {
+        return false, err
+    }
+    resultsJSON, err := json.Marshal(attempt.Results)
+    if err != nil {
+        return false, err
+    }
+
+    query := `
+        INSERT INTO quiz_attempts (material_id, block_id, user_id, material_revision, answers, results, score, max_score, attempt_number)
+        SELECT $1, $2, $3, $4, $5, $6, $7, $8, COALESCE(MAX(attempt_number), 0) + 1
+        FROM quiz_attempts
+        WHERE material_id = $1 AND block_id = $2 AND user_id = $3
+        HAVING COALESCE(MAX(attempt_number), 0) < $9
+        RETURNING id, attempt_number, created_at
+    `
+
+    err = r.db.QueryRow(ctx, query,
+        attempt.MaterialID, attempt.BlockID, attempt.UserID, attempt.Revision,
+        answersJSON, resultsJSON, attempt.Score, attempt.MaxScore, maxAttempts,
+    ).Scan(&attempt.ID, &attempt.Number, &attempt.CreatedAt)
+
+    var pgErr *pgconn.PgError
+    if errors.Is(err, pgx.ErrNoRows) || (errors.As(err, &pgErr) && pgErr.Code == "23505") {
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+    return true, nil
+}
+
+// CountUserAttempts возвращает, сколько попыток ученик сделал по тесту
+func (r *QuizRepository) CountUserAttempts(ctx context.Context, userID, materialID int, blockID string) (int, error) {
+    var count int
+    err := r.db.QueryRow(ctx,
+        "SELECT COALESCE(MAX(attempt_number), 0) FROM quiz_attempts WHERE user_id = $1 AND material_id = $2 AND block_id = $3",
+        userID, materialID, blockID,
+    ).Scan(&count)
+    return count, err
+}
+
+// GetUserAttempts возвращает попытки ученика по тесту, последние первыми
+func (r *QuizRepository) GetUserAttempts(ctx context.Context, userID, materialID int, blockID string) ([]models.QuizAttempt, error) {
+    query := `SELECT ` + attemptColumns + ` FROM quiz_attempts
+              WHERE user_id = $1 AND material_id = $2 AND block_id = $3
+              ORDER BY attempt_number DESC`
+
+    rows, err := r.db.Query(ctx, query, userID, materialID, blockID)
+    if err != nil {
+        return nil, err
+    }
+    return collectQuizAttempts(rows)
+}
+
+// GetBlockAttempts возвращает все попытки по тесту (для статистики)
+func (r *QuizRepository) GetBlockAttempts(ctx context.Context, materialID int, blockID string) ([]models.QuizAttempt, error) {
+    query := `SELECT ` + attemptColumns + ` FROM quiz_attempts
+              WHERE material_id = $1 AND block_id = $2
+              ORDER BY created_at`
+
+    rows, err := r.db.Query(ctx, query, materialID, blockID)
+    if err != nil {
+        return nil, err
+    }
+    return collectQuizAttempts(rows)
+}
+
+// GetLastAttempts возвращает номер и результат (в процентах) последней попытки ученика по каждому тесту материала
+func (r *QuizRepository) GetLastAttempts(ctx context.Context, userID, materialID int) (map[string]models.QuizAttempt, error) {
+    query := `
+        SELECT DISTINCT ON (block_id) block_id, attempt_number,
+               CASE WHEN max_score > 0 THEN score * 100 / max_score ELSE 100 END
+        FROM quiz_attempts
+        WHERE user_id = $1 AND material_id = $2
+        ORDER BY block_id, attempt_number DESC
+    `
+
+    rows, err := r.db.Query(ctx, query, userID, materialID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    last := make(map[string]models.QuizAttempt)
+    for rows.Next() {
+        attempt := models.QuizAttempt{UserID: userID, MaterialID: materialID}
+        if err := rows.Scan(&attempt.BlockID, &attempt.Number, &attempt.Percent); err != nil {
+            return nil, err
+        }
+        last[attempt.BlockID] = attempt
+    }
+
+    return last, rows.Err()
+}
+
+// GetBlockUserIDs возвращает учеников, у которых есть попытки по тесту
+func (r *QuizRepository) GetBlockUserIDs(ctx context.Context, materialID int, blockID string) ([]int, error) {
+    rows, err := r.db.Query(ctx,
+        "SELECT DISTINCT user_id FROM quiz_attempts WHERE material_id = $1 AND block_id = $2", materialID, blockID,
+    )
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    userIDs := []int{}
+    for rows.Next() {
+        var userID int
+        if err := rows.Scan(&userID); err != nil {
+            return nil, err
+        }
+        userIDs = append(userIDs, userID)
+    }
+
+    return userIDs, rows.Err()
+}
+
+// IsReleased проверяет, открыл ли преподаватель результаты теста ученикам
+func (r *QuizRepository) IsReleased(ctx context.Context, materialID int, blockID string) (bool, error) {
+    var released bool
+    err := r.db.QueryRow(ctx,
+        "SELECT EXISTS(SELECT 1 FROM quiz_result_releases WHERE material_id = $1 AND block_id = $2)", materialID, blockID,
+    ).Scan(&released)
+    return released, err
+}
+
+// ReleaseResults открывает результаты теста ученикам
+func (r *QuizRepository) ReleaseResults(ctx context.Context, materialID int, blockID string, userID int) error {
+    query := `
+        INSERT INTO quiz_result_releases (material_id, block_id, released_by)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (material_id, block_id) DO NOTHING
+    `
+
+    _, err := r.db.Exec(ctx, query, materialID, blockID, nullableID(userID))
+    return err
+}
@@ -0,0 +1,123 @@
+package repositories
+
+import (
+    "context"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RefreshTokenRepository struct {
+    db *pgxpool.Pool
+}
+
+func NewRefreshTokenRepository(db *pgxpool.Pool) *RefreshTokenRepository {
+    return &RefreshTokenRepository{db: db}
+}
+
+// CreateToken сохраняет выданный refresh токен
+func (r *RefreshTokenRepository) CreateToken(ctx context.Context, token *models.RefreshToken) error {
+    query := `
+        INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id, created_at
+    `
+
+    return r.db.QueryRow(ctx, query,
+        token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt,
+    ).Scan(&token.ID, &token.CreatedAt)
+}
+
+// GetTokenByHash возвращает refresh токен по хешу
+func (r *RefreshTokenRepository) GetTokenByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+    var token models.RefreshToken
+
+    query := `
+        SELECT id, user_id, family_id, token_hash, expires_at, rotated_at, revoked_at, created_at
+        FROM refresh_tokens
+        WHERE token_hash = $1
+    `
+
+    err := r.db.QueryRow(ctx, query, tokenHash).Scan(
+        &token.ID, &token.UserID, &token.FamilyID, &token.TokenHash,
+        &token.ExpiresAt, &token.RotatedAt, &token.RevokedAt, &token.CreatedAt,
+    )
+
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+
+    return &token, err
+}
+
+// RotateToken помечает старый токен как обменянный и сохраняет новый.
+// Возвращает false, если старый токен уже был обменян или отозван
+// (например, параллельный запрос успел раньше).
+func (r *RefreshTokenRepository) RotateToken(ctx context.Context, oldID int64, next *models.RefreshToken) (bool, error) {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback(ctx)
+
+    tag, err := tx.Exec(ctx, `
+        UPDATE refresh_tokens
+        SET rotated_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND rotated_at IS NULL AND revoked_at IS NULL
+    `, oldID)
+    if err != nil {
+        return false, err
+    }
+    if tag.RowsAffected() == 0 {
+        return false, nil
+    }
+
+    err = tx.QueryRow(ctx, `
+        INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id, created_at
+    `, next.UserID, next.FamilyID, next.TokenHash, next.ExpiresAt).Scan(&next.ID, &next.CreatedAt)
+    if err != nil {
+        return false, err
+    }
+
+    return true, tx.Commit(ctx)
+}
+
+// RevokeFamily отзывает все токены семейства
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+    query := `
+        UPDATE refresh_tokens
+        SET revoked_at = CURRENT_TIMESTAMP
+        WHERE family_id = $1 AND revoked_at IS NULL
+    `
+
+    _, err := r.db.Exec(ctx, query, familyID)
+    return err
+}
+
+// RevokeAllForUser отзывает все семейства токенов пользователя
+func (r *RefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID int) error {
+    query := `
+        UPDATE refresh_tokens
+        SET revoked_at = CURRENT_TIMESTAMP
+        WHERE user_id = $1 AND revoked_at IS NULL
+    `
+
+    _, err := r.db.Exec(ctx, query, userID)
+    return err
+}
+
+// RevokeOtherFamilies отзывает все семейства токенов пользователя, кроме текущего
+func (r *RefreshTokenRepository) RevokeOtherFamilies(ctx context.Context, userID int, keepFamilyID string) error {
+    query := `
+        UPDATE refresh_tokens
+        SET revoked_at = CURRENT_TIMESTAMP
+        WHERE user_id = $1 AND family_id <> $2 AND revoked_at IS NULL
+    `
+
+    _, err := r.db.Exec(ctx, query, userID, keepFamilyID)
+    return err
+}
@@ -0,0 +1,148 @@
+package repositories
+
+import (
+    "context"
+    "encoding/json"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RevisionRepository struct {
+    db DBTX
+}
+
+func NewRevisionRepository(db *pgxpool.Pool) *RevisionRepository {
+    return &RevisionRepository{db: db}
+}
+
+// WithTx возвращает репозиторий, работающий внутри транзакции tx
+func (r *RevisionRepository) WithTx(tx pgx.Tx) *RevisionRepository {
+    return &RevisionRepository{db: tx}
+}
+
+// CreateRevision сохраняет снимок материала под следующим номером ревизии.
+// Строка материала блокируется, чтобы параллельные правки не получили один номер.
+func (r *RevisionRepository) CreateRevision(ctx context.Context, revision *models.MaterialRevision) error {
+    blocks := revision.Blocks
+    if blocks == nil {
+        blocks = []models.Block{}
+    }
+    blocksJSON, err := json.Marshal(blocks)
+    if err != nil {
+        return err
+    }
+
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
+    if _, err := tx.Exec(ctx, "SELECT id FROM materials WHERE id = $1 FOR UPDATE", revision.MaterialID); err != nil {
+        return err
+    }
+
+    query := `
+        INSERT INTO material_revisions (material_id, revision, title, blocks, author_id, action, restored_from)
+        SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4, $5, $6
+        FROM material_revisions
+        WHERE material_id = $1
+        RETURNING revision, created_at
+    `
+
+    err = tx.QueryRow(ctx, query,
+        revision.MaterialID, revision.Title, blocksJSON, nullableID(revision.AuthorID),
+        revision.Action, revision.RestoredFrom,
+    ).Scan(&revision.Revision, &revision.CreatedAt)
+    if err != nil {
+        return err
+    }
+
+    revision.BlocksCount = len(blocks)
+    return tx.Commit(ctx)
+}
+
+// HasRevisions проверяет, есть ли у материала сохраненные ревизии
+func (r *RevisionRepository) HasRevisions(ctx context.Context, materialID int) (bool, error) {
+    var exists bool
+    err := r.db.QueryRow(ctx,
+        "SELECT EXISTS(SELECT 1 FROM material_revisions WHERE material_id = $1)", materialID,
+    ).Scan(&exists)
+    return exists, err
+}
+
+// GetRevisions возвращает ревизии материала без содержимого блоков, новые сверху
+func (r *RevisionRepository) GetRevisions(ctx context.Context, materialID, page, limit int) ([]models.MaterialRevision, int, error) {
+    var total int
+    err := r.db.QueryRow(ctx,
+        "SELECT COUNT(*) FROM material_revisions WHERE material_id = $1", materialID,
+    ).Scan(&total)
+    if err != nil {
+        return nil, 0, err
+    }
+
+    query := `
+        SELECT mr.revision, mr.material_id, mr.title, jsonb_array_length(mr.blocks),
+               COALESCE(mr.author_id, 0), COALESCE(u.full_name, ''), mr.action, mr.restored_from, mr.created_at
+        FROM material_revisions mr
+        LEFT JOIN users u ON u.id = mr.author_id
+        WHERE mr.material_id = $1
+        ORDER BY mr.revision DESC
+        LIMIT $2 OFFSET $3
+    `
+
+    rows, err := r.db.Query(ctx, query, materialID, limit, (page-1)*limit)
+    if err != nil {
+        return nil, 0, err
+    }
+    defer rows.Close()
+
+    revisions := []models.MaterialRevision{}
+    for rows.Next() {
+        var revision models.MaterialRevision
+        if err := rows.Scan(
+            &revision.Revision, &revision.MaterialID, &revision.Title, &revision.BlocksCount,
+            &revision.AuthorID, &revision.AuthorName, &revision.Action, &revision.RestoredFrom, &revision.CreatedAt,
+        ); err != nil {
+            return nil, 0, err
+        }
+        revisions = append(revisions, revision)
+    }
+
+    return revisions, total, rows.Err()
+}
+
+// GetRevision возвращает ревизию вместе с блоками (nil, если ее нет)
+func (r *RevisionRepository) GetRevision(ctx context.Context, materialID, number int) (*models.MaterialRevision, error) {
+    var revision models.MaterialRevision
+    var blocksJSON []byte
+
+    query := `
+        SELECT mr.revision, mr.material_id, mr.title, mr.blocks,
+               COALESCE(mr.author_id, 0), COALESCE(u.full_name, ''), mr.action, mr.restored_from, mr.created_at
+        FROM material_revisions mr
+        LEFT JOIN users u ON u.id = mr.author_id
+        WHERE mr.material_id = $1 AND mr.revision = $2
+    `
+
+    err := r.db.QueryRow(ctx, query, materialID, number).Scan(
+        &revision.Revision, &revision.MaterialID, &revision.Title, &blocksJSON,
+        &revision.AuthorID, &revision.AuthorName, &revision.Action, &revision.RestoredFrom, &revision.CreatedAt,
+    )
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    if err := json.Unmarshal(blocksJSON, &revision.Blocks); err != nil {
+        return nil, err
+    }
+    revision.BlocksCount = len(revision.Blocks)
+
+    return &revision, nil
+}
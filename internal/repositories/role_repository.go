@@ -0,0 +1,139 @@
+package repositories
+
+import (
+    "context"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RoleRepository struct {
+    db *pgxpool.Pool
+}
+
+func NewRoleRepository(db *pgxpool.Pool) *RoleRepository {
+    return &RoleRepository{db: db}
+}
+
+// ListRoles возвращает все роли с их правами
+func (r *RoleRepository) ListRoles(ctx context.Context) ([]models.Role, error) {
+    query := `
+        SELECT r.name, COALESCE(r.description, ''), COALESCE(array_agg(rp.permission ORDER BY rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '{}')
+        FROM roles r
+        LEFT JOIN role_permissions rp ON rp.role = r.name
+        GROUP BY r.name, r.description
+        ORDER BY r.name
+    `
+
+    rows, err := r.db.Query(ctx, query)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    roles := []models.Role{}
+    for rows.Next() {
+        var role models.Role
+        if err := rows.Scan(&role.Name, &role.Description, &role.Permissions); err != nil {
+            return nil, err
+        }
+        roles = append(roles, role)
+    }
+
+    return roles, rows.Err()
+}
+
+// ListPermissions возвращает названия всех существующих прав
+func (r *RoleRepository) ListPermissions(ctx context.Context) ([]string, error) {
+    rows, err := r.db.Query(ctx, `SELECT name FROM permissions ORDER BY name`)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var permissions []string
+    for rows.Next() {
+        var name string
+        if err := rows.Scan(&name); err != nil {
+            return nil, err
+        }
+        permissions = append(permissions, name)
+    }
+
+    return permissions, rows.Err()
+}
+
+// RoleExists проверяет, существует ли роль
+func (r *RoleRepository) RoleExists(ctx context.Context, name string) (bool, error) {
+    var exists bool
+    err := r.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM roles WHERE name = $1)`, name).Scan(&exists)
+    return exists, err
+}
+
+// SaveRole создает роль или заменяет ее описание и набор прав. Если роль
+// теряет role.manage и после этого управлять ролями не сможет ни один
+// действующий пользователь, изменение не сохраняется и возвращается false.
+func (r *RoleRepository) SaveRole(ctx context.Context, role *models.Role) (bool, error) {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback(ctx)
+
+    // Блокируем роли с role.manage, чтобы два параллельных изменения
+    // не отобрали это право у последних его обладателей
+    var hadRoleManage bool
+    err = tx.QueryRow(ctx, `
+        SELECT EXISTS(
+            SELECT 1 FROM (
+                SELECT role FROM role_permissions WHERE permission = $2 FOR UPDATE
+            ) managers
+            WHERE role = $1
+        )
+    `, role.Name, models.PermRoleManage).Scan(&hadRoleManage)
+    if err != nil {
+        return false, err
+    }
+
+    _, err = tx.Exec(ctx, `
+        INSERT INTO roles (name, description) VALUES ($1, $2)
+        ON CONFLICT (name) DO UPDATE SET description = EXCLUDED.description
+    `, role.Name, role.Description)
+    if err != nil {
+        return false, err
+    }
+
+    if _, err = tx.Exec(ctx, `DELETE FROM role_permissions WHERE role = $1`, role.Name); err != nil {
+        return false, err
+    }
+
+    for _, permission := range role.Permissions {
+        _, err = tx.Exec(ctx,
+            `INSERT INTO role_permissions (role, permission) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+            role.Name, permission,
+        )
+        if err != nil {
+            return false, err
+        }
+    }
+
+    if hadRoleManage {
+        var managed bool
+        err = tx.QueryRow(ctx, `
+            SELECT EXISTS(
+                SELECT 1 FROM users u
+                JOIN role_permissions rp ON rp.role = u.role
+                WHERE rp.permission = $1 AND u.is_blocked = FALSE AND u.deleted_at IS NULL
+            )
+        `, models.PermRoleManage).Scan(&managed)
+        if err != nil {
+            return false, err
+        }
+        if !managed {
+            return false, nil
+        }
+    }
+
+    return true, tx.Commit(ctx)
+}
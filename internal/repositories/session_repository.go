@@ -0,0 +1,89 @@
+package repositories
+
+import (
+    "context"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SessionRepository struct {
+    db *pgxpool.Pool
+}
+
+func NewSessionRepository(db *pgxpool.Pool) *SessionRepository {
+    return &SessionRepository{db: db}
+}
+
+// activeSessionCondition отбирает сессии, в семействе которых есть действующий refresh токен
+const activeSessionCondition = `
+    EXISTS (
+        SELECT 1 FROM refresh_tokens rt
+        WHERE rt.family_id = s.id AND rt.revoked_at IS NULL AND rt.rotated_at IS NULL
+          AND rt.expires_at > CURRENT_TIMESTAMP
+    )
+`
+
+// TouchSession создает сессию или обновляет устройство и время последней активности
+func (r *SessionRepository) TouchSession(ctx context.Context, session *models.Session) error {
+    query := `
+        INSERT INTO auth_sessions (id, user_id, user_agent, ip)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (id) DO UPDATE
+        SET user_agent = EXCLUDED.user_agent, ip = EXCLUDED.ip, last_seen_at = CURRENT_TIMESTAMP
+    `
+
+    _, err := r.db.Exec(ctx, query, session.ID, session.UserID, session.UserAgent, session.IP)
+    return err
+}
+
+// GetActiveSession возвращает активную сессию по ID или nil
+func (r *SessionRepository) GetActiveSession(ctx context.Context, id string) (*models.Session, error) {
+    var session models.Session
+
+    query := `
+        SELECT s.id, s.user_id, COALESCE(s.user_agent, ''), COALESCE(s.ip, ''), s.created_at, s.last_seen_at
+        FROM auth_sessions s
+        WHERE s.id = $1 AND ` + activeSessionCondition
+
+    err := r.db.QueryRow(ctx, query, id).Scan(
+        &session.ID, &session.UserID, &session.UserAgent, &session.IP, &session.CreatedAt, &session.LastSeenAt,
+    )
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+
+    return &session, err
+}
+
+// GetUserSessions возвращает активные сессии пользователя, последние активные первыми
+func (r *SessionRepository) GetUserSessions(ctx context.Context, userID int) ([]models.Session, error) {
+    query := `
+        SELECT s.id, s.user_id, COALESCE(s.user_agent, ''), COALESCE(s.ip, ''), s.created_at, s.last_seen_at
+        FROM auth_sessions s
+        WHERE s.user_id = $1 AND ` + activeSessionCondition + `
+        ORDER BY s.last_seen_at DESC
+    `
+
+    rows, err := r.db.Query(ctx, query, userID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    sessions := []models.Session{}
+    for rows.Next() {
+        var session models.Session
+        err := rows.Scan(
+            &session.ID, &session.UserID, &session.UserAgent, &session.IP, &session.CreatedAt, &session.LastSeenAt,
+        )
+        if err != nil {
+            return nil, err
+        }
+        sessions = append(sessions, session)
+    }
+
+    return sessions, rows.Err()
+}
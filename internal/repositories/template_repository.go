@@ -0,0 +1,83 @@
+package repositories
+
+import (
+    "context"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type TemplateRepository struct {
+    db *pgxpool.Pool
+}
+
+func NewTemplateRepository(db *pgxpool.Pool) *TemplateRepository {
+    return &TemplateRepository{db: db}
+}
+
+// GetGallery возвращает шаблоны галереи. Материалы, которые перестали быть
+// шаблонами или сняты с публикации, не показываются, но остаются в галерее
+func (r *TemplateRepository) GetGallery(ctx context.Context) ([]models.GalleryTemplate, error) {
+    query := `
+        SELECT m.id, COALESCE(pr.title, m.title), m.subject, m.author_id, u.full_name,
+               COALESCE(g.description, ''), g.position, COALESCE(jsonb_array_length(pr.blocks), 0), g.added_at
+        FROM template_gallery g
+        JOIN materials m ON m.id = g.material_id
+        JOIN users u ON u.id = m.author_id
+        LEFT JOIN material_revisions pr ON pr.material_id = m.id AND pr.revision = m.published_revision
+        WHERE m.is_template AND m.status = 'published' AND m.published_revision IS NOT NULL
+        ORDER BY g.position, g.added_at
+    `
+
+    rows, err := r.db.Query(ctx, query)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    templates := []models.GalleryTemplate{}
+    for rows.Next() {
+        var template models.GalleryTemplate
+        if err := rows.Scan(
+            &template.MaterialID, &template.Title, &template.Subject, &template.AuthorID, &template.AuthorName,
+            &template.Description, &template.Position, &template.BlockCount, &template.AddedAt,
+        ); err != nil {
+            return nil, err
+        }
+        templates = append(templates, template)
+    }
+
+    return templates, rows.Err()
+}
+
+// InGallery проверяет, добавлен ли материал в галерею
+func (r *TemplateRepository) InGallery(ctx context.Context, materialID int) (bool, error) {
+    var exists bool
+    err := r.db.QueryRow(ctx,
+        "SELECT EXISTS(SELECT 1 FROM template_gallery WHERE material_id = $1)", materialID,
+    ).Scan(&exists)
+    return exists, err
+}
+
+// SaveToGallery добавляет шаблон в галерею или обновляет его описание и место
+func (r *TemplateRepository) SaveToGallery(ctx context.Context, req *models.GalleryTemplateRequest, adminID int) error {
+    query := `
+        INSERT INTO template_gallery (material_id, position, description, added_by)
+        VALUES ($1, $2, NULLIF($3, ''), $4)
+        ON CONFLICT (material_id) DO UPDATE
+        SET position = EXCLUDED.position, description = EXCLUDED.description
+    `
+
+    _, err := r.db.Exec(ctx, query, req.MaterialID, req.Position, req.Description, nullableID(adminID))
+    return err
+}
+
+// RemoveFromGallery убирает шаблон из галереи. Возвращает false, если его там не было
+func (r *TemplateRepository) RemoveFromGallery(ctx context.Context, materialID int) (bool, error) {
+    tag, err := r.db.Exec(ctx, "DELETE FROM template_gallery WHERE material_id = $1", materialID)
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,252 @@
+package repositories
+
+import (
+    "context"
+    "time"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type TwoFactorRepository struct {
+    db *pgxpool.Pool
+}
+
+func NewTwoFactorRepository(db *pgxpool.Pool) *TwoFactorRepository {
+    return &TwoFactorRepository{db: db}
+}
+
+// GetTOTP возвращает настройки TOTP пользователя или nil, если их нет
+func (r *TwoFactorRepository) GetTOTP(ctx context.Context, userID int) (*models.UserTOTP, error) {
+    var totp models.UserTOTP
+
+    query := `
+        SELECT user_id, secret, enabled, last_used_step, failed_attempts, locked_until, confirmed_at, created_at
+        FROM user_totp
+        WHERE user_id = $1
+    `
+
+    err := r.db.QueryRow(ctx, query, userID).Scan(
+        &totp.UserID, &totp.Secret, &totp.Enabled, &totp.LastUsedStep,
+        &totp.FailedAttempts, &totp.LockedUntil, &totp.ConfirmedAt, &totp.CreatedAt,
+    )
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+
+    return &totp, err
+}
+
+// SaveSecret сохраняет новый неподтвержденный секрет. Включенную 2FA не
+// перезаписывает и возвращает false.
+func (r *TwoFactorRepository) SaveSecret(ctx context.Context, userID int, secret string) (bool, error) {
+    query := `
+        INSERT INTO user_totp (user_id, secret)
+        VALUES ($1, $2)
+        ON CONFLICT (user_id) DO UPDATE
+        SET secret = EXCLUDED.secret, last_used_step = 0, failed_attempts = 0,
+            locked_until = NULL, created_at = CURRENT_TIMESTAMP
+        WHERE user_totp.enabled = FALSE
+    `
+
+    tag, err := r.db.Exec(ctx, query, userID, secret)
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() > 0, nil
+}
+
+// Enable включает 2FA и заменяет коды восстановления
+func (r *TwoFactorRepository) Enable(ctx context.Context, userID int, step int64, codeHashes []string) (bool, error) {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback(ctx)
+
+    tag, err := tx.Exec(ctx, `
+        UPDATE user_totp
+        SET enabled = TRUE, confirmed_at = CURRENT_TIMESTAMP, last_used_step = $2, failed_attempts = 0
+        WHERE user_id = $1 AND enabled = FALSE
+    `, userID, step)
+    if err != nil {
+        return false, err
+    }
+    if tag.RowsAffected() == 0 {
+        return false, nil
+    }
+
+    if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+        return false, err
+    }
+
+    return true, tx.Commit(ctx)
+}
+
+// Disable удаляет настройки 2FA и коды восстановления
+func (r *TwoFactorRepository) Disable(ctx context.Context, userID int) error {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
+    if _, err := tx.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+        return err
+    }
+    if _, err := tx.Exec(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID); err != nil {
+        return err
+    }
+
+    return tx.Commit(ctx)
+}
+
+// UseStep принимает код интервала step. Возвращает false, если код этого
+// или более позднего интервала уже использовался (защита от повтора).
+func (r *TwoFactorRepository) UseStep(ctx context.Context, userID int, step int64) (bool, error) {
+    query := `
+        UPDATE user_totp
+        SET last_used_step = $2, failed_attempts = 0, locked_until = NULL
+        WHERE user_id = $1 AND last_used_step < $2
+    `
+
+    tag, err := r.db.Exec(ctx, query, userID, step)
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() > 0, nil
+}
+
+// UseRecoveryCode погашает код восстановления
+func (r *TwoFactorRepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback(ctx)
+
+    tag, err := tx.Exec(ctx, `
+        UPDATE user_recovery_codes
+        SET used_at = CURRENT_TIMESTAMP
+        WHERE id = (
+            SELECT id FROM user_recovery_codes
+            WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+            LIMIT 1
+        )
+    `, userID, codeHash)
+    if err != nil {
+        return false, err
+    }
+    if tag.RowsAffected() == 0 {
+        return false, nil
+    }
+
+    _, err = tx.Exec(ctx,
+        `UPDATE user_totp SET failed_attempts = 0, locked_until = NULL WHERE user_id = $1`,
+        userID,
+    )
+    if err != nil {
+        return false, err
+    }
+
+    return true, tx.Commit(ctx)
+}
+
+// RegisterFailure учитывает неверный код. После maxAttempts ошибок подряд
+// ввод кода блокируется на lockFor.
+func (r *TwoFactorRepository) RegisterFailure(ctx context.Context, userID, maxAttempts int, lockFor time.Duration) error {
+    query := `
+        UPDATE user_totp
+        SET failed_attempts = CASE WHEN failed_attempts + 1 >= $2 THEN 0 ELSE failed_attempts + 1 END,
+            locked_until = CASE WHEN failed_attempts + 1 >= $2 THEN $3 ELSE locked_until END
+        WHERE user_id = $1
+    `
+
+    _, err := r.db.Exec(ctx, query, userID, maxAttempts, time.Now().Add(lockFor))
+    return err
+}
+
+// ReplaceRecoveryCodes заменяет все коды восстановления пользователя
+func (r *TwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
+    if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+        return err
+    }
+
+    return tx.Commit(ctx)
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, userID int, codeHashes []string) error {
+    if _, err := tx.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+        return err
+    }
+
+    for _, hash := range codeHashes {
+        _, err := tx.Exec(ctx,
+            `INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)`,
+            userID, hash,
+        )
+        if err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// CountRecoveryCodes возвращает число неиспользованных кодов восстановления
+func (r *TwoFactorRepository) CountRecoveryCodes(ctx context.Context, userID int) (int, error) {
+    var count int
+    query := `SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = $1 AND used_at IS NULL`
+    err := r.db.QueryRow(ctx, query, userID).Scan(&count)
+    return count, err
+}
+
+// CreateChallenge сохраняет выданный challenge токен и заодно удаляет просроченные
+func (r *TwoFactorRepository) CreateChallenge(ctx context.Context, tokenID string, userID int, expiresAt time.Time) error {
+    if _, err := r.db.Exec(ctx, `DELETE FROM two_factor_challenges WHERE expires_at < CURRENT_TIMESTAMP`); err != nil {
+        return err
+    }
+
+    query := `
+        INSERT INTO two_factor_challenges (token_id, user_id, expires_at)
+        VALUES ($1, $2, $3)
+    `
+
+    _, err := r.db.Exec(ctx, query, tokenID, userID, expiresAt)
+    return err
+}
+
+// ChallengeActive проверяет, что challenge токен выдан пользователю, не истек и еще не использован
+func (r *TwoFactorRepository) ChallengeActive(ctx context.Context, tokenID string, userID int) (bool, error) {
+    var active bool
+    query := `
+        SELECT EXISTS(
+            SELECT 1 FROM two_factor_challenges
+            WHERE token_id = $1 AND user_id = $2 AND expires_at > CURRENT_TIMESTAMP
+        )
+    `
+    err := r.db.QueryRow(ctx, query, tokenID, userID).Scan(&active)
+    return active, err
+}
+
+// ConsumeChallenge гасит challenge токен. Возвращает false, если токен не
+// найден, истек или уже использован (например, параллельным запросом).
+func (r *TwoFactorRepository) ConsumeChallenge(ctx context.Context, tokenID string, userID int) (bool, error) {
+    query := `
+        DELETE FROM two_factor_challenges
+        WHERE token_id = $1 AND user_id = $2 AND expires_at > CURRENT_TIMESTAMP
+    `
+
+    tag, err := r.db.Exec(ctx, query, tokenID, userID)
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() > 0, nil
+}
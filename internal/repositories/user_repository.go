@@ -22,13 +22,13 @@ func NewUserRepository(db *pgxpool.Pool) *UserRepository {
 // CreateUser создает нового пользователя
 func (r *UserRepository) CreateUser(ctx context.Context, user *models.User) error {
     query := `
-        INSERT INTO users (email, password_hash, full_name, role, avatar_url, is_verified)
-        VALUES ($1, $2, $3, $4, $5, $6)
+        INSERT INTO users (email, password_hash, full_name, role, avatar_url, is_verified, email_verified)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
         RETURNING id, created_at, updated_at
     `
 
     err := r.db.QueryRow(ctx, query,
-        user.Email, user.PasswordHash, user.FullName, user.Role, user.AvatarURL, user.IsVerified,
+        user.Email, user.PasswordHash, user.FullName, user.Role, user.AvatarURL, user.IsVerified, user.EmailVerified,
     ).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
 
     return err
@@ -40,14 +40,14 @@ func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*mod
     var blockReason *string
 
     query := `
-        SELECT id, email, password_hash, full_name, role, avatar_url, is_verified, is_blocked, block_reason, created_at, updated_at
+        SELECT id, email, password_hash, full_name, role, avatar_url, is_verified, email_verified, is_blocked, block_reason, created_at, updated_at
         FROM users
         WHERE email = $1
     `
 
     err := r.db.QueryRow(ctx, query, email).Scan(
            &user.ID, &user.Email, &user.PasswordHash, &user.FullName, &user.Role,
-           &user.AvatarURL, &user.IsVerified, &user.IsBlocked, &blockReason,
+           &user.AvatarURL, &user.IsVerified, &user.EmailVerified, &user.IsBlocked, &blockReason,
            &user.CreatedAt, &user.UpdatedAt,
     )
 
@@ -64,14 +64,15 @@ func (r *UserRepository) GetUserByID(ctx context.Context, id int) (*models.User,
     var user models.User
 
     query := `
-        SELECT id, email, password_hash, full_name, role, avatar_url, is_verified, created_at, updated_at
+        SELECT id, email, password_hash, full_name, role, avatar_url, is_verified, email_verified, is_blocked, block_reason, created_at, updated_at
         FROM users
         WHERE id = $1
     `
 
     err := r.db.QueryRow(ctx, query, id).Scan(
         &user.ID, &user.Email, &user.PasswordHash, &user.FullName, &user.Role,
-        &user.AvatarURL, &user.IsVerified, &user.CreatedAt, &user.UpdatedAt,
+        &user.AvatarURL, &user.IsVerified, &user.EmailVerified, &user.IsBlocked, &user.BlockReason,
+        &user.CreatedAt, &user.UpdatedAt,
     )
 
     if err == pgx.ErrNoRows {
@@ -93,14 +94,14 @@ func (r *UserRepository) GetUserProfile(ctx context.Context, userID int) (*model
     var user models.User
 
     query := `
-        SELECT id, email, full_name, role, avatar_url, is_verified, created_at, updated_at
+        SELECT id, email, full_name, role, avatar_url, is_verified, email_verified, created_at, updated_at
         FROM users
         WHERE id = $1
     `
 
     err := r.db.QueryRow(ctx, query, userID).Scan(
         &user.ID, &user.Email, &user.FullName, &user.Role,
-        &user.AvatarURL, &user.IsVerified, &user.CreatedAt, &user.UpdatedAt,
+        &user.AvatarURL, &user.IsVerified, &user.EmailVerified, &user.CreatedAt, &user.UpdatedAt,
     )
 
     if err == pgx.ErrNoRows {
@@ -222,4 +223,51 @@ func (r *UserRepository) UpdateUserSpecializations(ctx context.Context, userID i
     }
 
     return tx.Commit(ctx)
-}
\ No newline at end of file
+}
+
+// UpdatePassword обновляет хеш пароля пользователя
+func (r *UserRepository) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+    query := `
+        UPDATE users
+        SET password_hash = $1, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $2
+    `
+
+    _, err := r.db.Exec(ctx, query, passwordHash, userID)
+    return err
+}
+
+// UpdateEmail меняет email пользователя. Новый адрес подтвержден ссылкой из письма.
+func (r *UserRepository) UpdateEmail(ctx context.Context, userID int, email string) error {
+    query := `
+        UPDATE users
+        SET email = $1, email_verified = TRUE, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $2
+    `
+
+    _, err := r.db.Exec(ctx, query, email, userID)
+    return err
+}
+
+// MarkEmailVerified отмечает email пользователя как подтвержденный
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, userID int) error {
+    query := `
+        UPDATE users
+        SET email_verified = TRUE, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1
+    `
+
+    _, err := r.db.Exec(ctx, query, userID)
+    return err
+}
+
+// IsEmailVerified проверяет, подтвержден ли email пользователя
+func (r *UserRepository) IsEmailVerified(ctx context.Context, userID int) (bool, error) {
+    var verified bool
+    query := `SELECT email_verified FROM users WHERE id = $1`
+    err := r.db.QueryRow(ctx, query, userID).Scan(&verified)
+    if err == pgx.ErrNoRows {
+        return false, nil
+    }
+    return verified, err
+}
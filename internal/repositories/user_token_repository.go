@@ -0,0 +1,133 @@
+package repositories
+
+import (
+    "context"
+    "time"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type UserTokenRepository struct {
+    db *pgxpool.Pool
+}
+
+func NewUserTokenRepository(db *pgxpool.Pool) *UserTokenRepository {
+    return &UserTokenRepository{db: db}
+}
+
+// CreateToken сохраняет одноразовый токен
+func (r *UserTokenRepository) CreateToken(ctx context.Context, token *models.UserToken) error {
+    query := `
+        INSERT INTO user_tokens (user_id, purpose, token_hash, new_email, expires_at)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at
+    `
+
+    return r.db.QueryRow(ctx, query,
+        token.UserID, token.Purpose, token.TokenHash, token.NewEmail, token.ExpiresAt,
+    ).Scan(&token.ID, &token.CreatedAt)
+}
+
+// ConsumeToken помечает действующий токен использованным и возвращает его.
+// Если токен не найден, истек или уже использован - возвращает nil.
+func (r *UserTokenRepository) ConsumeToken(ctx context.Context, tokenHash, purpose string) (*models.UserToken, error) {
+    var token models.UserToken
+
+    query := `
+        UPDATE user_tokens
+        SET used_at = CURRENT_TIMESTAMP
+        WHERE token_hash = $1 AND purpose = $2
+          AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+        RETURNING id, user_id, purpose, token_hash, new_email, expires_at, used_at, created_at
+    `
+
+    err := r.db.QueryRow(ctx, query, tokenHash, purpose).Scan(
+        &token.ID, &token.UserID, &token.Purpose, &token.TokenHash, &token.NewEmail,
+        &token.ExpiresAt, &token.UsedAt, &token.CreatedAt,
+    )
+
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+
+    return &token, err
+}
+
+// ResetPassword по токену сброса меняет хеш пароля и отзывает сессии пользователя.
+// Токен гасится в той же транзакции, поэтому при ошибке обновления ссылка остается рабочей.
+// Если токен не найден, истек или уже использован - возвращает nil.
+func (r *UserTokenRepository) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (*models.UserToken, error) {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return nil, err
+    }
+    defer tx.Rollback(ctx)
+
+    var token models.UserToken
+
+    query := `
+        UPDATE user_tokens
+        SET used_at = CURRENT_TIMESTAMP
+        WHERE token_hash = $1 AND purpose = $2
+          AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+        RETURNING id, user_id, purpose, token_hash, new_email, expires_at, used_at, created_at
+    `
+
+    err = tx.QueryRow(ctx, query, tokenHash, models.TokenPurposePasswordReset).Scan(
+        &token.ID, &token.UserID, &token.Purpose, &token.TokenHash, &token.NewEmail,
+        &token.ExpiresAt, &token.UsedAt, &token.CreatedAt,
+    )
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    _, err = tx.Exec(ctx, `
+        UPDATE users
+        SET password_hash = $1, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $2
+    `, passwordHash, token.UserID)
+    if err != nil {
+        return nil, err
+    }
+
+    _, err = tx.Exec(ctx, `
+        UPDATE refresh_tokens
+        SET revoked_at = CURRENT_TIMESTAMP
+        WHERE user_id = $1 AND revoked_at IS NULL
+    `, token.UserID)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := tx.Commit(ctx); err != nil {
+        return nil, err
+    }
+
+    return &token, nil
+}
+
+// InvalidateUserTokens гасит все неиспользованные токены пользователя с указанным назначением
+func (r *UserTokenRepository) InvalidateUserTokens(ctx context.Context, userID int, purpose string) error {
+    query := `
+        UPDATE user_tokens
+        SET used_at = CURRENT_TIMESTAMP
+        WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL
+    `
+
+    _, err := r.db.Exec(ctx, query, userID, purpose)
+    return err
+}
+
+// CountTokensSince возвращает количество токенов, выданных пользователю с указанного момента
+func (r *UserTokenRepository) CountTokensSince(ctx context.Context, userID int, purpose string, since time.Time) (int, error) {
+    var count int
+    query := `SELECT COUNT(*) FROM user_tokens WHERE user_id = $1 AND purpose = $2 AND created_at >= $3`
+    err := r.db.QueryRow(ctx, query, userID, purpose, since).Scan(&count)
+    return count, err
+}
@@ -0,0 +1,192 @@
+package repositories
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type VerificationRepository struct {
+    db *pgxpool.Pool
+}
+
+func NewVerificationRepository(db *pgxpool.Pool) *VerificationRepository {
+    return &VerificationRepository{db: db}
+}
+
+const applicationColumns = `
+    a.id, a.user_id, u.email, u.full_name, a.status, COALESCE(a.message, ''), a.documents,
+    COALESCE(a.review_comment, ''), a.reviewed_by, a.reviewed_at, a.created_at
+`
+
+func scanApplication(row pgx.Row) (*models.TeacherApplication, error) {
+    var application models.TeacherApplication
+    var documentsJSON []byte
+
+    err := row.Scan(
+        &application.ID, &application.UserID, &application.UserEmail, &application.UserName,
+        &application.Status, &application.Message, &documentsJSON,
+        &application.ReviewComment, &application.ReviewedBy, &application.ReviewedAt, &application.CreatedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+
+    if err := json.Unmarshal(documentsJSON, &application.Documents); err != nil {
+        return nil, err
+    }
+
+    return &application, nil
+}
+
+// CreateApplication создает заявку на верификацию
+func (r *VerificationRepository) CreateApplication(ctx context.Context, application *models.TeacherApplication) error {
+    documentsJSON, err := json.Marshal(application.Documents)
+    if err != nil {
+        return err
+    }
+
+    query := `
+        INSERT INTO teacher_verification_applications (user_id, message, documents)
+        VALUES ($1, $2, $3)
+        RETURNING id, status, created_at
+    `
+
+    return r.db.QueryRow(ctx, query,
+        application.UserID, application.Message, documentsJSON,
+    ).Scan(&application.ID, &application.Status, &application.CreatedAt)
+}
+
+// HasPendingApplication проверяет, есть ли у пользователя заявка на рассмотрении
+func (r *VerificationRepository) HasPendingApplication(ctx context.Context, userID int) (bool, error) {
+    var exists bool
+    query := `SELECT EXISTS(SELECT 1 FROM teacher_verification_applications WHERE user_id = $1 AND status = 'pending')`
+    err := r.db.QueryRow(ctx, query, userID).Scan(&exists)
+    return exists, err
+}
+
+// GetApplication возвращает заявку по ID
+func (r *VerificationRepository) GetApplication(ctx context.Context, id int) (*models.TeacherApplication, error) {
+    query := `SELECT ` + applicationColumns + `
+        FROM teacher_verification_applications a
+        JOIN users u ON u.id = a.user_id
+        WHERE a.id = $1
+    `
+
+    application, err := scanApplication(r.db.QueryRow(ctx, query, id))
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+
+    return application, err
+}
+
+// GetUserApplications возвращает заявки пользователя, новые первыми
+func (r *VerificationRepository) GetUserApplications(ctx context.Context, userID int) ([]models.TeacherApplication, error) {
+    query := `SELECT ` + applicationColumns + `
+        FROM teacher_verification_applications a
+        JOIN users u ON u.id = a.user_id
+        WHERE a.user_id = $1
+        ORDER BY a.created_at DESC
+    `
+
+    return r.queryApplications(ctx, query, userID)
+}
+
+// ListApplications возвращает заявки с фильтрацией по статусу и пагинацией
+func (r *VerificationRepository) ListApplications(ctx context.Context, status string, page, limit int) ([]models.TeacherApplication, int, error) {
+    baseQuery := `
+        FROM teacher_verification_applications a
+        JOIN users u ON u.id = a.user_id
+    `
+
+    var args []interface{}
+    argIndex := 1
+
+    if status != "" {
+        baseQuery += fmt.Sprintf(" WHERE a.status = $%d", argIndex)
+        args = append(args, status)
+        argIndex++
+    }
+
+    var total int
+    if err := r.db.QueryRow(ctx, "SELECT COUNT(*) "+baseQuery, args...).Scan(&total); err != nil {
+        return nil, 0, err
+    }
+
+    query := "SELECT " + applicationColumns + baseQuery + " ORDER BY a.created_at"
+
+    if limit > 0 {
+        query += fmt.Sprintf(" LIMIT $%d", argIndex)
+        args = append(args, limit)
+        argIndex++
+
+        if page > 0 {
+            query += fmt.Sprintf(" OFFSET $%d", argIndex)
+            args = append(args, (page-1)*limit)
+        }
+    }
+
+    applications, err := r.queryApplications(ctx, query, args...)
+    return applications, total, err
+}
+
+func (r *VerificationRepository) queryApplications(ctx context.Context, query string, args ...interface{}) ([]models.TeacherApplication, error) {
+    rows, err := r.db.Query(ctx, query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    applications := []models.TeacherApplication{}
+    for rows.Next() {
+        application, err := scanApplication(rows)
+        if err != nil {
+            return nil, err
+        }
+        applications = append(applications, *application)
+    }
+
+    return applications, rows.Err()
+}
+
+// ReviewApplication фиксирует решение по заявке. При одобрении преподаватель
+// становится верифицированным. Возвращает false, если заявка уже рассмотрена.
+func (r *VerificationRepository) ReviewApplication(ctx context.Context, id int, status, comment string, reviewerID int) (bool, error) {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback(ctx)
+
+    var userID int
+    err = tx.QueryRow(ctx, `
+        UPDATE teacher_verification_applications
+        SET status = $1, review_comment = $2, reviewed_by = $3, reviewed_at = CURRENT_TIMESTAMP
+        WHERE id = $4 AND status = 'pending'
+        RETURNING user_id
+    `, status, comment, reviewerID, id).Scan(&userID)
+    if err == pgx.ErrNoRows {
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+
+    if status == "approved" {
+        _, err = tx.Exec(ctx,
+            "UPDATE users SET is_verified = TRUE, updated_at = CURRENT_TIMESTAMP WHERE id = $1",
+            userID,
+        )
+        if err != nil {
+            return false, err
+        }
+    }
+
+    return true, tx.Commit(ctx)
+}
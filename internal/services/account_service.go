@@ -0,0 +1,635 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "strings"
+    "time"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/repositories"
+    "paydeya-backend/internal/utils"
+
+    "golang.org/x/crypto/bcrypt"
+)
+
+var (
+    ErrEmailTaken              = errors.New("user with this email already exists")
+    ErrSameEmail               = errors.New("new email matches current email")
+    ErrInvalidEmailChangeToken = errors.New("invalid or expired email change token")
+    ErrDeletionNotScheduled    = errors.New("account deletion is not scheduled")
+    ErrInvalidTransferTarget   = errors.New("materials can be transferred only to another teacher")
+)
+
+const (
+    // emailChangeTTL время жизни ссылки подтверждения нового email
+    emailChangeTTL = 24 * time.Hour
+    // accountDeletionGracePeriod время, в течение которого удаление можно отменить
+    accountDeletionGracePeriod = 14 * 24 * time.Hour
+)
+
+// AccountService отвечает за учетные данные пользователя, выгрузку
+// его данных и удаление аккаунта
+type AccountService struct {
+    userRepo         *repositories.UserRepository
+    refreshRepo      *repositories.RefreshTokenRepository
+    tokenRepo        *repositories.UserTokenRepository
+    auditRepo        *repositories.AuditRepository
+    accountRepo      *repositories.AccountRepository
+    materialRepo     *repositories.MaterialRepository
+    blockRepo        *repositories.BlockRepository
+    verificationRepo *repositories.VerificationRepository
+    oauthRepo        *repositories.OAuthRepository
+    authorizer       *Authorizer
+    quizService      *QuizService
+    fileService      *FileService
+    privateFiles     *PrivateFileService
+    mailer           Mailer
+    appURL           string
+}
+
+func NewAccountService(
+    userRepo *repositories.UserRepository,
+    refreshRepo *repositories.RefreshTokenRepository,
+    tokenRepo *repositories.UserTokenRepository,
+    auditRepo *repositories.AuditRepository,
+    accountRepo *repositories.AccountRepository,
+    materialRepo *repositories.MaterialRepository,
+    blockRepo *repositories.BlockRepository,
+    verificationRepo *repositories.VerificationRepository,
+    oauthRepo *repositories.OAuthRepository,
+    authorizer *Authorizer,
+    quizService *QuizService,
+    fileService *FileService,
+    privateFiles *PrivateFileService,
+    mailer Mailer,
+    appURL string,
+) *AccountService {
+    return &AccountService{
+        userRepo:         userRepo,
+        refreshRepo:      refreshRepo,
+        tokenRepo:        tokenRepo,
+        auditRepo:        auditRepo,
+        accountRepo:      accountRepo,
+        materialRepo:     materialRepo,
+        blockRepo:        blockRepo,
+        verificationRepo: verificationRepo,
+        oauthRepo:        oauthRepo,
+        authorizer:       authorizer,
+        quizService:      quizService,
+        fileService:      fileService,
+        privateFiles:     privateFiles,
+        mailer:           mailer,
+        appURL:           strings.TrimRight(appURL, "/"),
+    }
+}
+
+// ChangePassword меняет пароль после проверки текущего и завершает
+// все сессии пользователя, кроме текущей
+func (s *AccountService) ChangePassword(ctx context.Context, userID int, sessionID string, req *models.ChangePasswordRequest, client models.ClientInfo) error {
+    user, err := s.checkPassword(ctx, userID, req.CurrentPassword)
+    if err != nil {
+        return err
+    }
+
+    hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+    if err != nil {
+        return fmt.Errorf("error hashing password: %w", err)
+    }
+
+    if err := s.userRepo.UpdatePassword(ctx, userID, string(hashedPassword)); err != nil {
+        return fmt.Errorf("error updating password: %w", err)
+    }
+
+    // Ссылки на сброс, отправленные до смены, больше не нужны
+    if err := s.tokenRepo.InvalidateUserTokens(ctx, userID, models.TokenPurposePasswordReset); err != nil {
+        return fmt.Errorf("error invalidating reset tokens: %w", err)
+    }
+
+    if err := s.refreshRepo.RevokeOtherFamilies(ctx, userID, sessionID); err != nil {
+        return fmt.Errorf("error revoking sessions: %w", err)
+    }
+
+    s.audit(ctx, userID, models.AuditPasswordChanged, nil, client)
+
+    err = s.mailer.Send(ctx, &Mail{
+        To:      user.Email,
+        Subject: "Пароль на Пайдее изменен",
+        Body: fmt.Sprintf(
+            "Здравствуйте, %s!\n\nПароль от вашего аккаунта был изменен, на других устройствах выполнен выход.\n\n"+
+                "Если это были не вы, восстановите доступ по ссылке:\n%s/forgot-password",
+            user.FullName, s.appURL,
+        ),
+    })
+    if err != nil {
+        log.Printf("⚠️ Failed to send password change notice to user %d: %v", userID, err)
+    }
+
+    return nil
+}
+
+// RequestEmailChange отправляет ссылку подтверждения на новый адрес.
+// Email меняется только после перехода по ссылке.
+func (s *AccountService) RequestEmailChange(ctx context.Context, userID int, req *models.ChangeEmailRequest, client models.ClientInfo) error {
+    user, err := s.checkPassword(ctx, userID, req.CurrentPassword)
+    if err != nil {
+        return err
+    }
+
+    if strings.EqualFold(user.Email, req.NewEmail) {
+        return ErrSameEmail
+    }
+
+    exists, err := s.userRepo.EmailExists(ctx, req.NewEmail)
+    if err != nil {
+        return fmt.Errorf("error checking email: %w", err)
+    }
+    if exists {
+        return ErrEmailTaken
+    }
+
+    // Действует только ссылка из последнего письма
+    if err := s.tokenRepo.InvalidateUserTokens(ctx, userID, models.TokenPurposeEmailChange); err != nil {
+        return fmt.Errorf("error invalidating email change tokens: %w", err)
+    }
+
+    token, tokenHash, err := utils.GenerateOpaqueToken()
+    if err != nil {
+        return fmt.Errorf("error generating email change token: %w", err)
+    }
+
+    err = s.tokenRepo.CreateToken(ctx, &models.UserToken{
+        UserID:    userID,
+        Purpose:   models.TokenPurposeEmailChange,
+        TokenHash: tokenHash,
+        NewEmail:  &req.NewEmail,
+        ExpiresAt: time.Now().Add(emailChangeTTL),
+    })
+    if err != nil {
+        return fmt.Errorf("error saving email change token: %w", err)
+    }
+
+    s.audit(ctx, userID, models.AuditEmailChangeRequested, map[string]interface{}{"newEmail": req.NewEmail}, client)
+
+    return s.mailer.Send(ctx, &Mail{
+        To:      req.NewEmail,
+        Subject: "Подтверждение нового email на Пайдее",
+        Body: fmt.Sprintf(
+            "Здравствуйте, %s!\n\nЧтобы использовать этот адрес для входа на Пайдею, перейдите по ссылке:\n%s/confirm-email-change?token=%s\n\n"+
+                "Ссылка действует 24 часа. Если вы не меняли email, просто проигнорируйте это письмо.",
+            user.FullName, s.appURL, token,
+        ),
+    })
+}
+
+// ConfirmEmailChange меняет email по токену из письма и завершает
+// все сессии пользователя, кроме текущей
+func (s *AccountService) ConfirmEmailChange(ctx context.Context, userID int, sessionID, token string, client models.ClientInfo) (string, error) {
+    stored, err := s.tokenRepo.ConsumeToken(ctx, utils.HashToken(token), models.TokenPurposeEmailChange)
+    if err != nil {
+        return "", fmt.Errorf("error checking email change token: %w", err)
+    }
+    // Ссылку подтверждает тот же пользователь, который запросил смену
+    if stored == nil || stored.UserID != userID || stored.NewEmail == nil {
+        return "", ErrInvalidEmailChangeToken
+    }
+    newEmail := *stored.NewEmail
+
+    user, err := s.userRepo.GetUserByID(ctx, userID)
+    if err != nil {
+        return "", fmt.Errorf("error finding user: %w", err)
+    }
+    if user == nil {
+        return "", ErrUserNotFound
+    }
+
+    // Адрес мог занять другой пользователь, пока письмо шло
+    exists, err := s.userRepo.EmailExists(ctx, newEmail)
+    if err != nil {
+        return "", fmt.Errorf("error checking email: %w", err)
+    }
+    if exists {
+        return "", ErrEmailTaken
+    }
+
+    if err := s.userRepo.UpdateEmail(ctx, userID, newEmail); err != nil {
+        return "", fmt.Errorf("error updating email: %w", err)
+    }
+
+    if err := s.refreshRepo.RevokeOtherFamilies(ctx, userID, sessionID); err != nil {
+        return "", fmt.Errorf("error revoking sessions: %w", err)
+    }
+
+    s.audit(ctx, userID, models.AuditEmailChanged, map[string]interface{}{"oldEmail": user.Email, "newEmail": newEmail}, client)
+
+    // Предупреждаем старый адрес: если аккаунт угнали, владелец узнает об этом
+    err = s.mailer.Send(ctx, &Mail{
+        To:      user.Email,
+        Subject: "Email на Пайдее изменен",
+        Body: fmt.Sprintf(
+            "Здравствуйте, %s!\n\nEmail вашего аккаунта изменен на %s.\n\n"+
+                "Если это были не вы, срочно свяжитесь с поддержкой.",
+            user.FullName, newEmail,
+        ),
+    })
+    if err != nil {
+        log.Printf("⚠️ Failed to send email change notice to user %d: %v", userID, err)
+    }
+
+    return newEmail, nil
+}
+
+// ExportData собирает все данные пользователя: профиль, материалы с блоками,
+// прогресс, оценки, избранное и ссылки на загруженные файлы
+func (s *AccountService) ExportData(ctx context.Context, userID int, client models.ClientInfo) (*models.UserDataExport, error) {
+    user, err := s.userRepo.GetUserByID(ctx, userID)
+    if err != nil {
+        return nil, fmt.Errorf("error finding user: %w", err)
+    }
+    if user == nil {
+        return nil, ErrUserNotFound
+    }
+
+    export := &models.UserDataExport{
+        ExportedAt: time.Now().UTC(),
+        Profile:    user,
+    }
+
+    if export.Specializations, err = s.userRepo.GetUserSpecializations(ctx, userID); err != nil {
+        return nil, fmt.Errorf("error getting specializations: %w", err)
+    }
+    if export.Identities, err = s.oauthRepo.GetUserIdentities(ctx, userID); err != nil {
+        return nil, fmt.Errorf("error getting identities: %w", err)
+    }
+    if export.Materials, err = s.materialRepo.GetUserMaterials(ctx, userID, ""); err != nil {
+        return nil, fmt.Errorf("error getting materials: %w", err)
+    }
+    for _, material := range export.Materials {
+        if material.Blocks, err = s.blockRepo.GetBlocks(ctx, material.ID); err != nil {
+            return nil, fmt.Errorf("error getting blocks of material %d: %w", material.ID, err)
+        }
+    }
+    if export.Completions, err = s.accountRepo.GetCompletions(ctx, userID); err != nil {
+        return nil, fmt.Errorf("error getting completions: %w", err)
+    }
+    if export.QuizAttempts, err = s.accountRepo.GetQuizAttempts(ctx, userID); err != nil {
+        return nil, fmt.Errorf("error getting quiz attempts: %w", err)
+    }
+    // Выгрузка не должна раскрывать правильные ответы раньше, чем сам тест
+    if err := s.quizService.HideUnreleasedResults(ctx, export.QuizAttempts); err != nil {
+        return nil, fmt.Errorf("error preparing quiz attempts: %w", err)
+    }
+    if export.Submissions, err = s.accountRepo.GetAssignmentSubmissions(ctx, userID); err != nil {
+        return nil, fmt.Errorf("error getting assignment submissions: %w", err)
+    }
+    if export.Ratings, err = s.accountRepo.GetRatings(ctx, userID); err != nil {
+        return nil, fmt.Errorf("error getting ratings: %w", err)
+    }
+    if export.Favorites, err = s.accountRepo.GetFavorites(ctx, userID); err != nil {
+        return nil, fmt.Errorf("error getting favorites: %w", err)
+    }
+    if export.Applications, err = s.verificationRepo.GetUserApplications(ctx, userID); err != nil {
+        return nil, fmt.Errorf("error getting verification applications: %w", err)
+    }
+
+    // Пустые разделы выгружаются пустыми списками, а не null
+    if export.Specializations == nil {
+        export.Specializations = []string{}
+    }
+    if export.Materials == nil {
+        export.Materials = []*models.Material{}
+    }
+    if export.Applications == nil {
+        export.Applications = []models.TeacherApplication{}
+    }
+
+    export.Media = collectMediaURLs(export)
+
+    s.audit(ctx, userID, models.AuditDataExported, nil, client)
+
+    return export, nil
+}
+
+// collectMediaURLs перечисляет загруженные пользователем файлы: аватар,
+// документы заявок, файлы сданных заданий и файлы, на которые ссылаются блоки материалов
+func collectMediaURLs(export *models.UserDataExport) []string {
+    media := []string{}
+    seen := make(map[string]bool)
+    add := func(url string) {
+        if url != "" && !seen[url] {
+            seen[url] = true
+            media = append(media, url)
+        }
+    }
+
+    add(export.Profile.AvatarURL)
+    for _, application := range export.Applications {
+        for _, document := range application.Documents {
+            if document.FileID != "" {
+                add("/api/v1/files/" + document.FileID)
+            }
+            add(document.URL)
+        }
+    }
+    for _, submission := range export.Submissions {
+        if submission.FileID != "" {
+            add("/api/v1/files/" + submission.FileID)
+        }
+        add(submission.FileURL)
+    }
+    for _, material := range export.Materials {
+        for _, block := range material.Blocks {
+            collectContentURLs(block.Content, add)
+        }
+    }
+    return media
+}
+
+// collectContentURLs обходит содержимое блока и находит поля url и src
+func collectContentURLs(value interface{}, add func(string)) {
+    switch v := value.(type) {
+    case map[string]interface{}:
+        for key, nested := range v {
+            if url, ok := nested.(string); ok && (key == "url" || key == "src") {
+                add(url)
+                continue
+            }
+            collectContentURLs(nested, add)
+        }
+    case []interface{}:
+        for _, nested := range v {
+            collectContentURLs(nested, add)
+        }
+    }
+}
+
+// ScheduleDeletion планирует удаление аккаунта через период ожидания.
+// Остальные сессии завершаются; из текущей удаление можно отменить.
+func (s *AccountService) ScheduleDeletion(ctx context.Context, userID int, sessionID string, req *models.DeleteAccountRequest, client models.ClientInfo) (*models.AccountDeletion, error) {
+    user, err := s.checkPassword(ctx, userID, req.Password)
+    if err != nil {
+        return nil, err
+    }
+
+    deletion := &models.AccountDeletion{
+        UserID:          userID,
+        MaterialsAction: models.MaterialsActionArchive,
+        ScheduledFor:    time.Now().Add(accountDeletionGracePeriod),
+    }
+
+    if req.MaterialsAction == models.MaterialsActionTransfer {
+        target, err := s.userRepo.GetUserByEmail(ctx, req.TransferToEmail)
+        if err != nil {
+            return nil, fmt.Errorf("error finding user: %w", err)
+        }
+        if target == nil || target.ID == userID || target.IsBlocked {
+            return nil, ErrInvalidTransferTarget
+        }
+        canAuthor, err := s.authorizer.HasPermission(ctx, target.Role, models.PermMaterialCreate)
+        if err != nil {
+            return nil, fmt.Errorf("error checking permissions: %w", err)
+        }
+        if !canAuthor {
+            return nil, ErrInvalidTransferTarget
+        }
+
+        deletion.MaterialsAction = models.MaterialsActionTransfer
+        deletion.TransferToID = &target.ID
+    }
+
+    if err := s.accountRepo.ScheduleDeletion(ctx, deletion); err != nil {
+        return nil, fmt.Errorf("error scheduling deletion: %w", err)
+    }
+
+    if err := s.refreshRepo.RevokeOtherFamilies(ctx, userID, sessionID); err != nil {
+        return nil, fmt.Errorf("error revoking sessions: %w", err)
+    }
+
+    s.audit(ctx, userID, models.AuditDeletionRequested, map[string]interface{}{
+        "materialsAction": deletion.MaterialsAction,
+        "scheduledFor":    deletion.ScheduledFor,
+    }, client)
+
+    err = s.mailer.Send(ctx, &Mail{
+        To:      user.Email,
+        Subject: "Удаление аккаунта на Пайдее",
+        Body: fmt.Sprintf(
+            "Здравствуйте, %s!\n\nВаш аккаунт будет удален %s.\n\n"+
+                "До этого момента удаление можно отменить в профиле:\n%s/profile",
+            user.FullName, deletion.ScheduledFor.Format("02.01.2006 15:04 MST"), s.appURL,
+        ),
+    })
+    if err != nil {
+        log.Printf("⚠️ Failed to send deletion notice to user %d: %v", userID, err)
+    }
+
+    return deletion, nil
+}
+
+// GetDeletion возвращает запланированное удаление аккаунта
+func (s *AccountService) GetDeletion(ctx context.Context, userID int) (*models.AccountDeletion, error) {
+    deletion, err := s.accountRepo.GetDeletion(ctx, userID)
+    if err != nil {
+        return nil, fmt.Errorf("error getting deletion: %w", err)
+    }
+    if deletion == nil {
+        return nil, ErrDeletionNotScheduled
+    }
+    return deletion, nil
+}
+
+// CancelDeletion отменяет запланированное удаление аккаунта
+func (s *AccountService) CancelDeletion(ctx context.Context, userID int, client models.ClientInfo) error {
+    cancelled, err := s.accountRepo.CancelDeletion(ctx, userID)
+    if err != nil {
+        return fmt.Errorf("error cancelling deletion: %w", err)
+    }
+    if !cancelled {
+        return ErrDeletionNotScheduled
+    }
+
+    s.audit(ctx, userID, models.AuditDeletionCancelled, nil, client)
+    return nil
+}
+
+// ProcessDueDeletions удаляет аккаунты, у которых истек период ожидания
+func (s *AccountService) ProcessDueDeletions(ctx context.Context) (int, error) {
+    deletions, err := s.accountRepo.GetDueDeletions(ctx, time.Now())
+    if err != nil {
+        return 0, fmt.Errorf("error getting due deletions: %w", err)
+    }
+
+    processed := 0
+    for i := range deletions {
+        if err := s.deleteAccount(ctx, &deletions[i]); err != nil {
+            log.Printf("⚠️ Failed to delete account %d: %v", deletions[i].UserID, err)
+            continue
+        }
+        processed++
+    }
+    return processed, nil
+}
+
+func (s *AccountService) deleteAccount(ctx context.Context, deletion *models.AccountDeletion) error {
+    user, err := s.userRepo.GetUserByID(ctx, deletion.UserID)
+    if err != nil {
+        return fmt.Errorf("error finding user: %w", err)
+    }
+    if user == nil {
+        return nil
+    }
+
+    // Список файлов собирается заранее: после удаления ссылки на них пропадут
+    files, err := s.collectOwnedFiles(ctx, user)
+    if err != nil {
+        return err
+    }
+
+    anonymized, err := s.accountRepo.DeleteAccount(ctx, deletion)
+    if err != nil {
+        return err
+    }
+
+    s.removeOwnedFiles(ctx, user.ID, files)
+
+    // Полностью удаленный пользователь остается в журнале только номером
+    entry := &models.AuditEntry{
+        Action: models.AuditAccountDeleted,
+        Details: map[string]interface{}{
+            "userId":          user.ID,
+            "anonymized":      anonymized,
+            "materialsAction": deletion.MaterialsAction,
+        },
+    }
+    if anonymized {
+        entry.UserID = user.ID
+    }
+    if err := s.auditRepo.Record(ctx, entry); err != nil {
+        log.Printf("⚠️ Failed to write audit record for deleted user %d: %v", user.ID, err)
+    }
+
+    log.Printf("🗑️ Account %d deleted (anonymized: %t)", user.ID, anonymized)
+    return nil
+}
+
+// ownedFiles - файлы, которые удаляются вместе с аккаунтом. Файлы в блоках
+// материалов не входят: материалы могут перейти другому автору
+type ownedFiles struct {
+    urls    []string // аватар и файлы, загруженные до закрытого хранения документов
+    private []models.PrivateFile
+}
+
+// collectOwnedFiles собирает файлы пользователя: аватар, документы заявок
+// на верификацию, файлы сданных заданий и все его закрытые файлы
+func (s *AccountService) collectOwnedFiles(ctx context.Context, user *models.User) (*ownedFiles, error) {
+    applications, err := s.verificationRepo.GetUserApplications(ctx, user.ID)
+    if err != nil {
+        return nil, fmt.Errorf("error getting verification applications: %w", err)
+    }
+    submissions, err := s.accountRepo.GetAssignmentSubmissions(ctx, user.ID)
+    if err != nil {
+        return nil, fmt.Errorf("error getting assignment submissions: %w", err)
+    }
+    private, err := s.privateFiles.UserFiles(ctx, user.ID)
+    if err != nil {
+        return nil, fmt.Errorf("error getting private files: %w", err)
+    }
+
+    return &ownedFiles{
+        urls:    ownedFileURLs(user, applications, submissions),
+        private: private,
+    }, nil
+}
+
+func ownedFileURLs(user *models.User, applications []models.TeacherApplication, submissions []models.AssignmentSubmission) []string {
+    var urls []string
+    if user.AvatarURL != "" {
+        urls = append(urls, user.AvatarURL)
+    }
+    for _, application := range applications {
+        for _, document := range application.Documents {
+            if document.URL != "" {
+                urls = append(urls, document.URL)
+            }
+        }
+    }
+    for _, submission := range submissions {
+        if submission.FileURL != "" {
+            urls = append(urls, submission.FileURL)
+        }
+    }
+    return urls
+}
+
+// removeOwnedFiles удаляет файлы удаленного аккаунта из хранилища. Ошибки
+// только пишутся в лог: аккаунт уже удален, повторить удаление нельзя
+func (s *AccountService) removeOwnedFiles(ctx context.Context, userID int, files *ownedFiles) {
+    for _, fileURL := range files.urls {
+        if err := s.fileService.DeleteStoredURL(ctx, fileURL); err != nil {
+            log.Printf("⚠️ Failed to delete file %s of user %d: %v", fileURL, userID, err)
+        }
+    }
+    for i := range files.private {
+        if err := s.privateFiles.RemoveContent(ctx, &files.private[i]); err != nil {
+            log.Printf("⚠️ Failed to delete private file %s of user %d: %v", files.private[i].ID, userID, err)
+        }
+    }
+}
+
+// RunDeletionWorker периодически удаляет аккаунты с истекшим периодом ожидания
+func (s *AccountService) RunDeletionWorker(ctx context.Context, interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        if _, err := s.ProcessDueDeletions(ctx); err != nil {
+            log.Printf("⚠️ Account deletion worker: %v", err)
+        }
+
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
+
+// checkPassword проверяет текущий пароль пользователя
+func (s *AccountService) checkPassword(ctx context.Context, userID int, password string) (*models.User, error) {
+    user, err := s.userRepo.GetUserByID(ctx, userID)
+    if err != nil {
+        return nil, fmt.Errorf("error finding user: %w", err)
+    }
+    if user == nil {
+        return nil, ErrUserNotFound
+    }
+
+    if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+        return nil, ErrInvalidPassword
+    }
+
+    return user, nil
+}
+
+// audit записывает действие пользователя со своим аккаунтом в журнал
+func (s *AccountService) audit(ctx context.Context, userID int, action string, details map[string]interface{}, client models.ClientInfo) {
+    recordAudit(ctx, s.auditRepo, userID, action, details, client)
+}
+
+// recordAudit записывает действие пользователя в журнал аудита.
+// Ошибка записи не отменяет уже выполненное действие.
+func recordAudit(ctx context.Context, auditRepo *repositories.AuditRepository, userID int, action string, details map[string]interface{}, client models.ClientInfo) {
+    err := auditRepo.Record(ctx, &models.AuditEntry{
+        UserID:    userID,
+        ActorID:   userID,
+        Action:    action,
+        Details:   details,
+        IP:        client.IP,
+        UserAgent: truncateUserAgent(client.UserAgent),
+    })
+    if err != nil {
+        log.Printf("⚠️ Failed to write audit record %s for user %d: %v", action, userID, err)
+    }
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+    "context"
+    "os"
+    "path/filepath"
+    "testing"
+
+    "paydeya-backend/internal/models"
+)
+
+func writeTestFile(t *testing.T, path string) string {
+    t.Helper()
+    if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+        t.Fatal(err)
+    }
+    if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    return path
+}
+
+// При удалении аккаунта удаляются все файлы пользователя: аватар, документы
+// заявок и файлы работ (закрытые и загруженные по старым публичным ссылкам).
+// Чужие файлы и файлы из блоков материалов остаются.
+func TestDeleteAccountRemovesUserFiles(t *testing.T) {
+    dir := t.TempDir()
+    uploadPath := filepath.Join(dir, "uploads")
+    privatePath := filepath.Join(dir, "private")
+
+    s := &AccountService{
+        fileService:  NewFileService(uploadPath, nil),
+        privateFiles: NewPrivateFileService(privatePath, nil, nil, nil),
+    }
+
+    const userID = 7
+    avatar := writeTestFile(t, filepath.Join(uploadPath, "avatars", "avatar_7_a1.png"))
+    legacyDocument := writeTestFile(t, filepath.Join(uploadPath, "documents", "7", "b2.pdf"))
+    legacySubmission := writeTestFile(t, filepath.Join(uploadPath, "documents", "7", "c3.pdf"))
+    materialImage := writeTestFile(t, filepath.Join(uploadPath, "images", "7", "d4.png"))
+    otherUserFile := writeTestFile(t, filepath.Join(uploadPath, "documents", "8", "e5.pdf"))
+
+    private := []models.PrivateFile{
+        {ID: "f6", UserID: userID, Kind: models.PrivateFileVerification, Storage: models.FileStorageLocal, StorageKey: "verification/7/f6.pdf"},
+        {ID: "g7", UserID: userID, Kind: models.PrivateFileSubmission, Storage: models.FileStorageLocal, StorageKey: "submission/7/g7.pdf"},
+    }
+    privateDocument := writeTestFile(t, filepath.Join(privatePath, "verification", "7", "f6.pdf"))
+    privateSubmission := writeTestFile(t, filepath.Join(privatePath, "submission", "7", "g7.pdf"))
+
+    user := &models.User{ID: userID, AvatarURL: "/uploads/avatars/avatar_7_a1.png"}
+    applications := []models.TeacherApplication{{
+        UserID: userID,
+        Documents: []models.VerificationDocument{
+            {URL: "/uploads/documents/7/b2.pdf", FileName: "diploma.pdf"},
+            {FileID: "f6", FileName: "certificate.pdf"},
+        },
+    }}
+    submissions := []models.AssignmentSubmission{
+        {UserID: userID, FileURL: "/uploads/documents/7/c3.pdf"},
+        {UserID: userID, FileID: "g7"},
+        {UserID: userID, FileURL: "https://example.com/solution.pdf"},
+        {UserID: userID, FileURL: "/uploads/../private/submission/8/h8.pdf"},
+    }
+
+    files := &ownedFiles{
+        urls:    ownedFileURLs(user, applications, submissions),
+        private: private,
+    }
+    s.removeOwnedFiles(context.Background(), userID, files)
+
+    for _, path := range []string{avatar, legacyDocument, legacySubmission, privateDocument, privateSubmission} {
+        if _, err := os.Stat(path); !os.IsNotExist(err) {
+            t.Errorf("%s must be deleted, stat error: %v", path, err)
+        }
+    }
+    for _, path := range []string{materialImage, otherUserFile} {
+        if _, err := os.Stat(path); err != nil {
+            t.Errorf("%s must be kept: %v", path, err)
+        }
+    }
+
+    // Повторное удаление (файлы уже удалены) не считается ошибкой
+    if err := s.privateFiles.RemoveContent(context.Background(), &private[0]); err != nil {
+        t.Errorf("removing deleted file: %v", err)
+    }
+}
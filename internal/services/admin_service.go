@@ -2,17 +2,30 @@ package services
 
 import (
     "context"
+    "errors"
+    "fmt"
 
     "paydeya-backend/internal/models"
     "paydeya-backend/internal/repositories"
 )
 
+var (
+    ErrUserNotFound        = errors.New("user not found")
+    ErrCannotChangeOwnRole = errors.New("you cannot change your own role")
+)
+
 type AdminService struct {
-    adminRepo *repositories.AdminRepository
+    adminRepo  *repositories.AdminRepository
+    auditRepo  *repositories.AuditRepository
+    authorizer *Authorizer
 }
 
-func NewAdminService(adminRepo *repositories.AdminRepository) *AdminService {
-    return &AdminService{adminRepo: adminRepo}
+func NewAdminService(adminRepo *repositories.AdminRepository, auditRepo *repositories.AuditRepository, authorizer *Authorizer) *AdminService {
+    return &AdminService{
+        adminRepo:  adminRepo,
+        auditRepo:  auditRepo,
+        authorizer: authorizer,
+    }
 }
 
 // GetPlatformStats возвращает статистику платформыrrrr
@@ -25,6 +38,11 @@ func (s *AdminService) GetUsers(ctx context.Context, role string, page, limit in
     return s.adminRepo.GetUsers(ctx, role, page, limit)
 }
 
+// GetUserAudit возвращает журнал аудита пользователя
+func (s *AdminService) GetUserAudit(ctx context.Context, userID, page, limit int) ([]models.AuditEntry, int, error) {
+    return s.auditRepo.GetUserEntries(ctx, userID, page, limit)
+}
+
 // BlockUser блокирует пользователя
 func (s *AdminService) BlockUser(ctx context.Context, userID int, reason string) error {
     return s.adminRepo.BlockUser(ctx, userID, reason)
@@ -33,4 +51,29 @@ func (s *AdminService) BlockUser(ctx context.Context, userID int, reason string)
 // CreateSubject создает новый предмет
 func (s *AdminService) CreateSubject(ctx context.Context, req *models.CreateSubjectRequest) error {
     return s.adminRepo.CreateSubject(ctx, req)
-}
\ No newline at end of file
+}
+
+// ChangeUserRole назначает пользователю роль из таблицы ролей
+func (s *AdminService) ChangeUserRole(ctx context.Context, adminID, userID int, role string) error {
+    if adminID == userID {
+        return ErrCannotChangeOwnRole
+    }
+
+    exists, err := s.authorizer.RoleExists(ctx, role)
+    if err != nil {
+        return fmt.Errorf("error checking role: %w", err)
+    }
+    if !exists {
+        return ErrUnknownRole
+    }
+
+    updated, err := s.adminRepo.ChangeUserRole(ctx, userID, role)
+    if err != nil {
+        return fmt.Errorf("failed to change role: %w", err)
+    }
+    if !updated {
+        return ErrUserNotFound
+    }
+
+    return nil
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "strings"
+    "time"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/repositories"
+    "paydeya-backend/internal/utils"
+)
+
+var (
+    ErrAPIKeyNotFound = errors.New("api key not found")
+    ErrInvalidAPIKey  = errors.New("invalid api key")
+    ErrTooManyAPIKeys = errors.New("too many active api keys")
+)
+
+const (
+    // APIKeyPrefix отличает наши ключи от прочих секретов (удобно для поиска утечек)
+    APIKeyPrefix      = "pdk_"
+    apiKeyShownLength = len(APIKeyPrefix) + 8
+    maxActiveAPIKeys  = 20
+)
+
+// APIKeyService выдает персональные API ключи и проверяет их при запросах.
+// Ключ показывается один раз при создании, в БД хранится только его хеш.
+type APIKeyService struct {
+    apiKeyRepo *repositories.APIKeyRepository
+    userRepo   *repositories.UserRepository
+    auditRepo  *repositories.AuditRepository
+}
+
+func NewAPIKeyService(apiKeyRepo *repositories.APIKeyRepository, userRepo *repositories.UserRepository, auditRepo *repositories.AuditRepository) *APIKeyService {
+    return &APIKeyService{
+        apiKeyRepo: apiKeyRepo,
+        userRepo:   userRepo,
+        auditRepo:  auditRepo,
+    }
+}
+
+// CreateKey создает ключ с выбранными областями доступа. Области только сужают
+// доступ: права роли владельца по-прежнему проверяются на каждом маршруте.
+func (s *APIKeyService) CreateKey(ctx context.Context, userID int, req *models.CreateAPIKeyRequest, client models.ClientInfo) (*models.CreateAPIKeyResponse, error) {
+    count, err := s.apiKeyRepo.CountActiveKeys(ctx, userID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to count api keys: %w", err)
+    }
+    if count >= maxActiveAPIKeys {
+        return nil, ErrTooManyAPIKeys
+    }
+
+    token, _, err := utils.GenerateOpaqueToken()
+    if err != nil {
+        return nil, fmt.Errorf("failed to generate api key: %w", err)
+    }
+    rawKey := APIKeyPrefix + token
+
+    key := &models.APIKey{
+        UserID: userID,
+        Name:   strings.TrimSpace(req.Name),
+        Prefix: rawKey[:apiKeyShownLength],
+        Scopes: uniqueScopes(req.Scopes),
+    }
+    if req.ExpiresInDays > 0 {
+        expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+        key.ExpiresAt = &expiresAt
+    }
+
+    if err := s.apiKeyRepo.CreateKey(ctx, key, utils.HashToken(rawKey)); err != nil {
+        return nil, fmt.Errorf("failed to save api key: %w", err)
+    }
+
+    recordAudit(ctx, s.auditRepo, userID, models.AuditAPIKeyCreated, map[string]interface{}{
+        "keyId":  key.ID,
+        "name":   key.Name,
+        "scopes": key.Scopes,
+    }, client)
+
+    return &models.CreateAPIKeyResponse{Key: rawKey, APIKey: key}, nil
+}
+
+// ListKeys возвращает действующие и истекшие (но не отозванные) ключи пользователя
+func (s *APIKeyService) ListKeys(ctx context.Context, userID int) ([]models.APIKey, error) {
+    keys, err := s.apiKeyRepo.GetUserKeys(ctx, userID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get api keys: %w", err)
+    }
+    return keys, nil
+}
+
+// RevokeKey отзывает ключ пользователя
+func (s *APIKeyService) RevokeKey(ctx context.Context, userID, keyID int, client models.ClientInfo) error {
+    revoked, err := s.apiKeyRepo.RevokeKey(ctx, userID, keyID)
+    if err != nil {
+        return fmt.Errorf("failed to revoke api key: %w", err)
+    }
+    if !revoked {
+        return ErrAPIKeyNotFound
+    }
+
+    recordAudit(ctx, s.auditRepo, userID, models.AuditAPIKeyRevoked, map[string]interface{}{"keyId": keyID}, client)
+    return nil
+}
+
+// Authenticate проверяет ключ из заголовка X-API-Key и возвращает его владельца.
+// Роль и email берутся из БД, поэтому смена роли или блокировка действуют сразу.
+func (s *APIKeyService) Authenticate(ctx context.Context, rawKey string) (*models.APIKeyOwner, error) {
+    if !strings.HasPrefix(rawKey, APIKeyPrefix) {
+        return nil, ErrInvalidAPIKey
+    }
+
+    key, err := s.apiKeyRepo.GetActiveKeyByHash(ctx, utils.HashToken(rawKey))
+    if err != nil {
+        return nil, fmt.Errorf("failed to get api key: %w", err)
+    }
+    if key == nil {
+        return nil, ErrInvalidAPIKey
+    }
+
+    user, err := s.userRepo.GetUserByID(ctx, key.UserID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get user: %w", err)
+    }
+    if user == nil {
+        return nil, ErrInvalidAPIKey
+    }
+    if err := checkNotBlocked(user); err != nil {
+        return nil, err
+    }
+
+    if err := s.apiKeyRepo.TouchKey(ctx, key.ID); err != nil {
+        log.Printf("⚠️ Failed to update api key %d last use: %v", key.ID, err)
+    }
+
+    return &models.APIKeyOwner{Key: key, Email: user.Email, Role: user.Role}, nil
+}
+
+func uniqueScopes(scopes []string) []string {
+    seen := make(map[string]bool, len(scopes))
+    result := make([]string, 0, len(scopes))
+    for _, scope := range scopes {
+        if !seen[scope] {
+            seen[scope] = true
+            result = append(result, scope)
+        }
+    }
+    return result
+}
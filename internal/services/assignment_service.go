@@ -0,0 +1,325 @@
+package services
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "mime/multipart"
+    "strings"
+    "unicode/utf8"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/repositories"
+)
+
+var (
+    ErrSubmissionNotFound = errors.New("submission not found")
+    ErrSubmissionGraded   = errors.New("submission is already graded")
+    ErrSubmissionReturned = errors.New("submission is returned for revision")
+    ErrInvalidSubmission  = errors.New("invalid submission")
+)
+
+const (
+    maxSubmissionFileSize     = 20 * 1024 * 1024 // 20MB
+    maxSubmissionTextLength   = 20000
+    defaultAssignmentMaxScore = 10
+)
+
+// assignmentContent - содержимое блока assignment (схема assignmentBlockSchema)
+type assignmentContent struct {
+    Title      string   `json:"title"`
+    Prompt     string   `json:"prompt"`
+    Submission string   `json:"submission"`
+    MaxScore   *float64 `json:"maxScore"`
+}
+
+func (a *assignmentContent) maxScore() float64 {
+    if a.MaxScore != nil {
+        return *a.MaxScore
+    }
+    return defaultAssignmentMaxScore
+}
+
+func (a *assignmentContent) submissionType() string {
+    if a.Submission != "" {
+        return a.Submission
+    }
+    return models.SubmissionTypeAny
+}
+
+func findAssignment(blocks []models.Block, blockID string) (*assignmentContent, error) {
+    for i := range blocks {
+        if blocks[i].ID != blockID || blocks[i].Type != models.BlockTypeAssignment {
+            continue
+        }
+        data, err := json.Marshal(blocks[i].Content)
+        if err != nil {
+            return nil, err
+        }
+        var assignment assignmentContent
+        if err := json.Unmarshal(data, &assignment); err != nil {
+            return nil, err
+        }
+        return &assignment, nil
+    }
+    return nil, ErrBlockNotFound
+}
+
+type AssignmentService struct {
+    assignmentRepo *repositories.AssignmentRepository
+    materialRepo   *repositories.MaterialRepository
+    revisionRepo   *repositories.RevisionRepository
+    fileService    *PrivateFileService
+    grades         *GradeService
+    authorizer     *Authorizer
+}
+
+func NewAssignmentService(assignmentRepo *repositories.AssignmentRepository, materialRepo *repositories.MaterialRepository, revisionRepo *repositories.RevisionRepository, fileService *PrivateFileService, grades *GradeService, authorizer *Authorizer) *AssignmentService {
+    s := &AssignmentService{
+        assignmentRepo: assignmentRepo,
+        materialRepo:   materialRepo,
+        revisionRepo:   revisionRepo,
+        fileService:    fileService,
+        grades:         grades,
+        authorizer:     authorizer,
+    }
+    fileService.RegisterAccess(models.PrivateFileSubmission, s.canDownloadFile)
+    return s
+}
+
+// Submit сохраняет работу ученика по заданию опубликованной версии материала.
+// Файл сохраняется закрытым: его скачивают ученик и те, кто может редактировать
+// материал. Повторная отправка заменяет работу, пока она не оценена.
+func (s *AssignmentService) Submit(ctx context.Context, userID, materialID int, blockID, text string, file *multipart.FileHeader) (*models.AssignmentSubmission, error) {
+    revision, err := loadPublishedRevision(ctx, s.materialRepo, s.revisionRepo, materialID)
+    if err != nil {
+        return nil, err
+    }
+
+    assignment, err := findAssignment(revision.Blocks, blockID)
+    if err != nil {
+        return nil, err
+    }
+
+    text = strings.TrimSpace(text)
+    if utf8.RuneCountInString(text) > maxSubmissionTextLength {
+        return nil, fmt.Errorf("%w: text is too long: maximum is %d characters", ErrInvalidSubmission, maxSubmissionTextLength)
+    }
+    switch assignment.submissionType() {
+    case models.SubmissionTypeText:
+        if text == "" {
+            return nil, fmt.Errorf("%w: text answer is required", ErrInvalidSubmission)
+        }
+        if file != nil {
+            return nil, fmt.Errorf("%w: this assignment accepts only text", ErrInvalidSubmission)
+        }
+    case models.SubmissionTypeFile:
+        if file == nil {
+            return nil, fmt.Errorf("%w: file is required", ErrInvalidSubmission)
+        }
+    default:
+        if text == "" && file == nil {
+            return nil, fmt.Errorf("%w: text or file is required", ErrInvalidSubmission)
+        }
+    }
+
+    // Проверяем до загрузки файла, чтобы не оставлять в хранилище лишние файлы
+    existing, err := s.assignmentRepo.GetUserSubmission(ctx, userID, materialID, blockID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get submission: %w", err)
+    }
+    if existing != nil && existing.Status == models.SubmissionStatusGraded {
+        return nil, ErrSubmissionGraded
+    }
+
+    submission := &models.AssignmentSubmission{
+        MaterialID: materialID,
+        BlockID:    blockID,
+        UserID:     userID,
+        Revision:   revision.Revision,
+        Text:       text,
+        MaxScore:   assignment.maxScore(),
+    }
+    var uploaded *models.PrivateFile
+    if file != nil {
+        if uploaded, err = s.uploadFile(ctx, userID, file); err != nil {
+            return nil, err
+        }
+        submission.FileID = uploaded.ID
+        submission.FileName = file.Filename
+    }
+
+    saved, err := s.assignmentRepo.SaveSubmission(ctx, submission)
+    if err == nil && !saved {
+        err = ErrSubmissionGraded
+    }
+    if err != nil {
+        if uploaded != nil {
+            s.removeFile(ctx, uploaded.ID)
+        }
+        if errors.Is(err, ErrSubmissionGraded) {
+            return nil, err
+        }
+        return nil, fmt.Errorf("failed to save submission: %w", err)
+    }
+    // Файл замененной работы больше ни на что не ссылается
+    if existing != nil && existing.FileID != "" && existing.FileID != submission.FileID {
+        s.removeFile(ctx, existing.FileID)
+    }
+
+    if err := s.grades.Refresh(ctx, userID, materialID, revision.Blocks); err != nil {
+        return nil, err
+    }
+
+    return s.assignmentRepo.GetSubmission(ctx, submission.ID)
+}
+
+func (s *AssignmentService) uploadFile(ctx context.Context, userID int, fileHeader *multipart.FileHeader) (*models.PrivateFile, error) {
+    if fileHeader.Size > maxSubmissionFileSize {
+        return nil, fmt.Errorf("%w: file is too large: maximum size is 20MB", ErrInvalidSubmission)
+    }
+
+    file, err := fileHeader.Open()
+    if err != nil {
+        return nil, fmt.Errorf("failed to open file: %w", err)
+    }
+    defer file.Close()
+
+    uploaded, err := s.fileService.Save(ctx, file, fileHeader.Filename, fileHeader.Size, userID, models.PrivateFileSubmission)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidSubmission, err)
+    }
+    return uploaded, nil
+}
+
+func (s *AssignmentService) removeFile(ctx context.Context, fileID string) {
+    if err := s.fileService.DeleteByID(ctx, fileID); err != nil {
+        log.Printf("Failed to remove submission file %s: %v", fileID, err)
+    }
+}
+
+// canDownloadFile пускает к файлу работы тех, кто может редактировать материал задания.
+// Сам ученик скачивает свой файл как владелец
+func (s *AssignmentService) canDownloadFile(ctx context.Context, userID int, role string, file *models.PrivateFile) (bool, error) {
+    materialID, err := s.assignmentRepo.GetFileMaterial(ctx, file.ID)
+    if err != nil {
+        return false, fmt.Errorf("failed to find submission: %w", err)
+    }
+    if materialID == 0 {
+        return false, nil
+    }
+
+    material, err := s.materialRepo.GetMaterial(ctx, materialID)
+    if err != nil {
+        return false, fmt.Errorf("error finding material: %w", err)
+    }
+    if material == nil {
+        return false, nil
+    }
+
+    err = s.authorizer.CanEditMaterial(ctx, userID, role, material)
+    if errors.Is(err, ErrAccessDenied) {
+        return false, nil
+    }
+    return err == nil, err
+}
+
+// GetMySubmission возвращает работу ученика по заданию
+func (s *AssignmentService) GetMySubmission(ctx context.Context, userID, materialID int, blockID string) (*models.AssignmentSubmission, error) {
+    submission, err := s.assignmentRepo.GetUserSubmission(ctx, userID, materialID, blockID)
+    if err != nil {
+        return nil, err
+    }
+    if submission == nil {
+        return nil, ErrSubmissionNotFound
+    }
+    return submission, nil
+}
+
+// ListMySubmissions возвращает все работы ученика со статусами проверки
+func (s *AssignmentService) ListMySubmissions(ctx context.Context, userID int) ([]models.AssignmentSubmission, error) {
+    return s.assignmentRepo.GetUserSubmissions(ctx, userID, 0)
+}
+
+// ListQueue возвращает очередь проверки преподавателя: работы по указанному
+// материалу (нужно право редактировать его) или по всем своим материалам
+func (s *AssignmentService) ListQueue(ctx context.Context, userID int, role string, materialID int, status string, page, limit int) ([]models.AssignmentSubmission, int, error) {
+    if materialID > 0 {
+        if _, err := s.editableMaterial(ctx, userID, role, materialID); err != nil {
+            return nil, 0, err
+        }
+    }
+    return s.assignmentRepo.ListSubmissions(ctx, userID, materialID, status, page, limit)
+}
+
+// Review выставляет оценку за работу или возвращает ее на доработку
+// и пересчитывает оценку ученика за материал
+func (s *AssignmentService) Review(ctx context.Context, userID int, role string, submissionID int, req *models.ReviewSubmissionRequest) (*models.AssignmentSubmission, error) {
+    submission, err := s.assignmentRepo.GetSubmission(ctx, submissionID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get submission: %w", err)
+    }
+    if submission == nil {
+        return nil, ErrSubmissionNotFound
+    }
+    if _, err := s.editableMaterial(ctx, userID, role, submission.MaterialID); err != nil {
+        return nil, err
+    }
+    if submission.Status == models.SubmissionStatusReturned {
+        return nil, ErrSubmissionReturned
+    }
+
+    score := req.Score
+    switch req.Status {
+    case models.SubmissionStatusGraded:
+        if score == nil {
+            return nil, fmt.Errorf("%w: score is required", ErrInvalidSubmission)
+        }
+        if *score > submission.MaxScore {
+            return nil, fmt.Errorf("%w: score must not exceed %g", ErrInvalidSubmission, submission.MaxScore)
+        }
+    case models.SubmissionStatusReturned:
+        if strings.TrimSpace(req.Feedback) == "" {
+            return nil, fmt.Errorf("%w: feedback is required when returning work", ErrInvalidSubmission)
+        }
+        score = nil
+    }
+
+    reviewed, err := s.assignmentRepo.ReviewSubmission(ctx, submissionID, req.Status, score, strings.TrimSpace(req.Feedback), userID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to review submission: %w", err)
+    }
+    if !reviewed {
+        return nil, ErrSubmissionReturned
+    }
+
+    revision, err := loadPublishedRevision(ctx, s.materialRepo, s.revisionRepo, submission.MaterialID)
+    switch {
+    case errors.Is(err, ErrMaterialNotFound):
+        // Материал сняли с публикации - оценку пересчитает отметка о завершении
+    case err != nil:
+        return nil, err
+    default:
+        if err := s.grades.Refresh(ctx, submission.UserID, submission.MaterialID, revision.Blocks); err != nil {
+            return nil, err
+        }
+    }
+
+    return s.assignmentRepo.GetSubmission(ctx, submissionID)
+}
+
+func (s *AssignmentService) editableMaterial(ctx context.Context, userID int, role string, materialID int) (*models.Material, error) {
+    material, err := s.materialRepo.GetMaterial(ctx, materialID)
+    if err != nil {
+        return nil, fmt.Errorf("error finding material: %w", err)
+    }
+    if material == nil {
+        return nil, ErrMaterialNotFound
+    }
+    if err := s.authorizer.CanEditMaterial(ctx, userID, role, material); err != nil {
+        return nil, err
+    }
+    return material, nil
+}
@@ -4,29 +4,76 @@ import (
     "context"
     "errors"
     "fmt"
-    "strconv"
+    "log"
+    "strings"
+    "time"
 
     "paydeya-backend/internal/models"
     "paydeya-backend/internal/repositories"
     "paydeya-backend/internal/utils"
 
     "golang.org/x/crypto/bcrypt"
-    "github.com/golang-jwt/jwt/v5"
+    "github.com/google/uuid"
+)
+
+var (
+    ErrInvalidRefreshToken      = errors.New("invalid refresh token")
+    ErrRefreshTokenReused       = errors.New("refresh token has already been used, session revoked")
+    ErrInvalidResetToken        = errors.New("invalid or expired reset token")
+    ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+    ErrEmailAlreadyVerified     = errors.New("email is already verified")
+    ErrVerificationThrottled    = errors.New("verification email was sent recently, try again later")
+    ErrAccountBlocked           = errors.New("account is blocked")
+)
+
+const (
+    // passwordResetTTL время жизни ссылки на сброс пароля
+    passwordResetTTL = time.Hour
+    // emailVerificationTTL время жизни ссылки подтверждения email
+    emailVerificationTTL = 24 * time.Hour
+    // Ограничения на повторную отправку письма подтверждения
+    verificationResendInterval = time.Minute
+    verificationMaxPerHour     = 5
 )
 
 type AuthService struct {
-    userRepo  *repositories.UserRepository
-    jwtSecret string
+    userRepo    *repositories.UserRepository
+    refreshRepo *repositories.RefreshTokenRepository
+    sessionRepo *repositories.SessionRepository
+    tokenRepo   *repositories.UserTokenRepository
+    inviteRepo  *repositories.InviteRepository
+    mailer      Mailer
+    throttler   *LoginThrottler
+    keys        *utils.KeySet
+    appURL      string // адрес фронтенда для ссылок в письмах
 }
 
-func NewAuthService(userRepo *repositories.UserRepository, jwtSecret string) *AuthService {
+func NewAuthService(
+    userRepo *repositories.UserRepository,
+    refreshRepo *repositories.RefreshTokenRepository,
+    sessionRepo *repositories.SessionRepository,
+    tokenRepo *repositories.UserTokenRepository,
+    inviteRepo *repositories.InviteRepository,
+    mailer Mailer,
+    throttler *LoginThrottler,
+    keys *utils.KeySet,
+    appURL string,
+) *AuthService {
     return &AuthService{
-        userRepo: userRepo,
-        jwtSecret: jwtSecret,
+        userRepo:    userRepo,
+        refreshRepo: refreshRepo,
+        sessionRepo: sessionRepo,
+        tokenRepo:   tokenRepo,
+        inviteRepo:  inviteRepo,
+        mailer:      mailer,
+        throttler:   throttler,
+        keys:        keys,
+        appURL:      strings.TrimRight(appURL, "/"),
     }
 }
 
-// Register регистрирует нового пользователя
+// Register регистрирует нового пользователя. Публичная регистрация доступна
+// только ученикам и преподавателям; роль администратора выдается по приглашению.
 func (s *AuthService) Register(ctx context.Context, req *models.RegisterRequest) (*models.User, error) {
     // Проверяем, существует ли email
     exists, err := s.userRepo.EmailExists(ctx, req.Email)
@@ -37,9 +84,32 @@ func (s *AuthService) Register(ctx context.Context, req *models.RegisterRequest)
         return nil, errors.New("user with this email already exists")
     }
 
+    role := req.Role
+    var invite *models.AdminInvite
+    if req.InviteToken != "" {
+        // Занимаем приглашение сразу, чтобы параллельная регистрация не смогла его использовать
+        invite, err = s.inviteRepo.ClaimInvite(ctx, utils.HashToken(req.InviteToken))
+        if err != nil {
+            return nil, fmt.Errorf("error checking invite: %w", err)
+        }
+        if invite == nil {
+            return nil, ErrInvalidInvite
+        }
+        if invite.Email != nil && !strings.EqualFold(*invite.Email, req.Email) {
+            s.releaseInvite(ctx, invite)
+            return nil, ErrInvalidInvite
+        }
+        role = invite.Role
+    }
+
+    if role != "student" && role != "teacher" && invite == nil {
+        return nil, errors.New("invalid role")
+    }
+
     // Хешируем пароль
     hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
     if err != nil {
+        s.releaseInvite(ctx, invite)
         return nil, fmt.Errorf("error hashing password: %w", err)
     }
 
@@ -48,116 +118,397 @@ func (s *AuthService) Register(ctx context.Context, req *models.RegisterRequest)
         Email:        req.Email,
         PasswordHash: string(hashedPassword),
         FullName:     req.FullName,
-        Role:         req.Role,
-        IsVerified:   req.Role == "student", // Учеников автоматически верифицируем
+        Role:         role,
+        IsVerified:   role != "teacher", // Преподаватели проходят верификацию отдельно
     }
 
     // Сохраняем в БД
     if err := s.userRepo.CreateUser(ctx, user); err != nil {
+        s.releaseInvite(ctx, invite)
         return nil, fmt.Errorf("error creating user: %w", err)
     }
 
+    if invite != nil {
+        if err := s.inviteRepo.CompleteInvite(ctx, invite.ID, user.ID); err != nil {
+            log.Printf("⚠️ Failed to record invite %d usage by user %d: %v", invite.ID, user.ID, err)
+        }
+    }
+
+    // Письмо не критично для регистрации: его всегда можно запросить повторно
+    if err := s.sendVerificationEmail(ctx, user); err != nil {
+        log.Printf("⚠️ Failed to send verification email to user %d: %v", user.ID, err)
+    }
+
     return user, nil
 }
 
+// releaseInvite возвращает приглашение, если регистрация по нему не удалась
+func (s *AuthService) releaseInvite(ctx context.Context, invite *models.AdminInvite) {
+    if invite == nil {
+        return
+    }
+    if err := s.inviteRepo.ReleaseInvite(ctx, invite.ID); err != nil {
+        log.Printf("⚠️ Failed to release invite %d: %v", invite.ID, err)
+    }
+}
+
+// sendVerificationEmail выдает токен подтверждения и отправляет письмо со ссылкой
+func (s *AuthService) sendVerificationEmail(ctx context.Context, user *models.User) error {
+    token, tokenHash, err := utils.GenerateOpaqueToken()
+    if err != nil {
+        return fmt.Errorf("error generating verification token: %w", err)
+    }
+
+    err = s.tokenRepo.CreateToken(ctx, &models.UserToken{
+        UserID:    user.ID,
+        Purpose:   models.TokenPurposeEmailVerification,
+        TokenHash: tokenHash,
+        ExpiresAt: time.Now().Add(emailVerificationTTL),
+    })
+    if err != nil {
+        return fmt.Errorf("error saving verification token: %w", err)
+    }
+
+    return s.mailer.Send(ctx, &Mail{
+        To:      user.Email,
+        Subject: "Подтверждение email на Пайдее",
+        Body: fmt.Sprintf(
+            "Здравствуйте, %s!\n\nЧтобы подтвердить email, перейдите по ссылке:\n%s/verify-email?token=%s\n\n"+
+                "Ссылка действует 24 часа.",
+            user.FullName, s.appURL, token,
+        ),
+    })
+}
+
+// VerifyEmail подтверждает email по токену из письма
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) error {
+    stored, err := s.tokenRepo.ConsumeToken(ctx, utils.HashToken(token), models.TokenPurposeEmailVerification)
+    if err != nil {
+        return fmt.Errorf("error checking verification token: %w", err)
+    }
+    if stored == nil {
+        return ErrInvalidVerificationToken
+    }
+
+    if err := s.userRepo.MarkEmailVerified(ctx, stored.UserID); err != nil {
+        return fmt.Errorf("error verifying email: %w", err)
+    }
+
+    return nil
+}
+
+// ResendVerificationEmail повторно отправляет письмо подтверждения.
+// Не чаще раза в минуту и не больше пяти писем в час.
+func (s *AuthService) ResendVerificationEmail(ctx context.Context, userID int) error {
+    user, err := s.userRepo.GetUserByID(ctx, userID)
+    if err != nil {
+        return fmt.Errorf("error finding user: %w", err)
+    }
+    if user == nil {
+        return errors.New("user not found")
+    }
+    if user.EmailVerified {
+        return ErrEmailAlreadyVerified
+    }
+
+    now := time.Now()
+    recent, err := s.tokenRepo.CountTokensSince(ctx, userID, models.TokenPurposeEmailVerification, now.Add(-verificationResendInterval))
+    if err != nil {
+        return fmt.Errorf("error checking resend limit: %w", err)
+    }
+    hourly, err := s.tokenRepo.CountTokensSince(ctx, userID, models.TokenPurposeEmailVerification, now.Add(-time.Hour))
+    if err != nil {
+        return fmt.Errorf("error checking resend limit: %w", err)
+    }
+    if recent > 0 || hourly >= verificationMaxPerHour {
+        return ErrVerificationThrottled
+    }
+
+    return s.sendVerificationEmail(ctx, user)
+}
+
+// IsEmailVerified проверяет, подтвердил ли пользователь email
+func (s *AuthService) IsEmailVerified(ctx context.Context, userID int) (bool, error) {
+    return s.userRepo.IsEmailVerified(ctx, userID)
+}
+
 // Login выполняет вход пользователя
-func (s *AuthService) Login(ctx context.Context, req *models.LoginRequest) (*models.User, error) {
+func (s *AuthService) Login(ctx context.Context, req *models.LoginRequest, clientIP string) (*models.User, error) {
+    // Проверяем лимит попыток до bcrypt, чтобы перебор не нагружал сервер
+    attempt, err := s.throttler.Begin(ctx, req.Email, clientIP)
+    if err != nil {
+        return nil, err
+    }
+
     // Находим пользователя по email
     user, err := s.userRepo.GetUserByEmail(ctx, req.Email)
     if err != nil {
+        s.throttler.Release(ctx, attempt)
         return nil, fmt.Errorf("error finding user: %w", err)
     }
     if user == nil {
         return nil, errors.New("invalid email or password")
     }
 
-    // Проверяем пароль
+    // Проверяем пароль. Неудачная попытка уже учтена в Begin
     if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
         return nil, errors.New("invalid email or password")
     }
+    s.throttler.Succeed(ctx, attempt)
 
-    if user.IsBlocked {
-        reason := "No reason provided"
-        if user.BlockReason != nil {
-            reason = *user.BlockReason
-        }
-        return nil, fmt.Errorf("account is blocked. Reason: %s", reason)
+    if err := checkNotBlocked(user); err != nil {
+        return nil, err
     }
 
     return user, nil
 }
 
-// GenerateTokens создает access и refresh токены
-func (s *AuthService) GenerateTokens(user *models.User) (string, string, error) {
-    accessToken, err := utils.GenerateAccessToken(user.ID, user.Email, user.Role, s.jwtSecret)
+// checkNotBlocked запрещает вход заблокированным пользователям
+func checkNotBlocked(user *models.User) error {
+    if !user.IsBlocked {
+        return nil
+    }
+
+    reason := "No reason provided"
+    if user.BlockReason != nil {
+        reason = *user.BlockReason
+    }
+    return fmt.Errorf("%w. Reason: %s", ErrAccountBlocked, reason)
+}
+
+// GenerateTokens создает access и refresh токены для нового входа
+// (начинает новое семейство refresh токенов и новую сессию)
+func (s *AuthService) GenerateTokens(ctx context.Context, user *models.User, client models.ClientInfo) (string, string, error) {
+    familyID := uuid.New().String()
+
+    accessToken, err := utils.GenerateAccessToken(user.ID, user.Email, user.Role, familyID, s.keys)
     if err != nil {
         return "", "", fmt.Errorf("error generating access token: %w", err)
     }
 
-    refreshToken, err := utils.GenerateRefreshToken(user.ID, s.jwtSecret)
+    refreshToken, stored, err := s.newRefreshToken(user.ID, familyID)
     if err != nil {
-        return "", "", fmt.Errorf("error generating refresh token: %w", err)
+        return "", "", err
+    }
+
+    if err := s.refreshRepo.CreateToken(ctx, stored); err != nil {
+        return "", "", fmt.Errorf("error saving refresh token: %w", err)
+    }
+
+    if err := s.touchSession(ctx, user.ID, familyID, client); err != nil {
+        return "", "", err
     }
 
     return accessToken, refreshToken, nil
 }
 
+// maxUserAgentLength ограничивает длину User-Agent, сохраняемого в сессии
+const maxUserAgentLength = 512
+
+func truncateUserAgent(userAgent string) string {
+    if len(userAgent) > maxUserAgentLength {
+        return strings.ToValidUTF8(userAgent[:maxUserAgentLength], "")
+    }
+    return userAgent
+}
 
-// RefreshTokens обновляет токены
-func (s *AuthService) RefreshTokens(refreshToken string) (string, string, error) {
-    token, err := jwt.ParseWithClaims(refreshToken, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
-        return []byte(s.jwtSecret), nil
+// touchSession записывает устройство и время последней активности сессии
+func (s *AuthService) touchSession(ctx context.Context, userID int, sessionID string, client models.ClientInfo) error {
+    err := s.sessionRepo.TouchSession(ctx, &models.Session{
+        ID:        sessionID,
+        UserID:    userID,
+        UserAgent: truncateUserAgent(client.UserAgent),
+        IP:        client.IP,
     })
+    if err != nil {
+        return fmt.Errorf("error saving session: %w", err)
+    }
+    return nil
+}
+
+// newRefreshToken подписывает refresh токен и готовит запись для БД
+func (s *AuthService) newRefreshToken(userID int, familyID string) (string, *models.RefreshToken, error) {
+    refreshToken, err := utils.GenerateRefreshToken(userID, uuid.New().String(), s.keys)
+    if err != nil {
+        return "", nil, fmt.Errorf("error generating refresh token: %w", err)
+    }
 
-    if err != nil || !token.Valid {
-        return "", "", errors.New("invalid refresh token")
+    return refreshToken, &models.RefreshToken{
+        UserID:    userID,
+        FamilyID:  familyID,
+        TokenHash: utils.HashToken(refreshToken),
+        ExpiresAt: time.Now().Add(utils.RefreshTokenTTL),
+    }, nil
+}
+
+// findRefreshToken проверяет подпись токена и находит его запись в БД
+func (s *AuthService) findRefreshToken(ctx context.Context, refreshToken string) (*models.RefreshToken, error) {
+    if _, err := utils.ValidateRefreshToken(refreshToken, s.keys); err != nil {
+        return nil, ErrInvalidRefreshToken
+    }
+
+    stored, err := s.refreshRepo.GetTokenByHash(ctx, utils.HashToken(refreshToken))
+    if err != nil {
+        return nil, fmt.Errorf("error finding refresh token: %w", err)
+    }
+    if stored == nil {
+        return nil, ErrInvalidRefreshToken
+    }
+
+    return stored, nil
+}
+
+// RefreshTokens обменивает refresh токен на новую пару токенов.
+// Каждый refresh токен одноразовый: повторное предъявление уже обменянного
+// токена означает его утечку, поэтому отзывается все семейство.
+func (s *AuthService) RefreshTokens(ctx context.Context, refreshToken string, client models.ClientInfo) (string, string, error) {
+    stored, err := s.findRefreshToken(ctx, refreshToken)
+    if err != nil {
+        return "", "", err
+    }
+
+    if stored.RevokedAt != nil || time.Now().After(stored.ExpiresAt) {
+        return "", "", ErrInvalidRefreshToken
+    }
+
+    if stored.RotatedAt != nil {
+        return "", "", s.revokeReusedFamily(ctx, stored)
+    }
+
+    // Удаленный или заблокированный пользователь не получает новых токенов,
+    // а его семейство отзывается
+    user, err := s.userRepo.GetUserByID(ctx, stored.UserID)
+    if err != nil {
+        return "", "", fmt.Errorf("error finding user: %w", err)
+    }
+    if user == nil {
+        if err := s.refreshRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+            return "", "", fmt.Errorf("error revoking token family: %w", err)
+        }
+        return "", "", ErrInvalidRefreshToken
+    }
+    if err := checkNotBlocked(user); err != nil {
+        if err := s.refreshRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+            return "", "", fmt.Errorf("error revoking token family: %w", err)
+        }
+        return "", "", err
+    }
+
+    accessToken, err := utils.GenerateAccessToken(user.ID, user.Email, user.Role, stored.FamilyID, s.keys)
+    if err != nil {
+        return "", "", fmt.Errorf("error generating access token: %w", err)
     }
 
-    claims, ok := token.Claims.(*jwt.RegisteredClaims)
-    if !ok {
-        return "", "", errors.New("invalid token claims")
+    newRefreshToken, next, err := s.newRefreshToken(user.ID, stored.FamilyID)
+    if err != nil {
+        return "", "", err
     }
 
-    // Получаем userID из subject
-    userIDStr := claims.Subject
-    userID, err := strconv.Atoi(userIDStr)
+    rotated, err := s.refreshRepo.RotateToken(ctx, stored.ID, next)
     if err != nil {
-        return "", "", errors.New("invalid user ID in token")
+        return "", "", fmt.Errorf("error rotating refresh token: %w", err)
+    }
+    if !rotated {
+        // Токен успели обменять между проверкой и ротацией
+        return "", "", s.revokeReusedFamily(ctx, stored)
+    }
+
+    if err := s.touchSession(ctx, user.ID, stored.FamilyID, client); err != nil {
+        return "", "", err
+    }
+
+    return accessToken, newRefreshToken, nil
+}
+
+// revokeReusedFamily отзывает семейство, в котором повторно использован токен
+func (s *AuthService) revokeReusedFamily(ctx context.Context, stored *models.RefreshToken) error {
+    log.Printf("⚠️ Refresh token reuse detected for user %d, revoking family %s", stored.UserID, stored.FamilyID)
+    if err := s.refreshRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+        return fmt.Errorf("error revoking token family: %w", err)
     }
+    return ErrRefreshTokenReused
+}
 
-    // Находим пользователя
-    user, err := s.userRepo.GetUserByID(context.Background(), userID)
-    if err != nil || user == nil {
-        return "", "", errors.New("user not found")
+// Logout отзывает семейство, к которому относится refresh токен
+func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
+    stored, err := s.findRefreshToken(ctx, refreshToken)
+    if err != nil {
+        return err
     }
 
-    // Генерируем новые токены
-    return s.GenerateTokens(user)
+    return s.refreshRepo.RevokeFamily(ctx, stored.FamilyID)
 }
 
-// ForgotPassword - отправка email с токеном сброса
+// LogoutAll отзывает все семейства refresh токенов пользователя
+func (s *AuthService) LogoutAll(ctx context.Context, userID int) error {
+    return s.refreshRepo.RevokeAllForUser(ctx, userID)
+}
+
+// ForgotPassword отправляет на email ссылку для сброса пароля.
+// Если пользователь не найден, молча ничего не делает, чтобы по ответу
+// нельзя было проверить, зарегистрирован ли email.
 func (s *AuthService) ForgotPassword(ctx context.Context, email string) error {
-    // Пока заглушка - в реальности отправили бы email
-    fmt.Printf("📧 Password reset requested for: %s\n", email)
-    fmt.Printf("🔗 Reset token: reset-token-%s\n", email) // временный токен
-    return nil
+    user, err := s.userRepo.GetUserByEmail(ctx, email)
+    if err != nil {
+        return fmt.Errorf("error finding user: %w", err)
+    }
+    if user == nil {
+        return nil
+    }
+
+    // Старые ссылки перестают работать, действует только последняя
+    if err := s.tokenRepo.InvalidateUserTokens(ctx, user.ID, models.TokenPurposePasswordReset); err != nil {
+        return fmt.Errorf("error invalidating reset tokens: %w", err)
+    }
+
+    token, tokenHash, err := utils.GenerateOpaqueToken()
+    if err != nil {
+        return fmt.Errorf("error generating reset token: %w", err)
+    }
+
+    err = s.tokenRepo.CreateToken(ctx, &models.UserToken{
+        UserID:    user.ID,
+        Purpose:   models.TokenPurposePasswordReset,
+        TokenHash: tokenHash,
+        ExpiresAt: time.Now().Add(passwordResetTTL),
+    })
+    if err != nil {
+        return fmt.Errorf("error saving reset token: %w", err)
+    }
+
+    return s.mailer.Send(ctx, &Mail{
+        To:      user.Email,
+        Subject: "Сброс пароля на Пайдее",
+        Body: fmt.Sprintf(
+            "Здравствуйте, %s!\n\nЧтобы задать новый пароль, перейдите по ссылке:\n%s/reset-password?token=%s\n\n"+
+                "Ссылка действует 1 час. Если вы не запрашивали сброс пароля, просто проигнорируйте это письмо.",
+            user.FullName, s.appURL, token,
+        ),
+    })
 }
 
-// ResetPassword - сброс пароля по токену
+// ResetPassword устанавливает новый пароль по токену сброса
+// и завершает все сеансы пользователя
 func (s *AuthService) ResetPassword(ctx context.Context, token, newPassword string) error {
-    // Пока заглушка - в реальности проверили бы токен в БД
-    fmt.Printf("🔄 Password reset with token: %s\n", token)
-
     // Хешируем новый пароль
     hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
     if err != nil {
         return fmt.Errorf("error hashing password: %w", err)
     }
 
-    fmt.Printf("✅ New password hash: %s\n", string(hashedPassword))
+    // Токен гасится вместе со сменой пароля и отзывом сессий
+    stored, err := s.tokenRepo.ResetPassword(ctx, utils.HashToken(token), string(hashedPassword))
+    if err != nil {
+        return fmt.Errorf("error resetting password: %w", err)
+    }
+    if stored == nil {
+        return ErrInvalidResetToken
+    }
+
     return nil
 }
+
 // ValidateToken проверяет access token
 func (s *AuthService) ValidateToken(tokenString string) (*utils.Claims, error) {
-    return utils.ValidateToken(tokenString, s.jwtSecret)
+    return utils.ValidateToken(tokenString, s.keys)
 }
\ No newline at end of file
@@ -0,0 +1,184 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sync"
+    "time"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/repositories"
+)
+
+var (
+    ErrAccessDenied      = errors.New("access denied")
+    ErrUnknownRole       = errors.New("unknown role")
+    ErrUnknownPermission = errors.New("unknown permission")
+    ErrLastRoleManager   = errors.New("no active user would be left with role.manage permission")
+)
+
+// permissionsCacheTTL ограничивает время, через которое изменения ролей,
+// сделанные на другом экземпляре сервиса, станут видны этому экземпляру
+const permissionsCacheTTL = time.Minute
+
+// Authorizer проверяет права ролей. Соответствие ролей и прав хранится в БД
+// и кэшируется в памяти, чтобы не ходить в базу на каждый запрос.
+type Authorizer struct {
+    roleRepo *repositories.RoleRepository
+
+    mu       sync.RWMutex
+    roles    map[string]map[string]bool
+    loadedAt time.Time
+}
+
+func NewAuthorizer(roleRepo *repositories.RoleRepository) *Authorizer {
+    return &Authorizer{roleRepo: roleRepo}
+}
+
+// permissions возвращает актуальное соответствие ролей и прав
+func (a *Authorizer) permissions(ctx context.Context) (map[string]map[string]bool, error) {
+    a.mu.RLock()
+    roles, loadedAt := a.roles, a.loadedAt
+    a.mu.RUnlock()
+
+    if roles != nil && time.Since(loadedAt) < permissionsCacheTTL {
+        return roles, nil
+    }
+
+    list, err := a.roleRepo.ListRoles(ctx)
+    if err != nil {
+        return nil, fmt.Errorf("failed to load roles: %w", err)
+    }
+
+    roles = make(map[string]map[string]bool, len(list))
+    for _, role := range list {
+        perms := make(map[string]bool, len(role.Permissions))
+        for _, permission := range role.Permissions {
+            perms[permission] = true
+        }
+        roles[role.Name] = perms
+    }
+
+    a.mu.Lock()
+    a.roles, a.loadedAt = roles, time.Now()
+    a.mu.Unlock()
+
+    return roles, nil
+}
+
+// Invalidate сбрасывает кэш прав после изменения ролей
+func (a *Authorizer) Invalidate() {
+    a.mu.Lock()
+    a.roles = nil
+    a.mu.Unlock()
+}
+
+// HasPermission проверяет, есть ли у роли все перечисленные права
+func (a *Authorizer) HasPermission(ctx context.Context, role string, permissions ...string) (bool, error) {
+    roles, err := a.permissions(ctx)
+    if err != nil {
+        return false, err
+    }
+
+    granted := roles[role]
+    for _, permission := range permissions {
+        if !granted[permission] {
+            return false, nil
+        }
+    }
+    return true, nil
+}
+
+// HasAnyPermission проверяет, есть ли у роли хотя бы одно из перечисленных прав
+func (a *Authorizer) HasAnyPermission(ctx context.Context, role string, permissions ...string) (bool, error) {
+    roles, err := a.permissions(ctx)
+    if err != nil {
+        return false, err
+    }
+
+    granted := roles[role]
+    for _, permission := range permissions {
+        if granted[permission] {
+            return true, nil
+        }
+    }
+    return false, nil
+}
+
+// Require возвращает ErrAccessDenied, если у роли нет хотя бы одного из прав
+func (a *Authorizer) Require(ctx context.Context, role string, permissions ...string) error {
+    allowed, err := a.HasPermission(ctx, role, permissions...)
+    if err != nil {
+        return err
+    }
+    if !allowed {
+        return ErrAccessDenied
+    }
+    return nil
+}
+
+// CanEditMaterial проверяет право на изменение материала: любой материал
+// с material.edit.any или собственный с material.edit.own
+func (a *Authorizer) CanEditMaterial(ctx context.Context, userID int, role string, material *models.Material) error {
+    if allowed, err := a.HasPermission(ctx, role, models.PermMaterialEditAny); err != nil || allowed {
+        return err
+    }
+
+    if material.AuthorID == userID {
+        return a.Require(ctx, role, models.PermMaterialEditOwn)
+    }
+    return ErrAccessDenied
+}
+
+// ListRoles возвращает роли с их правами
+func (a *Authorizer) ListRoles(ctx context.Context) ([]models.Role, error) {
+    return a.roleRepo.ListRoles(ctx)
+}
+
+// SaveRole создает роль или заменяет ее набор прав. Нельзя отобрать
+// role.manage, если после этого управлять ролями будет некому.
+func (a *Authorizer) SaveRole(ctx context.Context, req *models.SaveRoleRequest) (*models.Role, error) {
+    known, err := a.roleRepo.ListPermissions(ctx)
+    if err != nil {
+        return nil, fmt.Errorf("failed to load permissions: %w", err)
+    }
+
+    knownSet := make(map[string]bool, len(known))
+    for _, permission := range known {
+        knownSet[permission] = true
+    }
+    for _, permission := range req.Permissions {
+        if !knownSet[permission] {
+            return nil, fmt.Errorf("%w: %s", ErrUnknownPermission, permission)
+        }
+    }
+
+    role := &models.Role{
+        Name:        req.Name,
+        Description: req.Description,
+        Permissions: req.Permissions,
+    }
+    saved, err := a.roleRepo.SaveRole(ctx, role)
+    if err != nil {
+        return nil, fmt.Errorf("failed to save role: %w", err)
+    }
+    if !saved {
+        return nil, ErrLastRoleManager
+    }
+
+    a.Invalidate()
+    return role, nil
+}
+
+// RoleExists проверяет, что роль заведена в системе
+func (a *Authorizer) RoleExists(ctx context.Context, role string) (bool, error) {
+    roles, err := a.permissions(ctx)
+    if err != nil {
+        return false, err
+    }
+    if _, ok := roles[role]; ok {
+        return true, nil
+    }
+    return a.roleRepo.RoleExists(ctx, role)
+}
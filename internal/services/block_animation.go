@@ -0,0 +1,137 @@
+package services
+
+import (
+    "fmt"
+    "strings"
+
+    "paydeya-backend/internal/models"
+)
+
+const (
+    maxAnimationSteps = 100
+    maxAnimationTime  = 60000 // предел паузы и длительности шага, мс
+)
+
+var (
+    animationTriggers = []string{models.AnimationTriggerClick, models.AnimationTriggerAuto}
+    animationActions  = []string{models.AnimationActionShow, models.AnimationActionHide, models.AnimationActionHighlight}
+)
+
+// animationErrors проверяет анимацию блока: запуск и действия из известных,
+// элементы шагов есть в содержимом блока
+func animationErrors(path string, animation *models.BlockAnimation, content map[string]interface{}) []models.FieldError {
+    var errs []models.FieldError
+
+    if animation.Trigger != "" && !containsString(animationTriggers, animation.Trigger) {
+        errs = append(errs, models.FieldError{
+            Field:   joinPath(path, "trigger"),
+            Message: "must be one of: " + strings.Join(animationTriggers, ", "),
+        })
+    }
+    if animation.Delay < 0 || animation.Delay > maxAnimationTime {
+        errs = append(errs, models.FieldError{
+            Field:   joinPath(path, "delay"),
+            Message: fmt.Sprintf("must be between 0 and %d", maxAnimationTime),
+        })
+    }
+
+    switch {
+    case len(animation.Steps) == 0:
+        errs = append(errs, models.FieldError{Field: joinPath(path, "steps"), Message: "must contain at least 1 item"})
+    case len(animation.Steps) > maxAnimationSteps:
+        errs = append(errs, models.FieldError{
+            Field:   joinPath(path, "steps"),
+            Message: fmt.Sprintf("must contain at most %d items", maxAnimationSteps),
+        })
+    }
+
+    elements := animationElements(content)
+    for i, step := range animation.Steps {
+        stepPath := joinPath(path, fmt.Sprintf("steps[%d]", i))
+
+        switch {
+        case step.Element == "":
+            errs = append(errs, models.FieldError{Field: joinPath(stepPath, "element"), Message: "is required"})
+        case step.Element != models.AnimationElementBlock && !elements[step.Element]:
+            errs = append(errs, models.FieldError{Field: joinPath(stepPath, "element"), Message: "must refer to an element of the block content"})
+        }
+        if !containsString(animationActions, step.Action) {
+            errs = append(errs, models.FieldError{
+                Field:   joinPath(stepPath, "action"),
+                Message: "must be one of: " + strings.Join(animationActions, ", "),
+            })
+        }
+        if step.Duration != nil && (*step.Duration < 0 || *step.Duration > maxAnimationTime) {
+            errs = append(errs, models.FieldError{
+                Field:   joinPath(stepPath, "duration"),
+                Message: fmt.Sprintf("must be between 0 and %d", maxAnimationTime),
+            })
+        }
+    }
+
+    return errs
+}
+
+// animationElements собирает id элементов содержимого блока
+// (фрагментов текста, вопросов, вариантов ответа), на которые ссылаются шаги анимации
+func animationElements(content map[string]interface{}) map[string]bool {
+    elements := make(map[string]bool)
+
+    var walk func(value interface{})
+    walk = func(value interface{}) {
+        switch v := value.(type) {
+        case map[string]interface{}:
+            if id, ok := v["id"].(string); ok && id != "" {
+                elements[id] = true
+            }
+            for _, item := range v {
+                walk(item)
+            }
+        case []interface{}:
+            for _, item := range v {
+                walk(item)
+            }
+        }
+    }
+    for _, value := range content {
+        walk(value)
+    }
+
+    return elements
+}
+
+// resolveAnimation переводит анимацию проверенного блока в шаги с абсолютным
+// временем от запуска: перед каждым шагом пауза Delay, затем сам шаг
+func resolveAnimation(block *models.Block) *models.AnimationTimeline {
+    animation := block.Animation
+    timeline := &models.AnimationTimeline{
+        BlockID: block.ID,
+        Trigger: animation.Trigger,
+        Steps:   make([]models.TimelineStep, 0, len(animation.Steps)),
+    }
+    if timeline.Trigger == "" {
+        timeline.Trigger = models.AnimationTriggerClick
+    }
+
+    at := 0
+    for i, step := range animation.Steps {
+        duration := models.AnimationDefaultDuration
+        if step.Duration != nil {
+            duration = *step.Duration
+        }
+
+        start := at + animation.Delay
+        at = start + duration
+        timeline.Steps = append(timeline.Steps, models.TimelineStep{
+            Index:   i,
+            Element: step.Element,
+            Action:  step.Action,
+            Style:   step.Style,
+            Start:   start,
+            End:     at,
+        })
+    }
+    timeline.Duration = at
+
+    return timeline
+}
@@ -0,0 +1,355 @@
+package services
+
+import (
+    "errors"
+    "fmt"
+    "math"
+    "net/url"
+    "sort"
+    "strings"
+    "unicode/utf8"
+
+    "paydeya-backend/internal/models"
+)
+
+var ErrInvalidBlock = errors.New("invalid block")
+
+// BlockValidationError - содержимое блоков не соответствует схемам их типов
+type BlockValidationError struct {
+    Errors []models.FieldError
+}
+
+func (e *BlockValidationError) Error() string {
+    return fmt.Sprintf("%s: %s %s", ErrInvalidBlock, e.Errors[0].Field, e.Errors[0].Message)
+}
+
+func (e *BlockValidationError) Is(target error) bool {
+    return target == ErrInvalidBlock
+}
+
+// blockCheck - проверка содержимого, которую не выразить схемой (ссылки между полями и т.п.).
+// Вызывается только для содержимого, которое уже прошло проверку схемой.
+type blockCheck func(content map[string]interface{}) []models.FieldError
+
+type blockType struct {
+    schema models.BlockSchema
+    check  blockCheck
+}
+
+// BlockRegistry - реестр типов блоков. Каждый тип объявляет схему содержимого,
+// по которой проверяются блоки и которую получают клиенты.
+type BlockRegistry struct {
+    types   map[string]*blockType
+    order   []string
+    formats map[string]func(string) bool
+}
+
+func NewBlockRegistry(fileService *FileService) *BlockRegistry {
+    r := &BlockRegistry{
+        types: make(map[string]*blockType),
+        formats: map[string]func(string) bool{
+            models.FieldFormatImageURL: func(value string) bool {
+                return fileService.IsStoredURL(value, "images")
+            },
+            models.FieldFormatVideoURL: func(value string) bool {
+                return fileService.IsStoredURL(value, "videos") || isVideoEmbedURL(value)
+            },
+            models.FieldFormatLinkURL: isLinkURL,
+        },
+    }
+
+    r.Register(textBlockSchema, checkTextBlock)
+    r.Register(imageBlockSchema, nil)
+    r.Register(videoBlockSchema, nil)
+    r.Register(formulaBlockSchema, checkFormulaBlock)
+    r.Register(quizBlockSchema, checkQuizBlock)
+    r.Register(assignmentBlockSchema, nil)
+
+    return r
+}
+
+// Register добавляет тип блока (или заменяет уже зарегистрированный)
+func (r *BlockRegistry) Register(schema models.BlockSchema, check blockCheck) {
+    if _, exists := r.types[schema.Type]; !exists {
+        r.order = append(r.order, schema.Type)
+    }
+    r.types[schema.Type] = &blockType{schema: schema, check: check}
+}
+
+// Schemas возвращает схемы всех типов блоков в порядке регистрации
+func (r *BlockRegistry) Schemas() []models.BlockSchema {
+    schemas := make([]models.BlockSchema, 0, len(r.order))
+    for _, name := range r.order {
+        schemas = append(schemas, r.types[name].schema)
+    }
+    return schemas
+}
+
+// StudentView возвращает копию блока без скрытых полей схемы (ключей ответов и т.п.)
+func (r *BlockRegistry) StudentView(block models.Block) models.Block {
+    bt, ok := r.types[block.Type]
+    if !ok {
+        return block
+    }
+    block.Content, _ = redactValue(&models.SchemaField{Type: models.FieldTypeObject, Fields: bt.schema.Fields}, block.Content).(map[string]interface{})
+    return block
+}
+
+// redactValue копирует значение, пропуская скрытые поля
+func redactValue(field *models.SchemaField, value interface{}) interface{} {
+    switch v := value.(type) {
+    case map[string]interface{}:
+        if field.Type != models.FieldTypeObject {
+            return v
+        }
+        fields := make(map[string]*models.SchemaField, len(field.Fields))
+        for i := range field.Fields {
+            fields[field.Fields[i].Name] = &field.Fields[i]
+        }
+        copied := make(map[string]interface{}, len(v))
+        for name, item := range v {
+            f, known := fields[name]
+            if !known {
+                copied[name] = item
+                continue
+            }
+            if !f.Hidden {
+                copied[name] = redactValue(f, item)
+            }
+        }
+        return copied
+    case []interface{}:
+        if field.Items == nil {
+            return v
+        }
+        copied := make([]interface{}, len(v))
+        for i, item := range v {
+            copied[i] = redactValue(field.Items, item)
+        }
+        return copied
+    }
+    return value
+}
+
+// ValidateBlock проверяет блок по схеме его типа
+func (r *BlockRegistry) ValidateBlock(block *models.Block) error {
+    if errs := r.blockErrors("", block); len(errs) > 0 {
+        return &BlockValidationError{Errors: errs}
+    }
+    return nil
+}
+
+// ValidateBlocks проверяет список блоков материала. Пути ошибок
+// начинаются с индекса блока: blocks[2].content.url
+func (r *BlockRegistry) ValidateBlocks(blocks []models.Block) error {
+    var errs []models.FieldError
+    for i := range blocks {
+        errs = append(errs, r.blockErrors(fmt.Sprintf("blocks[%d]", i), &blocks[i])...)
+    }
+    if len(errs) > 0 {
+        return &BlockValidationError{Errors: errs}
+    }
+    return nil
+}
+
+func (r *BlockRegistry) blockErrors(path string, block *models.Block) []models.FieldError {
+    var errs []models.FieldError
+    if block.ID == "" {
+        errs = append(errs, models.FieldError{Field: joinPath(path, "id"), Message: "is required"})
+    } else if len(block.ID) > 100 {
+        errs = append(errs, models.FieldError{Field: joinPath(path, "id"), Message: "must be at most 100 characters"})
+    }
+
+    bt, ok := r.types[block.Type]
+    if !ok {
+        return append(errs, models.FieldError{
+            Field:   joinPath(path, "type"),
+            Message: "must be one of: " + strings.Join(r.order, ", "),
+        })
+    }
+
+    contentPath := joinPath(path, "content")
+    contentErrs := r.validateObject(contentPath, bt.schema.Fields, block.Content)
+    if len(contentErrs) == 0 && bt.check != nil {
+        for _, fieldErr := range bt.check(block.Content) {
+            fieldErr.Field = joinPath(contentPath, fieldErr.Field)
+            contentErrs = append(contentErrs, fieldErr)
+        }
+    }
+
+    errs = append(errs, contentErrs...)
+    if block.Animation != nil {
+        errs = append(errs, animationErrors(joinPath(path, "animation"), block.Animation, block.Content)...)
+    }
+
+    return errs
+}
+
+func (r *BlockRegistry) validateObject(path string, fields []models.SchemaField, object map[string]interface{}) []models.FieldError {
+    var errs []models.FieldError
+
+    known := make(map[string]bool, len(fields))
+    for i := range fields {
+        field := &fields[i]
+        known[field.Name] = true
+
+        value, ok := object[field.Name]
+        if !ok || value == nil {
+            if field.Required {
+                errs = append(errs, models.FieldError{Field: joinPath(path, field.Name), Message: "is required"})
+            }
+            continue
+        }
+        errs = append(errs, r.validateValue(joinPath(path, field.Name), field, value)...)
+    }
+
+    var unknown []string
+    for name := range object {
+        if !known[name] {
+            unknown = append(unknown, name)
+        }
+    }
+    sort.Strings(unknown)
+    for _, name := range unknown {
+        errs = append(errs, models.FieldError{Field: joinPath(path, name), Message: "unknown field"})
+    }
+
+    return errs
+}
+
+func (r *BlockRegistry) validateValue(path string, field *models.SchemaField, value interface{}) []models.FieldError {
+    fail := func(format string, args ...interface{}) []models.FieldError {
+        return []models.FieldError{{Field: path, Message: fmt.Sprintf(format, args...)}}
+    }
+
+    switch field.Type {
+    case models.FieldTypeString:
+        s, ok := value.(string)
+        if !ok {
+            return fail("must be a string")
+        }
+        if s == "" {
+            if field.Required {
+                return fail("is required")
+            }
+            return nil
+        }
+        if field.MaxLength > 0 && utf8.RuneCountInString(s) > field.MaxLength {
+            return fail("must be at most %d characters", field.MaxLength)
+        }
+        if len(field.Enum) > 0 && !containsString(field.Enum, s) {
+            return fail("must be one of: %s", strings.Join(field.Enum, ", "))
+        }
+        if check, ok := r.formats[field.Format]; ok && !check(s) {
+            return fail("%s", formatMessages[field.Format])
+        }
+
+    case models.FieldTypeNumber, models.FieldTypeInteger:
+        n, ok := value.(float64)
+        if !ok {
+            return fail("must be a number")
+        }
+        if field.Type == models.FieldTypeInteger && n != math.Trunc(n) {
+            return fail("must be an integer")
+        }
+        if field.Min != nil && n < *field.Min {
+            return fail("must be at least %v", *field.Min)
+        }
+        if field.Max != nil && n > *field.Max {
+            return fail("must be at most %v", *field.Max)
+        }
+
+    case models.FieldTypeBoolean:
+        if _, ok := value.(bool); !ok {
+            return fail("must be a boolean")
+        }
+
+    case models.FieldTypeArray:
+        items, ok := value.([]interface{})
+        if !ok {
+            return fail("must be an array")
+        }
+        if len(items) < field.MinItems {
+            return fail("must contain at least %d items", field.MinItems)
+        }
+        if field.MaxItems > 0 && len(items) > field.MaxItems {
+            return fail("must contain at most %d items", field.MaxItems)
+        }
+        var errs []models.FieldError
+        for i, item := range items {
+            itemPath := fmt.Sprintf("%s[%d]", path, i)
+            if item == nil {
+                errs = append(errs, models.FieldError{Field: itemPath, Message: "must not be null"})
+                continue
+            }
+            errs = append(errs, r.validateValue(itemPath, field.Items, item)...)
+        }
+        return errs
+
+    case models.FieldTypeObject:
+        object, ok := value.(map[string]interface{})
+        if !ok {
+            return fail("must be an object")
+        }
+        return r.validateObject(path, field.Fields, object)
+    }
+
+    return nil
+}
+
+var formatMessages = map[string]string{
+    models.FieldFormatImageURL: "must point to an image uploaded to our storage",
+    models.FieldFormatVideoURL: "must point to a video uploaded to our storage or a supported video service",
+    models.FieldFormatLinkURL:  "must be an http(s) or mailto link",
+}
+
+// videoEmbedHosts - видеосервисы, ссылки на которые можно вставлять в блок видео
+var videoEmbedHosts = []string{"youtube.com", "youtu.be", "vk.com", "vkvideo.ru", "rutube.ru", "vimeo.com"}
+
+func isVideoEmbedURL(value string) bool {
+    u, err := url.Parse(value)
+    if err != nil || u.Scheme != "https" {
+        return false
+    }
+    host := strings.ToLower(u.Hostname())
+    for _, allowed := range videoEmbedHosts {
+        if host == allowed || strings.HasSuffix(host, "."+allowed) {
+            return true
+        }
+    }
+    return false
+}
+
+func isLinkURL(value string) bool {
+    u, err := url.Parse(value)
+    if err != nil {
+        return false
+    }
+    switch u.Scheme {
+    case "http", "https":
+        return u.Host != ""
+    case "mailto":
+        return u.Opaque != ""
+    }
+    return false
+}
+
+func joinPath(path, name string) string {
+    if path == "" {
+        return name
+    }
+    return path + "." + name
+}
+
+func containsString(values []string, value string) bool {
+    for _, v := range values {
+        if v == value {
+            return true
+        }
+    }
+    return false
+}
+
+func floatPtr(value float64) *float64 {
+    return &value
+}
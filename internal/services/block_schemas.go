@@ -0,0 +1,230 @@
+package services
+
+import (
+    "errors"
+    "fmt"
+
+    "paydeya-backend/internal/models"
+)
+
+// Встроенные типы блоков
+
+var textBlockSchema = models.BlockSchema{
+    Type:        models.BlockTypeText,
+    Description: "Текст: простая строка text или размеченные фрагменты nodes",
+    Fields: []models.SchemaField{
+        {Name: "level", Type: models.FieldTypeString, Enum: []string{"h1", "h2", "h3", "p", "code", "quote"}, Description: "Вид текста, по умолчанию p"},
+        {Name: "text", Type: models.FieldTypeString, MaxLength: 20000, Description: "Текст без разметки"},
+        {Name: "nodes", Type: models.FieldTypeArray, MaxItems: 1000, Description: "Фрагменты текста с разметкой (вместо text)", Items: &models.SchemaField{
+            Type: models.FieldTypeObject,
+            Fields: []models.SchemaField{
+                {Name: "id", Type: models.FieldTypeString, MaxLength: 64, Description: "Идентификатор фрагмента для шагов анимации"},
+                {Name: "text", Type: models.FieldTypeString, Required: true, MaxLength: 20000},
+                {Name: "marks", Type: models.FieldTypeArray, MaxItems: 6, Items: &models.SchemaField{
+                    Type: models.FieldTypeString, Enum: []string{"bold", "italic", "underline", "strike", "code", "link"},
+                }},
+                {Name: "href", Type: models.FieldTypeString, Format: models.FieldFormatLinkURL, MaxLength: 2000, Description: "Адрес ссылки для отметки link"},
+            },
+        }},
+    },
+}
+
+var imageBlockSchema = models.BlockSchema{
+    Type:        models.BlockTypeImage,
+    Description: "Изображение, загруженное в хранилище (POST /upload/image)",
+    Fields: []models.SchemaField{
+        {Name: "url", Type: models.FieldTypeString, Required: true, Format: models.FieldFormatImageURL, MaxLength: 2000},
+        {Name: "alt", Type: models.FieldTypeString, Required: true, MaxLength: 500, Description: "Альтернативный текст"},
+        {Name: "caption", Type: models.FieldTypeString, MaxLength: 1000},
+        {Name: "width", Type: models.FieldTypeInteger, Min: floatPtr(1), Max: floatPtr(10000)},
+        {Name: "height", Type: models.FieldTypeInteger, Min: floatPtr(1), Max: floatPtr(10000)},
+    },
+}
+
+var videoBlockSchema = models.BlockSchema{
+    Type:        models.BlockTypeVideo,
+    Description: "Видео из хранилища (POST /upload/video) или ссылка на видеосервис",
+    Fields: []models.SchemaField{
+        {Name: "url", Type: models.FieldTypeString, Required: true, Format: models.FieldFormatVideoURL, MaxLength: 2000},
+        {Name: "title", Type: models.FieldTypeString, MaxLength: 300},
+        {Name: "poster", Type: models.FieldTypeString, Format: models.FieldFormatImageURL, MaxLength: 2000, Description: "Обложка из хранилища"},
+    },
+}
+
+var formulaBlockSchema = models.BlockSchema{
+    Type:        models.BlockTypeFormula,
+    Description: "Формула в LaTeX (математический режим без $): индексы, дроби, корни, греческие буквы, операторы и функции, \\left/\\right, \\text, \\mathbf и подобные, окружения matrix, pmatrix, bmatrix, cases, aligned. Ошибки разбора возвращаются с позицией символа",
+    Fields: []models.SchemaField{
+        {Name: "latex", Type: models.FieldTypeString, Required: true, MaxLength: 5000},
+        {Name: "display", Type: models.FieldTypeString, Enum: []string{"inline", "block"}, Description: "Строчная или выключная формула, по умолчанию block"},
+    },
+}
+
+var quizBlockSchema = models.BlockSchema{
+    Type:        models.BlockTypeQuiz,
+    Description: "Тест. Вопросы с выбором (single, multiple) перечисляют варианты options и id правильных в correct, числовые (numeric) задают answer и допуск tolerance, текстовые (text) - список принимаемых ответов answers. Скрытые поля ученикам не отдаются. Результаты проверки ученик видит, когда израсходует попытки или преподаватель откроет результаты",
+    Fields: []models.SchemaField{
+        {Name: "title", Type: models.FieldTypeString, MaxLength: 300},
+        {Name: "maxAttempts", Type: models.FieldTypeInteger, Min: floatPtr(1), Max: floatPtr(20), Description: "Число попыток, по умолчанию 3. Оценка ставится по последней попытке"},
+        {Name: "questions", Type: models.FieldTypeArray, Required: true, MinItems: 1, MaxItems: 100, Items: &models.SchemaField{
+            Type: models.FieldTypeObject,
+            Fields: []models.SchemaField{
+                {Name: "id", Type: models.FieldTypeString, Required: true, MaxLength: 64},
+                {Name: "type", Type: models.FieldTypeString, Required: true, Enum: []string{
+                    models.QuestionTypeSingle, models.QuestionTypeMultiple, models.QuestionTypeNumeric, models.QuestionTypeText,
+                }},
+                {Name: "text", Type: models.FieldTypeString, Required: true, MaxLength: 2000},
+                {Name: "points", Type: models.FieldTypeNumber, Min: floatPtr(0), Max: floatPtr(100), Description: "Баллы за верный ответ, по умолчанию 1"},
+                {Name: "options", Type: models.FieldTypeArray, MinItems: 2, MaxItems: 20, Items: &models.SchemaField{
+                    Type: models.FieldTypeObject,
+                    Fields: []models.SchemaField{
+                        {Name: "id", Type: models.FieldTypeString, Required: true, MaxLength: 64},
+                        {Name: "text", Type: models.FieldTypeString, Required: true, MaxLength: 1000},
+                    },
+                }},
+                {Name: "correct", Type: models.FieldTypeArray, Hidden: true, MinItems: 1, MaxItems: 20, Items: &models.SchemaField{
+                    Type: models.FieldTypeString, MaxLength: 64,
+                }},
+                {Name: "answer", Type: models.FieldTypeNumber, Hidden: true, Description: "Правильный ответ на числовой вопрос"},
+                {Name: "tolerance", Type: models.FieldTypeNumber, Hidden: true, Min: floatPtr(0), Description: "Допустимое отклонение от answer, по умолчанию 0"},
+                {Name: "answers", Type: models.FieldTypeArray, Hidden: true, MinItems: 1, MaxItems: 20, Description: "Принимаемые ответы на текстовый вопрос (без учета регистра и лишних пробелов)", Items: &models.SchemaField{
+                    Type: models.FieldTypeString, Required: true, MaxLength: 200,
+                }},
+                {Name: "explanation", Type: models.FieldTypeString, Hidden: true, MaxLength: 2000, Description: "Пояснение, показывается после ответа"},
+            },
+        }},
+    },
+}
+
+var assignmentBlockSchema = models.BlockSchema{
+    Type:        models.BlockTypeAssignment,
+    Description: "Задание с ручной проверкой: ученик отправляет текст и/или файл, преподаватель выставляет баллы и пишет отзыв. Критерии оценки rubric ученикам не отдаются",
+    Fields: []models.SchemaField{
+        {Name: "title", Type: models.FieldTypeString, MaxLength: 300},
+        {Name: "prompt", Type: models.FieldTypeString, Required: true, MaxLength: 10000, Description: "Формулировка задания"},
+        {Name: "submission", Type: models.FieldTypeString, Enum: []string{
+            models.SubmissionTypeText, models.SubmissionTypeFile, models.SubmissionTypeAny,
+        }, Description: "Что сдает ученик, по умолчанию any"},
+        {Name: "maxScore", Type: models.FieldTypeNumber, Min: floatPtr(1), Max: floatPtr(100), Description: "Максимальный балл, по умолчанию 10"},
+        {Name: "rubric", Type: models.FieldTypeString, Hidden: true, MaxLength: 5000, Description: "Критерии оценки для преподавателя"},
+    },
+}
+
+// quizQuestionFields - поля ответа, которые нужны (true) или запрещены (false) вопросу каждого типа
+var quizQuestionFields = map[string]map[string]bool{
+    models.QuestionTypeSingle:   {"options": true, "correct": true, "answer": false, "tolerance": false, "answers": false},
+    models.QuestionTypeMultiple: {"options": true, "correct": true, "answer": false, "tolerance": false, "answers": false},
+    models.QuestionTypeNumeric:  {"options": false, "correct": false, "answer": true, "answers": false},
+    models.QuestionTypeText:     {"options": false, "correct": false, "answer": false, "tolerance": false, "answers": true},
+}
+
+// checkTextBlock проверяет, что текст задан одним способом и ссылки размечены
+func checkTextBlock(content map[string]interface{}) []models.FieldError {
+    var errs []models.FieldError
+
+    nodes, hasNodes := content["nodes"].([]interface{})
+    if text, _ := content["text"].(string); text != "" && hasNodes {
+        errs = append(errs, models.FieldError{Field: "nodes", Message: "cannot be used together with text"})
+    }
+
+    for i, item := range nodes {
+        node := item.(map[string]interface{})
+        isLink := false
+        if marks, ok := node["marks"].([]interface{}); ok {
+            for _, mark := range marks {
+                if mark == "link" {
+                    isLink = true
+                }
+            }
+        }
+
+        href, _ := node["href"].(string)
+        hasHref := href != ""
+        switch {
+        case isLink && !hasHref:
+            errs = append(errs, models.FieldError{Field: fmt.Sprintf("nodes[%d].href", i), Message: "is required for link mark"})
+        case !isLink && hasHref:
+            errs = append(errs, models.FieldError{Field: fmt.Sprintf("nodes[%d].href", i), Message: "is allowed only with link mark"})
+        }
+    }
+
+    return errs
+}
+
+// checkFormulaBlock разбирает формулу и указывает место ошибки (номер символа от 0)
+func checkFormulaBlock(content map[string]interface{}) []models.FieldError {
+    _, err := parseFormula(content["latex"].(string))
+
+    var formulaErr *FormulaError
+    if !errors.As(err, &formulaErr) {
+        return nil
+    }
+    position := formulaErr.Position
+    return []models.FieldError{{Field: "latex", Message: formulaErr.Message, Position: &position, Length: formulaErr.Length}}
+}
+
+// checkQuizBlock проверяет поля ответа для типа вопроса, уникальность id
+// и ссылки правильных ответов на варианты
+func checkQuizBlock(content map[string]interface{}) []models.FieldError {
+    var errs []models.FieldError
+
+    questionIDs := make(map[string]bool)
+    for i, item := range content["questions"].([]interface{}) {
+        question := item.(map[string]interface{})
+        path := fmt.Sprintf("questions[%d]", i)
+
+        id := question["id"].(string)
+        if questionIDs[id] {
+            errs = append(errs, models.FieldError{Field: path + ".id", Message: "must be unique"})
+        }
+        questionIDs[id] = true
+
+        questionType := question["type"].(string)
+        var fieldErrs []models.FieldError
+        for _, name := range []string{"options", "correct", "answer", "tolerance", "answers"} {
+            needed, known := quizQuestionFields[questionType][name]
+            if !known {
+                continue
+            }
+            _, present := question[name]
+            present = present && question[name] != nil
+            switch {
+            case needed && !present:
+                fieldErrs = append(fieldErrs, models.FieldError{Field: path + "." + name, Message: "is required for " + questionType + " question"})
+            case !needed && present:
+                fieldErrs = append(fieldErrs, models.FieldError{Field: path + "." + name, Message: "is not allowed for " + questionType + " question"})
+            }
+        }
+        errs = append(errs, fieldErrs...)
+        if len(fieldErrs) > 0 || (questionType != models.QuestionTypeSingle && questionType != models.QuestionTypeMultiple) {
+            continue
+        }
+
+        optionIDs := make(map[string]bool)
+        for j, option := range question["options"].([]interface{}) {
+            optionID := option.(map[string]interface{})["id"].(string)
+            if optionIDs[optionID] {
+                errs = append(errs, models.FieldError{Field: fmt.Sprintf("%s.options[%d].id", path, j), Message: "must be unique"})
+            }
+            optionIDs[optionID] = true
+        }
+
+        correct := question["correct"].([]interface{})
+        seen := make(map[string]bool)
+        for j, value := range correct {
+            optionID := value.(string)
+            switch {
+            case !optionIDs[optionID]:
+                errs = append(errs, models.FieldError{Field: fmt.Sprintf("%s.correct[%d]", path, j), Message: "must reference an option id"})
+            case seen[optionID]:
+                errs = append(errs, models.FieldError{Field: fmt.Sprintf("%s.correct[%d]", path, j), Message: "must be unique"})
+            }
+            seen[optionID] = true
+        }
+        if questionType == models.QuestionTypeSingle && len(correct) != 1 {
+            errs = append(errs, models.FieldError{Field: path + ".correct", Message: "must contain exactly one option for single choice"})
+        }
+    }
+
+    return errs
+}
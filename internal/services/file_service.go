@@ -4,6 +4,7 @@ import (
     "context"
     "crypto/rand"
     "encoding/hex"
+    "errors"
     "fmt"
     "io"
     "mime/multipart"
@@ -108,6 +109,18 @@ func (s *FileService) uploadVideoLocal(ctx context.Context, file io.Reader, file
     }, nil
 }
 
+// IsStoredURL проверяет, что url указывает на файл, загруженный в наше хранилище
+// в каталог kind (images, videos): облачное или локальное
+func (s *FileService) IsStoredURL(fileURL, kind string) bool {
+    if strings.Contains(fileURL, "..") {
+        return false
+    }
+    if strings.HasPrefix(fileURL, "/uploads/"+kind+"/") {
+        return true
+    }
+    return s.storageService != nil && strings.HasPrefix(fileURL, s.storageService.cdnURL+"/"+kind+"/")
+}
+
 // SaveAvatar сохраняет аватар и возвращает URL
 func (s *FileService) SaveAvatar(userID int, fileHeader *multipart.FileHeader) (string, error) {
     // Открываем файл
@@ -195,11 +208,27 @@ func (s *FileService) saveAvatarLocal(userID int, fileHeader *multipart.FileHead
 }
 // DeleteAvatar удаляет старый аватар (существующий метод)
 func (s *FileService) DeleteAvatar(avatarURL string) error {
-    if avatarURL == "" {
+    return s.DeleteStoredURL(context.Background(), avatarURL)
+}
+
+// DeleteStoredURL удаляет файл нашего хранилища (облачного или локального) по его URL.
+// Ссылки на чужие ресурсы и уже удаленные файлы пропускаются
+func (s *FileService) DeleteStoredURL(ctx context.Context, fileURL string) error {
+    if fileURL == "" || strings.Contains(fileURL, "..") {
         return nil
     }
 
-    // Убираем первый слэш для создания правильного пути
-    filePath := strings.TrimPrefix(avatarURL, "/")
-    return os.Remove(filePath)
+    if strings.HasPrefix(fileURL, "/uploads/") {
+        filePath := filepath.Join(s.uploadPath, filepath.FromSlash(strings.TrimPrefix(fileURL, "/uploads/")))
+        err := os.Remove(filePath)
+        if err != nil && !errors.Is(err, os.ErrNotExist) {
+            return err
+        }
+        return nil
+    }
+
+    if s.storageService != nil && strings.HasPrefix(fileURL, s.storageService.cdnURL+"/") {
+        return s.storageService.DeleteFile(ctx, strings.TrimPrefix(fileURL, s.storageService.cdnURL+"/"))
+    }
+    return nil
 }
\ No newline at end of file
@@ -0,0 +1,225 @@
+package services
+
+import (
+    "encoding/xml"
+    "fmt"
+    "strings"
+)
+
+// renderMathML выводит дерево формулы в MathML. Исходный LaTeX сохраняется
+// в аннотации, чтобы формулу можно было скопировать и отредактировать.
+func renderMathML(root *mathRow, latex string, display bool) string {
+    var b strings.Builder
+
+    mode := "inline"
+    if display {
+        mode = "block"
+    }
+    fmt.Fprintf(&b, `<math xmlns="http://www.w3.org/1998/Math/MathML" display="%s"><semantics>`, mode)
+    writeMathML(&b, root, "")
+    b.WriteString(`<annotation encoding="application/x-tex">`)
+    b.WriteString(xmlEscape(latex))
+    b.WriteString(`</annotation></semantics></math>`)
+
+    return b.String()
+}
+
+// writeMathML выводит узел. variant - шрифт, заданный \mathbf и т.п.
+func writeMathML(b *strings.Builder, node mathNode, variant string) {
+    switch n := node.(type) {
+    case *mathRow:
+        b.WriteString("<mrow>")
+        for _, child := range n.children {
+            writeMathML(b, child, variant)
+        }
+        b.WriteString("</mrow>")
+
+    case *mathIdent:
+        v := n.variant
+        if variant != "" {
+            v = variant
+        }
+        // Многобуквенные идентификаторы и так выводятся прямым шрифтом
+        if v == "normal" && len([]rune(n.text)) > 1 {
+            v = ""
+        }
+        writeToken(b, "mi", n.text, v)
+        if n.fn {
+            b.WriteString("<mo>&#x2061;</mo>")
+        }
+
+    case *mathNumber:
+        if variant == "italic" || variant == "normal" {
+            variant = ""
+        }
+        writeToken(b, "mn", n.text, variant)
+
+    case *mathOp:
+        attrs := ""
+        switch {
+        case n.class == "open" || n.class == "close":
+            attrs = ` stretchy="false"`
+        case n.class == "op" && !n.limits:
+            attrs = ` movablelimits="false"`
+        }
+        fmt.Fprintf(b, "<mo%s>%s</mo>", attrs, xmlEscape(n.text))
+
+    case *mathText:
+        v := n.variant
+        if v == "normal" {
+            v = ""
+        }
+        writeToken(b, "mtext", n.text, v)
+
+    case *mathSpace:
+        fmt.Fprintf(b, `<mspace width="%.4gem"/>`, n.width)
+
+    case *mathScripts:
+        op, ok := n.base.(*mathOp)
+        limits := ok && op.class == "op" && op.limits
+
+        var tag string
+        switch {
+        case limits && n.sub != nil && n.sup != nil:
+            tag = "munderover"
+        case limits && n.sub != nil:
+            tag = "munder"
+        case limits:
+            tag = "mover"
+        case n.sub != nil && n.sup != nil:
+            tag = "msubsup"
+        case n.sub != nil:
+            tag = "msub"
+        default:
+            tag = "msup"
+        }
+        fmt.Fprintf(b, "<%s>", tag)
+        writeMathML(b, n.base, variant)
+        if n.sub != nil {
+            writeMathML(b, n.sub, variant)
+        }
+        if n.sup != nil {
+            writeMathML(b, n.sup, variant)
+        }
+        fmt.Fprintf(b, "</%s>", tag)
+
+    case *mathFrac:
+        switch n.style {
+        case "display":
+            b.WriteString(`<mstyle displaystyle="true">`)
+        case "text":
+            b.WriteString(`<mstyle displaystyle="false">`)
+        }
+        if n.noLine {
+            b.WriteString(`<mfrac linethickness="0">`)
+        } else {
+            b.WriteString("<mfrac>")
+        }
+        writeMathML(b, n.num, variant)
+        writeMathML(b, n.den, variant)
+        b.WriteString("</mfrac>")
+        if n.style != "" {
+            b.WriteString("</mstyle>")
+        }
+
+    case *mathSqrt:
+        if n.index == nil {
+            b.WriteString("<msqrt>")
+            writeMathML(b, n.body, variant)
+            b.WriteString("</msqrt>")
+            return
+        }
+        b.WriteString("<mroot>")
+        writeMathML(b, n.body, variant)
+        writeMathML(b, n.index, variant)
+        b.WriteString("</mroot>")
+
+    case *mathFenced:
+        b.WriteString("<mrow>")
+        writeFence(b, n.open)
+        writeMathML(b, n.body, variant)
+        writeFence(b, n.close)
+        b.WriteString("</mrow>")
+
+    case *mathAccent:
+        switch n.accent {
+        case "under":
+            b.WriteString(`<munder accentunder="true">`)
+            writeMathML(b, n.body, variant)
+            b.WriteString(`<mo>_</mo></munder>`)
+        case "over":
+            b.WriteString(`<mover accent="true">`)
+            writeMathML(b, n.body, variant)
+            b.WriteString(`<mo>‾</mo></mover>`)
+        case "vec":
+            b.WriteString(`<mover accent="true">`)
+            writeMathML(b, n.body, variant)
+            b.WriteString(`<mo>→</mo></mover>`)
+        default:
+            b.WriteString(`<mover accent="true">`)
+            writeMathML(b, n.body, variant)
+            fmt.Fprintf(b, `<mo stretchy="false">%s</mo></mover>`, xmlEscape(n.accent))
+        }
+
+    case *mathStyled:
+        writeMathML(b, n.body, n.variant)
+
+    case *mathTable:
+        b.WriteString("<mrow>")
+        writeFence(b, n.open)
+
+        columns := 0
+        for _, row := range n.rows {
+            if len(row) > columns {
+                columns = len(row)
+            }
+        }
+        align := make([]string, columns)
+        for i := range align {
+            switch {
+            case n.align == "left":
+                align[i] = "left"
+            case n.align == "rl" && i%2 == 0:
+                align[i] = "right"
+            case n.align == "rl":
+                align[i] = "left"
+            default:
+                align[i] = "center"
+            }
+        }
+        fmt.Fprintf(b, `<mtable columnalign="%s">`, strings.Join(align, " "))
+        for _, row := range n.rows {
+            b.WriteString("<mtr>")
+            for _, cell := range row {
+                b.WriteString("<mtd>")
+                writeMathML(b, cell, variant)
+                b.WriteString("</mtd>")
+            }
+            b.WriteString("</mtr>")
+        }
+        b.WriteString("</mtable>")
+
+        writeFence(b, n.close)
+        b.WriteString("</mrow>")
+    }
+}
+
+func writeToken(b *strings.Builder, tag, text, variant string) {
+    if variant != "" {
+        fmt.Fprintf(b, `<%s mathvariant="%s">%s</%s>`, tag, variant, xmlEscape(text), tag)
+        return
+    }
+    fmt.Fprintf(b, "<%s>%s</%s>", tag, xmlEscape(text), tag)
+}
+
+func writeFence(b *strings.Builder, delimiter string) {
+    if delimiter != "" {
+        fmt.Fprintf(b, `<mo fence="true" stretchy="true">%s</mo>`, xmlEscape(delimiter))
+    }
+}
+
+func xmlEscape(s string) string {
+    var b strings.Builder
+    xml.EscapeText(&b, []byte(s))
+    return b.String()
+}
@@ -0,0 +1,806 @@
+package services
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+    "unicode"
+)
+
+var ErrInvalidFormula = errors.New("invalid formula")
+
+// FormulaError - ошибка разбора формулы. Position и Length задаются
+// в символах исходной строки (с 0), чтобы редактор мог подсветить место ошибки.
+type FormulaError struct {
+    Position int
+    Length   int
+    Message  string
+}
+
+func (e *FormulaError) Error() string {
+    return fmt.Sprintf("%s at position %d", e.Message, e.Position)
+}
+
+func (e *FormulaError) Is(target error) bool {
+    return target == ErrInvalidFormula
+}
+
+// Дерево формулы. Одно дерево используется для вывода в MathML и SVG.
+type mathNode interface{}
+
+type mathRow struct {
+    children []mathNode
+}
+
+type mathIdent struct {
+    text    string
+    variant string // "" - курсив для одной буквы, normal, bold, ...
+    fn      bool   // имя функции: sin, log, ...
+}
+
+type mathNumber struct {
+    text string
+}
+
+// mathOp - оператор или знак. class определяет отступы как в TeX:
+// ord, bin, rel, open, close, punct, op (большие операторы и lim)
+type mathOp struct {
+    text   string
+    class  string
+    large  bool
+    limits bool // индексы над и под знаком в выключной формуле
+}
+
+type mathText struct {
+    text    string
+    variant string
+}
+
+type mathScripts struct {
+    base, sub, sup mathNode
+}
+
+type mathFrac struct {
+    num, den mathNode
+    style    string // "", display (\dfrac), text (\tfrac)
+    noLine   bool   // \binom
+}
+
+type mathSqrt struct {
+    index, body mathNode
+}
+
+type mathFenced struct {
+    open, close string // "" - пустой разделитель \left.
+    body        mathNode
+}
+
+type mathAccent struct {
+    body   mathNode
+    accent string // over, under, vec или символ акцента
+}
+
+type mathStyled struct {
+    variant string
+    body    mathNode
+}
+
+type mathSpace struct {
+    width float64 // в em
+}
+
+type mathTable struct {
+    rows        [][]mathNode
+    align       string // center, left, rl (чередование по правому и левому краю)
+    open, close string
+}
+
+const maxFormulaDepth = 50
+
+var greekLetters = map[string]string{
+    "alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ϵ", "varepsilon": "ε",
+    "zeta": "ζ", "eta": "η", "theta": "θ", "vartheta": "ϑ", "iota": "ι", "kappa": "κ",
+    "lambda": "λ", "mu": "μ", "nu": "ν", "xi": "ξ", "omicron": "ο", "pi": "π", "varpi": "ϖ",
+    "rho": "ρ", "varrho": "ϱ", "sigma": "σ", "varsigma": "ς", "tau": "τ", "upsilon": "υ",
+    "phi": "ϕ", "varphi": "φ", "chi": "χ", "psi": "ψ", "omega": "ω",
+    "Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ", "Xi": "Ξ", "Pi": "Π",
+    "Sigma": "Σ", "Upsilon": "Υ", "Phi": "Φ", "Psi": "Ψ", "Omega": "Ω",
+}
+
+type latexSymbol struct {
+    text  string
+    class string
+}
+
+var latexSymbols = map[string]latexSymbol{
+    // Отношения
+    "le": {"≤", "rel"}, "leq": {"≤", "rel"}, "ge": {"≥", "rel"}, "geq": {"≥", "rel"},
+    "leqslant": {"⩽", "rel"}, "geqslant": {"⩾", "rel"}, "ne": {"≠", "rel"}, "neq": {"≠", "rel"},
+    "approx": {"≈", "rel"}, "equiv": {"≡", "rel"}, "sim": {"∼", "rel"}, "simeq": {"≃", "rel"},
+    "cong": {"≅", "rel"}, "propto": {"∝", "rel"}, "ll": {"≪", "rel"}, "gg": {"≫", "rel"},
+    "to": {"→", "rel"}, "rightarrow": {"→", "rel"}, "leftarrow": {"←", "rel"}, "gets": {"←", "rel"},
+    "leftrightarrow": {"↔", "rel"}, "Rightarrow": {"⇒", "rel"}, "Leftarrow": {"⇐", "rel"},
+    "Leftrightarrow": {"⇔", "rel"}, "implies": {"⟹", "rel"}, "iff": {"⟺", "rel"}, "mapsto": {"↦", "rel"},
+    "uparrow": {"↑", "rel"}, "downarrow": {"↓", "rel"},
+    "in": {"∈", "rel"}, "notin": {"∉", "rel"}, "ni": {"∋", "rel"}, "subset": {"⊂", "rel"},
+    "subseteq": {"⊆", "rel"}, "supset": {"⊃", "rel"}, "supseteq": {"⊇", "rel"},
+    "perp": {"⊥", "rel"}, "parallel": {"∥", "rel"}, "mid": {"∣", "rel"},
+    // Бинарные операции
+    "pm": {"±", "bin"}, "mp": {"∓", "bin"}, "times": {"×", "bin"}, "div": {"÷", "bin"},
+    "cdot": {"⋅", "bin"}, "ast": {"∗", "bin"}, "star": {"⋆", "bin"}, "circ": {"∘", "bin"},
+    "bullet": {"∙", "bin"}, "cup": {"∪", "bin"}, "cap": {"∩", "bin"}, "setminus": {"∖", "bin"},
+    "oplus": {"⊕", "bin"}, "otimes": {"⊗", "bin"}, "wedge": {"∧", "bin"}, "land": {"∧", "bin"},
+    "vee": {"∨", "bin"}, "lor": {"∨", "bin"},
+    // Обычные символы
+    "infty": {"∞", "ord"}, "partial": {"∂", "ord"}, "nabla": {"∇", "ord"}, "forall": {"∀", "ord"},
+    "exists": {"∃", "ord"}, "nexists": {"∄", "ord"}, "emptyset": {"∅", "ord"}, "varnothing": {"∅", "ord"},
+    "neg": {"¬", "ord"}, "lnot": {"¬", "ord"}, "angle": {"∠", "ord"}, "triangle": {"△", "ord"},
+    "degree": {"°", "ord"}, "prime": {"′", "ord"}, "ldots": {"…", "ord"}, "dots": {"…", "ord"},
+    "cdots": {"⋯", "ord"}, "vdots": {"⋮", "ord"}, "ddots": {"⋱", "ord"}, "hbar": {"ℏ", "ord"},
+    "ell": {"ℓ", "ord"}, "Re": {"ℜ", "ord"}, "Im": {"ℑ", "ord"}, "aleph": {"ℵ", "ord"},
+    "therefore": {"∴", "ord"}, "because": {"∵", "ord"}, "backslash": {"∖", "ord"}, "square": {"□", "ord"},
+    "vert": {"|", "ord"}, "Vert": {"‖", "ord"}, "|": {"‖", "ord"},
+    // Скобки
+    "langle": {"⟨", "open"}, "rangle": {"⟩", "close"}, "lfloor": {"⌊", "open"}, "rfloor": {"⌋", "close"},
+    "lceil": {"⌈", "open"}, "rceil": {"⌉", "close"}, "lbrace": {"{", "open"}, "rbrace": {"}", "close"},
+    "{": {"{", "open"}, "}": {"}", "close"},
+    // Экранированные символы
+    "%": {"%", "ord"}, "$": {"$", "ord"}, "&": {"&", "ord"}, "#": {"#", "ord"}, "_": {"_", "ord"},
+    "colon": {":", "punct"},
+}
+
+// largeOperators - большие операторы, true - индексы над и под знаком
+var largeOperators = map[string]struct {
+    text   string
+    limits bool
+}{
+    "sum": {"∑", true}, "prod": {"∏", true}, "coprod": {"∐", true},
+    "bigcup": {"⋃", true}, "bigcap": {"⋂", true},
+    "int": {"∫", false}, "iint": {"∬", false}, "iiint": {"∭", false}, "oint": {"∮", false},
+}
+
+// functionNames - имена функций прямым шрифтом, true - индексы под именем (lim, max)
+var functionNames = map[string]bool{
+    "sin": false, "cos": false, "tan": false, "cot": false, "sec": false, "csc": false,
+    "tg": false, "ctg": false, "arcsin": false, "arccos": false, "arctan": false,
+    "arctg": false, "arcctg": false, "sinh": false, "cosh": false, "tanh": false, "coth": false,
+    "sh": false, "ch": false, "th": false, "log": false, "ln": false, "lg": false, "exp": false,
+    "arg": false, "deg": false, "dim": false, "hom": false, "ker": false,
+    "lim": true, "limsup": true, "liminf": true, "max": true, "min": true, "sup": true,
+    "inf": true, "det": true, "gcd": true, "Pr": true,
+}
+
+var latexSpaces = map[string]float64{
+    ",": 0.1667, "thinspace": 0.1667, ":": 0.2222, ">": 0.2222, "medspace": 0.2222,
+    ";": 0.2778, "thickspace": 0.2778, "!": -0.1667, " ": 0.3333,
+    "quad": 1, "qquad": 2,
+}
+
+var fontCommands = map[string]string{
+    "mathrm": "normal", "mathbf": "bold", "mathit": "italic", "mathbb": "double-struck",
+    "mathcal": "script", "mathsf": "sans-serif", "mathtt": "monospace", "boldsymbol": "bold-italic",
+    "operatorname": "normal",
+}
+
+var textCommands = map[string]string{
+    "text": "normal", "textrm": "normal", "mbox": "normal", "textit": "italic", "textbf": "bold",
+}
+
+var accentCommands = map[string]string{
+    "hat": "ˆ", "widehat": "ˆ", "check": "ˇ", "tilde": "˜", "widetilde": "˜", "dot": "˙",
+    "ddot": "¨", "acute": "ˊ", "grave": "ˋ", "breve": "˘",
+    "bar": "over", "overline": "over", "underline": "under", "vec": "vec", "overrightarrow": "vec",
+}
+
+// delimiters - разделители после \left и \right
+var delimiters = map[string]string{
+    "(": "(", ")": ")", "[": "[", "]": "]", "|": "|", "/": "/", ".": "",
+    `\{`: "{", `\}`: "}", `\|`: "‖", `\lbrace`: "{", `\rbrace`: "}", `\vert`: "|", `\Vert`: "‖",
+    `\langle`: "⟨", `\rangle`: "⟩", `\lfloor`: "⌊", `\rfloor`: "⌋", `\lceil`: "⌈", `\rceil`: "⌉",
+}
+
+type latexEnvironment struct {
+    align       string
+    open, close string
+}
+
+var latexEnvironments = map[string]latexEnvironment{
+    "matrix":   {"center", "", ""},
+    "pmatrix":  {"center", "(", ")"},
+    "bmatrix":  {"center", "[", "]"},
+    "Bmatrix":  {"center", "{", "}"},
+    "vmatrix":  {"center", "|", "|"},
+    "Vmatrix":  {"center", "‖", "‖"},
+    "cases":    {"left", "{", ""},
+    "aligned":  {"rl", "", ""},
+    "gathered": {"center", "", ""},
+}
+
+// operatorChars - одиночные символы-операторы и их класс
+var operatorChars = map[rune]latexSymbol{
+    '+': {"+", "bin"}, '-': {"−", "bin"}, '*': {"∗", "bin"}, '/': {"/", "ord"},
+    '=': {"=", "rel"}, '<': {"<", "rel"}, '>': {">", "rel"}, ':': {":", "rel"},
+    ',': {",", "punct"}, ';': {";", "punct"}, '!': {"!", "close"}, '?': {"?", "close"},
+    '(': {"(", "open"}, ')': {")", "close"}, '[': {"[", "open"}, ']': {"]", "close"},
+    '|': {"|", "ord"}, '.': {".", "ord"}, '\'': {"′", "ord"}, '@': {"@", "ord"},
+}
+
+// formulaParser разбирает поддерживаемое подмножество LaTeX (математический режим)
+type formulaParser struct {
+    src   []rune
+    pos   int
+    depth int
+}
+
+// parseFormula разбирает формулу и возвращает ее дерево или *FormulaError
+func parseFormula(latex string) (*mathRow, error) {
+    p := &formulaParser{src: []rune(latex)}
+
+    row, err := p.parseRow(0)
+    if err != nil {
+        return nil, err
+    }
+    if p.pos < len(p.src) {
+        return nil, p.unexpected()
+    }
+    if len(row.children) == 0 {
+        return nil, &FormulaError{Position: 0, Length: len(p.src), Message: "formula is empty"}
+    }
+    return row, nil
+}
+
+func (p *formulaParser) fail(position, length int, format string, args ...interface{}) error {
+    return &FormulaError{Position: position, Length: length, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *formulaParser) skipSpaces() {
+    for p.pos < len(p.src) && unicode.IsSpace(p.src[p.pos]) {
+        p.pos++
+    }
+}
+
+// peekCommand возвращает имя команды в текущей позиции (без \) и ее длину в символах
+func (p *formulaParser) peekCommand() (string, int) {
+    if p.pos >= len(p.src) || p.src[p.pos] != '\\' {
+        return "", 0
+    }
+    if p.pos+1 >= len(p.src) {
+        return "", 1
+    }
+    end := p.pos + 1
+    for end < len(p.src) && isASCIILetter(p.src[end]) {
+        end++
+    }
+    if end == p.pos+1 {
+        end++
+    }
+    return string(p.src[p.pos+1 : end]), end - p.pos
+}
+
+// atTerminator сообщает, что текущая строка формулы закончилась:
+// закрывающая скобка, разделитель ячеек или конец окружения
+func (p *formulaParser) atTerminator(closer rune) bool {
+    if p.pos >= len(p.src) {
+        return true
+    }
+    switch c := p.src[p.pos]; {
+    case c == '}' || c == '&' || (closer != 0 && c == closer):
+        return true
+    case c == '\\':
+        name, _ := p.peekCommand()
+        return name == "\\" || name == "right" || name == "end"
+    }
+    return false
+}
+
+func (p *formulaParser) unexpected() error {
+    if p.pos >= len(p.src) {
+        return p.fail(p.pos, 0, "unexpected end of formula")
+    }
+    switch p.src[p.pos] {
+    case '}':
+        return p.fail(p.pos, 1, "unexpected }")
+    case '&':
+        return p.fail(p.pos, 1, "& is allowed only inside environments")
+    case '\\':
+        name, n := p.peekCommand()
+        switch name {
+        case "\\":
+            return p.fail(p.pos, n, `\\ is allowed only inside environments`)
+        case "right":
+            return p.fail(p.pos, n, `\right without matching \left`)
+        case "end":
+            return p.fail(p.pos, n, `\end without matching \begin`)
+        }
+    }
+    return p.fail(p.pos, 1, "unexpected %q", p.src[p.pos])
+}
+
+// parseRow разбирает последовательность элементов до конца строки формулы.
+// closer - дополнительный закрывающий символ (] для \sqrt[n]).
+func (p *formulaParser) parseRow(closer rune) (*mathRow, error) {
+    row := &mathRow{}
+    for {
+        p.skipSpaces()
+        if p.atTerminator(closer) {
+            return row, nil
+        }
+        node, err := p.parseScripted()
+        if err != nil {
+            return nil, err
+        }
+        row.children = append(row.children, node)
+    }
+}
+
+// parseScripted разбирает элемент с индексами x_1^2
+func (p *formulaParser) parseScripted() (mathNode, error) {
+    var base mathNode
+    if c := p.src[p.pos]; c != '^' && c != '_' {
+        var err error
+        if base, err = p.parseAtom(); err != nil {
+            return nil, err
+        }
+        if err := p.parseLimits(base); err != nil {
+            return nil, err
+        }
+    }
+
+    var sub, sup mathNode
+    for {
+        p.skipSpaces()
+        if p.pos >= len(p.src) || (p.src[p.pos] != '^' && p.src[p.pos] != '_') {
+            break
+        }
+        start, c := p.pos, p.src[p.pos]
+        p.pos++
+        arg, err := p.parseArgument(string(c), start)
+        if err != nil {
+            return nil, err
+        }
+        if c == '^' {
+            if sup != nil {
+                return nil, p.fail(start, 1, "double superscript")
+            }
+            sup = arg
+        } else {
+            if sub != nil {
+                return nil, p.fail(start, 1, "double subscript")
+            }
+            sub = arg
+        }
+    }
+
+    if sub == nil && sup == nil {
+        return base, nil
+    }
+    if base == nil {
+        base = &mathRow{}
+    }
+    return &mathScripts{base: base, sub: sub, sup: sup}, nil
+}
+
+// parseLimits обрабатывает \limits и \nolimits после большого оператора
+func (p *formulaParser) parseLimits(base mathNode) error {
+    for {
+        p.skipSpaces()
+        name, n := p.peekCommand()
+        if name != "limits" && name != "nolimits" {
+            return nil
+        }
+        op, ok := base.(*mathOp)
+        if !ok || op.class != "op" {
+            return p.fail(p.pos, n, `\%s is allowed only after a large operator`, name)
+        }
+        op.limits = name == "limits"
+        p.pos += n
+    }
+}
+
+// parseArgument разбирает обязательный аргумент команды: группу {...},
+// команду или один символ (как в TeX: x^23 - это x^2 и 3)
+func (p *formulaParser) parseArgument(owner string, ownerPos int) (mathNode, error) {
+    p.skipSpaces()
+    if p.atTerminator(0) || p.src[p.pos] == '^' || p.src[p.pos] == '_' {
+        return nil, p.fail(ownerPos, p.pos-ownerPos, "missing argument for %s", owner)
+    }
+
+    c := p.src[p.pos]
+    switch {
+    case c == '{' || c == '\\':
+        return p.parseAtom()
+    case unicode.IsDigit(c):
+        p.pos++
+        return &mathNumber{text: string(c)}, nil
+    case unicode.IsLetter(c):
+        p.pos++
+        return &mathIdent{text: string(c)}, nil
+    }
+    return p.parseAtom()
+}
+
+func (p *formulaParser) parseGroup() (*mathRow, error) {
+    start := p.pos
+    p.pos++
+    if err := p.enter(start); err != nil {
+        return nil, err
+    }
+    defer p.leave()
+
+    row, err := p.parseRow(0)
+    if err != nil {
+        return nil, err
+    }
+    if p.pos >= len(p.src) {
+        return nil, p.fail(start, 1, "missing closing }")
+    }
+    if p.src[p.pos] != '}' {
+        return nil, p.unexpected()
+    }
+    p.pos++
+    return row, nil
+}
+
+func (p *formulaParser) enter(position int) error {
+    p.depth++
+    if p.depth > maxFormulaDepth {
+        return p.fail(position, 1, "formula is nested too deeply")
+    }
+    return nil
+}
+
+func (p *formulaParser) leave() {
+    p.depth--
+}
+
+func (p *formulaParser) parseAtom() (mathNode, error) {
+    c := p.src[p.pos]
+    switch {
+    case c == '{':
+        return p.parseGroup()
+    case c == '\\':
+        return p.parseCommand()
+    case unicode.IsDigit(c) || (c == '.' && p.pos+1 < len(p.src) && unicode.IsDigit(p.src[p.pos+1])):
+        start := p.pos
+        for p.pos < len(p.src) && unicode.IsDigit(p.src[p.pos]) {
+            p.pos++
+        }
+        if p.pos+1 < len(p.src) && p.src[p.pos] == '.' && unicode.IsDigit(p.src[p.pos+1]) {
+            p.pos++
+            for p.pos < len(p.src) && unicode.IsDigit(p.src[p.pos]) {
+                p.pos++
+            }
+        }
+        return &mathNumber{text: string(p.src[start:p.pos])}, nil
+    case unicode.IsLetter(c):
+        p.pos++
+        return &mathIdent{text: string(c)}, nil
+    case c == '~':
+        p.pos++
+        return &mathSpace{width: latexSpaces[" "]}, nil
+    case c == '%':
+        return nil, p.fail(p.pos, 1, `unescaped %% (comments are not allowed, use \%%)`)
+    case c == '$':
+        return nil, p.fail(p.pos, 1, "$ is not allowed: formula is already in math mode")
+    case c == '#':
+        return nil, p.fail(p.pos, 1, `unescaped # (use \#)`)
+    }
+
+    if op, ok := operatorChars[c]; ok {
+        p.pos++
+        return &mathOp{text: op.text, class: op.class}, nil
+    }
+    if unicode.IsPrint(c) {
+        p.pos++
+        return &mathOp{text: string(c), class: "ord"}, nil
+    }
+    return nil, p.fail(p.pos, 1, "unexpected character %q", c)
+}
+
+func (p *formulaParser) parseCommand() (mathNode, error) {
+    start := p.pos
+    name, n := p.peekCommand()
+    if name == "" {
+        return nil, p.fail(start, 1, `incomplete command \`)
+    }
+    p.pos += n
+    command := `\` + name
+
+    if letter, ok := greekLetters[name]; ok {
+        variant := ""
+        if unicode.IsUpper([]rune(name)[0]) {
+            variant = "normal"
+        }
+        return &mathIdent{text: letter, variant: variant}, nil
+    }
+    if symbol, ok := latexSymbols[name]; ok {
+        return &mathOp{text: symbol.text, class: symbol.class}, nil
+    }
+    if op, ok := largeOperators[name]; ok {
+        return &mathOp{text: op.text, class: "op", large: true, limits: op.limits}, nil
+    }
+    if limits, ok := functionNames[name]; ok {
+        if limits {
+            text := name
+            if name == "limsup" || name == "liminf" {
+                text = "lim " + name[3:]
+            }
+            return &mathOp{text: text, class: "op", limits: true}, nil
+        }
+        return &mathIdent{text: name, variant: "normal", fn: true}, nil
+    }
+    if width, ok := latexSpaces[name]; ok {
+        return &mathSpace{width: width}, nil
+    }
+    if variant, ok := textCommands[name]; ok {
+        text, err := p.parseTextArgument(command, start)
+        if err != nil {
+            return nil, err
+        }
+        return &mathText{text: text, variant: variant}, nil
+    }
+    if variant, ok := fontCommands[name]; ok {
+        arg, err := p.parseArgument(command, start)
+        if err != nil {
+            return nil, err
+        }
+        if name == "operatorname" {
+            return &mathStyled{variant: variant, body: &mathRow{children: []mathNode{arg}}}, nil
+        }
+        return &mathStyled{variant: variant, body: arg}, nil
+    }
+    if accent, ok := accentCommands[name]; ok {
+        arg, err := p.parseArgument(command, start)
+        if err != nil {
+            return nil, err
+        }
+        return &mathAccent{body: arg, accent: accent}, nil
+    }
+
+    switch name {
+    case "frac", "dfrac", "tfrac", "binom":
+        num, err := p.parseArgument(command, start)
+        if err != nil {
+            return nil, err
+        }
+        den, err := p.parseArgument(command, start)
+        if err != nil {
+            return nil, err
+        }
+        frac := &mathFrac{num: num, den: den}
+        switch name {
+        case "dfrac":
+            frac.style = "display"
+        case "tfrac":
+            frac.style = "text"
+        case "binom":
+            frac.noLine = true
+            return &mathFenced{open: "(", close: ")", body: frac}, nil
+        }
+        return frac, nil
+
+    case "sqrt":
+        sqrt := &mathSqrt{}
+        p.skipSpaces()
+        if p.pos < len(p.src) && p.src[p.pos] == '[' {
+            open := p.pos
+            p.pos++
+            if err := p.enter(open); err != nil {
+                return nil, err
+            }
+            index, err := p.parseRow(']')
+            p.leave()
+            if err != nil {
+                return nil, err
+            }
+            if p.pos >= len(p.src) {
+                return nil, p.fail(open, 1, "missing closing ]")
+            }
+            if p.src[p.pos] != ']' {
+                return nil, p.unexpected()
+            }
+            p.pos++
+            sqrt.index = index
+        }
+        body, err := p.parseArgument(command, start)
+        if err != nil {
+            return nil, err
+        }
+        sqrt.body = body
+        return sqrt, nil
+
+    case "not":
+        p.skipSpaces()
+        operand, err := p.parseArgument(command, start)
+        if err != nil {
+            return nil, err
+        }
+        op, ok := operand.(*mathOp)
+        if !ok || op.class != "rel" {
+            return nil, p.fail(start, p.pos-start, `\not must be followed by a relation`)
+        }
+        op.text += "̸"
+        return op, nil
+
+    case "left":
+        return p.parseFenced(start)
+
+    case "begin":
+        return p.parseEnvironment(start)
+
+    case "limits", "nolimits":
+        return nil, p.fail(start, n, `%s is allowed only after a large operator`, command)
+    }
+
+    return nil, p.fail(start, n, "unknown command %s", command)
+}
+
+// parseTextArgument читает аргумент \text{...} как есть, с пробелами
+func (p *formulaParser) parseTextArgument(command string, start int) (string, error) {
+    p.skipSpaces()
+    if p.pos >= len(p.src) || p.src[p.pos] != '{' {
+        return "", p.fail(start, p.pos-start, "missing argument for %s", command)
+    }
+    open := p.pos
+    p.pos++
+
+    var text []rune
+    nesting := 0
+    for p.pos < len(p.src) {
+        c := p.src[p.pos]
+        switch {
+        case c == '\\':
+            if p.pos+1 < len(p.src) && strings.ContainsRune(`{}%$&#_ `, p.src[p.pos+1]) {
+                text = append(text, p.src[p.pos+1])
+                p.pos += 2
+                continue
+            }
+            _, n := p.peekCommand()
+            return "", p.fail(p.pos, n, "commands are not supported inside %s", command)
+        case c == '{':
+            nesting++
+        case c == '}':
+            if nesting == 0 {
+                p.pos++
+                return string(text), nil
+            }
+            nesting--
+        }
+        text = append(text, c)
+        p.pos++
+    }
+    return "", p.fail(open, 1, "missing closing }")
+}
+
+func (p *formulaParser) parseDelimiter(command string, start int) (string, error) {
+    p.skipSpaces()
+    if p.pos >= len(p.src) {
+        return "", p.fail(start, p.pos-start, "missing delimiter after %s", command)
+    }
+
+    token := string(p.src[p.pos])
+    length := 1
+    if p.src[p.pos] == '\\' {
+        _, length = p.peekCommand()
+        token = string(p.src[p.pos : p.pos+length])
+    }
+    delimiter, ok := delimiters[token]
+    if !ok {
+        return "", p.fail(p.pos, length, "unknown delimiter %s after %s", token, command)
+    }
+    p.pos += length
+    return delimiter, nil
+}
+
+func (p *formulaParser) parseFenced(start int) (mathNode, error) {
+    open, err := p.parseDelimiter(`\left`, start)
+    if err != nil {
+        return nil, err
+    }
+    if err := p.enter(start); err != nil {
+        return nil, err
+    }
+    defer p.leave()
+
+    body, err := p.parseRow(0)
+    if err != nil {
+        return nil, err
+    }
+    name, n := p.peekCommand()
+    if name != "right" {
+        if p.pos >= len(p.src) {
+            return nil, p.fail(start, len(`\left`), `\left without matching \right`)
+        }
+        return nil, p.unexpected()
+    }
+
+    rightPos := p.pos
+    p.pos += n
+    close, err := p.parseDelimiter(`\right`, rightPos)
+    if err != nil {
+        return nil, err
+    }
+    return &mathFenced{open: open, close: close, body: body}, nil
+}
+
+func (p *formulaParser) parseEnvironmentName(command string, start int) (string, int, error) {
+    p.skipSpaces()
+    if p.pos >= len(p.src) || p.src[p.pos] != '{' {
+        return "", 0, p.fail(start, p.pos-start, "missing environment name for %s", command)
+    }
+    nameStart := p.pos
+    end := p.pos + 1
+    for end < len(p.src) && p.src[end] != '}' {
+        end++
+    }
+    if end >= len(p.src) {
+        return "", 0, p.fail(nameStart, 1, "missing closing }")
+    }
+    p.pos = end + 1
+    return string(p.src[nameStart+1 : end]), nameStart, nil
+}
+
+func (p *formulaParser) parseEnvironment(start int) (mathNode, error) {
+    name, namePos, err := p.parseEnvironmentName(`\begin`, start)
+    if err != nil {
+        return nil, err
+    }
+    env, ok := latexEnvironments[name]
+    if !ok {
+        return nil, p.fail(namePos, len([]rune(name))+2, "unknown environment %s", name)
+    }
+    if err := p.enter(start); err != nil {
+        return nil, err
+    }
+    defer p.leave()
+
+    rows := [][]mathNode{{}}
+    for {
+        cell, err := p.parseRow(0)
+        if err != nil {
+            return nil, err
+        }
+        last := len(rows) - 1
+        rows[last] = append(rows[last], cell)
+
+        if p.pos >= len(p.src) {
+            return nil, p.fail(start, p.pos-start, `\begin{%s} without matching \end`, name)
+        }
+        if p.src[p.pos] == '&' {
+            p.pos++
+            continue
+        }
+
+        command, n := p.peekCommand()
+        switch command {
+        case "\\":
+            p.pos += n
+            rows = append(rows, []mathNode{})
+            continue
+        case "end":
+            endPos := p.pos
+            p.pos += n
+            endName, _, err := p.parseEnvironmentName(`\end`, endPos)
+            if err != nil {
+                return nil, err
+            }
+            if endName != name {
+                return nil, p.fail(endPos, p.pos-endPos, `\end{%s} does not match \begin{%s}`, endName, name)
+            }
+        default:
+            return nil, p.unexpected()
+        }
+        break
+    }
+
+    // Перенос строки перед \end не создает пустую строку
+    if last := rows[len(rows)-1]; len(rows) > 1 && len(last) == 1 && len(last[0].(*mathRow).children) == 0 {
+        rows = rows[:len(rows)-1]
+    }
+
+    return &mathTable{rows: rows, align: env.align, open: env.open, close: env.close}, nil
+}
+
+func isASCIILetter(c rune) bool {
+    return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+    "container/list"
+    "sync"
+
+    "paydeya-backend/internal/models"
+)
+
+// FormulaService проверяет и отрисовывает формулы блоков formula.
+// Отрисовка выполняется локально, готовые результаты хранятся в LRU-кэше,
+// ограниченном суммарным размером. Слишком большие результаты не кэшируются,
+// чтобы одна формула не вытесняла весь кэш.
+type FormulaService struct {
+    mu        sync.Mutex
+    maxBytes  int
+    usedBytes int
+    entries   map[string]*list.Element
+    order     *list.List // последние использованные - в начале
+}
+
+type formulaCacheEntry struct {
+    key     string
+    formula *models.RenderedFormula
+    size    int
+}
+
+// maxFormulaCacheShare - какую долю кэша может занять одна формула (1/16)
+const maxFormulaCacheShare = 16
+
+func NewFormulaService(maxBytes int) *FormulaService {
+    if maxBytes <= 0 {
+        maxBytes = 1
+    }
+    return &FormulaService{
+        maxBytes: maxBytes,
+        entries:  make(map[string]*list.Element),
+        order:    list.New(),
+    }
+}
+
+// Validate проверяет формулу. Ошибка разбора - *FormulaError с позицией
+func (s *FormulaService) Validate(latex string) error {
+    _, err := parseFormula(latex)
+    return err
+}
+
+// Render возвращает формулу в MathML и SVG. display - inline или block (по умолчанию)
+func (s *FormulaService) Render(latex, display string) (*models.RenderedFormula, error) {
+    if display != models.FormulaDisplayInline {
+        display = models.FormulaDisplayBlock
+    }
+    key := display + ":" + latex
+
+    if formula, ok := s.cached(key); ok {
+        return formula, nil
+    }
+
+    root, err := parseFormula(latex)
+    if err != nil {
+        return nil, err
+    }
+
+    isBlock := display == models.FormulaDisplayBlock
+    svg, width, height, depth := renderSVG(root, latex, isBlock)
+    formula := &models.RenderedFormula{
+        LaTeX:   latex,
+        Display: display,
+        MathML:  renderMathML(root, latex, isBlock),
+        SVG:     svg,
+        Width:   roundEm(width),
+        Height:  roundEm(height),
+        Depth:   roundEm(depth),
+    }
+
+    s.store(key, formula)
+    return formula, nil
+}
+
+func (s *FormulaService) cached(key string) (*models.RenderedFormula, bool) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    element, ok := s.entries[key]
+    if !ok {
+        return nil, false
+    }
+    s.order.MoveToFront(element)
+    return element.Value.(*formulaCacheEntry).formula, true
+}
+
+func (s *FormulaService) store(key string, formula *models.RenderedFormula) {
+    size := len(key) + len(formula.LaTeX) + len(formula.MathML) + len(formula.SVG)
+    if size > s.maxBytes/maxFormulaCacheShare {
+        return
+    }
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    if element, ok := s.entries[key]; ok {
+        s.order.MoveToFront(element)
+        return
+    }
+    s.entries[key] = s.order.PushFront(&formulaCacheEntry{key: key, formula: formula, size: size})
+    s.usedBytes += size
+
+    for s.usedBytes > s.maxBytes {
+        oldest := s.order.Back()
+        entry := oldest.Value.(*formulaCacheEntry)
+        s.order.Remove(oldest)
+        delete(s.entries, entry.key)
+        s.usedBytes -= entry.size
+    }
+}
+
+func roundEm(value float64) float64 {
+    return float64(svgUnits(value)) / svgUnitsPerEm
+}
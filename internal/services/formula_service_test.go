@@ -0,0 +1,262 @@
+package services
+
+import (
+    "encoding/xml"
+    "errors"
+    "fmt"
+    "io"
+    "strings"
+    "testing"
+
+    "paydeya-backend/internal/models"
+)
+
+// Поддерживаемое подмножество LaTeX разбирается без ошибок и выводится
+// в ожидаемые элементы MathML
+func TestRenderMathML(t *testing.T) {
+    tests := []struct {
+        latex string
+        want  string
+    }{
+        {`x^2`, `<msup><mi>x</mi><mn>2</mn></msup>`},
+        {`a_{i}^{2}`, `<msubsup><mi>a</mi><mrow><mi>i</mi></mrow><mrow><mn>2</mn></mrow></msubsup>`},
+        {`3.14`, `<mn>3.14</mn>`},
+        {`\frac{a}{b}`, `<mfrac><mrow><mi>a</mi></mrow><mrow><mi>b</mi></mrow></mfrac>`},
+        {`\sqrt{x}`, `<msqrt><mrow><mi>x</mi></mrow></msqrt>`},
+        {`\sqrt[3]{x}`, `<mroot><mrow><mi>x</mi></mrow><mrow><mn>3</mn></mrow></mroot>`},
+        {`\alpha+\beta`, `<mi>α</mi><mo>+</mo><mi>β</mi>`},
+        {`a \cdot b`, `<mi>a</mi><mo>⋅</mo><mi>b</mi>`},
+        {`x \le 0`, `<mo>≤</mo>`},
+        {`10\%`, `<mn>10</mn><mo>%</mo>`},
+        {`x'`, `<mi>x</mi><mo>′</mo>`},
+        {`\sum_{i=1}^{n} i`, `<munderover><mo>∑</mo><mrow><mi>i</mi><mo>=</mo><mn>1</mn></mrow><mrow><mi>n</mi></mrow></munderover>`},
+        {`\int_0^1 f(x)\,dx`, `<msubsup><mo movablelimits="false">∫</mo><mn>0</mn><mn>1</mn></msubsup>`},
+        {`\int_0^1 f(x)\,dx`, `<mspace width="0.1667em"/>`},
+        {`\lim_{x \to 0} \sin x`, `<munder><mo>lim</mo><mrow><mi>x</mi><mo>→</mo><mn>0</mn></mrow></munder><mi>sin</mi><mo>&#x2061;</mo>`},
+        {`\left( \frac{1}{2} \right)`, `<mo fence="true" stretchy="true">(</mo>`},
+        {`\mathbf{v}`, `<mi mathvariant="bold">v</mi>`},
+        {`\mathbb{R}`, `<mi mathvariant="double-struck">R</mi>`},
+        {`\vec{v}`, `<mover accent="true"><mrow><mi>v</mi></mrow><mo>→</mo></mover>`},
+        {`\overline{AB}`, `<mo>‾</mo>`},
+        {`\text{если } x > 0`, `<mtext>если </mtext><mi>x</mi><mo>&gt;</mo><mn>0</mn>`},
+        {`\begin{pmatrix} a & b \\ c & d \end{pmatrix}`, `<mtable columnalign="center center"><mtr><mtd><mrow><mi>a</mi></mrow></mtd><mtd><mrow><mi>b</mi></mrow></mtd></mtr><mtr>`},
+        {`\begin{cases} 1 & x>0 \\ 0 & x \le 0 \end{cases}`, `<mtable columnalign="left left">`},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.latex, func(t *testing.T) {
+            root, err := parseFormula(tt.latex)
+            if err != nil {
+                t.Fatalf("parseFormula: %v", err)
+            }
+
+            mathML := renderMathML(root, tt.latex, true)
+            if !strings.Contains(mathML, tt.want) {
+                t.Fatalf("MathML does not contain %s:\n%s", tt.want, mathML)
+            }
+            if !strings.HasPrefix(mathML, `<math xmlns="http://www.w3.org/1998/Math/MathML" display="block">`) {
+                t.Fatalf("unexpected root element: %s", mathML)
+            }
+            checkWellFormedXML(t, mathML)
+        })
+    }
+}
+
+// Исходный LaTeX сохраняется в аннотации экранированным
+func TestRenderMathMLAnnotation(t *testing.T) {
+    latex := `a < b \& c`
+    root, err := parseFormula(latex)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    mathML := renderMathML(root, latex, false)
+    if !strings.Contains(mathML, `display="inline"`) {
+        t.Fatalf("inline formula must have display=inline: %s", mathML)
+    }
+    if !strings.Contains(mathML, `<annotation encoding="application/x-tex">a &lt; b \&amp; c</annotation>`) {
+        t.Fatalf("annotation is not escaped: %s", mathML)
+    }
+}
+
+// Позиция и длина ошибки считаются в символах, а не в байтах
+func TestParseFormulaErrors(t *testing.T) {
+    tests := []struct {
+        latex    string
+        position int
+        length   int
+        message  string
+    }{
+        {`\foo`, 0, 4, `unknown command \foo`},
+        {`x^`, 1, 1, `missing argument for ^`},
+        {`a_`, 1, 1, `missing argument for _`},
+        {`x^2^3`, 3, 1, `double superscript`},
+        {`\frac{a}`, 0, 8, `missing argument for \frac`},
+        {`{x`, 0, 1, `missing closing }`},
+        {`x}`, 1, 1, `unexpected }`},
+        {`\sqrt{`, 5, 1, `missing closing }`},
+        {`\text{abc`, 5, 1, `missing closing }`},
+        {`\left( x`, 0, 5, `\left without matching \right`},
+        {`\right)`, 0, 6, `\right without matching \left`},
+        {`\left\foo x \right)`, 5, 4, `unknown delimiter \foo after \left`},
+        {`\begin{foo} x \end{foo}`, 6, 5, `unknown environment foo`},
+        {`\begin{pmatrix} a \end{bmatrix}`, 18, 13, `\end{bmatrix} does not match \begin{pmatrix}`},
+        {`&`, 0, 1, `& is allowed only inside environments`},
+        {`#`, 0, 1, `unescaped # (use \#)`},
+        // Многобайтовые символы перед ошибкой
+        {`α + \foo`, 4, 4, `unknown command \foo`},
+        {`ё^`, 1, 1, `missing argument for ^`},
+        {`\text{привет} + \bar`, 16, 4, `missing argument for \bar`},
+        {`\text{привет}}`, 13, 1, `unexpected }`},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.latex, func(t *testing.T) {
+            _, err := parseFormula(tt.latex)
+
+            var formulaErr *FormulaError
+            if !errors.As(err, &formulaErr) {
+                t.Fatalf("err = %v, want *FormulaError", err)
+            }
+            if !errors.Is(err, ErrInvalidFormula) {
+                t.Fatal("FormulaError must match ErrInvalidFormula")
+            }
+            if formulaErr.Position != tt.position || formulaErr.Length != tt.length {
+                t.Fatalf("position %d, length %d, want %d, %d", formulaErr.Position, formulaErr.Length, tt.position, tt.length)
+            }
+            if formulaErr.Message != tt.message {
+                t.Fatalf("message %q, want %q", formulaErr.Message, tt.message)
+            }
+            if end := formulaErr.Position + formulaErr.Length; end > len([]rune(tt.latex)) {
+                t.Fatalf("error range [%d, %d) is out of the formula", formulaErr.Position, end)
+            }
+        })
+    }
+}
+
+func TestRenderSVG(t *testing.T) {
+    tests := []struct {
+        latex    string
+        contains []string
+    }{
+        {`x`, []string{`<text x="0" y="0" font-size="1000" font-style="italic">x</text>`}},
+        // Показатель степени меньше и выше базовой линии
+        {`x^2`, []string{`<text x="540" y="-420" font-size="700">2</text>`}},
+        // Дробная черта
+        {`\frac{a}{b}`, []string{`<rect x="50" y="-275" width="600" height="50"/>`}},
+        {`\sqrt{x}`, []string{`<path d="M 0 -300 L 120 -360 L 300 50 L 550 -700 L 1130 -700"`}},
+        {`\left( x \right)`, []string{`<path d="M 350 -850 Q -110 -250 350 350"`, `<path d="M 1050 -850 Q 1510 -250 1050 350"`}},
+        // Текст экранируется
+        {`\text{a<b}`, []string{`>a&lt;b</text>`, `aria-label="\text{a&lt;b}"`}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.latex, func(t *testing.T) {
+            root, err := parseFormula(tt.latex)
+            if err != nil {
+                t.Fatalf("parseFormula: %v", err)
+            }
+
+            svg, width, height, depth := renderSVG(root, tt.latex, true)
+            if width <= 0 || height <= 0 || depth < 0 {
+                t.Fatalf("bad size %v x %v, depth %v", width, height, depth)
+            }
+            for _, want := range append(tt.contains, `fill="currentColor"`, `role="img"`) {
+                if !strings.Contains(svg, want) {
+                    t.Fatalf("SVG does not contain %s:\n%s", want, svg)
+                }
+            }
+            checkWellFormedXML(t, svg)
+        })
+    }
+}
+
+// В строчной формуле дробь набирается мельче, чем в выключной
+func TestRenderSVGInlineFraction(t *testing.T) {
+    root, err := parseFormula(`\frac{a}{b}`)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    _, blockWidth, blockHeight, blockDepth := renderSVG(root, `\frac{a}{b}`, true)
+    _, inlineWidth, inlineHeight, inlineDepth := renderSVG(root, `\frac{a}{b}`, false)
+    if inlineWidth >= blockWidth || inlineHeight >= blockHeight || inlineDepth >= blockDepth {
+        t.Fatalf("inline %v/%v/%v must be smaller than block %v/%v/%v",
+            inlineWidth, inlineHeight, inlineDepth, blockWidth, blockHeight, blockDepth)
+    }
+}
+
+// Кэш ограничен суммарным размером: давно не использованные формулы
+// вытесняются, а слишком большие не кэшируются вовсе
+func TestFormulaCacheEvictsBySize(t *testing.T) {
+    s := NewFormulaService(1 << 20)
+
+    formulas := make([]string, 0, 200)
+    for i := 0; i < 200; i++ {
+        latex := fmt.Sprintf(`\frac{x_{%d}}{y^{%d}} + \sqrt{%d}`, i, i, i)
+        if _, err := s.Render(latex, models.FormulaDisplayBlock); err != nil {
+            t.Fatal(err)
+        }
+        formulas = append(formulas, latex)
+    }
+    // Кэш примерно на 30 формул, каждая заметно меньше допустимой доли
+    size := s.usedBytes / len(s.entries)
+    s = NewFormulaService(2 * size * maxFormulaCacheShare)
+    for _, latex := range formulas[:5] {
+        s.Render(latex, models.FormulaDisplayBlock)
+    }
+    // Первая формула использована недавно и не должна вытесняться
+    s.Render(formulas[0], models.FormulaDisplayBlock)
+    for _, latex := range formulas[5:] {
+        s.Render(latex, models.FormulaDisplayBlock)
+        s.Render(formulas[0], models.FormulaDisplayBlock)
+    }
+
+    if len(s.entries) < 10 {
+        t.Fatalf("cache holds only %d formulas", len(s.entries))
+    }
+    if s.usedBytes > s.maxBytes {
+        t.Fatalf("cache holds %d bytes, limit %d", s.usedBytes, s.maxBytes)
+    }
+    total := 0
+    for _, element := range s.entries {
+        total += element.Value.(*formulaCacheEntry).size
+    }
+    if total != s.usedBytes || s.order.Len() != len(s.entries) {
+        t.Fatalf("cache accounting is broken: %d entries, %d bytes counted, %d stored", len(s.entries), s.usedBytes, total)
+    }
+    if _, ok := s.entries[models.FormulaDisplayBlock+":"+formulas[0]]; !ok {
+        t.Fatal("recently used formula was evicted")
+    }
+    if _, ok := s.entries[models.FormulaDisplayBlock+":"+formulas[1]]; ok {
+        t.Fatal("least recently used formula was not evicted")
+    }
+
+    // Формула больше доли кэша не кэшируется и ничего не вытесняет
+    before := len(s.entries)
+    large := strings.Repeat(`\frac{a}{b} + `, 200) + "x"
+    formula, err := s.Render(large, models.FormulaDisplayBlock)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if formula.SVG == "" {
+        t.Fatal("large formula must still be rendered")
+    }
+    if _, ok := s.entries[models.FormulaDisplayBlock+":"+large]; ok || len(s.entries) != before {
+        t.Fatal("oversized formula must not be cached")
+    }
+}
+
+func checkWellFormedXML(t *testing.T, data string) {
+    t.Helper()
+    decoder := xml.NewDecoder(strings.NewReader(data))
+    for {
+        _, err := decoder.Token()
+        if err == io.EOF {
+            return
+        }
+        if err != nil {
+            t.Fatalf("malformed XML: %v\n%s", err, data)
+        }
+    }
+}
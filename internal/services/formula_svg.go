@@ -0,0 +1,771 @@
+package services
+
+import (
+    "fmt"
+    "math"
+    "strings"
+    "unicode"
+)
+
+// SVG-вывод формулы. Файлы шрифтов на сервере не используются: ширина и высота
+// символов берутся из приближенных таблиц, поэтому раскладка близка к TeX,
+// но не совпадает с ним попиксельно. Все размеры при раскладке - в em,
+// в SVG они переводятся в единицы viewBox (1000 на em).
+
+const (
+    svgUnitsPerEm    = 1000
+    svgAxisHeight    = 0.25 // высота математической оси (черта дроби) над базовой линией
+    svgRuleThickness = 0.05
+    svgFontFamily    = `'Latin Modern Math', 'STIX Two Math', 'Cambria Math', 'Times New Roman', serif`
+)
+
+type svgStyle struct {
+    scale   float64
+    display bool
+    variant string
+}
+
+// script возвращает стиль индексов: шрифт меньше, но не мельче половины основного
+func (s svgStyle) script() svgStyle {
+    return svgStyle{scale: math.Max(s.scale*0.7, 0.5), variant: s.variant}
+}
+
+type svgItem struct {
+    x, y float64
+    draw func(x, y float64) string
+}
+
+// svgBox - прямоугольник раскладки. Начало координат - на базовой линии слева,
+// ось y направлена вниз, как в SVG: height - над базовой линией, depth - под ней.
+type svgBox struct {
+    width, height, depth float64
+    items                []svgItem
+}
+
+func (b *svgBox) place(child *svgBox, x, y float64) {
+    for _, item := range child.items {
+        b.items = append(b.items, svgItem{x: item.x + x, y: item.y + y, draw: item.draw})
+    }
+}
+
+func svgUnits(value float64) int {
+    return int(math.Round(value * svgUnitsPerEm))
+}
+
+// renderSVG раскладывает формулу и возвращает SVG и ее размеры в em:
+// ширину, высоту над базовой линией и глубину под ней
+func renderSVG(root *mathRow, latex string, display bool) (string, float64, float64, float64) {
+    box := layoutSVG(root, svgStyle{scale: 1, display: display})
+
+    // Небольшой запас, чтобы не обрезались выступающие части символов
+    const pad = 0.05
+    width := box.width + 2*pad
+    height := box.height + box.depth + 2*pad
+
+    var b strings.Builder
+    fmt.Fprintf(&b,
+        `<svg xmlns="http://www.w3.org/2000/svg" width="%.3fem" height="%.3fem" viewBox="%d %d %d %d" style="vertical-align: %.3fem" role="img" aria-label="%s">`,
+        width, height, svgUnits(-pad), svgUnits(-box.height-pad), svgUnits(width), svgUnits(height), -(box.depth + pad), xmlEscape(latex),
+    )
+    fmt.Fprintf(&b, `<title>%s</title><g font-family="%s" fill="currentColor" stroke="none">`, xmlEscape(latex), svgFontFamily)
+    for _, item := range box.items {
+        b.WriteString(item.draw(item.x, item.y))
+    }
+    b.WriteString(`</g></svg>`)
+
+    return b.String(), box.width, box.height, box.depth
+}
+
+func layoutSVG(node mathNode, st svgStyle) *svgBox {
+    switch n := node.(type) {
+    case *mathRow:
+        return layoutRow(n.children, st)
+
+    case *mathIdent:
+        variant := n.variant
+        if st.variant != "" {
+            variant = st.variant
+        }
+        runes := []rune(n.text)
+        return svgToken(n.text, variant, len(runes) == 1 && unicode.IsLetter(runes[0]), st.scale)
+
+    case *mathNumber:
+        return svgToken(n.text, st.variant, false, st.scale)
+
+    case *mathText:
+        return svgToken(n.text, n.variant, false, st.scale)
+
+    case *mathSpace:
+        return &svgBox{width: n.width * st.scale}
+
+    case *mathOp:
+        return layoutOp(n, st)
+
+    case *mathScripts:
+        return layoutScripts(n, st)
+
+    case *mathFrac:
+        return layoutFrac(n, st)
+
+    case *mathSqrt:
+        return layoutSqrt(n, st)
+
+    case *mathFenced:
+        return wrapDelimiters(layoutSVG(n.body, st), n.open, n.close, st.scale)
+
+    case *mathAccent:
+        return layoutAccent(n, st)
+
+    case *mathStyled:
+        st.variant = n.variant
+        return layoutSVG(n.body, st)
+
+    case *mathTable:
+        return layoutTable(n, st)
+    }
+
+    return &svgBox{}
+}
+
+// atomClass возвращает класс элемента для расстановки отступов
+func atomClass(node mathNode) string {
+    switch n := node.(type) {
+    case *mathOp:
+        return n.class
+    case *mathIdent:
+        if n.fn {
+            return "op"
+        }
+    case *mathScripts:
+        return atomClass(n.base)
+    case *mathStyled:
+        return atomClass(n.body)
+    }
+    return "ord"
+}
+
+// atomSpacing - отступ между соседними элементами по правилам TeX.
+// В индексах отступы вокруг операций и отношений не ставятся.
+func atomSpacing(prev, cur string, scale float64) float64 {
+    script := scale < 1
+    var space float64
+    switch {
+    case prev == "bin" || cur == "bin":
+        if !script {
+            space = 0.2222
+        }
+    case prev == "rel" || cur == "rel":
+        if !script && prev != cur && prev != "open" && cur != "close" {
+            space = 0.2778
+        }
+    case prev == "punct":
+        if !script {
+            space = 0.1667
+        }
+    case prev == "op" && (cur == "ord" || cur == "op"), cur == "op" && (prev == "ord" || prev == "close"):
+        space = 0.1667
+    }
+    return space * scale
+}
+
+func layoutRow(children []mathNode, st svgStyle) *svgBox {
+    classes := make([]string, len(children))
+    for i, child := range children {
+        classes[i] = atomClass(child)
+    }
+    // Знак операции в начале, после другого знака или перед отношением - унарный (−x)
+    for i, class := range classes {
+        if class != "bin" {
+            continue
+        }
+        if i == 0 || i == len(classes)-1 ||
+            containsString([]string{"bin", "rel", "open", "punct", "op"}, classes[i-1]) ||
+            containsString([]string{"rel", "close", "punct"}, classes[i+1]) {
+            classes[i] = "ord"
+        }
+    }
+
+    box := &svgBox{}
+    for i, child := range children {
+        if i > 0 {
+            box.width += atomSpacing(classes[i-1], classes[i], st.scale)
+        }
+        childBox := layoutSVG(child, st)
+        box.place(childBox, box.width, 0)
+        box.width += childBox.width
+        box.height = math.Max(box.height, childBox.height)
+        box.depth = math.Max(box.depth, childBox.depth)
+    }
+    return box
+}
+
+// svgToken выводит текст одним элементом text. defaultItalic - курсив,
+// если шрифт не задан явно (однобуквенные переменные).
+func svgToken(text, variant string, defaultItalic bool, scale float64) *svgBox {
+    italic, bold, family := defaultItalic, false, ""
+    switch variant {
+    case "normal":
+        italic = false
+    case "italic", "script":
+        italic = true
+    case "bold":
+        italic, bold = false, true
+    case "bold-italic":
+        italic, bold = true, true
+    case "double-struck":
+        italic = false
+        text = doubleStruckText(text)
+    case "sans-serif", "monospace":
+        italic, family = false, variant
+    }
+    return svgTextBox(text, scale, italic, bold, family)
+}
+
+func svgTextBox(text string, scale float64, italic, bold bool, family string) *svgBox {
+    box := &svgBox{}
+    runes := 0
+    for _, r := range text {
+        width, height, depth := glyphMetrics(r)
+        box.width += width * scale
+        box.height = math.Max(box.height, height*scale)
+        box.depth = math.Max(box.depth, depth*scale)
+        runes++
+    }
+
+    attrs := ""
+    if italic {
+        attrs += ` font-style="italic"`
+    }
+    if bold {
+        attrs += ` font-weight="bold"`
+    }
+    if family != "" {
+        attrs += fmt.Sprintf(` font-family="%s"`, family)
+    }
+    if strings.Contains(text, " ") {
+        attrs += ` xml:space="preserve"`
+    }
+    // Длина строки из наших метрик, чтобы шрифт клиента не ломал раскладку
+    if runes > 1 {
+        attrs += fmt.Sprintf(` textLength="%d" lengthAdjust="spacing"`, svgUnits(box.width))
+    }
+
+    escaped := xmlEscape(text)
+    width := box.width
+    box.items = append(box.items, svgItem{draw: func(x, y float64) string {
+        if width <= 0 {
+            return ""
+        }
+        return fmt.Sprintf(`<text x="%d" y="%d" font-size="%d"%s>%s</text>`, svgUnits(x), svgUnits(y), svgUnits(scale), attrs, escaped)
+    }})
+    return box
+}
+
+// glyphMetrics возвращает приближенные ширину, высоту и глубину символа в em
+func glyphMetrics(r rune) (float64, float64, float64) {
+    switch {
+    case unicode.Is(unicode.Mn, r):
+        return 0, 0, 0
+    case r == ' ':
+        return 0.25, 0, 0
+    case r >= '0' && r <= '9':
+        return 0.5, 0.66, 0
+    case r >= 'a' && r <= 'z':
+        width, height, depth := 0.5, 0.45, 0.0
+        switch r {
+        case 'i', 'j', 'l':
+            width = 0.3
+        case 'f', 't', 'r':
+            width = 0.38
+        case 'm', 'w':
+            width = 0.75
+        }
+        if strings.ContainsRune("bdfhklt", r) {
+            height = 0.7
+        }
+        if strings.ContainsRune("fgjpqy", r) {
+            depth = 0.2
+        }
+        return width, height, depth
+    case r >= 'A' && r <= 'Z':
+        switch r {
+        case 'I', 'J':
+            return 0.45, 0.68, 0
+        case 'M', 'W':
+            return 0.9, 0.68, 0
+        }
+        return 0.7, 0.68, 0
+    case strings.ContainsRune("()[]⌊⌋⌈⌉", r):
+        return 0.39, 0.75, 0.25
+    case strings.ContainsRune("{}⟨⟩", r):
+        return 0.5, 0.75, 0.25
+    case strings.ContainsRune("|∣", r):
+        return 0.28, 0.75, 0.25
+    case strings.ContainsRune("‖∥", r):
+        return 0.5, 0.75, 0.25
+    case strings.ContainsRune(",.;:!?′", r):
+        return 0.28, 0.45, 0.1
+    case strings.ContainsRune("∑∏∐⋃⋂", r):
+        return 1, 0.75, 0.25
+    case r == '∫' || r == '∮':
+        return 0.55, 0.8, 0.3
+    case r == '∬':
+        return 0.9, 0.8, 0.3
+    case r == '∭':
+        return 1.25, 0.8, 0.3
+    case unicode.IsLetter(r):
+        // Греческие, кириллица и другие буквы
+        if unicode.IsUpper(r) {
+            return 0.7, 0.68, 0
+        }
+        return 0.55, 0.6, 0.1
+    }
+    // Знаки операций и отношений
+    return 0.78, 0.58, 0.08
+}
+
+var doubleStruckLetters = map[rune]rune{
+    'C': 'ℂ', 'H': 'ℍ', 'N': 'ℕ', 'P': 'ℙ', 'Q': 'ℚ', 'R': 'ℝ', 'Z': 'ℤ',
+}
+
+// doubleStruckText заменяет буквы и цифры на ажурные символы Unicode (\mathbb)
+func doubleStruckText(text string) string {
+    return strings.Map(func(r rune) rune {
+        if special, ok := doubleStruckLetters[r]; ok {
+            return special
+        }
+        switch {
+        case r >= 'A' && r <= 'Z':
+            return 0x1D538 + (r - 'A')
+        case r >= 'a' && r <= 'z':
+            return 0x1D552 + (r - 'a')
+        case r >= '0' && r <= '9':
+            return 0x1D7D8 + (r - '0')
+        }
+        return r
+    }, text)
+}
+
+func layoutOp(op *mathOp, st svgStyle) *svgBox {
+    bold := strings.HasPrefix(st.variant, "bold")
+    if !op.large {
+        return svgTextBox(op.text, st.scale, false, bold, "")
+    }
+
+    // Большой оператор центрируется по математической оси
+    size := st.scale
+    if st.display {
+        size *= 1.4
+    }
+    glyph := svgTextBox(op.text, size, false, bold, "")
+    shift := svgAxisHeight*st.scale - (glyph.height-glyph.depth)/2
+
+    box := &svgBox{width: glyph.width, height: glyph.height + shift, depth: glyph.depth - shift}
+    box.place(glyph, 0, -shift)
+    return box
+}
+
+func layoutScripts(n *mathScripts, st svgStyle) *svgBox {
+    base := layoutSVG(n.base, st)
+    scriptStyle := st.script()
+
+    var sub, sup *svgBox
+    if n.sub != nil {
+        sub = layoutSVG(n.sub, scriptStyle)
+    }
+    if n.sup != nil {
+        sup = layoutSVG(n.sup, scriptStyle)
+    }
+
+    if op, ok := n.base.(*mathOp); ok && op.class == "op" && op.limits && st.display {
+        return layoutLimits(base, sub, sup, st.scale)
+    }
+
+    s := st.scale
+    box := &svgBox{width: base.width, height: base.height, depth: base.depth}
+    box.place(base, 0, 0)
+
+    var supShift, subShift float64
+    if sup != nil {
+        supShift = math.Max(math.Max(0.42*s, base.height-0.25*s), sup.depth+0.25*s)
+    }
+    if sub != nil {
+        subShift = math.Max(math.Max(0.2*s, base.depth+0.1*s), sub.height-0.4*s)
+    }
+    if sup != nil && sub != nil {
+        // Индексы не должны налезать друг на друга
+        if gap := (supShift - sup.depth) - (sub.height - subShift); gap < 0.12*s {
+            delta := 0.12*s - gap
+            supShift += delta / 2
+            subShift += delta / 2
+        }
+    }
+
+    x := base.width + 0.04*s
+    if sup != nil {
+        box.place(sup, x, -supShift)
+        box.width = math.Max(box.width, x+sup.width)
+        box.height = math.Max(box.height, supShift+sup.height)
+        box.depth = math.Max(box.depth, sup.depth-supShift)
+    }
+    if sub != nil {
+        box.place(sub, x, subShift)
+        box.width = math.Max(box.width, x+sub.width)
+        box.height = math.Max(box.height, sub.height-subShift)
+        box.depth = math.Max(box.depth, subShift+sub.depth)
+    }
+    return box
+}
+
+// layoutLimits ставит индексы над и под большим оператором (выключные формулы)
+func layoutLimits(base, sub, sup *svgBox, scale float64) *svgBox {
+    width := base.width
+    if sub != nil {
+        width = math.Max(width, sub.width)
+    }
+    if sup != nil {
+        width = math.Max(width, sup.width)
+    }
+
+    gap := 0.12 * scale
+    box := &svgBox{width: width, height: base.height, depth: base.depth}
+    box.place(base, (width-base.width)/2, 0)
+    if sup != nil {
+        box.place(sup, (width-sup.width)/2, -(base.height + gap + sup.depth))
+        box.height = base.height + gap + sup.depth + sup.height
+    }
+    if sub != nil {
+        y := base.depth + gap + sub.height
+        box.place(sub, (width-sub.width)/2, y)
+        box.depth = y + sub.depth
+    }
+    return box
+}
+
+func layoutFrac(n *mathFrac, st svgStyle) *svgBox {
+    // В выключной формуле и в \dfrac числитель и знаменатель того же размера,
+    // в строчной и в \tfrac - размера индексов
+    inner := svgStyle{scale: st.scale, variant: st.variant}
+    if n.style == "text" || (n.style == "" && !st.display) {
+        inner = st.script()
+    }
+    num := layoutSVG(n.num, inner)
+    den := layoutSVG(n.den, inner)
+
+    s := st.scale
+    axis := svgAxisHeight * s
+    rule := svgRuleThickness * s
+    gap := 0.1 * s
+    if st.display || n.style == "display" {
+        gap = 0.15 * s
+    }
+    pad := 0.1 * s
+
+    width := math.Max(num.width, den.width) + 2*pad
+    numY := -(axis + rule/2 + gap + num.depth)
+    denY := -axis + rule/2 + gap + den.height
+
+    box := &svgBox{width: width, height: -numY + num.height, depth: denY + den.depth}
+    box.place(num, (width-num.width)/2, numY)
+    box.place(den, (width-den.width)/2, denY)
+    if !n.noLine {
+        box.items = append(box.items, svgRule(pad/2, -axis-rule/2, width-pad, rule))
+    }
+    return box
+}
+
+func layoutSqrt(n *mathSqrt, st svgStyle) *svgBox {
+    s := st.scale
+    body := layoutSVG(n.body, st)
+
+    rule := svgRuleThickness * s
+    top := math.Max(body.height, 0.6*s) + 0.1*s
+    bottom := math.Max(body.depth, 0.05*s)
+    radical := 0.55 * s
+
+    var index *svgBox
+    shift := 0.0
+    if n.index != nil {
+        index = layoutSVG(n.index, svgStyle{scale: math.Max(s*0.5, 0.4), variant: st.variant})
+        shift = math.Max(0, index.width-0.3*s)
+    }
+
+    box := &svgBox{width: shift + radical + body.width + 0.1*s, height: top + rule, depth: bottom + rule/2}
+    path := svgPath{
+        svgSegment('M', 0, -0.3*s),
+        svgSegment('L', 0.12*s, -0.36*s),
+        svgSegment('L', 0.3*s, bottom),
+        svgSegment('L', radical, -top),
+        svgSegment('L', radical+body.width+0.08*s, -top),
+    }
+    box.items = append(box.items, svgItem{x: shift, draw: path.draw(rule)})
+    box.place(body, shift+radical+0.04*s, 0)
+
+    if index != nil {
+        y := -top * 0.55
+        box.place(index, shift+0.3*s-index.width, y)
+        box.height = math.Max(box.height, -y+index.height)
+    }
+    return box
+}
+
+func layoutAccent(n *mathAccent, st svgStyle) *svgBox {
+    s := st.scale
+    body := layoutSVG(n.body, st)
+    rule := svgRuleThickness * s
+
+    box := &svgBox{width: body.width, height: body.height, depth: body.depth}
+    box.place(body, 0, 0)
+
+    switch n.accent {
+    case "under":
+        y := body.depth + 0.08*s
+        box.items = append(box.items, svgRule(0, y, body.width, rule))
+        box.depth = y + rule + 0.02*s
+    case "over":
+        y := -(body.height + 0.08*s + rule)
+        box.items = append(box.items, svgRule(0, y, body.width, rule))
+        box.height = -y
+    case "vec":
+        width := math.Max(body.width, 0.4*s)
+        y := -(body.height + 0.15*s)
+        path := svgPath{
+            svgSegment('M', 0, y),
+            svgSegment('L', width, y),
+            svgSegment('M', width-0.12*s, y-0.08*s),
+            svgSegment('L', width, y),
+            svgSegment('L', width-0.12*s, y+0.08*s),
+        }
+        box.items = append(box.items, svgItem{draw: path.draw(rule)})
+        box.width = width
+        box.height = -y + 0.1*s
+    default:
+        accent := svgTextBox(n.accent, s, false, false, "")
+        box.place(accent, (body.width-accent.width)/2, -(body.height - 0.4*s))
+        box.height = body.height + 0.35*s
+    }
+    return box
+}
+
+func layoutTable(n *mathTable, st svgStyle) *svgBox {
+    s := st.scale
+    cellStyle := svgStyle{scale: s, display: n.align == "rl", variant: st.variant}
+
+    columns := 0
+    for _, row := range n.rows {
+        columns = int(math.Max(float64(columns), float64(len(row))))
+    }
+
+    cells := make([][]*svgBox, len(n.rows))
+    widths := make([]float64, columns)
+    heights := make([]float64, len(n.rows))
+    depths := make([]float64, len(n.rows))
+    for i, row := range n.rows {
+        heights[i], depths[i] = 0.7*s, 0.25*s
+        for j, cell := range row {
+            box := layoutSVG(cell, cellStyle)
+            cells[i] = append(cells[i], box)
+            widths[j] = math.Max(widths[j], box.width)
+            heights[i] = math.Max(heights[i], box.height)
+            depths[i] = math.Max(depths[i], box.depth)
+        }
+    }
+
+    // Отступы между столбцами: в aligned пары столбцов "право-лево" стоят вплотную
+    gaps := make([]float64, columns)
+    for j := 1; j < columns; j++ {
+        switch {
+        case n.align == "rl" && j%2 == 1:
+            gaps[j] = 0
+        case n.align == "center":
+            gaps[j] = 0.8 * s
+        default:
+            gaps[j] = 1 * s
+        }
+    }
+
+    pad := 0.0
+    if n.open != "" || n.close != "" {
+        pad = 0.12 * s
+    }
+    rowGap := 0.2 * s
+    total := rowGap * float64(len(n.rows)-1)
+    for i := range n.rows {
+        total += heights[i] + depths[i]
+    }
+
+    axis := svgAxisHeight * s
+    box := &svgBox{height: axis + total/2, depth: total/2 - axis}
+    y := -box.height
+    for i := range n.rows {
+        y += heights[i]
+        x := pad
+        for j := 0; j < columns; j++ {
+            x += gaps[j]
+            if j < len(cells[i]) {
+                cell := cells[i][j]
+                align := n.align
+                if align == "rl" {
+                    align = map[bool]string{true: "right", false: "left"}[j%2 == 0]
+                }
+                switch align {
+                case "left":
+                    box.place(cell, x, y)
+                case "right":
+                    box.place(cell, x+widths[j]-cell.width, y)
+                default:
+                    box.place(cell, x+(widths[j]-cell.width)/2, y)
+                }
+            }
+            x += widths[j]
+        }
+        box.width = x + pad
+        y += depths[i] + rowGap
+    }
+
+    if n.open == "" && n.close == "" {
+        return box
+    }
+    return wrapDelimiters(box, n.open, n.close, s)
+}
+
+// wrapDelimiters окружает содержимое растянутыми скобками (\left, \right, матрицы)
+func wrapDelimiters(body *svgBox, open, close string, scale float64) *svgBox {
+    axis := svgAxisHeight * scale
+    half := math.Max(math.Max(body.height-axis, body.depth+axis), 0.5*scale) + 0.1*scale
+    top, bottom := -(axis + half), half-axis
+
+    box := &svgBox{height: math.Max(body.height, -top), depth: math.Max(body.depth, bottom)}
+    if open != "" {
+        delimiter := delimiterBox(open, top, bottom, scale)
+        box.place(delimiter, box.width, 0)
+        box.width += delimiter.width
+    }
+    box.place(body, box.width, 0)
+    box.width += body.width
+    if close != "" {
+        delimiter := delimiterBox(close, top, bottom, scale)
+        box.place(delimiter, box.width, 0)
+        box.width += delimiter.width
+    }
+    return box
+}
+
+// delimiterBox рисует скобку линиями от top до bottom. Закрывающие скобки -
+// зеркальное отражение открывающих.
+func delimiterBox(delimiter string, top, bottom, scale float64) *svgBox {
+    s := scale
+    mid := (top + bottom) / 2
+    var width float64
+    var path svgPath
+    mirror := false
+
+    switch delimiter {
+    case "(", ")":
+        width = 0.45 * s
+        end, middle := width-0.1*s, 0.12*s
+        // Квадратичная кривая проходит через middle в середине высоты
+        path = svgPath{svgSegment('M', end, top), svgSegment('Q', 2*middle-end, mid, end, bottom)}
+        mirror = delimiter == ")"
+    case "[", "]":
+        width = 0.35 * s
+        stem, end := 0.12*s, width-0.05*s
+        path = svgPath{svgSegment('M', end, top), svgSegment('L', stem, top), svgSegment('L', stem, bottom), svgSegment('L', end, bottom)}
+        mirror = delimiter == "]"
+    case "{", "}":
+        width = 0.5 * s
+        end, stem, tip := width-0.08*s, 0.25*s, 0.06*s
+        r := math.Min(0.12*s, (bottom-top)/8)
+        path = svgPath{
+            svgSegment('M', end, top),
+            svgSegment('Q', stem, top, stem, top+r),
+            svgSegment('L', stem, mid-r),
+            svgSegment('Q', stem, mid, tip, mid),
+            svgSegment('Q', stem, mid, stem, mid+r),
+            svgSegment('L', stem, bottom-r),
+            svgSegment('Q', stem, bottom, end, bottom),
+        }
+        mirror = delimiter == "}"
+    case "⟨", "⟩":
+        width = 0.4 * s
+        path = svgPath{svgSegment('M', width-0.08*s, top), svgSegment('L', 0.08*s, mid), svgSegment('L', width-0.08*s, bottom)}
+        mirror = delimiter == "⟩"
+    case "⌊", "⌋":
+        width = 0.4 * s
+        path = svgPath{svgSegment('M', 0.12*s, top), svgSegment('L', 0.12*s, bottom), svgSegment('L', width-0.05*s, bottom)}
+        mirror = delimiter == "⌋"
+    case "⌈", "⌉":
+        width = 0.4 * s
+        path = svgPath{svgSegment('M', 0.12*s, bottom), svgSegment('L', 0.12*s, top), svgSegment('L', width-0.05*s, top)}
+        mirror = delimiter == "⌉"
+    case "|":
+        width = 0.3 * s
+        path = svgPath{svgSegment('M', width/2, top), svgSegment('L', width/2, bottom)}
+    case "‖":
+        width = 0.45 * s
+        path = svgPath{
+            svgSegment('M', 0.13*s, top), svgSegment('L', 0.13*s, bottom),
+            svgSegment('M', width-0.13*s, top), svgSegment('L', width-0.13*s, bottom),
+        }
+    case "/":
+        width = 0.5 * s
+        path = svgPath{svgSegment('M', width-0.05*s, top), svgSegment('L', 0.05*s, bottom)}
+    default:
+        return &svgBox{}
+    }
+
+    if mirror {
+        path = path.mirror(width)
+    }
+    box := &svgBox{width: width, height: -top, depth: bottom}
+    box.items = append(box.items, svgItem{draw: path.draw(svgRuleThickness * s)})
+    return box
+}
+
+func svgRule(x, y, width, height float64) svgItem {
+    return svgItem{x: x, y: y, draw: func(x, y float64) string {
+        return fmt.Sprintf(`<rect x="%d" y="%d" width="%d" height="%d"/>`, svgUnits(x), svgUnits(y), svgUnits(width), svgUnits(height))
+    }}
+}
+
+// svgPath - контур из команд M, L, Q с абсолютными координатами
+type svgPath []svgPathSegment
+
+type svgPathSegment struct {
+    command byte
+    points  []float64 // пары x, y
+}
+
+func svgSegment(command byte, points ...float64) svgPathSegment {
+    return svgPathSegment{command: command, points: points}
+}
+
+func (p svgPath) mirror(width float64) svgPath {
+    mirrored := make(svgPath, len(p))
+    for i, segment := range p {
+        points := make([]float64, len(segment.points))
+        copy(points, segment.points)
+        for j := 0; j < len(points); j += 2 {
+            points[j] = width - points[j]
+        }
+        mirrored[i] = svgPathSegment{command: segment.command, points: points}
+    }
+    return mirrored
+}
+
+func (p svgPath) draw(thickness float64) func(x, y float64) string {
+    return func(x, y float64) string {
+        var d strings.Builder
+        for i, segment := range p {
+            if i > 0 {
+                d.WriteByte(' ')
+            }
+            d.WriteByte(segment.command)
+            for j := 0; j < len(segment.points); j += 2 {
+                fmt.Fprintf(&d, " %d %d", svgUnits(segment.points[j]+x), svgUnits(segment.points[j+1]+y))
+            }
+        }
+        return fmt.Sprintf(`<path d="%s" fill="none" stroke="currentColor" stroke-width="%d" stroke-linecap="round" stroke-linejoin="round"/>`,
+            d.String(), svgUnits(thickness))
+    }
+}
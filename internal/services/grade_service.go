@@ -0,0 +1,164 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/repositories"
+)
+
+// GradeService вычисляет оценку ученика за материал по тестам
+// и заданиям с ручной проверкой и записывает ее в прогресс
+type GradeService struct {
+    quizRepo       *repositories.QuizRepository
+    assignmentRepo *repositories.AssignmentRepository
+    materialRepo   *repositories.MaterialRepository
+    revisionRepo   *repositories.RevisionRepository
+    progressRepo   *repositories.ProgressRepository
+}
+
+func NewGradeService(quizRepo *repositories.QuizRepository, assignmentRepo *repositories.AssignmentRepository, materialRepo *repositories.MaterialRepository, revisionRepo *repositories.RevisionRepository, progressRepo *repositories.ProgressRepository) *GradeService {
+    return &GradeService{
+        quizRepo:       quizRepo,
+        assignmentRepo: assignmentRepo,
+        materialRepo:   materialRepo,
+        revisionRepo:   revisionRepo,
+        progressRepo:   progressRepo,
+    }
+}
+
+// loadPublishedRevision возвращает опубликованную версию материала, которую видят ученики
+func loadPublishedRevision(ctx context.Context, materialRepo *repositories.MaterialRepository, revisionRepo *repositories.RevisionRepository, materialID int) (*models.MaterialRevision, error) {
+    material, err := materialRepo.GetMaterial(ctx, materialID)
+    if err != nil {
+        return nil, fmt.Errorf("error finding material: %w", err)
+    }
+    if material == nil || material.Status != "published" || material.PublishedRevision == nil {
+        return nil, ErrMaterialNotFound
+    }
+
+    revision, err := revisionRepo.GetRevision(ctx, materialID, *material.PublishedRevision)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get revision: %w", err)
+    }
+    if revision == nil {
+        return nil, ErrMaterialNotFound
+    }
+    return revision, nil
+}
+
+// MaterialGrade вычисляет оценку ученика за материал по опубликованной версии.
+// Если оценивать нечего - возвращает nil.
+func (s *GradeService) MaterialGrade(ctx context.Context, userID, materialID int) (*float64, error) {
+    revision, err := loadPublishedRevision(ctx, s.materialRepo, s.revisionRepo, materialID)
+    if errors.Is(err, ErrMaterialNotFound) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    return s.blocksGrade(ctx, userID, materialID, revision.Blocks)
+}
+
+// Refresh пересчитывает оценку за уже завершенный материал после
+// новой попытки теста, открытия результатов или проверки задания
+func (s *GradeService) Refresh(ctx context.Context, userID, materialID int, blocks []models.Block) error {
+    grade, err := s.blocksGrade(ctx, userID, materialID, blocks)
+    if err != nil {
+        return err
+    }
+    if err := s.progressRepo.UpdateGrade(ctx, userID, materialID, grade); err != nil {
+        return fmt.Errorf("failed to update grade: %w", err)
+    }
+    return nil
+}
+
+// blocksGrade - средний результат по оцениваемым блокам, переведенный в оценку 1-5.
+// Тест учитывается по последней попытке, задание - по оценке преподавателя.
+// Непройденный тест и несданное или возвращенное задание дают 0%,
+// задание на проверке пока не учитывается.
+func (s *GradeService) blocksGrade(ctx context.Context, userID, materialID int, blocks []models.Block) (*float64, error) {
+    var last map[string]models.QuizAttempt
+    var submissions map[string]models.AssignmentSubmission
+
+    var total float64
+    var count int
+    for _, block := range blocks {
+        switch block.Type {
+        case models.BlockTypeQuiz:
+            if last == nil {
+                var err error
+                if last, err = s.quizRepo.GetLastAttempts(ctx, userID, materialID); err != nil {
+                    return nil, fmt.Errorf("failed to get quiz results: %w", err)
+                }
+            }
+            percent, counted, err := s.quizPercent(ctx, materialID, &block, last)
+            if err != nil {
+                return nil, err
+            }
+            if !counted {
+                continue
+            }
+            total += percent
+            count++
+
+        case models.BlockTypeAssignment:
+            if submissions == nil {
+                list, err := s.assignmentRepo.GetUserSubmissions(ctx, userID, materialID)
+                if err != nil {
+                    return nil, fmt.Errorf("failed to get submissions: %w", err)
+                }
+                submissions = make(map[string]models.AssignmentSubmission, len(list))
+                for _, submission := range list {
+                    submissions[submission.BlockID] = submission
+                }
+            }
+            submission, ok := submissions[block.ID]
+            if ok && submission.Status == models.SubmissionStatusSubmitted {
+                continue
+            }
+            if ok && submission.Status == models.SubmissionStatusGraded && submission.Percent != nil {
+                total += *submission.Percent
+            }
+            count++
+        }
+    }
+    if count == 0 {
+        return nil, nil
+    }
+
+    grade := gradeFromPercent(total / float64(count))
+    return &grade, nil
+}
+
+// quizPercent возвращает результат теста для оценки и false, если тест пока не учитывается
+func (s *GradeService) quizPercent(ctx context.Context, materialID int, block *models.Block, last map[string]models.QuizAttempt) (float64, bool, error) {
+    attempt, ok := last[block.ID]
+    if !ok {
+        return 0, true, nil
+    }
+
+    quiz, err := parseQuiz(block)
+    if err != nil {
+        return 0, false, err
+    }
+    released, err := s.quizRepo.IsReleased(ctx, materialID, block.ID)
+    if err != nil {
+        return 0, false, fmt.Errorf("failed to check results release: %w", err)
+    }
+
+    percent, counted := quizGradePercent(quiz, attempt, released)
+    return percent, counted, nil
+}
+
+// quizGradePercent решает, входит ли тест в оценку. Пока у ученика остались
+// попытки и результаты не открыты, тест не учитывается: иначе по оценке за
+// материал можно было бы после каждой попытки узнать, верны ли ответы
+func quizGradePercent(quiz *quizContent, last models.QuizAttempt, released bool) (float64, bool) {
+    if last.Number < quiz.attemptLimit() && !released {
+        return 0, false
+    }
+    return last.Percent, true
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/repositories"
+    "paydeya-backend/internal/utils"
+
+    "github.com/google/uuid"
+)
+
+var (
+    ErrCannotImpersonateSelf       = errors.New("cannot impersonate yourself")
+    ErrCannotImpersonatePrivileged = errors.New("cannot impersonate users with admin permissions")
+)
+
+// ImpersonationService выдает администраторам токены для работы от имени
+// пользователя (например, чтобы увидеть то же, что видит преподаватель)
+// и записывает в журнал аудита каждый запрос, сделанный с таким токеном.
+type ImpersonationService struct {
+    userRepo   *repositories.UserRepository
+    auditRepo  *repositories.AuditRepository
+    authorizer *Authorizer
+    keys       *utils.KeySet
+}
+
+func NewImpersonationService(userRepo *repositories.UserRepository, auditRepo *repositories.AuditRepository, authorizer *Authorizer, keys *utils.KeySet) *ImpersonationService {
+    return &ImpersonationService{
+        userRepo:   userRepo,
+        auditRepo:  auditRepo,
+        authorizer: authorizer,
+        keys:       keys,
+    }
+}
+
+// Impersonate выдает токен пользователя с claim act. Под пользователями
+// с правами админки входить нельзя.
+func (s *ImpersonationService) Impersonate(ctx context.Context, actorID, targetID int, reason string, client models.ClientInfo) (*models.ImpersonationResponse, error) {
+    if actorID == targetID {
+        return nil, ErrCannotImpersonateSelf
+    }
+
+    actor, err := s.userRepo.GetUserByID(ctx, actorID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get actor: %w", err)
+    }
+    if actor == nil {
+        return nil, ErrUserNotFound
+    }
+
+    target, err := s.userRepo.GetUserByID(ctx, targetID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get user: %w", err)
+    }
+    if target == nil {
+        return nil, ErrUserNotFound
+    }
+    if err := checkNotBlocked(target); err != nil {
+        return nil, err
+    }
+
+    privileged, err := s.authorizer.HasAnyPermission(ctx, target.Role, models.AdminPermissions...)
+    if err != nil {
+        return nil, fmt.Errorf("failed to check permissions: %w", err)
+    }
+    if privileged {
+        return nil, ErrCannotImpersonatePrivileged
+    }
+
+    impersonationID := uuid.New().String()
+    token, err := utils.GenerateImpersonationToken(target.ID, target.Email, target.Role, actor.ID, actor.Email, impersonationID, s.keys)
+    if err != nil {
+        return nil, fmt.Errorf("error generating impersonation token: %w", err)
+    }
+    expiresAt := time.Now().Add(utils.ImpersonationTokenTTL)
+
+    // Без записи в журнал токен не выдаем: вход под пользователем должен быть виден
+    err = s.auditRepo.Record(ctx, &models.AuditEntry{
+        UserID:  target.ID,
+        ActorID: actor.ID,
+        Action:  models.AuditImpersonationStarted,
+        Details: map[string]interface{}{
+            "impersonationId": impersonationID,
+            "reason":          reason,
+            "expiresAt":       expiresAt,
+        },
+        IP:        client.IP,
+        UserAgent: truncateUserAgent(client.UserAgent),
+    })
+    if err != nil {
+        return nil, fmt.Errorf("failed to write audit record: %w", err)
+    }
+
+    return &models.ImpersonationResponse{
+        AccessToken:     token,
+        ExpiresIn:       int(utils.ImpersonationTokenTTL.Seconds()),
+        ExpiresAt:       expiresAt,
+        ImpersonationID: impersonationID,
+        User:            target,
+    }, nil
+}
+
+// RecordRequest записывает в журнал запрос, выполненный от имени пользователя
+func (s *ImpersonationService) RecordRequest(ctx context.Context, request *models.ImpersonatedRequest) error {
+    return s.auditRepo.Record(ctx, &models.AuditEntry{
+        UserID:  request.UserID,
+        ActorID: request.ActorID,
+        Action:  models.AuditImpersonatedRequest,
+        Details: map[string]interface{}{
+            "impersonationId": request.ImpersonationID,
+            "method":          request.Method,
+            "path":            request.Path,
+            "status":          request.Status,
+        },
+        IP:        request.Client.IP,
+        UserAgent: truncateUserAgent(request.Client.UserAgent),
+    })
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "strings"
+    "time"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/repositories"
+    "paydeya-backend/internal/utils"
+)
+
+var (
+    ErrInvalidInvite  = errors.New("invalid or expired invite")
+    ErrInviteNotFound = errors.New("invite not found or already used")
+)
+
+// defaultInviteTTL срок действия приглашения по умолчанию
+const defaultInviteTTL = 72 * time.Hour
+
+type InviteService struct {
+    inviteRepo *repositories.InviteRepository
+    mailer     Mailer
+    appURL     string
+}
+
+func NewInviteService(inviteRepo *repositories.InviteRepository, mailer Mailer, appURL string) *InviteService {
+    return &InviteService{
+        inviteRepo: inviteRepo,
+        mailer:     mailer,
+        appURL:     strings.TrimRight(appURL, "/"),
+    }
+}
+
+// CreateInvite выпускает одноразовое приглашение администратора.
+// Токен возвращается только здесь: в БД хранится лишь его хеш.
+// Если указан email, приглашение дополнительно отправляется письмом.
+func (s *InviteService) CreateInvite(ctx context.Context, adminID int, req *models.CreateInviteRequest) (*models.AdminInvite, string, string, error) {
+    token, tokenHash, err := utils.GenerateOpaqueToken()
+    if err != nil {
+        return nil, "", "", fmt.Errorf("error generating invite token: %w", err)
+    }
+
+    ttl := defaultInviteTTL
+    if req.ExpiresInHours > 0 {
+        ttl = time.Duration(req.ExpiresInHours) * time.Hour
+    }
+
+    invite := &models.AdminInvite{
+        Role:      "admin",
+        CreatedBy: &adminID,
+        ExpiresAt: time.Now().Add(ttl),
+    }
+    if req.Email != "" {
+        invite.Email = &req.Email
+    }
+
+    if err := s.inviteRepo.CreateInvite(ctx, invite, tokenHash); err != nil {
+        return nil, "", "", fmt.Errorf("failed to create invite: %w", err)
+    }
+
+    link := fmt.Sprintf("%s/register?invite=%s", s.appURL, token)
+
+    if invite.Email != nil {
+        err := s.mailer.Send(ctx, &Mail{
+            To:      *invite.Email,
+            Subject: "Приглашение в команду Пайдеи",
+            Body: fmt.Sprintf(
+                "Здравствуйте!\n\nВас пригласили стать администратором платформы Пайдея. "+
+                    "Чтобы зарегистрироваться, перейдите по ссылке:\n%s\n\nПриглашение действует до %s.",
+                link, invite.ExpiresAt.Format("02.01.2006 15:04"),
+            ),
+        })
+        if err != nil {
+            log.Printf("⚠️ Failed to email invite %d: %v", invite.ID, err)
+        }
+    }
+
+    return invite, token, link, nil
+}
+
+// ListInvites возвращает все приглашения
+func (s *InviteService) ListInvites(ctx context.Context) ([]models.AdminInvite, error) {
+    return s.inviteRepo.ListInvites(ctx)
+}
+
+// RevokeInvite отзывает неиспользованное приглашение
+func (s *InviteService) RevokeInvite(ctx context.Context, inviteID int) error {
+    revoked, err := s.inviteRepo.RevokeInvite(ctx, inviteID)
+    if err != nil {
+        return fmt.Errorf("failed to revoke invite: %w", err)
+    }
+    if !revoked {
+        return ErrInviteNotFound
+    }
+    return nil
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "math"
+    "sort"
+    "strings"
+    "time"
+
+    "paydeya-backend/internal/models"
+)
+
+var ErrLockoutNotFound = errors.New("lockout not found")
+
+// LoginThrottledError возвращается, пока вход для аккаунта или IP временно запрещен
+type LoginThrottledError struct {
+    RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+    return fmt.Sprintf("too many failed login attempts, try again in %d seconds", e.RetryAfterSeconds())
+}
+
+// RetryAfterSeconds возвращает значение для заголовка Retry-After
+func (e *LoginThrottledError) RetryAfterSeconds() int {
+    return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+// throttlePolicy описывает, как быстро растут задержки для одного ключа
+type throttlePolicy struct {
+    freeAttempts    int           // ошибки без задержки
+    maxFailures     int           // после стольких ошибок ключ блокируется на lockoutDuration
+    lockoutDuration time.Duration
+}
+
+const (
+    loginThrottleScopeAccount = "account"
+    loginThrottleScopeIP      = "ip"
+
+    loginFailureWindow = time.Hour // счетчик забывается через час без ошибок
+    loginBaseDelay     = time.Second
+    loginMaxDelay      = 30 * time.Second
+)
+
+var loginThrottlePolicies = map[string]throttlePolicy{
+    // Аккаунт: после 3 ошибок задержка 1s, 2s, 4s..., после 10 блокировка
+    loginThrottleScopeAccount: {freeAttempts: 3, maxFailures: 10, lockoutDuration: 15 * time.Minute},
+    // IP: порог выше, за одним адресом может быть целая школа
+    loginThrottleScopeIP: {freeAttempts: 20, maxFailures: 100, lockoutDuration: 15 * time.Minute},
+}
+
+// LoginThrottler ограничивает перебор паролей: считает неудачные входы по
+// аккаунту и по IP, вводит растущие задержки и временную блокировку.
+type LoginThrottler struct {
+    store ThrottleStore
+}
+
+func NewLoginThrottler(store ThrottleStore) *LoginThrottler {
+    return &LoginThrottler{store: store}
+}
+
+func loginThrottleKeys(email, ip string) map[string]string {
+    keys := map[string]string{
+        loginThrottleScopeAccount: loginThrottleScopeAccount + ":" + strings.ToLower(strings.TrimSpace(email)),
+    }
+    if ip != "" {
+        keys[loginThrottleScopeIP] = loginThrottleScopeIP + ":" + ip
+    }
+    return keys
+}
+
+// LoginAttempt - попытка входа, заранее учтенная как неудачная
+type LoginAttempt struct {
+    email string
+    // counted - учтенные ключи и блокировки, которые поставила эта попытка
+    counted map[string]time.Time
+}
+
+// Begin учитывает попытку входа до проверки пароля и возвращает
+// *LoginThrottledError, если вход сейчас запрещен. Попытка сразу считается
+// неудачной: проверка блокировки и увеличение счетчика - один атомарный шаг,
+// поэтому параллельные запросы не проходят все вместе до первой записи об ошибке.
+// Ошибки хранилища не блокируют вход: недоступный Redis не должен класть
+// авторизацию. Исключение - переполненное хранилище в памяти: иначе его
+// можно было бы заполнить и перебирать пароли без ограничений.
+func (t *LoginThrottler) Begin(ctx context.Context, email, ip string) (*LoginAttempt, error) {
+    attempt := &LoginAttempt{email: email, counted: make(map[string]time.Time)}
+
+    var retryAfter time.Duration
+    for scope, key := range loginThrottleKeys(email, ip) {
+        result, err := t.store.Attempt(ctx, key, loginFailureWindow, loginThrottlePolicies[scope].delay)
+        if errors.Is(err, ErrThrottleStoreFull) {
+            log.Printf("⚠️ Login throttle store is full, rejecting %s", key)
+            retryAfter = max(retryAfter, loginMaxDelay)
+            continue
+        }
+        if err != nil {
+            log.Printf("Login throttle check failed for %s: %v", key, err)
+            continue
+        }
+        if !result.Allowed {
+            retryAfter = max(retryAfter, time.Until(result.LockedUntil))
+            continue
+        }
+        attempt.counted[key] = result.LockedUntil
+    }
+
+    if retryAfter > 0 {
+        // Отклоненная попытка не должна расходовать лимит по другому ключу
+        t.Release(ctx, attempt)
+        return nil, &LoginThrottledError{RetryAfter: retryAfter}
+    }
+    return attempt, nil
+}
+
+// Succeed завершает удачную попытку. Счетчик аккаунта сбрасывается, а у IP
+// отменяется только эта попытка: иначе перебор можно было бы разбавлять
+// входами в свой аккаунт.
+func (t *LoginThrottler) Succeed(ctx context.Context, attempt *LoginAttempt) {
+    accountKey := loginThrottleKeys(attempt.email, "")[loginThrottleScopeAccount]
+    for key, lockedUntil := range attempt.counted {
+        var err error
+        if key == accountKey {
+            err = t.store.Reset(ctx, key)
+        } else {
+            err = t.store.Refund(ctx, key, lockedUntil)
+        }
+        if err != nil {
+            log.Printf("Failed to reset login throttle for %s: %v", key, err)
+        }
+    }
+}
+
+// Release отменяет попытку, которую не удалось проверить (например, из-за ошибки БД)
+func (t *LoginThrottler) Release(ctx context.Context, attempt *LoginAttempt) {
+    for key, lockedUntil := range attempt.counted {
+        if err := t.store.Refund(ctx, key, lockedUntil); err != nil {
+            log.Printf("Failed to refund login attempt for %s: %v", key, err)
+        }
+    }
+}
+
+// delay возвращает задержку после failures ошибок подряд
+func (p throttlePolicy) delay(failures int) time.Duration {
+    if failures >= p.maxFailures {
+        return p.lockoutDuration
+    }
+    if failures <= p.freeAttempts {
+        return 0
+    }
+
+    delay := loginBaseDelay << (failures - p.freeAttempts - 1)
+    if delay > loginMaxDelay || delay <= 0 {
+        delay = loginMaxDelay
+    }
+    return delay
+}
+
+// ListLockouts возвращает аккаунты и IP с неудачными попытками, заблокированные первыми
+func (t *LoginThrottler) ListLockouts(ctx context.Context) ([]models.LoginLockout, error) {
+    lockouts, err := t.store.List(ctx)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list lockouts: %w", err)
+    }
+
+    now := time.Now()
+    for i := range lockouts {
+        lockouts[i].Scope, lockouts[i].Subject, _ = strings.Cut(lockouts[i].Key, ":")
+        if lockouts[i].LockedUntil != nil && !lockouts[i].LockedUntil.After(now) {
+            lockouts[i].LockedUntil = nil
+        }
+    }
+
+    sort.Slice(lockouts, func(i, j int) bool {
+        if (lockouts[i].LockedUntil != nil) != (lockouts[j].LockedUntil != nil) {
+            return lockouts[i].LockedUntil != nil
+        }
+        return lockouts[i].Failures > lockouts[j].Failures
+    })
+
+    return lockouts, nil
+}
+
+// ClearLockout снимает блокировку и обнуляет счетчик ключа
+func (t *LoginThrottler) ClearLockout(ctx context.Context, key string) error {
+    lockout, err := t.store.Get(ctx, key)
+    if err != nil {
+        return fmt.Errorf("failed to get lockout: %w", err)
+    }
+    if lockout == nil {
+        return ErrLockoutNotFound
+    }
+
+    if err := t.store.Reset(ctx, key); err != nil {
+        return fmt.Errorf("failed to clear lockout: %w", err)
+    }
+    return nil
+}
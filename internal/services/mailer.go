@@ -0,0 +1,121 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "mime"
+    "net/smtp"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/google/uuid"
+)
+
+// Mail представляет исходящее письмо
+type Mail struct {
+    To      string
+    Subject string
+    Body    string
+}
+
+// Mailer отправляет письма пользователям. Реализация выбирается при старте:
+// SMTP в продакшене, файловая или in-memory для локальной разработки.
+type Mailer interface {
+    Send(ctx context.Context, mail *Mail) error
+}
+
+// buildMessage собирает письмо в формате RFC 5322
+func buildMessage(from string, mail *Mail) []byte {
+    var b strings.Builder
+    b.WriteString("From: " + from + "\r\n")
+    b.WriteString("To: " + mail.To + "\r\n")
+    b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", mail.Subject) + "\r\n")
+    b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+    b.WriteString("MIME-Version: 1.0\r\n")
+    b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+    b.WriteString("\r\n")
+    b.WriteString(strings.ReplaceAll(mail.Body, "\n", "\r\n"))
+    return []byte(b.String())
+}
+
+// SMTPMailer отправляет письма через SMTP сервер
+type SMTPMailer struct {
+    host     string
+    port     int
+    username string
+    password string
+    from     string
+}
+
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+    return &SMTPMailer{
+        host:     host,
+        port:     port,
+        username: username,
+        password: password,
+        from:     from,
+    }
+}
+
+// Send отправляет письмо через SMTP
+func (m *SMTPMailer) Send(ctx context.Context, mail *Mail) error {
+    var auth smtp.Auth
+    if m.username != "" {
+        auth = smtp.PlainAuth("", m.username, m.password, m.host)
+    }
+
+    addr := fmt.Sprintf("%s:%d", m.host, m.port)
+    if err := smtp.SendMail(addr, auth, m.from, []string{mail.To}, buildMessage(m.from, mail)); err != nil {
+        return fmt.Errorf("failed to send mail: %w", err)
+    }
+    return nil
+}
+
+// FileMailer складывает письма в папку (outbox) вместо отправки.
+// Удобно для локальной разработки: письмо можно открыть любым почтовым клиентом.
+type FileMailer struct {
+    dir  string
+    from string
+}
+
+func NewFileMailer(dir, from string) *FileMailer {
+    os.MkdirAll(dir, 0755)
+    return &FileMailer{dir: dir, from: from}
+}
+
+// Send сохраняет письмо в .eml файл
+func (m *FileMailer) Send(ctx context.Context, mail *Mail) error {
+    fileName := fmt.Sprintf("%s_%s.eml", time.Now().Format("20060102T150405"), uuid.New().String())
+    if err := os.WriteFile(filepath.Join(m.dir, fileName), buildMessage(m.from, mail), 0644); err != nil {
+        return fmt.Errorf("failed to write mail to outbox: %w", err)
+    }
+    return nil
+}
+
+// MemoryMailer хранит письма в памяти (для тестов)
+type MemoryMailer struct {
+    mu       sync.Mutex
+    messages []Mail
+}
+
+func NewMemoryMailer() *MemoryMailer {
+    return &MemoryMailer{}
+}
+
+// Send запоминает письмо
+func (m *MemoryMailer) Send(ctx context.Context, mail *Mail) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.messages = append(m.messages, *mail)
+    return nil
+}
+
+// Messages возвращает копию всех отправленных писем
+func (m *MemoryMailer) Messages() []Mail {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    return append([]Mail(nil), m.messages...)
+}
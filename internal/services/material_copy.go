@@ -0,0 +1,179 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+
+    "paydeya-backend/internal/models"
+)
+
+var ErrNotTemplate = errors.New("material is not a template")
+
+// DuplicateMaterial создает копию материала в черновиках пользователя.
+// Свой материал (или материал, который пользователь может редактировать)
+// копируется из черновика целиком. Чужой - только опубликованный в открытом
+// доступе: копируется опубликованная версия без ключей ответов и критериев
+// оценки, а у копии сохраняется автор оригинала.
+func (s *MaterialService) DuplicateMaterial(ctx context.Context, userID int, role string, materialID int, req *models.CopyMaterialRequest) (*models.Material, error) {
+    source, err := s.materialRepo.GetMaterial(ctx, materialID)
+    if err != nil {
+        return nil, fmt.Errorf("error finding material: %w", err)
+    }
+    if source == nil {
+        return nil, ErrMaterialNotFound
+    }
+
+    err = s.authorizer.CanEditMaterial(ctx, userID, role, source)
+    if err == nil {
+        blocks, err := s.blockRepo.GetBlocks(ctx, materialID)
+        if err != nil {
+            return nil, err
+        }
+        return s.copyMaterial(ctx, userID, source, nil, copyTitle(req, source.Title+" (копия)"), blocks)
+    }
+    if !errors.Is(err, ErrAccessDenied) {
+        return nil, err
+    }
+
+    if source.Status != "published" || source.PublishedRevision == nil {
+        return nil, ErrMaterialNotFound
+    }
+    if source.Access != "open" {
+        return nil, ErrAccessDenied
+    }
+
+    revision, err := s.loadRevision(ctx, materialID, *source.PublishedRevision)
+    if err != nil {
+        return nil, err
+    }
+    // Ключи ответов остаются у автора оригинала, как и при просмотре учениками
+    blocks := make([]models.Block, len(revision.Blocks))
+    for i := range revision.Blocks {
+        blocks[i] = s.blocks.StudentView(revision.Blocks[i])
+    }
+    return s.copyMaterial(ctx, userID, source, source.PublishedRevision, copyTitle(req, revision.Title), blocks)
+}
+
+// SetTemplate отмечает материал шаблоном или снимает отметку
+func (s *MaterialService) SetTemplate(ctx context.Context, userID int, role string, materialID int, isTemplate bool) (*models.Material, error) {
+    material, err := s.getEditableMaterial(ctx, userID, role, materialID)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := s.materialRepo.SetTemplate(ctx, materialID, isTemplate); err != nil {
+        return nil, fmt.Errorf("failed to update template flag: %w", err)
+    }
+
+    material.IsTemplate = isTemplate
+    return material, nil
+}
+
+// InstantiateTemplate создает материал по шаблону. Автор шаблона получает копию
+// черновика, остальные - опубликованной версии шаблона. Чужой шаблон доступен,
+// если он опубликован в открытом доступе или добавлен в галерею. Шаблон
+// копируется целиком, вместе с ключами ответов: автор отдал его для повторного использования.
+func (s *MaterialService) InstantiateTemplate(ctx context.Context, userID int, role string, materialID int, req *models.CopyMaterialRequest) (*models.Material, error) {
+    source, err := s.materialRepo.GetMaterial(ctx, materialID)
+    if err != nil {
+        return nil, fmt.Errorf("error finding material: %w", err)
+    }
+    if source == nil {
+        return nil, ErrMaterialNotFound
+    }
+
+    err = s.authorizer.CanEditMaterial(ctx, userID, role, source)
+    if err == nil {
+        if !source.IsTemplate {
+            return nil, ErrNotTemplate
+        }
+        blocks, err := s.blockRepo.GetBlocks(ctx, materialID)
+        if err != nil {
+            return nil, err
+        }
+        return s.copyMaterial(ctx, userID, source, nil, copyTitle(req, source.Title), blocks)
+    }
+    if !errors.Is(err, ErrAccessDenied) {
+        return nil, err
+    }
+
+    if source.Status != "published" || source.PublishedRevision == nil {
+        return nil, ErrMaterialNotFound
+    }
+    if !source.IsTemplate {
+        return nil, ErrNotTemplate
+    }
+    if source.Access != "open" {
+        inGallery, err := s.templateRepo.InGallery(ctx, materialID)
+        if err != nil {
+            return nil, fmt.Errorf("failed to check gallery: %w", err)
+        }
+        if !inGallery {
+            return nil, ErrAccessDenied
+        }
+    }
+
+    revision, err := s.loadRevision(ctx, materialID, *source.PublishedRevision)
+    if err != nil {
+        return nil, err
+    }
+    return s.copyMaterial(ctx, userID, source, source.PublishedRevision, copyTitle(req, revision.Title), revision.Blocks)
+}
+
+// copyMaterial создает черновик пользователя с переданными блоками и ссылкой на источник.
+// revision - опубликованная версия источника, если копировалась она, а не черновик.
+func (s *MaterialService) copyMaterial(ctx context.Context, userID int, source *models.Material, revision *int, title string, blocks []models.Block) (*models.Material, error) {
+    material := &models.Material{
+        Title:          title,
+        Subject:        source.Subject,
+        AuthorID:       userID,
+        Status:         "draft",
+        Access:         "open",
+        ForkedFrom:     &source.ID,
+        ForkedRevision: revision,
+    }
+
+    // У копий копий указывается первый автор
+    switch {
+    case source.OriginalAuthorID != nil && *source.OriginalAuthorID != userID:
+        material.OriginalAuthorID = source.OriginalAuthorID
+    case source.OriginalAuthorID == nil && source.AuthorID != userID:
+        material.OriginalAuthorID = &source.AuthorID
+    }
+
+    // Копия создается целиком или не создается: без блоков или первой
+    // ревизии в черновиках остался бы пустой материал
+    err := s.inTx(ctx, func(tx *MaterialService) error {
+        if err := tx.materialRepo.CreateMaterial(ctx, material); err != nil {
+            return fmt.Errorf("failed to create material: %w", err)
+        }
+        if len(blocks) > 0 {
+            if err := tx.blockRepo.SaveBlocks(ctx, material.ID, blocks); err != nil {
+                return fmt.Errorf("failed to save blocks: %w", err)
+            }
+        }
+        _, err := tx.recordRevision(ctx, material.ID, userID, models.RevisionActionCreated, nil)
+        return err
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    copied, err := s.GetMaterial(ctx, material.ID)
+    if err != nil {
+        return nil, err
+    }
+    if copied == nil {
+        return nil, ErrMaterialNotFound
+    }
+    copied.Version = models.MaterialVersionDraft
+    return copied, nil
+}
+
+func copyTitle(req *models.CopyMaterialRequest, fallback string) string {
+    if req.Title != "" {
+        return req.Title
+    }
+    return fallback
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+
+    "paydeya-backend/internal/models"
+)
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// recordRevision сохраняет текущее состояние материала как новую ревизию
+func (s *MaterialService) recordRevision(ctx context.Context, materialID, userID int, action string, restoredFrom *int) (*models.MaterialRevision, error) {
+    material, err := s.GetMaterial(ctx, materialID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to load material: %w", err)
+    }
+    if material == nil {
+        return nil, ErrMaterialNotFound
+    }
+
+    revision := &models.MaterialRevision{
+        MaterialID:   materialID,
+        Title:        material.Title,
+        Blocks:       material.Blocks,
+        AuthorID:     userID,
+        Action:       action,
+        RestoredFrom: restoredFrom,
+    }
+    if err := s.revisionRepo.CreateRevision(ctx, revision); err != nil {
+        return nil, fmt.Errorf("failed to save revision: %w", err)
+    }
+
+    return revision, nil
+}
+
+// ensureBaseline сохраняет исходное состояние материалов, созданных до появления
+// истории, чтобы первую же правку можно было откатить
+func (s *MaterialService) ensureBaseline(ctx context.Context, material *models.Material) error {
+    exists, err := s.revisionRepo.HasRevisions(ctx, material.ID)
+    if err != nil {
+        return fmt.Errorf("failed to check revisions: %w", err)
+    }
+    if exists {
+        return nil
+    }
+
+    _, err = s.recordRevision(ctx, material.ID, material.AuthorID, models.RevisionActionBaseline, nil)
+    return err
+}
+
+// ListRevisions возвращает историю ревизий материала
+func (s *MaterialService) ListRevisions(ctx context.Context, userID int, role string, materialID, page, limit int) ([]models.MaterialRevision, int, error) {
+    if _, err := s.getEditableMaterial(ctx, userID, role, materialID); err != nil {
+        return nil, 0, err
+    }
+
+    revisions, total, err := s.revisionRepo.GetRevisions(ctx, materialID, page, limit)
+    if err != nil {
+        return nil, 0, fmt.Errorf("failed to get revisions: %w", err)
+    }
+    return revisions, total, nil
+}
+
+// GetRevision возвращает ревизию материала вместе с блоками
+func (s *MaterialService) GetRevision(ctx context.Context, userID int, role string, materialID, number int) (*models.MaterialRevision, error) {
+    if _, err := s.getEditableMaterial(ctx, userID, role, materialID); err != nil {
+        return nil, err
+    }
+    return s.loadRevision(ctx, materialID, number)
+}
+
+func (s *MaterialService) loadRevision(ctx context.Context, materialID, number int) (*models.MaterialRevision, error) {
+    revision, err := s.revisionRepo.GetRevision(ctx, materialID, number)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get revision: %w", err)
+    }
+    if revision == nil {
+        return nil, fmt.Errorf("%w: %d", ErrRevisionNotFound, number)
+    }
+    return revision, nil
+}
+
+// DiffRevisions сравнивает ревизию to с ревизией from на уровне блоков.
+// from = 0 означает сравнение с пустым материалом.
+func (s *MaterialService) DiffRevisions(ctx context.Context, userID int, role string, materialID, from, to int) (*models.RevisionDiff, error) {
+    if _, err := s.getEditableMaterial(ctx, userID, role, materialID); err != nil {
+        return nil, err
+    }
+
+    toRevision, err := s.loadRevision(ctx, materialID, to)
+    if err != nil {
+        return nil, err
+    }
+
+    fromRevision := &models.MaterialRevision{MaterialID: materialID}
+    if from > 0 {
+        fromRevision, err = s.loadRevision(ctx, materialID, from)
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    return diffRevisions(fromRevision, toRevision), nil
+}
+
+// RestoreRevision возвращает материалу заголовок и блоки из ревизии.
+// История не переписывается: восстановление сохраняется новой ревизией.
+// Вместе с ней возвращается новая версия черновика.
+func (s *MaterialService) RestoreRevision(ctx context.Context, userID int, role string, materialID, number, version int) (*models.MaterialRevision, int, error) {
+    material, err := s.getEditableMaterial(ctx, userID, role, materialID)
+    if err != nil {
+        return nil, 0, err
+    }
+
+    revision, err := s.loadRevision(ctx, materialID, number)
+    if err != nil {
+        return nil, 0, err
+    }
+
+    var restored *models.MaterialRevision
+    var newVersion int
+    err = s.inTx(ctx, func(tx *MaterialService) error {
+        if newVersion, err = tx.claimVersion(ctx, materialID, version); err != nil {
+            return err
+        }
+
+        material.Title = revision.Title
+        if err := tx.materialRepo.UpdateMaterial(ctx, material); err != nil {
+            return fmt.Errorf("failed to restore title: %w", err)
+        }
+        if err := tx.blockRepo.SaveBlocks(ctx, materialID, revision.Blocks); err != nil {
+            return fmt.Errorf("failed to restore blocks: %w", err)
+        }
+
+        restored, err = tx.recordRevision(ctx, materialID, userID, models.RevisionActionRestored, &number)
+        return err
+    })
+    if err != nil {
+        return nil, 0, err
+    }
+    return restored, newVersion, nil
+}
@@ -4,6 +4,7 @@ import (
     "context"
     "crypto/rand"
     "encoding/hex"
+    "errors"
     "fmt"
     "strconv"
 
@@ -11,18 +12,121 @@ import (
     "paydeya-backend/internal/repositories"
 )
 
+var (
+    ErrMaterialNotFound     = errors.New("material not found")
+    ErrBlockNotFound        = errors.New("block not found")
+    ErrMaterialNotPublished = errors.New("material has no published version")
+    ErrInvalidBlockOrder    = errors.New("invalid block order")
+    ErrVersionMismatch      = errors.New("material was modified by someone else")
+)
+
+// VersionConflictError - запись с устаревшей версией. Содержит текущий
+// черновик, чтобы редактор мог слить изменения.
+type VersionConflictError struct {
+    Current *models.Material
+}
+
+func (e *VersionConflictError) Error() string {
+    return fmt.Sprintf("%s: current version %d", ErrVersionMismatch, e.Current.EditVersion)
+}
+
+func (e *VersionConflictError) Is(target error) bool {
+    return target == ErrVersionMismatch
+}
+
 type MaterialService struct {
     materialRepo *repositories.MaterialRepository
     blockRepo    *repositories.BlockRepository
+    revisionRepo *repositories.RevisionRepository
+    templateRepo *repositories.TemplateRepository
+    blocks       *BlockRegistry
+    authorizer   *Authorizer
 }
 
-func NewMaterialService(materialRepo *repositories.MaterialRepository, blockRepo *repositories.BlockRepository) *MaterialService {
+func NewMaterialService(materialRepo *repositories.MaterialRepository, blockRepo *repositories.BlockRepository, revisionRepo *repositories.RevisionRepository, templateRepo *repositories.TemplateRepository, blocks *BlockRegistry, authorizer *Authorizer) *MaterialService {
     return &MaterialService{
         materialRepo: materialRepo,
         blockRepo:    blockRepo,
+        revisionRepo: revisionRepo,
+        templateRepo: templateRepo,
+        blocks:       blocks,
+        authorizer:   authorizer,
     }
 }
 
+// BlockSchemas возвращает схемы содержимого всех типов блоков
+func (s *MaterialService) BlockSchemas() []models.BlockSchema {
+    return s.blocks.Schemas()
+}
+
+// PreviewAnimation проверяет блок с анимацией и возвращает шаги анимации
+// с абсолютным временем, одинаковым для всех проигрывателей
+func (s *MaterialService) PreviewAnimation(block *models.Block) (*models.AnimationTimeline, error) {
+    if block.Animation == nil {
+        return nil, &BlockValidationError{Errors: []models.FieldError{{Field: "animation", Message: "is required"}}}
+    }
+    if err := s.blocks.ValidateBlock(block); err != nil {
+        return nil, err
+    }
+    return resolveAnimation(block), nil
+}
+
+// getEditableMaterial загружает материал и проверяет право пользователя его изменять
+func (s *MaterialService) getEditableMaterial(ctx context.Context, userID int, role string, materialID int) (*models.Material, error) {
+    material, err := s.materialRepo.GetMaterial(ctx, materialID)
+    if err != nil {
+        return nil, fmt.Errorf("error finding material: %w", err)
+    }
+    if material == nil {
+        return nil, ErrMaterialNotFound
+    }
+
+    if err := s.authorizer.CanEditMaterial(ctx, userID, role, material); err != nil {
+        return nil, err
+    }
+
+    return material, nil
+}
+
+// claimVersion проверяет, что черновик не менялся с версии expected, и занимает
+// следующую версию. expected = 0 отключает проверку (If-Match: *).
+func (s *MaterialService) claimVersion(ctx context.Context, materialID, expected int) (int, error) {
+    version, err := s.materialRepo.BumpEditVersion(ctx, materialID, expected)
+    if err != nil {
+        return 0, err
+    }
+    if version != 0 {
+        return version, nil
+    }
+
+    current, err := s.GetMaterial(ctx, materialID)
+    if err != nil {
+        return 0, err
+    }
+    current.Version = models.MaterialVersionDraft
+    return 0, &VersionConflictError{Current: current}
+}
+
+// inTx выполняет многошаговое изменение в одной транзакции: новая версия
+// черновика, сами изменения и ревизия сохраняются вместе или не сохраняются
+// вовсе. fn получает копию сервиса, репозитории которой работают в транзакции.
+func (s *MaterialService) inTx(ctx context.Context, fn func(tx *MaterialService) error) error {
+    tx, err := s.materialRepo.Begin(ctx)
+    if err != nil {
+        return fmt.Errorf("failed to begin transaction: %w", err)
+    }
+    defer tx.Rollback(ctx)
+
+    txService := *s
+    txService.materialRepo = s.materialRepo.WithTx(tx)
+    txService.blockRepo = s.blockRepo.WithTx(tx)
+    txService.revisionRepo = s.revisionRepo.WithTx(tx)
+    if err := fn(&txService); err != nil {
+        return err
+    }
+    return tx.Commit(ctx)
+}
+
 // CreateMaterial создает новый материал
 func (s *MaterialService) CreateMaterial(ctx context.Context, userID int, req *models.CreateMaterialRequest) (*models.Material, error) {
     material := &models.Material{
@@ -34,14 +138,76 @@ func (s *MaterialService) CreateMaterial(ctx context.Context, userID int, req *m
         Blocks:   []models.Block{},
     }
 
-    if err := s.materialRepo.CreateMaterial(ctx, material); err != nil {
-        return nil, fmt.Errorf("failed to create material: %w", err)
+    err := s.inTx(ctx, func(tx *MaterialService) error {
+        if err := tx.materialRepo.CreateMaterial(ctx, material); err != nil {
+            return fmt.Errorf("failed to create material: %w", err)
+        }
+        _, err := tx.recordRevision(ctx, material.ID, userID, models.RevisionActionCreated, nil)
+        return err
+    })
+    if err != nil {
+        return nil, err
     }
 
     return material, nil
 }
 
-// GetMaterial возвращает материал с блоками
+// ViewMaterial возвращает материал так, как его должен видеть пользователь.
+// Тем, кто может редактировать материал, по умолчанию отдается черновик,
+// остальным - только опубликованная версия: незаконченные правки им не видны.
+func (s *MaterialService) ViewMaterial(ctx context.Context, userID int, role string, materialID int, version string) (*models.Material, error) {
+    material, err := s.materialRepo.GetMaterial(ctx, materialID)
+    if err != nil {
+        return nil, fmt.Errorf("error finding material: %w", err)
+    }
+    if material == nil {
+        return nil, ErrMaterialNotFound
+    }
+
+    canEdit := true
+    if err := s.authorizer.CanEditMaterial(ctx, userID, role, material); errors.Is(err, ErrAccessDenied) {
+        canEdit = false
+    } else if err != nil {
+        return nil, err
+    }
+
+    if canEdit && version != models.MaterialVersionPublished {
+        blocks, err := s.blockRepo.GetBlocks(ctx, materialID)
+        if err != nil {
+            return nil, err
+        }
+        material.Blocks = blocks
+        material.Version = models.MaterialVersionDraft
+        return material, nil
+    }
+
+    if material.Status != "published" || material.PublishedRevision == nil {
+        if canEdit {
+            return nil, ErrMaterialNotPublished
+        }
+        return nil, ErrMaterialNotFound
+    }
+
+    revision, err := s.loadRevision(ctx, materialID, *material.PublishedRevision)
+    if err != nil {
+        return nil, err
+    }
+
+    material.Title = revision.Title
+    material.Blocks = revision.Blocks
+    material.Version = models.MaterialVersionPublished
+    if !canEdit {
+        material.HasUnpublishedChanges = false
+        material.EditVersion = 0
+        // Ученикам не отдаются ключи ответов
+        for i := range material.Blocks {
+            material.Blocks[i] = s.blocks.StudentView(material.Blocks[i])
+        }
+    }
+    return material, nil
+}
+
+// GetMaterial возвращает материал с блоками черновика
 func (s *MaterialService) GetMaterial(ctx context.Context, materialID int) (*models.Material, error) {
     material, err := s.materialRepo.GetMaterial(ctx, materialID)
     if err != nil || material == nil {
@@ -63,69 +229,106 @@ func (s *MaterialService) GetUserMaterials(ctx context.Context, userID int, stat
     return s.materialRepo.GetUserMaterials(ctx, userID, status)
 }
 
-// UpdateMaterial обновляет материал и блоки
-func (s *MaterialService) UpdateMaterial(ctx context.Context, userID int, materialID int, req *models.UpdateMaterialRequest) error {
+// UpdateMaterial обновляет материал и блоки. Возвращает новую версию черновика.
+func (s *MaterialService) UpdateMaterial(ctx context.Context, userID int, role string, materialID int, req *models.UpdateMaterialRequest, version int) (int, error) {
     // Получаем текущий материал для проверки прав
-    material, err := s.materialRepo.GetMaterial(ctx, materialID)
-    if err != nil || material == nil {
-        return fmt.Errorf("material not found")
+    material, err := s.getEditableMaterial(ctx, userID, role, materialID)
+    if err != nil {
+        return 0, err
     }
 
-    if material.AuthorID != userID {
-        return fmt.Errorf("access denied")
+    if req.Title == "" && req.Blocks == nil {
+        return material.EditVersion, nil
+    }
+    if err := s.blocks.ValidateBlocks(req.Blocks); err != nil {
+        return 0, err
+    }
+    if err := checkUniqueBlockIDs(getBlockIDs(req.Blocks)); err != nil {
+        return 0, err
     }
 
-    // Обновляем заголовок если передан
-    if req.Title != "" {
-        material.Title = req.Title
-        if err := s.materialRepo.UpdateMaterial(ctx, material); err != nil {
+    var newVersion int
+    err = s.inTx(ctx, func(tx *MaterialService) error {
+        if newVersion, err = tx.claimVersion(ctx, materialID, version); err != nil {
+            return err
+        }
+        if err := tx.ensureBaseline(ctx, material); err != nil {
             return err
         }
-    }
 
-    // Сохраняем блоки если переданы
-    if req.Blocks != nil {
-        if err := s.blockRepo.SaveBlocks(ctx, materialID, req.Blocks); err != nil {
-            return fmt.Errorf("failed to save blocks: %w", err)
+        // Обновляем заголовок если передан
+        if req.Title != "" {
+            material.Title = req.Title
+            if err := tx.materialRepo.UpdateMaterial(ctx, material); err != nil {
+                return err
+            }
         }
-    }
 
-    return nil
-}
-// PublishMaterial публикует материал
-func (s *MaterialService) PublishMaterial(ctx context.Context, userID, materialID int, req *models.PublishMaterialRequest) (*models.Material, error) {
-    // Получаем материал
-    material, err := s.materialRepo.GetMaterial(ctx, materialID)
-    if err != nil || material == nil {
-        return nil, fmt.Errorf("material not found")
+        // Сохраняем блоки если переданы
+        if req.Blocks != nil {
+            if err := tx.blockRepo.SaveBlocks(ctx, materialID, req.Blocks); err != nil {
+                return fmt.Errorf("failed to save blocks: %w", err)
+            }
+        }
+
+        _, err := tx.recordRevision(ctx, materialID, userID, models.RevisionActionUpdated, nil)
+        return err
+    })
+    if err != nil {
+        return 0, err
     }
+    return newVersion, nil
+}
 
-    // Проверяем права
-    if material.AuthorID != userID {
-        return nil, fmt.Errorf("access denied")
+// PublishMaterial публикует материал: текущий черновик замораживается
+// неизменяемой ревизией, которую и видят ученики до следующей публикации
+func (s *MaterialService) PublishMaterial(ctx context.Context, userID int, role string, materialID int, req *models.PublishMaterialRequest, version int) (*models.Material, error) {
+    // Получаем материал и проверяем права
+    material, err := s.getEditableMaterial(ctx, userID, role, materialID)
+    if err != nil {
+        return nil, err
     }
 
-    // Обновляем статус и доступ
-    material.Status = req.Visibility
-    material.Access = req.Access
+    err = s.inTx(ctx, func(tx *MaterialService) error {
+        // Публикуется тот черновик, который видел автор
+        if material.EditVersion, err = tx.claimVersion(ctx, materialID, version); err != nil {
+            return err
+        }
 
-    // Генерируем share URL если нужно
-    if req.Access == "link" {
-        material.ShareURL = "/m/" + s.generateShareURL()
-    } else {
-        material.ShareURL = "/material/" + strconv.Itoa(materialID)
-    }
+        if req.Visibility == "published" {
+            revision, err := tx.recordRevision(ctx, materialID, userID, models.RevisionActionPublished, nil)
+            if err != nil {
+                return err
+            }
+            material.PublishedRevision = &revision.Revision
+            material.PublishedAt = &revision.CreatedAt
+            material.HasUnpublishedChanges = false
+        }
+
+        // Обновляем статус и доступ
+        material.Status = req.Visibility
+        material.Access = req.Access
 
-    // Сохраняем изменения
-    if err := s.materialRepo.UpdateMaterial(ctx, material); err != nil {
-        return nil, fmt.Errorf("failed to publish material: %w", err)
+        // Генерируем share URL если нужно
+        if req.Access == "link" {
+            material.ShareURL = "/m/" + s.generateShareURL()
+        } else {
+            material.ShareURL = "/material/" + strconv.Itoa(materialID)
+        }
+
+        // Сохраняем изменения
+        if err := tx.materialRepo.UpdateMaterial(ctx, material); err != nil {
+            return fmt.Errorf("failed to publish material: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
     }
 
     return material, nil
 }
 
-
-
 // generateShareURL генерирует уникальный URL для доступа по ссылке
 func (s *MaterialService) generateShareURL() string {
     bytes := make([]byte, 8)
@@ -133,94 +336,123 @@ func (s *MaterialService) generateShareURL() string {
     return hex.EncodeToString(bytes)
 }
 
-// AddBlock добавляет блок к материалу
-func (s *MaterialService) AddBlock(ctx context.Context, userID, materialID int, block *models.Block) error {
+// AddBlock добавляет блок в конец материала. Операции с блоками возвращают
+// новую версию черновика.
+func (s *MaterialService) AddBlock(ctx context.Context, userID int, role string, materialID int, block *models.Block, version int) (int, error) {
     // Проверяем права
-    material, err := s.materialRepo.GetMaterial(ctx, materialID)
-    if err != nil || material == nil {
-        return fmt.Errorf("material not found")
+    material, err := s.getEditableMaterial(ctx, userID, role, materialID)
+    if err != nil {
+        return 0, err
     }
-    if material.AuthorID != userID {
-        return fmt.Errorf("access denied")
+    if err := s.blocks.ValidateBlock(block); err != nil {
+        return 0, err
     }
 
-    // Получаем текущие блоки
-    blocks, err := s.blockRepo.GetBlocks(ctx, materialID)
-    if err != nil {
-        return err
-    }
+    var newVersion int
+    err = s.inTx(ctx, func(tx *MaterialService) error {
+        if newVersion, err = tx.claimVersion(ctx, materialID, version); err != nil {
+            return err
+        }
+        if err := tx.ensureBaseline(ctx, material); err != nil {
+            return err
+        }
 
-    // Добавляем новый блок
-    blocks = append(blocks, *block)
+        if err := tx.blockRepo.InsertBlock(ctx, materialID, block); err != nil {
+            return err
+        }
 
-    // Сохраняем все блоки
-    return s.blockRepo.SaveBlocks(ctx, materialID, blocks)
+        _, err := tx.recordRevision(ctx, materialID, userID, models.RevisionActionBlockAdded, nil)
+        return err
+    })
+    if err != nil {
+        return 0, err
+    }
+    return newVersion, nil
 }
 
 // UpdateBlock обновляет блок
-func (s *MaterialService) UpdateBlock(ctx context.Context, userID, materialID int, blockID string, block *models.Block) error {
-
+func (s *MaterialService) UpdateBlock(ctx context.Context, userID int, role string, materialID int, blockID string, block *models.Block, version int) (int, error) {
     // Проверяем права
-    material, err := s.materialRepo.GetMaterial(ctx, materialID)
-    if err != nil || material == nil {
-        return fmt.Errorf("material not found")
+    material, err := s.getEditableMaterial(ctx, userID, role, materialID)
+    if err != nil {
+        return 0, err
     }
-    if material.AuthorID != userID {
-        return fmt.Errorf("access denied")
+    block.ID = blockID
+    if err := s.blocks.ValidateBlock(block); err != nil {
+        return 0, err
     }
 
-    // Получаем текущие блоки
-    blocks, err := s.blockRepo.GetBlocks(ctx, materialID)
-    if err != nil {
-        return err
-    }
+    // Если блока нет, транзакция откатывается вместе с новой версией черновика
+    var newVersion int
+    err = s.inTx(ctx, func(tx *MaterialService) error {
+        if newVersion, err = tx.claimVersion(ctx, materialID, version); err != nil {
+            return err
+        }
+        if err := tx.ensureBaseline(ctx, material); err != nil {
+            return err
+        }
 
-    // Находим и обновляем блок
-    for i, b := range blocks {
-        if b.ID == blockID {
-            blocks[i] = *block
-            return s.blockRepo.SaveBlocks(ctx, materialID, blocks)
+        updated, err := tx.blockRepo.UpdateBlock(ctx, materialID, block)
+        if err != nil {
+            return err
+        }
+        if !updated {
+            return ErrBlockNotFound
         }
-    }
 
-    return fmt.Errorf("block not found")
+        _, err = tx.recordRevision(ctx, materialID, userID, models.RevisionActionBlockUpdated, nil)
+        return err
+    })
+    if err != nil {
+        return 0, err
+    }
+    return newVersion, nil
 }
 
 // DeleteBlock удаляет блок
-func (s *MaterialService) DeleteBlock(ctx context.Context, userID, materialID int, blockID string) error {
+func (s *MaterialService) DeleteBlock(ctx context.Context, userID int, role string, materialID int, blockID string, version int) (int, error) {
     // Проверяем права
-    material, err := s.materialRepo.GetMaterial(ctx, materialID)
-    if err != nil || material == nil {
-        return fmt.Errorf("material not found")
-    }
-    if material.AuthorID != userID {
-        return fmt.Errorf("access denied")
-    }
-
-    // Получаем текущие блоки
-    blocks, err := s.blockRepo.GetBlocks(ctx, materialID)
+    material, err := s.getEditableMaterial(ctx, userID, role, materialID)
     if err != nil {
-        return err
+        return 0, err
     }
+    // Если блока нет, транзакция откатывается вместе с новой версией черновика
+    var newVersion int
+    err = s.inTx(ctx, func(tx *MaterialService) error {
+        if newVersion, err = tx.claimVersion(ctx, materialID, version); err != nil {
+            return err
+        }
+        if err := tx.ensureBaseline(ctx, material); err != nil {
+            return err
+        }
 
-    // Удаляем блок
-    var newBlocks []models.Block
-    for _, block := range blocks {
-        if block.ID != blockID {
-            newBlocks = append(newBlocks, block)
+        deleted, err := tx.blockRepo.DeleteBlock(ctx, materialID, blockID)
+        if err != nil {
+            return err
+        }
+        if !deleted {
+            return ErrBlockNotFound
         }
-    }
 
-    return s.blockRepo.SaveBlocks(ctx, materialID, newBlocks)
+        _, err = tx.recordRevision(ctx, materialID, userID, models.RevisionActionBlockDeleted, nil)
+        return err
+    })
+    if err != nil {
+        return 0, err
+    }
+    return newVersion, nil
 }
 
-// Вспомогательные функции
-func getMapKeys(m map[string]models.Block) []string {
-    keys := make([]string, 0, len(m))
-    for k := range m {
-        keys = append(keys, k)
+// checkUniqueBlockIDs проверяет, что ID блоков не повторяются
+func checkUniqueBlockIDs(blockIDs []string) error {
+    seen := make(map[string]bool, len(blockIDs))
+    for _, blockID := range blockIDs {
+        if seen[blockID] {
+            return fmt.Errorf("%w: duplicate block %s", ErrInvalidBlockOrder, blockID)
+        }
+        seen[blockID] = true
     }
-    return keys
+    return nil
 }
 
 func getBlockIDs(blocks []models.Block) []string {
@@ -231,40 +463,63 @@ func getBlockIDs(blocks []models.Block) []string {
     return ids
 }
 
-// ReorderBlocks изменяет порядок блоков
-func (s *MaterialService) ReorderBlocks(ctx context.Context, userID, materialID int, blockIDs []string) error {
+// ReorderBlocks изменяет порядок блоков. Блоки, не указанные в списке,
+// сохраняют взаимный порядок и располагаются после указанных.
+func (s *MaterialService) ReorderBlocks(ctx context.Context, userID int, role string, materialID int, blockIDs []string, version int) (int, error) {
     // Проверяем права
-    material, err := s.materialRepo.GetMaterial(ctx, materialID)
-    if err != nil || material == nil {
-        return fmt.Errorf("material not found")
+    material, err := s.getEditableMaterial(ctx, userID, role, materialID)
+    if err != nil {
+        return 0, err
     }
-    if material.AuthorID != userID {
-        return fmt.Errorf("access denied")
+    if err := checkUniqueBlockIDs(blockIDs); err != nil {
+        return 0, err
     }
 
     // Получаем текущие блоки
     blocks, err := s.blockRepo.GetBlocks(ctx, materialID)
     if err != nil {
-        return err
+        return 0, err
     }
 
-    // Создаем мапу для быстрого поиска блоков по ID
-    blockMap := make(map[string]models.Block)
+    existing := make(map[string]bool, len(blocks))
     for _, block := range blocks {
-        blockMap[block.ID] = block
+        existing[block.ID] = true
     }
 
-    // Создаем новые блоки в указанном порядке
-    var newBlocks []models.Block
-    for position, blockID := range blockIDs {
-        block, exists := blockMap[blockID]
-        if !exists {
-            return fmt.Errorf("block not found: %s", blockID)
+    listed := make(map[string]bool, len(blockIDs))
+    for _, blockID := range blockIDs {
+        if !existing[blockID] {
+            return 0, fmt.Errorf("%w: %s", ErrBlockNotFound, blockID)
+        }
+        listed[blockID] = true
+    }
+
+    order := append([]string{}, blockIDs...)
+    for _, block := range blocks {
+        if !listed[block.ID] {
+            order = append(order, block.ID)
         }
-        block.Position = position
-        newBlocks = append(newBlocks, block)
     }
 
-    // Сохраняем новый порядок
-    return s.blockRepo.SaveBlocks(ctx, materialID, newBlocks)
+    var newVersion int
+    err = s.inTx(ctx, func(tx *MaterialService) error {
+        if newVersion, err = tx.claimVersion(ctx, materialID, version); err != nil {
+            return err
+        }
+        if err := tx.ensureBaseline(ctx, material); err != nil {
+            return err
+        }
+
+        // Сохраняем новый порядок
+        if err := tx.blockRepo.MoveBlocks(ctx, materialID, order); err != nil {
+            return err
+        }
+
+        _, err := tx.recordRevision(ctx, materialID, userID, models.RevisionActionBlocksOrdered, nil)
+        return err
+    })
+    if err != nil {
+        return 0, err
+    }
+    return newVersion, nil
 }
@@ -0,0 +1,315 @@
+package services
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "net/url"
+    "strings"
+
+    "paydeya-backend/internal/models"
+
+    "golang.org/x/oauth2"
+)
+
+// OAuthFieldMapping описывает, где в ответе userinfo лежат данные пользователя.
+// Пути записываются через точку: "user.email" для {"user": {"email": ...}}.
+type OAuthFieldMapping struct {
+    Subject       string
+    Email         string
+    EmailVerified string   // пусто: провайдер отдает только подтвержденные адреса
+    Name          []string // несколько полей склеиваются через пробел (имя + фамилия)
+    Avatar        string
+}
+
+// OAuthProvider описывает провайдера входа по OAuth2 authorization code с PKCE
+type OAuthProvider struct {
+    Name        string
+    DisplayName string
+    Config      oauth2.Config
+
+    UserInfoURL        string
+    UserInfoMethod     string // GET (по умолчанию) или POST с access_token в форме
+    UserInfoAuthScheme string // схема заголовка Authorization, по умолчанию Bearer
+    Fields             OAuthFieldMapping
+    AvatarURLTemplate  string // если задан, в него подставляется значение поля Avatar
+
+    // ExchangeParams — параметры из callback, которые провайдер ждет в запросе токена
+    ExchangeParams []string
+}
+
+// OAuthRegistry хранит включенных провайдеров в порядке регистрации
+type OAuthRegistry struct {
+    providers map[string]*OAuthProvider
+    order     []string
+}
+
+func NewOAuthRegistry() *OAuthRegistry {
+    return &OAuthRegistry{providers: make(map[string]*OAuthProvider)}
+}
+
+// Register добавляет провайдера (повторная регистрация заменяет его)
+func (r *OAuthRegistry) Register(provider *OAuthProvider) {
+    if _, exists := r.providers[provider.Name]; !exists {
+        r.order = append(r.order, provider.Name)
+    }
+    r.providers[provider.Name] = provider
+}
+
+// Get возвращает провайдера по имени
+func (r *OAuthRegistry) Get(name string) (*OAuthProvider, bool) {
+    provider, ok := r.providers[name]
+    return provider, ok
+}
+
+// List возвращает включенных провайдеров для кнопок входа на фронтенде
+func (r *OAuthRegistry) List() []models.OAuthProviderInfo {
+    providers := make([]models.OAuthProviderInfo, 0, len(r.order))
+    for _, name := range r.order {
+        providers = append(providers, models.OAuthProviderInfo{
+            Name:        name,
+            DisplayName: r.providers[name].DisplayName,
+        })
+    }
+    return providers
+}
+
+// NewYandexProvider настраивает вход через Яндекс ID
+func NewYandexProvider(clientID, clientSecret, redirectURL string) *OAuthProvider {
+    return &OAuthProvider{
+        Name:        "yandex",
+        DisplayName: "Яндекс ID",
+        Config: oauth2.Config{
+            ClientID:     clientID,
+            ClientSecret: clientSecret,
+            RedirectURL:  redirectURL,
+            Scopes:       []string{"login:email", "login:info", "login:avatar"},
+            Endpoint: oauth2.Endpoint{
+                AuthURL:  "https://oauth.yandex.ru/authorize",
+                TokenURL: "https://oauth.yandex.ru/token",
+            },
+        },
+        UserInfoURL:        "https://login.yandex.ru/info?format=json",
+        UserInfoAuthScheme: "OAuth",
+        Fields: OAuthFieldMapping{
+            Subject: "id",
+            Email:   "default_email",
+            Name:    []string{"real_name"},
+            Avatar:  "default_avatar_id",
+        },
+        AvatarURLTemplate: "https://avatars.yandex.net/get-yapic/%s/islands-200",
+    }
+}
+
+// NewVKProvider настраивает вход через VK ID (OAuth 2.1)
+func NewVKProvider(clientID, clientSecret, redirectURL string) *OAuthProvider {
+    return &OAuthProvider{
+        Name:        "vk",
+        DisplayName: "VK ID",
+        Config: oauth2.Config{
+            ClientID:     clientID,
+            ClientSecret: clientSecret,
+            RedirectURL:  redirectURL,
+            Scopes:       []string{"email"},
+            Endpoint: oauth2.Endpoint{
+                AuthURL:   "https://id.vk.com/authorize",
+                TokenURL:  "https://id.vk.com/oauth2/auth",
+                AuthStyle: oauth2.AuthStyleInParams,
+            },
+        },
+        UserInfoURL:    "https://id.vk.com/oauth2/user_info",
+        UserInfoMethod: http.MethodPost,
+        Fields: OAuthFieldMapping{
+            Subject: "user.user_id",
+            Email:   "user.email",
+            Name:    []string{"user.first_name", "user.last_name"},
+            Avatar:  "user.avatar",
+        },
+        ExchangeParams: []string{"device_id", "state"},
+    }
+}
+
+// NewGoogleProvider настраивает вход через Google
+func NewGoogleProvider(clientID, clientSecret, redirectURL string) *OAuthProvider {
+    return &OAuthProvider{
+        Name:        "google",
+        DisplayName: "Google",
+        Config: oauth2.Config{
+            ClientID:     clientID,
+            ClientSecret: clientSecret,
+            RedirectURL:  redirectURL,
+            Scopes:       []string{"openid", "email", "profile"},
+            Endpoint: oauth2.Endpoint{
+                AuthURL:  "https://accounts.google.com/o/oauth2/v2/auth",
+                TokenURL: "https://oauth2.googleapis.com/token",
+            },
+        },
+        UserInfoURL: "https://openidconnect.googleapis.com/v1/userinfo",
+        Fields:      oidcFieldMapping,
+    }
+}
+
+// oidcFieldMapping — стандартные claims OpenID Connect
+var oidcFieldMapping = OAuthFieldMapping{
+    Subject:       "sub",
+    Email:         "email",
+    EmailVerified: "email_verified",
+    Name:          []string{"name"},
+    Avatar:        "picture",
+}
+
+// DiscoverOIDCProvider настраивает произвольного OIDC провайдера по
+// issuer/.well-known/openid-configuration (например, локальный mock сервер)
+func DiscoverOIDCProvider(ctx context.Context, name, displayName, issuer, clientID, clientSecret, redirectURL string) (*OAuthProvider, error) {
+    discoveryURL := strings.TrimRight(issuer, "/") + "/.well-known/openid-configuration"
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+    if err != nil {
+        return nil, err
+    }
+    resp, err := http.DefaultClient.Do(req)
+    if err != nil {
+        return nil, fmt.Errorf("failed to fetch discovery document: %w", err)
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("discovery document returned status %d", resp.StatusCode)
+    }
+
+    var discovery struct {
+        AuthorizationEndpoint string `json:"authorization_endpoint"`
+        TokenEndpoint         string `json:"token_endpoint"`
+        UserinfoEndpoint      string `json:"userinfo_endpoint"`
+    }
+    if err := json.NewDecoder(resp.Body).Decode(&discovery); err != nil {
+        return nil, fmt.Errorf("invalid discovery document: %w", err)
+    }
+    if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.UserinfoEndpoint == "" {
+        return nil, fmt.Errorf("discovery document is missing endpoints")
+    }
+
+    return &OAuthProvider{
+        Name:        name,
+        DisplayName: displayName,
+        Config: oauth2.Config{
+            ClientID:     clientID,
+            ClientSecret: clientSecret,
+            RedirectURL:  redirectURL,
+            Scopes:       []string{"openid", "email", "profile"},
+            Endpoint: oauth2.Endpoint{
+                AuthURL:  discovery.AuthorizationEndpoint,
+                TokenURL: discovery.TokenEndpoint,
+            },
+        },
+        UserInfoURL: discovery.UserinfoEndpoint,
+        Fields:      oidcFieldMapping,
+    }, nil
+}
+
+// fetchProfile запрашивает данные пользователя у провайдера
+func (p *OAuthProvider) fetchProfile(ctx context.Context, client *http.Client, token *oauth2.Token) (*models.OAuthProfile, error) {
+    var req *http.Request
+    var err error
+    if p.UserInfoMethod == http.MethodPost {
+        form := url.Values{"client_id": {p.Config.ClientID}, "access_token": {token.AccessToken}}
+        req, err = http.NewRequestWithContext(ctx, http.MethodPost, p.UserInfoURL, strings.NewReader(form.Encode()))
+        if err == nil {
+            req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+        }
+    } else {
+        req, err = http.NewRequestWithContext(ctx, http.MethodGet, p.UserInfoURL, nil)
+        if err == nil {
+            scheme := p.UserInfoAuthScheme
+            if scheme == "" {
+                scheme = "Bearer"
+            }
+            req.Header.Set("Authorization", scheme+" "+token.AccessToken)
+        }
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    resp, err := client.Do(req)
+    if err != nil {
+        return nil, fmt.Errorf("userinfo request failed: %w", err)
+    }
+    defer resp.Body.Close()
+
+    body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+    if err != nil {
+        return nil, err
+    }
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("userinfo returned status %d", resp.StatusCode)
+    }
+
+    decoder := json.NewDecoder(bytes.NewReader(body))
+    decoder.UseNumber()
+    var data map[string]interface{}
+    if err := decoder.Decode(&data); err != nil {
+        return nil, fmt.Errorf("invalid userinfo response: %w", err)
+    }
+
+    profile := &models.OAuthProfile{
+        Provider:      p.Name,
+        Subject:       lookupString(data, p.Fields.Subject),
+        Email:         strings.ToLower(lookupString(data, p.Fields.Email)),
+        EmailVerified: true,
+        AvatarURL:     lookupString(data, p.Fields.Avatar),
+    }
+    if p.Fields.EmailVerified != "" {
+        profile.EmailVerified = lookupString(data, p.Fields.EmailVerified) == "true"
+    }
+
+    var nameParts []string
+    for _, path := range p.Fields.Name {
+        if part := lookupString(data, path); part != "" {
+            nameParts = append(nameParts, part)
+        }
+    }
+    profile.FullName = strings.Join(nameParts, " ")
+
+    if profile.AvatarURL != "" && p.AvatarURLTemplate != "" {
+        profile.AvatarURL = fmt.Sprintf(p.AvatarURLTemplate, url.PathEscape(profile.AvatarURL))
+    }
+
+    if profile.Subject == "" {
+        return nil, fmt.Errorf("userinfo response has no %q field", p.Fields.Subject)
+    }
+    return profile, nil
+}
+
+// lookupString достает значение по пути через точку и приводит его к строке
+func lookupString(data map[string]interface{}, path string) string {
+    if path == "" {
+        return ""
+    }
+
+    var value interface{} = data
+    for _, key := range strings.Split(path, ".") {
+        object, ok := value.(map[string]interface{})
+        if !ok {
+            return ""
+        }
+        value = object[key]
+    }
+
+    switch v := value.(type) {
+    case string:
+        return v
+    case json.Number:
+        return v.String()
+    case bool:
+        if v {
+            return "true"
+        }
+        return "false"
+    default:
+        return ""
+    }
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "net/http"
+    "time"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/repositories"
+    "paydeya-backend/internal/utils"
+
+    "golang.org/x/crypto/bcrypt"
+    "golang.org/x/oauth2"
+)
+
+var (
+    ErrUnknownOAuthProvider    = errors.New("unknown oauth provider")
+    ErrInvalidOAuthState       = errors.New("invalid or expired oauth state")
+    ErrOAuthEmailRequired      = errors.New("provider did not share email address")
+    ErrOAuthEmailNotVerified   = errors.New("email is not verified by provider")
+    ErrOAuthAccountNotVerified = errors.New("account with this email has not confirmed it, log in with password and confirm email first")
+    ErrOAuthExchangeFailed     = errors.New("failed to complete authorization with provider")
+)
+
+// oauthStateTTL время, за которое пользователь должен вернуться от провайдера
+const oauthStateTTL = 10 * time.Minute
+
+type OAuthService struct {
+    registry   *OAuthRegistry
+    oauthRepo  *repositories.OAuthRepository
+    userRepo   *repositories.UserRepository
+    httpClient *http.Client
+}
+
+func NewOAuthService(registry *OAuthRegistry, oauthRepo *repositories.OAuthRepository, userRepo *repositories.UserRepository) *OAuthService {
+    return &OAuthService{
+        registry:   registry,
+        oauthRepo:  oauthRepo,
+        userRepo:   userRepo,
+        httpClient: &http.Client{Timeout: 10 * time.Second},
+    }
+}
+
+// ListProviders возвращает включенных провайдеров
+func (s *OAuthService) ListProviders() []models.OAuthProviderInfo {
+    return s.registry.List()
+}
+
+// StartAuthorization создает state и PKCE verifier и возвращает ссылку на
+// страницу входа провайдера. role применяется, если по итогам входа
+// будет создан новый аккаунт.
+func (s *OAuthService) StartAuthorization(ctx context.Context, providerName, role string) (*models.OAuthAuthorizeResponse, error) {
+    provider, ok := s.registry.Get(providerName)
+    if !ok {
+        return nil, ErrUnknownOAuthProvider
+    }
+
+    if role == "" {
+        role = "student"
+    }
+    if role != "student" && role != "teacher" {
+        return nil, errors.New("invalid role")
+    }
+
+    state, stateHash, err := utils.GenerateOpaqueToken()
+    if err != nil {
+        return nil, fmt.Errorf("error generating state: %w", err)
+    }
+    verifier := oauth2.GenerateVerifier()
+
+    err = s.oauthRepo.CreateState(ctx, stateHash, &models.OAuthState{
+        Provider:     provider.Name,
+        CodeVerifier: verifier,
+        Role:         role,
+        ExpiresAt:    time.Now().Add(oauthStateTTL),
+    })
+    if err != nil {
+        return nil, fmt.Errorf("error saving state: %w", err)
+    }
+
+    return &models.OAuthAuthorizeResponse{
+        AuthorizationURL: provider.Config.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier)),
+        ExpiresIn:        int(oauthStateTTL.Seconds()),
+    }, nil
+}
+
+// CompleteAuthorization обменивает code на токен провайдера и находит или
+// создает пользователя. Внешний аккаунт привязывается к существующему
+// пользователю только по email, подтвержденному провайдером.
+func (s *OAuthService) CompleteAuthorization(ctx context.Context, providerName string, req *models.OAuthCallbackRequest) (*models.User, error) {
+    provider, ok := s.registry.Get(providerName)
+    if !ok {
+        return nil, ErrUnknownOAuthProvider
+    }
+
+    state, err := s.oauthRepo.ConsumeState(ctx, utils.HashToken(req.State))
+    if err != nil {
+        return nil, fmt.Errorf("error checking state: %w", err)
+    }
+    if state == nil || state.Provider != provider.Name || time.Now().After(state.ExpiresAt) {
+        return nil, ErrInvalidOAuthState
+    }
+
+    profile, err := s.fetchProfile(ctx, provider, state, req)
+    if err != nil {
+        log.Printf("⚠️ OAuth %s authorization failed: %v", provider.Name, err)
+        return nil, ErrOAuthExchangeFailed
+    }
+
+    user, err := s.resolveUser(ctx, profile, state.Role)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := checkNotBlocked(user); err != nil {
+        return nil, err
+    }
+
+    return user, nil
+}
+
+// fetchProfile обменивает code (с PKCE verifier) на токен и запрашивает профиль
+func (s *OAuthService) fetchProfile(ctx context.Context, provider *OAuthProvider, state *models.OAuthState, req *models.OAuthCallbackRequest) (*models.OAuthProfile, error) {
+    ctx = context.WithValue(ctx, oauth2.HTTPClient, s.httpClient)
+
+    options := []oauth2.AuthCodeOption{oauth2.VerifierOption(state.CodeVerifier)}
+    callbackParams := map[string]string{"device_id": req.DeviceID, "state": req.State}
+    for _, name := range provider.ExchangeParams {
+        if value := callbackParams[name]; value != "" {
+            options = append(options, oauth2.SetAuthURLParam(name, value))
+        }
+    }
+
+    token, err := provider.Config.Exchange(ctx, req.Code, options...)
+    if err != nil {
+        return nil, fmt.Errorf("code exchange failed: %w", err)
+    }
+
+    return provider.fetchProfile(ctx, s.httpClient, token)
+}
+
+// resolveUser находит пользователя по внешнему аккаунту, привязывает аккаунт
+// к пользователю, подтвердившему тот же email, или создает нового пользователя
+func (s *OAuthService) resolveUser(ctx context.Context, profile *models.OAuthProfile, role string) (*models.User, error) {
+    userID, err := s.oauthRepo.GetIdentityUserID(ctx, profile.Provider, profile.Subject)
+    if err != nil {
+        return nil, fmt.Errorf("error finding identity: %w", err)
+    }
+    if userID != 0 {
+        user, err := s.userRepo.GetUserByID(ctx, userID)
+        if err != nil {
+            return nil, fmt.Errorf("error finding user: %w", err)
+        }
+        if user == nil {
+            return nil, errors.New("user not found")
+        }
+        return user, nil
+    }
+
+    if profile.Email == "" {
+        return nil, ErrOAuthEmailRequired
+    }
+    // Неподтвержденный адрес мог указать кто угодно: ни привязывать
+    // к чужому аккаунту, ни занимать им новый аккаунт нельзя
+    if !profile.EmailVerified {
+        return nil, ErrOAuthEmailNotVerified
+    }
+
+    identity := &models.UserIdentity{
+        Provider: profile.Provider,
+        Subject:  profile.Subject,
+        Email:    profile.Email,
+    }
+
+    user, err := s.userRepo.GetUserByEmail(ctx, profile.Email)
+    if err != nil {
+        return nil, fmt.Errorf("error finding user: %w", err)
+    }
+    if user != nil {
+        // Аккаунт с неподтвержденным адресом мог зарегистрировать кто угодно,
+        // заранее заняв чужой email. Привязка отдала бы владельцу адреса аккаунт,
+        // пароль от которого знает зарегистрировавший, поэтому привязываем
+        // только к аккаунтам, владелец которых подтвердил email
+        if !user.EmailVerified {
+            return nil, ErrOAuthAccountNotVerified
+        }
+        identity.UserID = user.ID
+        if err := s.oauthRepo.CreateIdentity(ctx, identity); err != nil {
+            return nil, fmt.Errorf("error linking identity: %w", err)
+        }
+        return user, nil
+    }
+
+    return s.createUser(ctx, profile, identity, role)
+}
+
+// createUser регистрирует пользователя, впервые вошедшего через провайдера.
+// Пароль случайный: войти по паролю можно будет после сброса пароля.
+func (s *OAuthService) createUser(ctx context.Context, profile *models.OAuthProfile, identity *models.UserIdentity, role string) (*models.User, error) {
+    password, _, err := utils.GenerateOpaqueToken()
+    if err != nil {
+        return nil, fmt.Errorf("error generating password: %w", err)
+    }
+    hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+    if err != nil {
+        return nil, fmt.Errorf("error hashing password: %w", err)
+    }
+
+    fullName := profile.FullName
+    if fullName == "" {
+        fullName = profile.Email
+    }
+
+    user := &models.User{
+        Email:         profile.Email,
+        PasswordHash:  string(hashedPassword),
+        FullName:      fullName,
+        Role:          role,
+        AvatarURL:     profile.AvatarURL,
+        IsVerified:    role != "teacher", // Преподаватели проходят верификацию отдельно
+        EmailVerified: true,
+    }
+
+    if err := s.oauthRepo.CreateUserWithIdentity(ctx, user, identity); err != nil {
+        return nil, fmt.Errorf("error creating user: %w", err)
+    }
+
+    return user, nil
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "path"
+    "path/filepath"
+    "strings"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/repositories"
+)
+
+var ErrFileNotFound = errors.New("file not found")
+
+// FileAccessCheck решает, может ли пользователь, не владеющий файлом, его скачать
+type FileAccessCheck func(ctx context.Context, userID int, role string, file *models.PrivateFile) (bool, error)
+
+// PrivateFileService хранит закрытые файлы: документы заявок и файлы сданных заданий.
+// Файлы лежат вне каталога, который раздается как статика (в облаке - под
+// приватным ACL), клиент получает только непрозрачный id. Скачать файл может
+// владелец и те, кого пропускает проверка, зарегистрированная для вида файла.
+type PrivateFileService struct {
+    privatePath    string
+    storageService *StorageService
+    fileRepo       *repositories.PrivateFileRepository
+    authorizer     *Authorizer
+    access         map[string]FileAccessCheck
+}
+
+func NewPrivateFileService(privatePath string, storageService *StorageService, fileRepo *repositories.PrivateFileRepository, authorizer *Authorizer) *PrivateFileService {
+    os.MkdirAll(privatePath, 0700)
+    s := &PrivateFileService{
+        privatePath:    privatePath,
+        storageService: storageService,
+        fileRepo:       fileRepo,
+        authorizer:     authorizer,
+        access:         make(map[string]FileAccessCheck),
+    }
+
+    // Документы заявок проверяют администраторы с правом верификации
+    s.RegisterAccess(models.PrivateFileVerification, func(ctx context.Context, userID int, role string, file *models.PrivateFile) (bool, error) {
+        return authorizer.HasPermission(ctx, role, models.PermTeacherVerify)
+    })
+
+    return s
+}
+
+// RegisterAccess задает проверку доступа к файлам вида kind.
+// Вызывается при запуске, до обработки запросов
+func (s *PrivateFileService) RegisterAccess(kind string, check FileAccessCheck) {
+    s.access[kind] = check
+}
+
+// Save сохраняет закрытый файл пользователя. Допустимы документы pdf, jpg, jpeg, png
+func (s *PrivateFileService) Save(ctx context.Context, file io.Reader, fileName string, size int64, userID int, kind string) (*models.PrivateFile, error) {
+    ext := strings.ToLower(filepath.Ext(fileName))
+    if !isValidDocumentExt(ext) {
+        return nil, fmt.Errorf("unsupported document format: %s. Supported: pdf, jpg, jpeg, png", ext)
+    }
+
+    id := generateUUID()
+    saved := &models.PrivateFile{
+        ID:          id,
+        UserID:      userID,
+        Kind:        kind,
+        StorageKey:  path.Join(kind, fmt.Sprintf("%d", userID), id+ext),
+        FileName:    fileName,
+        ContentType: getContentType(ext),
+        Size:        size,
+    }
+
+    if s.storageService != nil {
+        saved.Storage = models.FileStorageS3
+        saved.StorageKey = path.Join("private", saved.StorageKey)
+        if err := s.storageService.UploadPrivate(ctx, saved.StorageKey, file, saved.ContentType); err != nil {
+            return nil, err
+        }
+    } else {
+        saved.Storage = models.FileStorageLocal
+        if err := s.saveLocal(saved.StorageKey, file); err != nil {
+            return nil, err
+        }
+    }
+
+    if err := s.fileRepo.CreateFile(ctx, saved); err != nil {
+        s.RemoveContent(ctx, saved)
+        return nil, fmt.Errorf("failed to save file: %w", err)
+    }
+
+    return saved, nil
+}
+
+func (s *PrivateFileService) saveLocal(key string, file io.Reader) error {
+    filePath := filepath.Join(s.privatePath, filepath.FromSlash(key))
+    if err := os.MkdirAll(filepath.Dir(filePath), 0700); err != nil {
+        return fmt.Errorf("failed to create directory: %w", err)
+    }
+
+    out, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+    if err != nil {
+        return fmt.Errorf("failed to create file: %w", err)
+    }
+    defer out.Close()
+
+    if _, err := io.Copy(out, file); err != nil {
+        os.Remove(filePath)
+        return fmt.Errorf("failed to save file: %w", err)
+    }
+    return nil
+}
+
+// Open проверяет доступ и открывает файл на чтение. Вызывающий закрывает reader
+func (s *PrivateFileService) Open(ctx context.Context, userID int, role string, fileID string) (*models.PrivateFile, io.ReadCloser, error) {
+    file, err := s.fileRepo.GetFile(ctx, fileID)
+    if err != nil {
+        return nil, nil, fmt.Errorf("error finding file: %w", err)
+    }
+    if file == nil {
+        return nil, nil, ErrFileNotFound
+    }
+
+    if file.UserID != userID {
+        check := s.access[file.Kind]
+        if check == nil {
+            return nil, nil, ErrAccessDenied
+        }
+        allowed, err := check(ctx, userID, role, file)
+        if err != nil {
+            return nil, nil, err
+        }
+        if !allowed {
+            return nil, nil, ErrAccessDenied
+        }
+    }
+
+    switch file.Storage {
+    case models.FileStorageS3:
+        if s.storageService == nil {
+            return nil, nil, fmt.Errorf("cloud storage is not configured")
+        }
+        reader, err := s.storageService.OpenFile(ctx, file.StorageKey)
+        if err != nil {
+            return nil, nil, err
+        }
+        return file, reader, nil
+    default:
+        reader, err := os.Open(filepath.Join(s.privatePath, filepath.FromSlash(file.StorageKey)))
+        if errors.Is(err, os.ErrNotExist) {
+            return nil, nil, ErrFileNotFound
+        }
+        if err != nil {
+            return nil, nil, fmt.Errorf("failed to open file: %w", err)
+        }
+        return file, reader, nil
+    }
+}
+
+// Delete удаляет файл из хранилища и сведения о нем
+func (s *PrivateFileService) Delete(ctx context.Context, file *models.PrivateFile) error {
+    if err := s.RemoveContent(ctx, file); err != nil {
+        return err
+    }
+    return s.fileRepo.DeleteFile(ctx, file.ID)
+}
+
+// DeleteByID удаляет файл по идентификатору. Отсутствующий файл не считается ошибкой
+func (s *PrivateFileService) DeleteByID(ctx context.Context, fileID string) error {
+    file, err := s.fileRepo.GetFile(ctx, fileID)
+    if err != nil {
+        return err
+    }
+    if file == nil {
+        return nil
+    }
+    return s.Delete(ctx, file)
+}
+
+// UserFiles возвращает все закрытые файлы пользователя
+func (s *PrivateFileService) UserFiles(ctx context.Context, userID int) ([]models.PrivateFile, error) {
+    return s.fileRepo.GetUserFiles(ctx, userID)
+}
+
+// RemoveContent удаляет содержимое файла из хранилища, не трогая сведения о нем
+// (например, когда они уже удалены вместе с пользователем). Уже удаленный файл не считается ошибкой
+func (s *PrivateFileService) RemoveContent(ctx context.Context, file *models.PrivateFile) error {
+    if file.Storage == models.FileStorageS3 {
+        if s.storageService == nil {
+            return fmt.Errorf("cloud storage is not configured")
+        }
+        return s.storageService.DeleteFile(ctx, file.StorageKey)
+    }
+
+    err := os.Remove(filepath.Join(s.privatePath, filepath.FromSlash(file.StorageKey)))
+    if err != nil && !errors.Is(err, os.ErrNotExist) {
+        return err
+    }
+    return nil
+}
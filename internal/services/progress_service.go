@@ -9,10 +9,11 @@ import (
 
 type ProgressService struct {
     progressRepo *repositories.ProgressRepository
+    grades       *GradeService
 }
 
-func NewProgressService(progressRepo *repositories.ProgressRepository) *ProgressService {
-    return &ProgressService{progressRepo: progressRepo}
+func NewProgressService(progressRepo *repositories.ProgressRepository, grades *GradeService) *ProgressService {
+    return &ProgressService{progressRepo: progressRepo, grades: grades}
 }
 
 // GetStudentProgress возвращает прогресс ученика
@@ -20,9 +21,17 @@ func (s *ProgressService) GetStudentProgress(ctx context.Context, userID int) (*
     return s.progressRepo.GetStudentProgress(ctx, userID)
 }
 
-// MarkMaterialComplete отмечает материал как завершенный
-func (s *ProgressService) MarkMaterialComplete(ctx context.Context, userID, materialID int, timeSpent int, grade float64) error {
-    return s.progressRepo.MarkMaterialComplete(ctx, userID, materialID, timeSpent, grade)
+// MarkMaterialComplete отмечает материал как завершенный. Оценка не принимается
+// от клиента, а вычисляется по тестам и проверенным заданиям (nil, если оценивать нечего).
+func (s *ProgressService) MarkMaterialComplete(ctx context.Context, userID, materialID int, timeSpent int) (*float64, error) {
+    grade, err := s.grades.MaterialGrade(ctx, userID, materialID)
+    if err != nil {
+        return nil, err
+    }
+    if err := s.progressRepo.MarkMaterialComplete(ctx, userID, materialID, timeSpent, grade); err != nil {
+        return nil, err
+    }
+    return grade, nil
 }
 
 // GetFavoriteMaterials возвращает избранные материалы
@@ -0,0 +1,187 @@
+package services
+
+import (
+    "encoding/json"
+    "fmt"
+    "math"
+    "strconv"
+    "strings"
+
+    "paydeya-backend/internal/models"
+)
+
+// quizContent - содержимое блока quiz (схема quizBlockSchema)
+type quizContent struct {
+    Title       string         `json:"title"`
+    MaxAttempts *int           `json:"maxAttempts"`
+    Questions   []quizQuestion `json:"questions"`
+}
+
+func (q *quizContent) attemptLimit() int {
+    if q.MaxAttempts != nil {
+        return *q.MaxAttempts
+    }
+    return defaultQuizMaxAttempts
+}
+
+type quizQuestion struct {
+    ID          string       `json:"id"`
+    Type        string       `json:"type"`
+    Text        string       `json:"text"`
+    Points      *float64     `json:"points"`
+    Options     []quizOption `json:"options"`
+    Correct     []string     `json:"correct"`
+    Answer      *float64     `json:"answer"`
+    Tolerance   float64      `json:"tolerance"`
+    Answers     []string     `json:"answers"`
+    Explanation string       `json:"explanation"`
+}
+
+type quizOption struct {
+    ID   string `json:"id"`
+    Text string `json:"text"`
+}
+
+func (q *quizQuestion) maxPoints() float64 {
+    if q.Points != nil {
+        return *q.Points
+    }
+    return 1
+}
+
+func parseQuiz(block *models.Block) (*quizContent, error) {
+    data, err := json.Marshal(block.Content)
+    if err != nil {
+        return nil, err
+    }
+    var quiz quizContent
+    if err := json.Unmarshal(data, &quiz); err != nil {
+        return nil, err
+    }
+    return &quiz, nil
+}
+
+// gradeQuiz проверяет ответы. Ответы на неизвестные вопросы и ответы
+// неподходящего типа отклоняются, пропущенные вопросы считаются неверными.
+func gradeQuiz(quiz *quizContent, answers map[string]interface{}) ([]models.QuestionResult, float64, float64, error) {
+    questions := make(map[string]bool, len(quiz.Questions))
+    for _, question := range quiz.Questions {
+        questions[question.ID] = true
+    }
+    for questionID := range answers {
+        if !questions[questionID] {
+            return nil, 0, 0, fmt.Errorf("%w: unknown question %s", ErrInvalidQuizAnswers, questionID)
+        }
+    }
+
+    results := make([]models.QuestionResult, 0, len(quiz.Questions))
+    var score, maxScore float64
+    for i := range quiz.Questions {
+        question := &quiz.Questions[i]
+        result := models.QuestionResult{
+            QuestionID:  question.ID,
+            MaxPoints:   question.maxPoints(),
+            Explanation: question.Explanation,
+        }
+
+        if answer, ok := answers[question.ID]; ok && answer != nil {
+            correct, err := checkAnswer(question, answer)
+            if err != nil {
+                return nil, 0, 0, fmt.Errorf("%w: question %s: %v", ErrInvalidQuizAnswers, question.ID, err)
+            }
+            result.Answered = true
+            result.Correct = correct
+            if correct {
+                result.Points = result.MaxPoints
+            }
+        }
+
+        score += result.Points
+        maxScore += result.MaxPoints
+        results = append(results, result)
+    }
+
+    return results, score, maxScore, nil
+}
+
+func checkAnswer(question *quizQuestion, answer interface{}) (bool, error) {
+    switch question.Type {
+    case models.QuestionTypeSingle:
+        optionID, ok := answer.(string)
+        if !ok {
+            return false, fmt.Errorf("answer must be an option id")
+        }
+        return len(question.Correct) == 1 && optionID == question.Correct[0], nil
+
+    case models.QuestionTypeMultiple:
+        values, ok := answer.([]interface{})
+        if !ok {
+            return false, fmt.Errorf("answer must be an array of option ids")
+        }
+        selected := make(map[string]bool, len(values))
+        for _, value := range values {
+            optionID, ok := value.(string)
+            if !ok {
+                return false, fmt.Errorf("answer must be an array of option ids")
+            }
+            selected[optionID] = true
+        }
+        if len(selected) != len(question.Correct) {
+            return false, nil
+        }
+        for _, optionID := range question.Correct {
+            if !selected[optionID] {
+                return false, nil
+            }
+        }
+        return true, nil
+
+    case models.QuestionTypeNumeric:
+        var value float64
+        switch v := answer.(type) {
+        case float64:
+            value = v
+        case string:
+            // Допускаем десятичную запятую: "3,14"
+            parsed, err := strconv.ParseFloat(strings.Replace(strings.TrimSpace(v), ",", ".", 1), 64)
+            if err != nil {
+                return false, fmt.Errorf("answer must be a number")
+            }
+            value = parsed
+        default:
+            return false, fmt.Errorf("answer must be a number")
+        }
+        if question.Answer == nil {
+            return false, nil
+        }
+        // Небольшой запас на погрешность представления дробей
+        return math.Abs(value-*question.Answer) <= question.Tolerance+1e-9, nil
+
+    case models.QuestionTypeText:
+        text, ok := answer.(string)
+        if !ok {
+            return false, fmt.Errorf("answer must be a string")
+        }
+        normalized := normalizeTextAnswer(text)
+        for _, accepted := range question.Answers {
+            if normalized == normalizeTextAnswer(accepted) {
+                return true, nil
+            }
+        }
+        return false, nil
+    }
+
+    return false, fmt.Errorf("unsupported question type %s", question.Type)
+}
+
+// normalizeTextAnswer приводит ответ к виду для сравнения:
+// без регистра, лишних пробелов и различия е/ё
+func normalizeTextAnswer(text string) string {
+    text = strings.ToLower(strings.Join(strings.Fields(text), " "))
+    return strings.ReplaceAll(text, "ё", "е")
+}
+
+// gradeFromPercent переводит процент набранных баллов в оценку по шкале 1-5
+func gradeFromPercent(percent float64) float64 {
+    return math.Round((1+4*percent/100)*100) / 100
+}
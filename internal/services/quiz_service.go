@@ -0,0 +1,336 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "math"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/repositories"
+)
+
+var (
+    ErrInvalidQuizAnswers    = errors.New("invalid quiz answers")
+    ErrQuizAttemptsExhausted = errors.New("no quiz attempts left")
+)
+
+const defaultQuizMaxAttempts = 3
+
+type QuizService struct {
+    quizRepo     *repositories.QuizRepository
+    materialRepo *repositories.MaterialRepository
+    revisionRepo *repositories.RevisionRepository
+    grades       *GradeService
+    authorizer   *Authorizer
+}
+
+func NewQuizService(quizRepo *repositories.QuizRepository, materialRepo *repositories.MaterialRepository, revisionRepo *repositories.RevisionRepository, grades *GradeService, authorizer *Authorizer) *QuizService {
+    return &QuizService{
+        quizRepo:     quizRepo,
+        materialRepo: materialRepo,
+        revisionRepo: revisionRepo,
+        grades:       grades,
+        authorizer:   authorizer,
+    }
+}
+
+func findQuiz(blocks []models.Block, blockID string) (*quizContent, error) {
+    for i := range blocks {
+        if blocks[i].ID == blockID && blocks[i].Type == models.BlockTypeQuiz {
+            return parseQuiz(&blocks[i])
+        }
+    }
+    return nil, ErrBlockNotFound
+}
+
+// SubmitAttempt проверяет ответы ученика по опубликованной версии теста,
+// сохраняет попытку и пересчитывает оценку за материал. Число попыток
+// ограничено maxAttempts теста, результаты проверки возвращаются только
+// после последней попытки или когда преподаватель их открыл - иначе
+// перебором попыток можно было бы подобрать правильные ответы.
+func (s *QuizService) SubmitAttempt(ctx context.Context, userID, materialID int, blockID string, answers map[string]interface{}) (*models.QuizAttempt, error) {
+    revision, err := loadPublishedRevision(ctx, s.materialRepo, s.revisionRepo, materialID)
+    if err != nil {
+        return nil, err
+    }
+
+    quiz, err := findQuiz(revision.Blocks, blockID)
+    if err != nil {
+        return nil, err
+    }
+
+    used, err := s.quizRepo.CountUserAttempts(ctx, userID, materialID, blockID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to count attempts: %w", err)
+    }
+    if used >= quiz.attemptLimit() {
+        return nil, ErrQuizAttemptsExhausted
+    }
+
+    results, score, maxScore, err := gradeQuiz(quiz, answers)
+    if err != nil {
+        return nil, err
+    }
+
+    attempt := &models.QuizAttempt{
+        MaterialID: materialID,
+        BlockID:    blockID,
+        UserID:     userID,
+        Revision:   revision.Revision,
+        Answers:    answers,
+        Results:    results,
+        Score:      score,
+        MaxScore:   maxScore,
+        Percent:    models.ScorePercent(score, maxScore),
+    }
+    saved, err := s.quizRepo.CreateAttempt(ctx, attempt, quiz.attemptLimit())
+    if err != nil {
+        return nil, fmt.Errorf("failed to save attempt: %w", err)
+    }
+    if !saved {
+        return nil, ErrQuizAttemptsExhausted
+    }
+
+    if err := s.grades.Refresh(ctx, userID, materialID, revision.Blocks); err != nil {
+        return nil, err
+    }
+
+    released, err := s.quizRepo.IsReleased(ctx, materialID, blockID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to check results release: %w", err)
+    }
+    presentAttempt(attempt, quiz.attemptLimit()-attempt.Number, released)
+
+    return attempt, nil
+}
+
+// presentAttempt готовит попытку для ученика: сообщает, сколько попыток
+// осталось, и скрывает результаты, пока попытки не кончились и преподаватель их не открыл
+func presentAttempt(attempt *models.QuizAttempt, attemptsLeft int, released bool) {
+    if attemptsLeft < 0 {
+        attemptsLeft = 0
+    }
+    attempt.AttemptsLeft = &attemptsLeft
+    if attemptsLeft > 0 && !released {
+        attempt.HideResults()
+    }
+}
+
+// ListAttempts возвращает попытки ученика по тесту. Результаты скрыты
+// по тем же правилам, что и при отправке ответов
+func (s *QuizService) ListAttempts(ctx context.Context, userID, materialID int, blockID string) ([]models.QuizAttempt, error) {
+    attempts, err := s.quizRepo.GetUserAttempts(ctx, userID, materialID, blockID)
+    if err != nil {
+        return nil, err
+    }
+    if err := s.HideUnreleasedResults(ctx, attempts); err != nil {
+        return nil, err
+    }
+    return attempts, nil
+}
+
+// HideUnreleasedResults скрывает результаты попыток ученика по тестам, где
+// у него остались попытки и результаты не открыты. Попытки по тестам, которых
+// больше нет в опубликованной версии, считаются незавершенными
+func (s *QuizService) HideUnreleasedResults(ctx context.Context, attempts []models.QuizAttempt) error {
+    type quizKey struct {
+        materialID int
+        blockID    string
+    }
+    used := make(map[quizKey]int)
+    for _, attempt := range attempts {
+        key := quizKey{attempt.MaterialID, attempt.BlockID}
+        if attempt.Number > used[key] {
+            used[key] = attempt.Number
+        }
+    }
+
+    revisions := make(map[int]*models.MaterialRevision)
+    attemptsLeft := make(map[quizKey]int)
+    released := make(map[quizKey]bool)
+    for key, count := range used {
+        revision, ok := revisions[key.materialID]
+        if !ok {
+            var err error
+            revision, err = loadPublishedRevision(ctx, s.materialRepo, s.revisionRepo, key.materialID)
+            if err != nil && !errors.Is(err, ErrMaterialNotFound) {
+                return err
+            }
+            revisions[key.materialID] = revision
+        }
+
+        limit := count + 1
+        if revision != nil {
+            if quiz, err := findQuiz(revision.Blocks, key.blockID); err == nil {
+                limit = quiz.attemptLimit()
+            }
+        }
+        attemptsLeft[key] = limit - count
+
+        isReleased, err := s.quizRepo.IsReleased(ctx, key.materialID, key.blockID)
+        if err != nil {
+            return fmt.Errorf("failed to check results release: %w", err)
+        }
+        released[key] = isReleased
+    }
+
+    for i := range attempts {
+        key := quizKey{attempts[i].MaterialID, attempts[i].BlockID}
+        presentAttempt(&attempts[i], attemptsLeft[key], released[key])
+    }
+    return nil
+}
+
+// ReleaseResults открывает ученикам результаты теста до того, как они
+// израсходуют попытки, и включает тест в их оценки за материал
+func (s *QuizService) ReleaseResults(ctx context.Context, userID int, role string, materialID int, blockID string) error {
+    material, err := s.materialRepo.GetMaterial(ctx, materialID)
+    if err != nil {
+        return fmt.Errorf("error finding material: %w", err)
+    }
+    if material == nil {
+        return ErrMaterialNotFound
+    }
+    if err := s.authorizer.CanEditMaterial(ctx, userID, role, material); err != nil {
+        return err
+    }
+
+    revision, err := loadPublishedRevision(ctx, s.materialRepo, s.revisionRepo, materialID)
+    if errors.Is(err, ErrMaterialNotFound) {
+        return ErrMaterialNotPublished
+    }
+    if err != nil {
+        return err
+    }
+    if _, err := findQuiz(revision.Blocks, blockID); err != nil {
+        return err
+    }
+
+    if err := s.quizRepo.ReleaseResults(ctx, materialID, blockID, userID); err != nil {
+        return fmt.Errorf("failed to release results: %w", err)
+    }
+
+    // Пока результаты были закрыты, тест не входил в оценку учеников
+    studentIDs, err := s.quizRepo.GetBlockUserIDs(ctx, materialID, blockID)
+    if err != nil {
+        return fmt.Errorf("failed to get quiz students: %w", err)
+    }
+    for _, studentID := range studentIDs {
+        if err := s.grades.Refresh(ctx, studentID, materialID, revision.Blocks); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// GetStats возвращает статистику по вопросам теста для автора материала.
+// Вопросы берутся из опубликованной версии, попытки - по всем версиям.
+func (s *QuizService) GetStats(ctx context.Context, userID int, role string, materialID int, blockID string) (*models.QuizStats, error) {
+    material, err := s.materialRepo.GetMaterial(ctx, materialID)
+    if err != nil {
+        return nil, fmt.Errorf("error finding material: %w", err)
+    }
+    if material == nil {
+        return nil, ErrMaterialNotFound
+    }
+    if err := s.authorizer.CanEditMaterial(ctx, userID, role, material); err != nil {
+        return nil, err
+    }
+
+    revision, err := loadPublishedRevision(ctx, s.materialRepo, s.revisionRepo, materialID)
+    if errors.Is(err, ErrMaterialNotFound) {
+        return nil, ErrMaterialNotPublished
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    quiz, err := findQuiz(revision.Blocks, blockID)
+    if err != nil {
+        return nil, err
+    }
+
+    attempts, err := s.quizRepo.GetBlockAttempts(ctx, materialID, blockID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get attempts: %w", err)
+    }
+
+    return buildQuizStats(materialID, blockID, quiz, attempts), nil
+}
+
+func buildQuizStats(materialID int, blockID string, quiz *quizContent, attempts []models.QuizAttempt) *models.QuizStats {
+    stats := &models.QuizStats{
+        MaterialID: materialID,
+        BlockID:    blockID,
+        Attempts:   len(attempts),
+        Questions:  make([]models.QuestionStats, 0, len(quiz.Questions)),
+    }
+
+    students := make(map[int]bool)
+    var percentSum float64
+    for _, attempt := range attempts {
+        students[attempt.UserID] = true
+        percentSum += attempt.Percent
+    }
+    stats.Students = len(students)
+    if len(attempts) > 0 {
+        stats.AveragePercent = roundPercent(percentSum / float64(len(attempts)))
+    }
+
+    for _, question := range quiz.Questions {
+        questionStats := models.QuestionStats{
+            QuestionID: question.ID,
+            Text:       question.Text,
+            Type:       question.Type,
+        }
+
+        picks := make(map[string]int)
+        var pointsSum float64
+        for _, attempt := range attempts {
+            for _, result := range attempt.Results {
+                if result.QuestionID != question.ID {
+                    continue
+                }
+                if result.Answered {
+                    questionStats.Answered++
+                }
+                if result.Correct {
+                    questionStats.Correct++
+                }
+                pointsSum += result.Points
+            }
+
+            switch answer := attempt.Answers[question.ID].(type) {
+            case string:
+                picks[answer]++
+            case []interface{}:
+                for _, value := range answer {
+                    if optionID, ok := value.(string); ok {
+                        picks[optionID]++
+                    }
+                }
+            }
+        }
+
+        if len(attempts) > 0 {
+            questionStats.CorrectRate = roundPercent(float64(questionStats.Correct) * 100 / float64(len(attempts)))
+            questionStats.AveragePoints = math.Round(pointsSum/float64(len(attempts))*100) / 100
+        }
+        for _, option := range question.Options {
+            questionStats.Options = append(questionStats.Options, models.OptionStats{
+                OptionID: option.ID,
+                Text:     option.Text,
+                Picks:    picks[option.ID],
+            })
+        }
+
+        stats.Questions = append(stats.Questions, questionStats)
+    }
+
+    return stats
+}
+
+func roundPercent(value float64) float64 {
+    return math.Round(value*10) / 10
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+    "bytes"
+    "encoding/json"
+    "testing"
+
+    "paydeya-backend/internal/models"
+)
+
+func testQuiz(maxAttempts *int) *quizContent {
+    return &quizContent{
+        MaxAttempts: maxAttempts,
+        Questions: []quizQuestion{{
+            ID:   "q1",
+            Type: models.QuestionTypeSingle,
+            Text: "Сколько будет 2+2?",
+            Options: []quizOption{
+                {ID: "a", Text: "3"},
+                {ID: "b", Text: "4"},
+                {ID: "c", Text: "5"},
+            },
+            Correct:     []string{"b"},
+            Explanation: "2+2=4",
+        }},
+    }
+}
+
+// submitTestAttempt проверяет ответ так же, как SubmitAttempt, и готовит попытку для ученика
+func submitTestAttempt(t *testing.T, quiz *quizContent, number int, optionID string, released bool) *models.QuizAttempt {
+    t.Helper()
+
+    answers := map[string]interface{}{"q1": optionID}
+    results, score, maxScore, err := gradeQuiz(quiz, answers)
+    if err != nil {
+        t.Fatalf("gradeQuiz: %v", err)
+    }
+
+    attempt := &models.QuizAttempt{
+        Number:   number,
+        Answers:  answers,
+        Results:  results,
+        Score:    score,
+        MaxScore: maxScore,
+        Percent:  models.ScorePercent(score, maxScore),
+    }
+    presentAttempt(attempt, quiz.attemptLimit()-number, released)
+    return attempt
+}
+
+func TestQuizAttemptLimit(t *testing.T) {
+    if limit := testQuiz(nil).attemptLimit(); limit != defaultQuizMaxAttempts {
+        t.Fatalf("default limit = %d, want %d", limit, defaultQuizMaxAttempts)
+    }
+
+    five := 5
+    if limit := testQuiz(&five).attemptLimit(); limit != 5 {
+        t.Fatalf("limit = %d, want 5", limit)
+    }
+}
+
+// Пока попытки не кончились, ответ сервера не зависит от правильности ответа:
+// перебирая варианты, ученик не узнает, какой из них верный
+func TestRepeatedAttemptsDoNotRevealKey(t *testing.T) {
+    quiz := testQuiz(nil)
+    limit := quiz.attemptLimit()
+
+    var first []byte
+    for number, optionID := range []string{"a", "b"} {
+        attempt := submitTestAttempt(t, quiz, number+1, optionID, false)
+
+        if !attempt.ResultsHidden || attempt.Results != nil || attempt.Score != 0 || attempt.Percent != 0 {
+            t.Fatalf("attempt %d with %q reveals results: %+v", number+1, optionID, attempt)
+        }
+        if attempt.AttemptsLeft == nil || *attempt.AttemptsLeft != limit-number-1 {
+            t.Fatalf("attempt %d: attemptsLeft = %v, want %d", number+1, attempt.AttemptsLeft, limit-number-1)
+        }
+
+        // Сравниваем все, кроме собственных ответов ученика и счетчиков
+        attempt.Answers, attempt.Number, attempt.AttemptsLeft = nil, 0, nil
+        data, err := json.Marshal(attempt)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if bytes.Contains(data, []byte(`"correct"`)) || bytes.Contains(data, []byte("2+2=4")) {
+            t.Fatalf("attempt %d with %q reveals correctness: %s", number+1, optionID, data)
+        }
+        if first != nil && !bytes.Equal(first, data) {
+            t.Fatalf("response depends on the answer:\n%s\n%s", first, data)
+        }
+        first = data
+    }
+
+    last := submitTestAttempt(t, quiz, limit, "c", false)
+    if last.ResultsHidden || len(last.Results) != 1 || last.Results[0].Correct {
+        t.Fatalf("last attempt must show results: %+v", last)
+    }
+    if *last.AttemptsLeft != 0 {
+        t.Fatalf("attemptsLeft = %d, want 0", *last.AttemptsLeft)
+    }
+}
+
+func TestReleasedQuizShowsResults(t *testing.T) {
+    attempt := submitTestAttempt(t, testQuiz(nil), 1, "b", true)
+
+    if attempt.ResultsHidden || len(attempt.Results) != 1 || !attempt.Results[0].Correct || attempt.Percent != 100 {
+        t.Fatalf("released quiz must show results: %+v", attempt)
+    }
+}
+
+// Пока у ученика остались попытки, тест не входит в оценку за материал:
+// оценка не меняется в зависимости от правильности ответа
+func TestQuizGradeHiddenUntilAttemptsRunOut(t *testing.T) {
+    quiz := testQuiz(nil)
+    limit := quiz.attemptLimit()
+
+    for number := 1; number < limit; number++ {
+        for _, percent := range []float64{0, 100} {
+            attempt := models.QuizAttempt{Number: number, Percent: percent}
+            if _, counted := quizGradePercent(quiz, attempt, false); counted {
+                t.Fatalf("attempt %d with %.0f%% must not be graded yet", number, percent)
+            }
+        }
+    }
+
+    last := models.QuizAttempt{Number: limit, Percent: 100}
+    if percent, counted := quizGradePercent(quiz, last, false); !counted || percent != 100 {
+        t.Fatalf("last attempt: percent = %v, counted = %v", percent, counted)
+    }
+
+    released := models.QuizAttempt{Number: 1, Percent: 100}
+    if percent, counted := quizGradePercent(quiz, released, true); !counted || percent != 100 {
+        t.Fatalf("released quiz: percent = %v, counted = %v", percent, counted)
+    }
+}
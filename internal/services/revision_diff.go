@@ -0,0 +1,134 @@
+package services
+
+import (
+    "encoding/json"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/utils"
+)
+
+// diffRevisions сравнивает две ревизии на уровне блоков. Блоки сопоставляются
+// по ID; перемещенными считаются блоки вне наибольшей общей подпоследовательности,
+// чтобы удаление одного блока не помечало перемещенными все блоки после него.
+func diffRevisions(from, to *models.MaterialRevision) *models.RevisionDiff {
+    diff := &models.RevisionDiff{
+        MaterialID: to.MaterialID,
+        From:       from.Revision,
+        To:         to.Revision,
+        Changes:    []models.BlockChange{},
+    }
+    if from.Title != to.Title {
+        diff.TitleChanged = true
+        diff.OldTitle = from.Title
+        diff.NewTitle = to.Title
+    }
+
+    oldIndex := make(map[string]int, len(from.Blocks))
+    for i, block := range from.Blocks {
+        oldIndex[block.ID] = i
+    }
+    newIndex := make(map[string]int, len(to.Blocks))
+    for i, block := range to.Blocks {
+        newIndex[block.ID] = i
+    }
+
+    // Общие блоки в старом и новом порядке
+    var oldCommon, newCommon []string
+    for _, block := range from.Blocks {
+        if _, ok := newIndex[block.ID]; ok {
+            oldCommon = append(oldCommon, block.ID)
+        }
+    }
+    for _, block := range to.Blocks {
+        if _, ok := oldIndex[block.ID]; ok {
+            newCommon = append(newCommon, block.ID)
+        }
+    }
+    stable := utils.LongestCommonSubsequence(oldCommon, newCommon)
+
+    for i := range to.Blocks {
+        block := to.Blocks[i]
+        newPosition := i
+
+        j, existed := oldIndex[block.ID]
+        if !existed {
+            diff.Changes = append(diff.Changes, models.BlockChange{
+                BlockID:     block.ID,
+                Type:        block.Type,
+                Change:      models.BlockChangeAdded,
+                NewPosition: &newPosition,
+                New:         &to.Blocks[i],
+            })
+            continue
+        }
+
+        oldPosition := j
+        fields := changedBlockFields(&from.Blocks[j], &block)
+        moved := !stable[block.ID]
+
+        switch {
+        case len(fields) > 0:
+            diff.Changes = append(diff.Changes, models.BlockChange{
+                BlockID:     block.ID,
+                Type:        block.Type,
+                Change:      models.BlockChangeModified,
+                OldPosition: &oldPosition,
+                NewPosition: &newPosition,
+                Fields:      fields,
+                Moved:       moved,
+                Old:         &from.Blocks[j],
+                New:         &to.Blocks[i],
+            })
+        case moved:
+            diff.Changes = append(diff.Changes, models.BlockChange{
+                BlockID:     block.ID,
+                Type:        block.Type,
+                Change:      models.BlockChangeMoved,
+                OldPosition: &oldPosition,
+                NewPosition: &newPosition,
+            })
+        }
+    }
+
+    for i := range from.Blocks {
+        block := from.Blocks[i]
+        if _, ok := newIndex[block.ID]; ok {
+            continue
+        }
+        oldPosition := i
+        diff.Changes = append(diff.Changes, models.BlockChange{
+            BlockID:     block.ID,
+            Type:        block.Type,
+            Change:      models.BlockChangeRemoved,
+            OldPosition: &oldPosition,
+            Old:         &from.Blocks[i],
+        })
+    }
+
+    return diff
+}
+
+// changedBlockFields возвращает поля блока, которые отличаются между версиями
+func changedBlockFields(old, new *models.Block) []string {
+    var fields []string
+    if old.Type != new.Type {
+        fields = append(fields, "type")
+    }
+    if !sameJSON(old.Content, new.Content) {
+        fields = append(fields, "content")
+    }
+    if !sameJSON(old.Styles, new.Styles) {
+        fields = append(fields, "styles")
+    }
+    if !sameJSON(old.Animation, new.Animation) {
+        fields = append(fields, "animation")
+    }
+    return fields
+}
+
+// sameJSON сравнивает значения по их JSON представлению (ключи map сортируются)
+func sameJSON(a, b interface{}) bool {
+    aJSON, errA := json.Marshal(a)
+    bJSON, errB := json.Marshal(b)
+    return errA == nil && errB == nil && string(aJSON) == string(bJSON)
+}
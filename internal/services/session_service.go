@@ -0,0 +1,56 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/repositories"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// SessionService показывает активные сессии пользователя и позволяет
+// завершать их по одной. Сессия = семейство refresh токенов.
+type SessionService struct {
+    sessionRepo *repositories.SessionRepository
+    refreshRepo *repositories.RefreshTokenRepository
+}
+
+func NewSessionService(sessionRepo *repositories.SessionRepository, refreshRepo *repositories.RefreshTokenRepository) *SessionService {
+    return &SessionService{
+        sessionRepo: sessionRepo,
+        refreshRepo: refreshRepo,
+    }
+}
+
+// ListSessions возвращает активные сессии пользователя и отмечает текущую
+func (s *SessionService) ListSessions(ctx context.Context, userID int, currentSessionID string) ([]models.Session, error) {
+    sessions, err := s.sessionRepo.GetUserSessions(ctx, userID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get sessions: %w", err)
+    }
+
+    for i := range sessions {
+        sessions[i].Current = currentSessionID != "" && sessions[i].ID == currentSessionID
+    }
+    return sessions, nil
+}
+
+// RevokeSession завершает сессию пользователя: ее refresh токены отзываются,
+// выданный access токен доживает свои 15 минут
+func (s *SessionService) RevokeSession(ctx context.Context, userID int, sessionID string) error {
+    session, err := s.sessionRepo.GetActiveSession(ctx, sessionID)
+    if err != nil {
+        return fmt.Errorf("failed to get session: %w", err)
+    }
+    if session == nil || session.UserID != userID {
+        return ErrSessionNotFound
+    }
+
+    if err := s.refreshRepo.RevokeFamily(ctx, sessionID); err != nil {
+        return fmt.Errorf("failed to revoke session: %w", err)
+    }
+    return nil
+}
@@ -11,6 +11,7 @@ import (
     "github.com/aws/aws-sdk-go-v2/config"
     "github.com/aws/aws-sdk-go-v2/credentials"
     "github.com/aws/aws-sdk-go-v2/service/s3"
+    "github.com/aws/aws-sdk-go-v2/service/s3/types"
     "github.com/google/uuid"
 )
 
@@ -72,6 +73,14 @@ func isValidImageExt(ext string) bool {
     return false
 }
 
+func isValidDocumentExt(ext string) bool {
+    switch strings.ToLower(ext) {
+    case ".pdf", ".jpg", ".jpeg", ".png":
+        return true
+    }
+    return false
+}
+
 func getContentType(ext string) string {
     switch strings.ToLower(ext) {
     case ".jpg", ".jpeg":
@@ -86,6 +95,8 @@ func getContentType(ext string) string {
         return "video/mp4"
     case ".webm":
         return "video/webm"
+    case ".pdf":
+        return "application/pdf"
     default:
         return "application/octet-stream"
     }
@@ -150,4 +161,31 @@ func (s *StorageService) DeleteFile(ctx context.Context, fileName string) error
         Key:    aws.String(fileName),
     })
     return err
-}
\ No newline at end of file
+}
+// UploadPrivate загружает закрытый файл под ключом key. Объект создается
+// с приватным ACL и отдается только через OpenFile, ссылка CDN на него не выдается
+func (s *StorageService) UploadPrivate(ctx context.Context, key string, file io.Reader, contentType string) error {
+    _, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+        Bucket:      aws.String(s.bucket),
+        Key:         aws.String(key),
+        Body:        file,
+        ContentType: aws.String(contentType),
+        ACL:         types.ObjectCannedACLPrivate,
+    })
+    if err != nil {
+        return fmt.Errorf("failed to upload file: %w", err)
+    }
+    return nil
+}
+
+// OpenFile открывает объект хранилища на чтение
+func (s *StorageService) OpenFile(ctx context.Context, key string) (io.ReadCloser, error) {
+    output, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+        Bucket: aws.String(s.bucket),
+        Key:    aws.String(key),
+    })
+    if err != nil {
+        return nil, fmt.Errorf("failed to open file: %w", err)
+    }
+    return output.Body, nil
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/repositories"
+)
+
+var ErrTemplateNotInGallery = errors.New("template is not in gallery")
+
+// TemplateService ведет галерею шаблонов, которую составляют администраторы
+type TemplateService struct {
+    templateRepo *repositories.TemplateRepository
+    materialRepo *repositories.MaterialRepository
+}
+
+func NewTemplateService(templateRepo *repositories.TemplateRepository, materialRepo *repositories.MaterialRepository) *TemplateService {
+    return &TemplateService{
+        templateRepo: templateRepo,
+        materialRepo: materialRepo,
+    }
+}
+
+// GetGallery возвращает опубликованные шаблоны галереи по порядку
+func (s *TemplateService) GetGallery(ctx context.Context) ([]models.GalleryTemplate, error) {
+    return s.templateRepo.GetGallery(ctx)
+}
+
+// SaveToGallery добавляет шаблон в галерею или меняет его описание и место.
+// В галерею попадают только опубликованные шаблоны.
+func (s *TemplateService) SaveToGallery(ctx context.Context, adminID int, req *models.GalleryTemplateRequest) error {
+    material, err := s.materialRepo.GetMaterial(ctx, req.MaterialID)
+    if err != nil {
+        return fmt.Errorf("error finding material: %w", err)
+    }
+    if material == nil {
+        return ErrMaterialNotFound
+    }
+    if !material.IsTemplate {
+        return ErrNotTemplate
+    }
+    if material.Status != "published" || material.PublishedRevision == nil {
+        return ErrMaterialNotPublished
+    }
+
+    return s.templateRepo.SaveToGallery(ctx, req, adminID)
+}
+
+// RemoveFromGallery убирает шаблон из галереи, сам материал не меняется
+func (s *TemplateService) RemoveFromGallery(ctx context.Context, materialID int) error {
+    removed, err := s.templateRepo.RemoveFromGallery(ctx, materialID)
+    if err != nil {
+        return err
+    }
+    if !removed {
+        return ErrTemplateNotInGallery
+    }
+    return nil
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sync"
+    "testing"
+    "time"
+)
+
+func noDelay(int) time.Duration { return 0 }
+
+func lockAfter(failures int) func(int) time.Duration {
+    return func(n int) time.Duration {
+        if n >= failures {
+            return time.Hour
+        }
+        return 0
+    }
+}
+
+// Перебор случайных ключей не снимает чужую блокировку: вытесняются только
+// ключи без действующей блокировки, давно не использованные - первыми
+func TestMemoryThrottleStoreKeepsLockedEntries(t *testing.T) {
+    ctx := context.Background()
+    store := NewMemoryThrottleStore(3)
+
+    if _, err := store.Attempt(ctx, "victim", time.Hour, lockAfter(1)); err != nil {
+        t.Fatal(err)
+    }
+    store.Attempt(ctx, "a", time.Hour, noDelay)
+    store.Attempt(ctx, "b", time.Hour, noDelay)
+    store.Get(ctx, "a")
+    store.Attempt(ctx, "c", time.Hour, noDelay)
+
+    if lockout, _ := store.Get(ctx, "b"); lockout != nil {
+        t.Fatalf("least recently used unlocked key must be evicted: %+v", lockout)
+    }
+    if lockout, _ := store.Get(ctx, "a"); lockout == nil {
+        t.Fatal("recently used key must be kept")
+    }
+
+    for i := 0; i < 100; i++ {
+        store.Attempt(ctx, fmt.Sprintf("random%d@example.com", i), time.Hour, noDelay)
+    }
+    if len(store.entries) != 3 || store.order.Len() != 3 {
+        t.Fatalf("store holds %d entries, want 3", len(store.entries))
+    }
+
+    lockout, _ := store.Get(ctx, "victim")
+    if lockout == nil || lockout.LockedUntil == nil {
+        t.Fatalf("locked key must not be evicted: %+v", lockout)
+    }
+    result, err := store.Attempt(ctx, "victim", time.Hour, noDelay)
+    if err != nil || result.Allowed {
+        t.Fatalf("locked key must reject attempts: %+v, %v", result, err)
+    }
+}
+
+// Когда место занято только блокировками, новые ключи не принимаются
+func TestMemoryThrottleStoreRejectsNewKeysWhenFull(t *testing.T) {
+    ctx := context.Background()
+    store := NewMemoryThrottleStore(2)
+
+    store.Attempt(ctx, "a", time.Hour, lockAfter(1))
+    store.Attempt(ctx, "b", time.Hour, lockAfter(1))
+
+    if _, err := store.Attempt(ctx, "c", time.Hour, noDelay); !errors.Is(err, ErrThrottleStoreFull) {
+        t.Fatalf("err = %v, want ErrThrottleStoreFull", err)
+    }
+    if len(store.entries) != 2 {
+        t.Fatalf("store holds %d entries, want 2", len(store.entries))
+    }
+}
+
+func TestMemoryThrottleStoreSweep(t *testing.T) {
+    ctx := context.Background()
+    store := NewMemoryThrottleStore(0)
+
+    store.Attempt(ctx, "short", time.Minute, noDelay)
+    store.Attempt(ctx, "long", time.Hour, noDelay)
+
+    if removed := store.Sweep(time.Now().Add(2 * time.Minute)); removed != 1 {
+        t.Fatalf("removed %d entries, want 1", removed)
+    }
+    if _, ok := store.entries["long"]; !ok || len(store.entries) != 1 {
+        t.Fatalf("only the expired key must be removed, left %d entries", len(store.entries))
+    }
+}
+
+// Параллельные попытки не проходят проверку все вместе: после бесплатных
+// попыток аккаунт блокируется, остальные запросы отклоняются
+func TestLoginThrottlerConcurrentAttempts(t *testing.T) {
+    ctx := context.Background()
+    throttler := NewLoginThrottler(NewMemoryThrottleStore(0))
+    policy := loginThrottlePolicies[loginThrottleScopeAccount]
+
+    var wg sync.WaitGroup
+    var mu sync.Mutex
+    allowed := 0
+    for i := 0; i < 50; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            if _, err := throttler.Begin(ctx, "victim@example.com", ""); err == nil {
+                mu.Lock()
+                allowed++
+                mu.Unlock()
+            }
+        }()
+    }
+    wg.Wait()
+
+    if allowed != policy.freeAttempts+1 {
+        t.Fatalf("%d parallel attempts allowed, want %d", allowed, policy.freeAttempts+1)
+    }
+}
+
+// Удачный вход не расходует лимит IP и не оставляет блокировку
+func TestLoginThrottlerSuccessRefundsIP(t *testing.T) {
+    ctx := context.Background()
+    store := NewMemoryThrottleStore(0)
+    throttler := NewLoginThrottler(store)
+    policy := loginThrottlePolicies[loginThrottleScopeIP]
+
+    for i := 0; i < policy.freeAttempts; i++ {
+        attempt, err := throttler.Begin(ctx, fmt.Sprintf("user%d@example.com", i), "10.0.0.1")
+        if err != nil {
+            t.Fatal(err)
+        }
+        if i%2 == 0 {
+            throttler.Succeed(ctx, attempt)
+        }
+    }
+
+    lockout, _ := store.Get(ctx, "ip:10.0.0.1")
+    if lockout == nil || lockout.Failures != policy.freeAttempts/2 {
+        t.Fatalf("ip failures = %+v, want %d", lockout, policy.freeAttempts/2)
+    }
+    if lockout.LockedUntil != nil && lockout.LockedUntil.After(time.Now()) {
+        t.Fatalf("ip must not be locked: %+v", lockout)
+    }
+}
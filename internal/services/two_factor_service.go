@@ -0,0 +1,312 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/repositories"
+    "paydeya-backend/internal/utils"
+
+    "github.com/google/uuid"
+    "golang.org/x/crypto/bcrypt"
+)
+
+var (
+    ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+    ErrTwoFactorNotSetUp       = errors.New("two-factor authentication setup was not started")
+    ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+    ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+    ErrInvalidChallengeToken   = errors.New("invalid, expired or already used challenge token")
+    ErrTwoFactorLocked         = errors.New("too many invalid codes, try again later")
+    ErrInvalidPassword         = errors.New("invalid password")
+)
+
+const (
+    totpIssuer             = "Пайдея"
+    recoveryCodesCount     = 10
+    twoFactorMaxAttempts   = 5
+    twoFactorLockoutPeriod = 5 * time.Minute
+)
+
+type TwoFactorService struct {
+    twoFactorRepo *repositories.TwoFactorRepository
+    userRepo      *repositories.UserRepository
+    keys          *utils.KeySet
+    secrets       *utils.SecretCipher // шифрует секреты TOTP в БД; nil - хранить открытыми
+}
+
+func NewTwoFactorService(twoFactorRepo *repositories.TwoFactorRepository, userRepo *repositories.UserRepository, keys *utils.KeySet, secrets *utils.SecretCipher) *TwoFactorService {
+    return &TwoFactorService{
+        twoFactorRepo: twoFactorRepo,
+        userRepo:      userRepo,
+        keys:          keys,
+        secrets:       secrets,
+    }
+}
+
+// IsEnabled проверяет, включена ли у пользователя 2FA
+func (s *TwoFactorService) IsEnabled(ctx context.Context, userID int) (bool, error) {
+    totp, err := s.twoFactorRepo.GetTOTP(ctx, userID)
+    if err != nil {
+        return false, fmt.Errorf("error finding totp settings: %w", err)
+    }
+    return totp != nil && totp.Enabled, nil
+}
+
+// GetStatus возвращает состояние 2FA пользователя
+func (s *TwoFactorService) GetStatus(ctx context.Context, userID int) (*models.TwoFactorStatus, error) {
+    totp, err := s.twoFactorRepo.GetTOTP(ctx, userID)
+    if err != nil {
+        return nil, fmt.Errorf("error finding totp settings: %w", err)
+    }
+
+    status := &models.TwoFactorStatus{}
+    if totp == nil || !totp.Enabled {
+        return status, nil
+    }
+
+    status.Enabled = true
+    status.ConfirmedAt = totp.ConfirmedAt
+    status.RecoveryCodesLeft, err = s.twoFactorRepo.CountRecoveryCodes(ctx, userID)
+    if err != nil {
+        return nil, fmt.Errorf("error counting recovery codes: %w", err)
+    }
+    return status, nil
+}
+
+// Setup начинает подключение 2FA: генерирует секрет и otpauth ссылку.
+// 2FA включится только после подтверждения кодом (Confirm).
+func (s *TwoFactorService) Setup(ctx context.Context, userID int) (*models.TwoFactorSetupResponse, error) {
+    user, err := s.userRepo.GetUserByID(ctx, userID)
+    if err != nil {
+        return nil, fmt.Errorf("error finding user: %w", err)
+    }
+    if user == nil {
+        return nil, ErrUserNotFound
+    }
+
+    secret, err := utils.GenerateTOTPSecret()
+    if err != nil {
+        return nil, fmt.Errorf("error generating secret: %w", err)
+    }
+
+    encrypted, err := s.secrets.Encrypt(secret)
+    if err != nil {
+        return nil, fmt.Errorf("error encrypting secret: %w", err)
+    }
+
+    saved, err := s.twoFactorRepo.SaveSecret(ctx, userID, encrypted)
+    if err != nil {
+        return nil, fmt.Errorf("error saving secret: %w", err)
+    }
+    if !saved {
+        return nil, ErrTwoFactorAlreadyEnabled
+    }
+
+    return &models.TwoFactorSetupResponse{
+        Secret:     secret,
+        OTPAuthURI: utils.TOTPURI(totpIssuer, user.Email, secret),
+    }, nil
+}
+
+// Confirm включает 2FA после проверки первого кода и возвращает коды восстановления
+func (s *TwoFactorService) Confirm(ctx context.Context, userID int, code string) ([]string, error) {
+    totp, err := s.twoFactorRepo.GetTOTP(ctx, userID)
+    if err != nil {
+        return nil, fmt.Errorf("error finding totp settings: %w", err)
+    }
+    if totp == nil {
+        return nil, ErrTwoFactorNotSetUp
+    }
+    if totp.Enabled {
+        return nil, ErrTwoFactorAlreadyEnabled
+    }
+
+    secret, err := s.secrets.Decrypt(totp.Secret)
+    if err != nil {
+        return nil, err
+    }
+    step, ok := utils.ValidateTOTP(secret, code, time.Now())
+    if !ok {
+        return nil, ErrInvalidTwoFactorCode
+    }
+
+    codes, hashes, err := generateRecoveryCodes()
+    if err != nil {
+        return nil, err
+    }
+
+    enabled, err := s.twoFactorRepo.Enable(ctx, userID, step, hashes)
+    if err != nil {
+        return nil, fmt.Errorf("error enabling two-factor authentication: %w", err)
+    }
+    if !enabled {
+        return nil, ErrTwoFactorAlreadyEnabled
+    }
+
+    return codes, nil
+}
+
+// Disable отключает 2FA. Требует пароль и действующий код.
+func (s *TwoFactorService) Disable(ctx context.Context, userID int, password, code string) error {
+    user, err := s.userRepo.GetUserByID(ctx, userID)
+    if err != nil {
+        return fmt.Errorf("error finding user: %w", err)
+    }
+    if user == nil {
+        return ErrUserNotFound
+    }
+    if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+        return ErrInvalidPassword
+    }
+
+    totp, err := s.enabledTOTP(ctx, userID)
+    if err != nil {
+        return err
+    }
+    if err := s.checkCode(ctx, totp, code); err != nil {
+        return err
+    }
+
+    if err := s.twoFactorRepo.Disable(ctx, userID); err != nil {
+        return fmt.Errorf("error disabling two-factor authentication: %w", err)
+    }
+    return nil
+}
+
+// RegenerateRecoveryCodes выпускает новые коды восстановления, старые перестают действовать
+func (s *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID int, code string) ([]string, error) {
+    totp, err := s.enabledTOTP(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+    if err := s.checkCode(ctx, totp, code); err != nil {
+        return nil, err
+    }
+
+    codes, hashes, err := generateRecoveryCodes()
+    if err != nil {
+        return nil, err
+    }
+    if err := s.twoFactorRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+        return nil, fmt.Errorf("error saving recovery codes: %w", err)
+    }
+
+    return codes, nil
+}
+
+// CreateChallenge выдает challenge токен после успешной проверки пароля
+func (s *TwoFactorService) CreateChallenge(ctx context.Context, userID int) (string, error) {
+    tokenID := uuid.New().String()
+    token, err := utils.GenerateChallengeToken(userID, tokenID, s.keys)
+    if err != nil {
+        return "", fmt.Errorf("error generating challenge token: %w", err)
+    }
+
+    if err := s.twoFactorRepo.CreateChallenge(ctx, tokenID, userID, time.Now().Add(utils.TwoFactorChallengeTTL)); err != nil {
+        return "", fmt.Errorf("error saving challenge: %w", err)
+    }
+    return token, nil
+}
+
+// VerifyChallenge проверяет второй шаг входа и возвращает пользователя.
+// Challenge токен одноразовый: после успешной проверки его нельзя предъявить повторно.
+func (s *TwoFactorService) VerifyChallenge(ctx context.Context, challengeToken, code string) (*models.User, error) {
+    userID, tokenID, err := utils.ValidateChallengeToken(challengeToken, s.keys)
+    if err != nil {
+        return nil, ErrInvalidChallengeToken
+    }
+
+    // Использованный токен отклоняем до проверки кода, чтобы повтор не расходовал коды восстановления
+    active, err := s.twoFactorRepo.ChallengeActive(ctx, tokenID, userID)
+    if err != nil {
+        return nil, fmt.Errorf("error checking challenge: %w", err)
+    }
+    if !active {
+        return nil, ErrInvalidChallengeToken
+    }
+
+    totp, err := s.enabledTOTP(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+    if err := s.checkCode(ctx, totp, code); err != nil {
+        return nil, err
+    }
+
+    consumed, err := s.twoFactorRepo.ConsumeChallenge(ctx, tokenID, userID)
+    if err != nil {
+        return nil, fmt.Errorf("error consuming challenge: %w", err)
+    }
+    if !consumed {
+        return nil, ErrInvalidChallengeToken
+    }
+
+    user, err := s.userRepo.GetUserByID(ctx, userID)
+    if err != nil {
+        return nil, fmt.Errorf("error finding user: %w", err)
+    }
+    if user == nil {
+        return nil, ErrInvalidChallengeToken
+    }
+    return user, nil
+}
+
+func (s *TwoFactorService) enabledTOTP(ctx context.Context, userID int) (*models.UserTOTP, error) {
+    totp, err := s.twoFactorRepo.GetTOTP(ctx, userID)
+    if err != nil {
+        return nil, fmt.Errorf("error finding totp settings: %w", err)
+    }
+    if totp == nil || !totp.Enabled {
+        return nil, ErrTwoFactorNotEnabled
+    }
+    return totp, nil
+}
+
+// checkCode принимает код из приложения или неиспользованный код восстановления.
+// Неверные коды считаются, после нескольких ошибок ввод временно блокируется.
+func (s *TwoFactorService) checkCode(ctx context.Context, totp *models.UserTOTP, code string) error {
+    if totp.LockedUntil != nil && time.Now().Before(*totp.LockedUntil) {
+        return ErrTwoFactorLocked
+    }
+
+    secret, err := s.secrets.Decrypt(totp.Secret)
+    if err != nil {
+        return err
+    }
+
+    var accepted bool
+    if step, ok := utils.ValidateTOTP(secret, code, time.Now()); ok {
+        accepted, err = s.twoFactorRepo.UseStep(ctx, totp.UserID, step)
+    } else {
+        accepted, err = s.twoFactorRepo.UseRecoveryCode(ctx, totp.UserID, utils.HashToken(utils.NormalizeRecoveryCode(code)))
+    }
+    if err != nil {
+        return fmt.Errorf("error checking two-factor code: %w", err)
+    }
+
+    if !accepted {
+        if err := s.twoFactorRepo.RegisterFailure(ctx, totp.UserID, twoFactorMaxAttempts, twoFactorLockoutPeriod); err != nil {
+            return fmt.Errorf("error registering failed attempt: %w", err)
+        }
+        return ErrInvalidTwoFactorCode
+    }
+    return nil
+}
+
+func generateRecoveryCodes() ([]string, []string, error) {
+    codes := make([]string, 0, recoveryCodesCount)
+    hashes := make([]string, 0, recoveryCodesCount)
+    for i := 0; i < recoveryCodesCount; i++ {
+        code, err := utils.GenerateRecoveryCode()
+        if err != nil {
+            return nil, nil, fmt.Errorf("error generating recovery code: %w", err)
+        }
+        codes = append(codes, code)
+        hashes = append(hashes, utils.HashToken(utils.NormalizeRecoveryCode(code)))
+    }
+    return codes, hashes, nil
+}
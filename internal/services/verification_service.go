@@ -0,0 +1,168 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "mime/multipart"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/repositories"
+)
+
+var (
+    ErrNotTeacher              = errors.New("only teachers can apply for verification")
+    ErrTeacherAlreadyVerified  = errors.New("teacher is already verified")
+    ErrApplicationPending      = errors.New("there is already an application under review")
+    ErrApplicationNotFound     = errors.New("application not found")
+    ErrApplicationReviewed     = errors.New("application has already been reviewed")
+    ErrApplicationNoDocuments  = errors.New("at least one document is required")
+    ErrRejectCommentRequired   = errors.New("comment is required when rejecting an application")
+)
+
+const (
+    maxVerificationDocuments    = 5
+    maxVerificationDocumentSize = 10 * 1024 * 1024 // 10MB
+)
+
+type VerificationService struct {
+    verificationRepo   *repositories.VerificationRepository
+    userRepo           *repositories.UserRepository
+    privateFileService *PrivateFileService
+}
+
+func NewVerificationService(verificationRepo *repositories.VerificationRepository, userRepo *repositories.UserRepository, privateFileService *PrivateFileService) *VerificationService {
+    return &VerificationService{
+        verificationRepo:   verificationRepo,
+        userRepo:           userRepo,
+        privateFileService: privateFileService,
+    }
+}
+
+// SubmitApplication загружает документы и создает заявку на верификацию преподавателя
+func (s *VerificationService) SubmitApplication(ctx context.Context, userID int, message string, files []*multipart.FileHeader) (*models.TeacherApplication, error) {
+    user, err := s.userRepo.GetUserByID(ctx, userID)
+    if err != nil {
+        return nil, fmt.Errorf("error finding user: %w", err)
+    }
+    if user == nil || user.Role != "teacher" {
+        return nil, ErrNotTeacher
+    }
+    if user.IsVerified {
+        return nil, ErrTeacherAlreadyVerified
+    }
+
+    pending, err := s.verificationRepo.HasPendingApplication(ctx, userID)
+    if err != nil {
+        return nil, fmt.Errorf("error checking applications: %w", err)
+    }
+    if pending {
+        return nil, ErrApplicationPending
+    }
+
+    if len(files) == 0 {
+        return nil, ErrApplicationNoDocuments
+    }
+    if len(files) > maxVerificationDocuments {
+        return nil, fmt.Errorf("too many documents: maximum is %d", maxVerificationDocuments)
+    }
+
+    documents := make([]models.VerificationDocument, 0, len(files))
+    uploaded := make([]*models.PrivateFile, 0, len(files))
+    for _, fileHeader := range files {
+        if fileHeader.Size > maxVerificationDocumentSize {
+            return nil, fmt.Errorf("document %s is too large: maximum size is 10MB", fileHeader.Filename)
+        }
+
+        document, err := s.uploadDocument(ctx, userID, fileHeader)
+        if err != nil {
+            s.removeDocuments(ctx, uploaded)
+            return nil, err
+        }
+        uploaded = append(uploaded, document)
+        documents = append(documents, models.VerificationDocument{
+            FileID:   document.ID,
+            FileName: document.FileName,
+        })
+    }
+
+    application := &models.TeacherApplication{
+        UserID:    userID,
+        UserEmail: user.Email,
+        UserName:  user.FullName,
+        Message:   message,
+        Documents: documents,
+    }
+
+    if err := s.verificationRepo.CreateApplication(ctx, application); err != nil {
+        s.removeDocuments(ctx, uploaded)
+        return nil, fmt.Errorf("failed to create application: %w", err)
+    }
+
+    return application, nil
+}
+
+// uploadDocument сохраняет документ как закрытый файл: он доступен только
+// преподавателю и администраторам, проверяющим заявки
+func (s *VerificationService) uploadDocument(ctx context.Context, userID int, fileHeader *multipart.FileHeader) (*models.PrivateFile, error) {
+    file, err := fileHeader.Open()
+    if err != nil {
+        return nil, fmt.Errorf("failed to open file: %w", err)
+    }
+    defer file.Close()
+
+    return s.privateFileService.Save(ctx, file, fileHeader.Filename, fileHeader.Size, userID, models.PrivateFileVerification)
+}
+
+// removeDocuments удаляет документы заявки, которую не удалось создать
+func (s *VerificationService) removeDocuments(ctx context.Context, documents []*models.PrivateFile) {
+    for _, document := range documents {
+        if err := s.privateFileService.Delete(ctx, document); err != nil {
+            log.Printf("Failed to remove verification document %s: %v", document.ID, err)
+        }
+    }
+}
+
+// GetUserApplications возвращает заявки преподавателя
+func (s *VerificationService) GetUserApplications(ctx context.Context, userID int) ([]models.TeacherApplication, error) {
+    return s.verificationRepo.GetUserApplications(ctx, userID)
+}
+
+// ListApplications возвращает заявки для администратора
+func (s *VerificationService) ListApplications(ctx context.Context, status string, page, limit int) ([]models.TeacherApplication, int, error) {
+    return s.verificationRepo.ListApplications(ctx, status, page, limit)
+}
+
+// ApproveApplication одобряет заявку и верифицирует преподавателя
+func (s *VerificationService) ApproveApplication(ctx context.Context, adminID, applicationID int, comment string) (*models.TeacherApplication, error) {
+    return s.review(ctx, adminID, applicationID, "approved", comment)
+}
+
+// RejectApplication отклоняет заявку с обязательным комментарием
+func (s *VerificationService) RejectApplication(ctx context.Context, adminID, applicationID int, comment string) (*models.TeacherApplication, error) {
+    if comment == "" {
+        return nil, ErrRejectCommentRequired
+    }
+    return s.review(ctx, adminID, applicationID, "rejected", comment)
+}
+
+func (s *VerificationService) review(ctx context.Context, adminID, applicationID int, status, comment string) (*models.TeacherApplication, error) {
+    application, err := s.verificationRepo.GetApplication(ctx, applicationID)
+    if err != nil {
+        return nil, fmt.Errorf("error finding application: %w", err)
+    }
+    if application == nil {
+        return nil, ErrApplicationNotFound
+    }
+
+    reviewed, err := s.verificationRepo.ReviewApplication(ctx, applicationID, status, comment, adminID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to review application: %w", err)
+    }
+    if !reviewed {
+        return nil, ErrApplicationReviewed
+    }
+
+    return s.verificationRepo.GetApplication(ctx, applicationID)
+}
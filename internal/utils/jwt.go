@@ -1,6 +1,7 @@
 package utils
 
 import (
+    "errors"
     "time"
     "strconv"
 
@@ -8,53 +9,153 @@ import (
 )
 
 type Claims struct {
-    UserID int    `json:"userId"`
-    Email  string `json:"email"`
-    Role   string `json:"role"`
+    UserID    int    `json:"userId"`
+    Email     string `json:"email"`
+    Role      string `json:"role"`
+    SessionID string `json:"sid,omitempty"` // сессия (семейство refresh токенов), в которой выдан токен
+    Actor     *ActorClaim `json:"act,omitempty"` // администратор, действующий от имени пользователя
     jwt.RegisteredClaims
 }
 
-func GenerateAccessToken(userID int, email, role, secret string) (string, error) {
+// ActorClaim описывает того, кто на самом деле выполняет запросы (RFC 8693, claim act)
+type ActorClaim struct {
+    Subject string `json:"sub"`
+    Email   string `json:"email"`
+}
+
+// ActorID возвращает ID администратора из claim act (0, если токен обычный)
+func (c *Claims) ActorID() int {
+    if c.Actor == nil {
+        return 0
+    }
+    id, _ := strconv.Atoi(c.Actor.Subject)
+    return id
+}
+
+func GenerateAccessToken(userID int, email, role, sessionID string, keys *KeySet) (string, error) {
+    claims := &Claims{
+        UserID:    userID,
+        Email:     email,
+        Role:      role,
+        SessionID: sessionID,
+        RegisteredClaims: jwt.RegisteredClaims{
+            ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)), // 15 минут
+            IssuedAt:  jwt.NewNumericDate(time.Now()),
+            Issuer:    tokenIssuer,
+            Subject:   strconv.Itoa(userID),
+            Audience:  jwt.ClaimStrings{AudienceAccess},
+        },
+    }
+
+    return keys.Sign(claims)
+}
+
+// ImpersonationTokenTTL время жизни токена входа под пользователем. Refresh токен
+// к нему не выдается: по истечении администратор запрашивает новый.
+const ImpersonationTokenTTL = 15 * time.Minute
+
+// GenerateImpersonationToken создает access токен пользователя с claim act,
+// по которому видно, какой администратор действует от его имени.
+// tokenID (jti) связывает записи аудита одного сеанса входа под пользователем.
+func GenerateImpersonationToken(userID int, email, role string, actorID int, actorEmail, tokenID string, keys *KeySet) (string, error) {
     claims := &Claims{
         UserID: userID,
         Email:  email,
         Role:   role,
+        Actor: &ActorClaim{
+            Subject: strconv.Itoa(actorID),
+            Email:   actorEmail,
+        },
         RegisteredClaims: jwt.RegisteredClaims{
-            ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)), // 15 минут
+            ExpiresAt: jwt.NewNumericDate(time.Now().Add(ImpersonationTokenTTL)),
             IssuedAt:  jwt.NewNumericDate(time.Now()),
-            Issuer:    "paydeya-backend",
+            Issuer:    tokenIssuer,
+            Subject:   strconv.Itoa(userID),
+            Audience:  jwt.ClaimStrings{AudienceAccess},
+            ID:        tokenID,
         },
     }
 
-    token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-    return token.SignedString([]byte(secret))
+    return keys.Sign(claims)
 }
 
-func GenerateRefreshToken(userID int, secret string) (string, error) {
+// RefreshTokenTTL время жизни refresh токена
+const RefreshTokenTTL = 7 * 24 * time.Hour // 7 дней
+
+// GenerateRefreshToken создает refresh токен. tokenID (jti) делает каждый
+// токен уникальным, даже если два токена выданы в одну секунду.
+func GenerateRefreshToken(userID int, tokenID string, keys *KeySet) (string, error) {
     claims := &jwt.RegisteredClaims{
-        ExpiresAt: jwt.NewNumericDate(time.Now().Add(7 * 24 * time.Hour)), // 7 дней
+        ExpiresAt: jwt.NewNumericDate(time.Now().Add(RefreshTokenTTL)),
         IssuedAt:  jwt.NewNumericDate(time.Now()),
-        Issuer:    "paydeya-backend",
+        Issuer:    tokenIssuer,
         Subject:   strconv.Itoa(userID),
+        Audience:  jwt.ClaimStrings{AudienceRefresh},
+        ID:        tokenID,
     }
 
-    token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-    return token.SignedString([]byte(secret))
+    return keys.Sign(claims)
 }
 
-func ValidateToken(tokenString, secret string) (*Claims, error) {
-    claims := &Claims{}
-    token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-        return []byte(secret), nil
-    })
-
+// ValidateRefreshToken проверяет подпись и аудиторию refresh токена.
+// Токены старого формата (HS256) принимаются, пока задан JWT_SECRET.
+func ValidateRefreshToken(tokenString string, keys *KeySet) (*jwt.RegisteredClaims, error) {
+    claims := &jwt.RegisteredClaims{}
+    err := keys.Parse(tokenString, claims, AudienceRefresh)
+    if err != nil && keys.legacySecret != nil {
+        claims = &jwt.RegisteredClaims{}
+        if legacyErr := keys.parseLegacyRefresh(tokenString, claims); legacyErr == nil {
+            return claims, nil
+        }
+    }
     if err != nil {
         return nil, err
     }
+    return claims, nil
+}
+
+// TwoFactorChallengeTTL время, за которое нужно ввести код второго фактора
+const TwoFactorChallengeTTL = 5 * time.Minute
+
+// GenerateChallengeToken создает короткоживущий токен, подтверждающий,
+// что пароль уже проверен и осталось ввести код второго фактора.
+// По tokenID (jti) токен гасится после успешной проверки кода.
+func GenerateChallengeToken(userID int, tokenID string, keys *KeySet) (string, error) {
+    claims := &jwt.RegisteredClaims{
+        ExpiresAt: jwt.NewNumericDate(time.Now().Add(TwoFactorChallengeTTL)),
+        IssuedAt:  jwt.NewNumericDate(time.Now()),
+        Issuer:    tokenIssuer,
+        Subject:   strconv.Itoa(userID),
+        Audience:  jwt.ClaimStrings{AudienceChallenge},
+        ID:        tokenID,
+    }
+
+    return keys.Sign(claims)
+}
 
-    if !token.Valid {
-        return nil, jwt.ErrSignatureInvalid
+// ValidateChallengeToken проверяет challenge токен и возвращает ID пользователя и jti
+func ValidateChallengeToken(tokenString string, keys *KeySet) (int, string, error) {
+    claims := &jwt.RegisteredClaims{}
+    if err := keys.Parse(tokenString, claims, AudienceChallenge); err != nil {
+        return 0, "", err
+    }
+
+    userID, err := strconv.Atoi(claims.Subject)
+    if err != nil {
+        return 0, "", errors.New("invalid challenge token subject")
+    }
+    if claims.ID == "" {
+        return 0, "", errors.New("challenge token has no id")
+    }
+    return userID, claims.ID, nil
+}
+
+// ValidateToken проверяет access токен
+func ValidateToken(tokenString string, keys *KeySet) (*Claims, error) {
+    claims := &Claims{}
+    if err := keys.Parse(tokenString, claims, AudienceAccess); err != nil {
+        return nil, err
     }
 
     return claims, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,257 @@
+package utils
+
+import (
+    "crypto"
+    "crypto/ed25519"
+    "crypto/rsa"
+    "crypto/x509"
+    "encoding/base64"
+    "encoding/pem"
+    "errors"
+    "fmt"
+    "math/big"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+
+    "github.com/golang-jwt/jwt/v5"
+)
+
+// Audiences разделяют назначение токенов: refresh токен нельзя предъявить
+// вместо access токена и наоборот
+const (
+    AudienceAccess    = "paydeya-access"
+    AudienceRefresh   = "paydeya-refresh"
+    AudienceChallenge = "paydeya-2fa"
+
+    tokenIssuer = "paydeya-backend"
+)
+
+// signingKey — ключ из набора. private есть только у ключей, которыми можно
+// подписывать; выведенные из оборота ключи хранят лишь публичную часть.
+type signingKey struct {
+    kid     string
+    method  jwt.SigningMethod
+    private crypto.Signer
+    public  crypto.PublicKey
+}
+
+// KeySet хранит ключи подписи JWT. Подписывает текущий ключ, проверка
+// принимает любой ключ из набора, поэтому ключи можно менять без разлогина
+// пользователей: новый ключ добавляется и становится активным, старый
+// остается в наборе, пока не истекут подписанные им токены.
+type KeySet struct {
+    active *signingKey
+    keys   map[string]*signingKey
+    // legacySecret проверяет refresh токены, подписанные HS256 до перехода
+    // на асимметричные ключи. Access токены им не принимаются.
+    legacySecret []byte
+}
+
+// LoadKeySet загружает PEM ключи из каталога. kid — имя файла без расширения.
+// Поддерживаются приватные ключи RSA и Ed25519 (PKCS#8 или PKCS#1) и публичные
+// ключи (PKIX) для проверки токенов выведенных из оборота ключей. Если activeKID
+// пустой, подписывает приватный ключ с наибольшим kid (удобно называть ключи по дате).
+func LoadKeySet(dir, activeKID, legacySecret string) (*KeySet, error) {
+    files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+    if err != nil {
+        return nil, err
+    }
+
+    ks := &KeySet{keys: make(map[string]*signingKey)}
+    for _, file := range files {
+        key, err := loadPEMKey(file)
+        if err != nil {
+            return nil, fmt.Errorf("failed to load key %s: %w", file, err)
+        }
+        ks.keys[key.kid] = key
+    }
+
+    if activeKID == "" {
+        kids := make([]string, 0, len(ks.keys))
+        for kid, key := range ks.keys {
+            if key.private != nil {
+                kids = append(kids, kid)
+            }
+        }
+        sort.Strings(kids)
+        if len(kids) > 0 {
+            activeKID = kids[len(kids)-1]
+        }
+    }
+
+    active, ok := ks.keys[activeKID]
+    if !ok || active.private == nil {
+        return nil, fmt.Errorf("no private signing key %q in %s", activeKID, dir)
+    }
+    ks.active = active
+
+    if legacySecret != "" {
+        ks.legacySecret = []byte(legacySecret)
+    }
+
+    return ks, nil
+}
+
+// NewEphemeralKeySet создает набор с одним Ed25519 ключом в памяти.
+// Только для разработки: после перезапуска все токены станут недействительны.
+// legacySecret, как и в LoadKeySet, принимает refresh токены старого формата.
+func NewEphemeralKeySet(legacySecret string) (*KeySet, error) {
+    public, private, err := ed25519.GenerateKey(nil)
+    if err != nil {
+        return nil, err
+    }
+
+    key := &signingKey{
+        kid:     "ephemeral-" + HashToken(string(public))[:8],
+        method:  jwt.SigningMethodEdDSA,
+        private: private,
+        public:  public,
+    }
+    ks := &KeySet{active: key, keys: map[string]*signingKey{key.kid: key}}
+    if legacySecret != "" {
+        ks.legacySecret = []byte(legacySecret)
+    }
+    return ks, nil
+}
+
+func loadPEMKey(file string) (*signingKey, error) {
+    data, err := os.ReadFile(file)
+    if err != nil {
+        return nil, err
+    }
+
+    block, _ := pem.Decode(data)
+    if block == nil {
+        return nil, errors.New("no PEM block found")
+    }
+
+    kid := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+
+    var parsed interface{}
+    switch block.Type {
+    case "PRIVATE KEY":
+        parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+    case "RSA PRIVATE KEY":
+        parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+    case "PUBLIC KEY":
+        parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+    default:
+        return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    switch key := parsed.(type) {
+    case *rsa.PrivateKey:
+        return &signingKey{kid: kid, method: jwt.SigningMethodRS256, private: key, public: &key.PublicKey}, nil
+    case *rsa.PublicKey:
+        return &signingKey{kid: kid, method: jwt.SigningMethodRS256, public: key}, nil
+    case ed25519.PrivateKey:
+        return &signingKey{kid: kid, method: jwt.SigningMethodEdDSA, private: key, public: key.Public()}, nil
+    case ed25519.PublicKey:
+        return &signingKey{kid: kid, method: jwt.SigningMethodEdDSA, public: key}, nil
+    default:
+        return nil, fmt.Errorf("unsupported key type %T", parsed)
+    }
+}
+
+// ActiveKeyID возвращает kid ключа, которым подписываются новые токены
+func (ks *KeySet) ActiveKeyID() string {
+    return ks.active.kid
+}
+
+// Sign подписывает claims активным ключом и проставляет kid в заголовок
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+    token := jwt.NewWithClaims(ks.active.method, claims)
+    token.Header["kid"] = ks.active.kid
+    return token.SignedString(ks.active.private)
+}
+
+// Parse проверяет подпись, срок действия, издателя и аудиторию токена
+func (ks *KeySet) Parse(tokenString string, claims jwt.Claims, audience string) error {
+    token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+        kid, _ := token.Header["kid"].(string)
+        key, ok := ks.keys[kid]
+        if !ok {
+            return nil, fmt.Errorf("unknown signing key %q", kid)
+        }
+        if token.Method.Alg() != key.method.Alg() {
+            return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+        }
+        return key.public, nil
+    },
+        jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+        jwt.WithIssuer(tokenIssuer),
+        jwt.WithAudience(audience),
+    )
+    if err != nil {
+        return err
+    }
+    if !token.Valid {
+        return jwt.ErrSignatureInvalid
+    }
+    return nil
+}
+
+// parseLegacyRefresh проверяет refresh токен старого формата (HS256, без аудитории)
+func (ks *KeySet) parseLegacyRefresh(tokenString string, claims jwt.Claims) error {
+    if ks.legacySecret == nil {
+        return errors.New("legacy tokens are not accepted")
+    }
+
+    _, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+        return ks.legacySecret, nil
+    }, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithIssuer(tokenIssuer))
+    return err
+}
+
+// JWK represents public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+    Kty string `json:"kty"`
+    Kid string `json:"kid"`
+    Use string `json:"use"`
+    Alg string `json:"alg"`
+    N   string `json:"n,omitempty"`
+    E   string `json:"e,omitempty"`
+    Crv string `json:"crv,omitempty"`
+    X   string `json:"x,omitempty"`
+}
+
+// JWKS represents JSON Web Key Set
+type JWKS struct {
+    Keys []JWK `json:"keys"`
+}
+
+// JWKS возвращает публичные ключи набора для /.well-known/jwks.json
+func (ks *KeySet) JWKS() JWKS {
+    kids := make([]string, 0, len(ks.keys))
+    for kid := range ks.keys {
+        kids = append(kids, kid)
+    }
+    sort.Strings(kids)
+
+    set := JWKS{Keys: make([]JWK, 0, len(kids))}
+    for _, kid := range kids {
+        key := ks.keys[kid]
+        jwk := JWK{Kid: kid, Use: "sig", Alg: key.method.Alg()}
+
+        switch public := key.public.(type) {
+        case *rsa.PublicKey:
+            jwk.Kty = "RSA"
+            jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+            jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+        case ed25519.PublicKey:
+            jwk.Kty = "OKP"
+            jwk.Crv = "Ed25519"
+            jwk.X = base64.RawURLEncoding.EncodeToString(public)
+        default:
+            continue
+        }
+
+        set.Keys = append(set.Keys, jwk)
+    }
+    return set
+}
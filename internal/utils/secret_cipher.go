@@ -0,0 +1,84 @@
+package utils
+
+import (
+    "crypto/aes"
+    "crypto/cipher"
+    "crypto/rand"
+    "encoding/base64"
+    "errors"
+    "fmt"
+    "strings"
+)
+
+// Префикс зашифрованного значения. Значения без префикса сохранены до
+// включения шифрования и возвращаются как есть.
+const encryptedSecretPrefix = "enc:v1:"
+
+// SecretCipher шифрует секреты, которые приходится хранить в БД в
+// восстановимом виде (например, секреты TOTP), с помощью AES-256-GCM
+type SecretCipher struct {
+    aead cipher.AEAD
+}
+
+// NewSecretCipher создает шифр по ключу в base64 (32 байта).
+// Новый ключ: openssl rand -base64 32
+func NewSecretCipher(encodedKey string) (*SecretCipher, error) {
+    key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encodedKey))
+    if err != nil {
+        return nil, fmt.Errorf("invalid encryption key encoding: %w", err)
+    }
+    if len(key) != 32 {
+        return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+    }
+
+    block, err := aes.NewCipher(key)
+    if err != nil {
+        return nil, err
+    }
+    aead, err := cipher.NewGCM(block)
+    if err != nil {
+        return nil, err
+    }
+    return &SecretCipher{aead: aead}, nil
+}
+
+// Encrypt шифрует значение. Без ключа (nil) значение хранится открытым.
+func (c *SecretCipher) Encrypt(plaintext string) (string, error) {
+    if c == nil {
+        return plaintext, nil
+    }
+
+    nonce := make([]byte, c.aead.NonceSize())
+    if _, err := rand.Read(nonce); err != nil {
+        return "", err
+    }
+
+    sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+    return encryptedSecretPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt расшифровывает значение. Значения без префикса считаются
+// сохраненными до включения шифрования и возвращаются как есть.
+func (c *SecretCipher) Decrypt(stored string) (string, error) {
+    if !strings.HasPrefix(stored, encryptedSecretPrefix) {
+        return stored, nil
+    }
+    if c == nil {
+        return "", errors.New("secret is encrypted but no encryption key is configured")
+    }
+
+    sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(stored, encryptedSecretPrefix))
+    if err != nil {
+        return "", fmt.Errorf("invalid encrypted secret: %w", err)
+    }
+    if len(sealed) < c.aead.NonceSize() {
+        return "", errors.New("invalid encrypted secret")
+    }
+
+    nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+    plaintext, err := c.aead.Open(nil, nonce, ciphertext, nil)
+    if err != nil {
+        return "", fmt.Errorf("error decrypting secret: %w", err)
+    }
+    return string(plaintext), nil
+}
@@ -0,0 +1,36 @@
+package utils
+
+// LongestCommonSubsequence возвращает элементы наибольшей общей подпоследовательности
+// двух списков ID, то есть элементы, сохранившие взаимный порядок
+func LongestCommonSubsequence(a, b []string) map[string]bool {
+    lengths := make([][]int, len(a)+1)
+    for i := range lengths {
+        lengths[i] = make([]int, len(b)+1)
+    }
+    for i := len(a) - 1; i >= 0; i-- {
+        for j := len(b) - 1; j >= 0; j-- {
+            if a[i] == b[j] {
+                lengths[i][j] = lengths[i+1][j+1] + 1
+            } else if lengths[i+1][j] >= lengths[i][j+1] {
+                lengths[i][j] = lengths[i+1][j]
+            } else {
+                lengths[i][j] = lengths[i][j+1]
+            }
+        }
+    }
+
+    result := make(map[string]bool)
+    for i, j := 0, 0; i < len(a) && j < len(b); {
+        switch {
+        case a[i] == b[j]:
+            result[a[i]] = true
+            i++
+            j++
+        case lengths[i+1][j] >= lengths[i][j+1]:
+            i++
+        default:
+            j++
+        }
+    }
+    return result
+}
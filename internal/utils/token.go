@@ -0,0 +1,24 @@
+package utils
+
+import (
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/hex"
+)
+
+// HashToken возвращает sha256 от токена. В БД храним только хеш,
+// чтобы утечка таблицы не давала готовых токенов.
+func HashToken(token string) string {
+    sum := sha256.Sum256([]byte(token))
+    return hex.EncodeToString(sum[:])
+}
+
+// GenerateOpaqueToken генерирует случайный токен и возвращает его вместе с хешем
+func GenerateOpaqueToken() (string, string, error) {
+    bytes := make([]byte, 32)
+    if _, err := rand.Read(bytes); err != nil {
+        return "", "", err
+    }
+    token := hex.EncodeToString(bytes)
+    return token, HashToken(token), nil
+}
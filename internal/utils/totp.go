@@ -0,0 +1,110 @@
+package utils
+
+import (
+    "crypto/hmac"
+    "crypto/rand"
+    "crypto/sha1"
+    "crypto/subtle"
+    "encoding/base32"
+    "encoding/binary"
+    "fmt"
+    "net/url"
+    "strings"
+    "time"
+)
+
+// Параметры TOTP по умолчанию (RFC 6238), их понимают все приложения-аутентификаторы
+const (
+    totpPeriod = 30
+    totpDigits = 6
+    totpSkew   = 1 // допускаем соседние интервалы из-за расхождения часов
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret создает случайный 160-битный секрет в base32
+func GenerateTOTPSecret() (string, error) {
+    bytes := make([]byte, 20)
+    if _, err := rand.Read(bytes); err != nil {
+        return "", err
+    }
+    return totpEncoding.EncodeToString(bytes), nil
+}
+
+// TOTPStep возвращает номер интервала для момента времени
+func TOTPStep(t time.Time) int64 {
+    return t.Unix() / totpPeriod
+}
+
+// TOTPCode вычисляет код для интервала (HOTP из RFC 4226)
+func TOTPCode(secret string, step int64) (string, error) {
+    key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+    if err != nil {
+        return "", fmt.Errorf("invalid totp secret: %w", err)
+    }
+
+    var counter [8]byte
+    binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+    mac := hmac.New(sha1.New, key)
+    mac.Write(counter[:])
+    sum := mac.Sum(nil)
+
+    offset := sum[len(sum)-1] & 0x0f
+    value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+    mod := uint32(1)
+    for i := 0; i < totpDigits; i++ {
+        mod *= 10
+    }
+    return fmt.Sprintf("%0*d", totpDigits, value%mod), nil
+}
+
+// ValidateTOTP проверяет код с учетом соседних интервалов и возвращает
+// номер интервала, которому он соответствует
+func ValidateTOTP(secret, code string, now time.Time) (int64, bool) {
+    if len(code) != totpDigits {
+        return 0, false
+    }
+
+    current := TOTPStep(now)
+    for step := current - totpSkew; step <= current+totpSkew; step++ {
+        expected, err := TOTPCode(secret, step)
+        if err != nil {
+            return 0, false
+        }
+        if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+            return step, true
+        }
+    }
+    return 0, false
+}
+
+// TOTPURI формирует otpauth:// ссылку, которую фронтенд показывает в виде QR-кода
+func TOTPURI(issuer, account, secret string) string {
+    params := url.Values{}
+    params.Set("secret", secret)
+    params.Set("issuer", issuer)
+    params.Set("algorithm", "SHA1")
+    params.Set("digits", fmt.Sprint(totpDigits))
+    params.Set("period", fmt.Sprint(totpPeriod))
+
+    label := url.PathEscape(issuer + ":" + account)
+    return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// GenerateRecoveryCode создает код восстановления вида xxxxx-xxxxx
+func GenerateRecoveryCode() (string, error) {
+    bytes := make([]byte, 7)
+    if _, err := rand.Read(bytes); err != nil {
+        return "", err
+    }
+    code := strings.ToLower(totpEncoding.EncodeToString(bytes))[:10]
+    return code[:5] + "-" + code[5:], nil
+}
+
+// NormalizeRecoveryCode приводит введенный код к виду, от которого считается хеш
+func NormalizeRecoveryCode(code string) string {
+    code = strings.ToLower(strings.TrimSpace(code))
+    return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -5,6 +5,7 @@ import (
     "log"
     "os"
     "strconv"
+    "strings"
     "fmt"
     _ "paydeya-backend/docs"
     "time"  // ← ДОБАВЬТЕ ЭТОТ
@@ -13,10 +14,13 @@ import (
     "github.com/swaggo/files"      // ← ДОБАВЬТЕ ЭТОТ
     ginSwagger "github.com/swaggo/gin-swagger"
 
+    "paydeya-backend/internal/config"
     "paydeya-backend/internal/database"
     "paydeya-backend/internal/handlers"
+    "paydeya-backend/internal/models"
     "paydeya-backend/internal/repositories"
     "paydeya-backend/internal/services"
+    "paydeya-backend/internal/utils"
     "paydeya-backend/internal/middleware"
 
 
@@ -48,6 +52,30 @@ func runMigrations() error {
         "migrations/004_add_ratings_table.sql",
         "migrations/005_create_progress_tables.sql",
         "migrations/006_sample_data.sql",
+        "migrations/007_create_refresh_tokens_table.sql",
+        "migrations/008_create_user_tokens_table.sql",
+        "migrations/009_add_email_verification.sql",
+        "migrations/010_create_teacher_verification_table.sql",
+        "migrations/011_create_admin_invites_table.sql",
+        "migrations/012_create_rbac_tables.sql",
+        "migrations/013_create_two_factor_tables.sql",
+        "migrations/014_create_auth_sessions_table.sql",
+        "migrations/015_create_oauth_tables.sql",
+        "migrations/016_create_audit_log.sql",
+        "migrations/017_create_account_deletions_table.sql",
+        "migrations/018_create_api_keys_table.sql",
+        "migrations/019_add_impersonation_permission.sql",
+        "migrations/020_create_material_revisions_table.sql",
+        "migrations/021_add_published_versions.sql",
+        "migrations/022_block_positions.sql",
+        "migrations/023_add_material_edit_version.sql",
+        "migrations/024_create_quiz_attempts_table.sql",
+        "migrations/025_create_assignment_submissions_table.sql",
+        "migrations/026_add_material_forks_and_templates.sql",
+        "migrations/027_create_private_files_table.sql",
+        "migrations/028_add_submission_file_id.sql",
+        "migrations/029_add_quiz_attempt_limits.sql",
+        "migrations/030_add_two_factor_challenges.sql",
     }
 
     for _, file := range migrationFiles {
@@ -85,6 +113,11 @@ func runMigrations() error {
 // @name Authorization
 // @description Введите: Bearer {token}
 
+// @securityDefinitions.apikey PersonalAPIKey
+// @in header
+// @name X-API-Key
+// @description Персональный API ключ из /profile/api-keys
+
 // @tag.name admin
 // @tag.description Эндпоинты для администраторов
 // @tag.name catalog
@@ -99,6 +132,8 @@ func runMigrations() error {
 // @tag.description Управление профилем пользователя
 // @tag.name media
 // @tag.description Загрузка и управление медиафайлами
+// @tag.name teacher
+// @tag.description Кабинет преподавателя
 func main() {
  // Загружаем .env файл локально
     if err := godotenv.Load(); err != nil {
@@ -144,27 +179,179 @@ func main() {
     userRepo := repositories.NewUserRepository(database.DB)
     materialRepo := repositories.NewMaterialRepository(database.DB)
     blockRepo := repositories.NewBlockRepository(database.DB)
+    revisionRepo := repositories.NewRevisionRepository(database.DB)
+    templateRepo := repositories.NewTemplateRepository(database.DB)
     catalogRepo := repositories.NewCatalogRepository(database.DB)
     progressRepo := repositories.NewProgressRepository(database.DB)
+    quizRepo := repositories.NewQuizRepository(database.DB)
+    assignmentRepo := repositories.NewAssignmentRepository(database.DB)
     adminRepo := repositories.NewAdminRepository(database.DB)
+    refreshTokenRepo := repositories.NewRefreshTokenRepository(database.DB)
+    userTokenRepo := repositories.NewUserTokenRepository(database.DB)
+    verificationRepo := repositories.NewVerificationRepository(database.DB)
+    inviteRepo := repositories.NewInviteRepository(database.DB)
+    roleRepo := repositories.NewRoleRepository(database.DB)
+    twoFactorRepo := repositories.NewTwoFactorRepository(database.DB)
+    sessionRepo := repositories.NewSessionRepository(database.DB)
+    oauthRepo := repositories.NewOAuthRepository(database.DB)
+    auditRepo := repositories.NewAuditRepository(database.DB)
+    accountRepo := repositories.NewAccountRepository(database.DB)
+    apiKeyRepo := repositories.NewAPIKeyRepository(database.DB)
+    privateFileRepo := repositories.NewPrivateFileRepository(database.DB)
+
+    appURL := getEnv("APP_URL", "http://localhost:3000")
+
+    // Почта: SMTP если настроен, иначе письма складываются в папку mail_outbox
+    var mailer services.Mailer
+    mailFrom := getEnv("MAIL_FROM", "Пайдея <no-reply@paydeya.ru>")
+    if smtpHost := os.Getenv("SMTP_HOST"); smtpHost != "" {
+        mailer = services.NewSMTPMailer(smtpHost, getEnvAsInt("SMTP_PORT", 587), os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), mailFrom)
+        log.Printf("✉️ Sending mail via SMTP %s", smtpHost)
+    } else {
+        mailer = services.NewFileMailer("mail_outbox", mailFrom)
+        log.Println("📭 SMTP_HOST not set, writing mail to ./mail_outbox")
+    }
+
+    // Счетчики неудачных входов: Redis, если настроен, иначе память процесса
+    var throttleStore services.ThrottleStore
+    if redisURL := config.Load().RedisURL; redisURL != "" {
+        redisStore, err := services.NewRedisThrottleStore(context.Background(), redisURL)
+        if err != nil {
+            log.Printf("⚠️ Failed to connect to Redis, login throttling is kept in memory: %v", err)
+        } else {
+            throttleStore = redisStore
+            log.Println("🧱 Login throttling uses Redis")
+        }
+    }
+    if throttleStore == nil {
+        memoryStore := services.NewMemoryThrottleStore(getEnvAsInt("LOGIN_THROTTLE_MAX_KEYS", services.DefaultMemoryThrottleEntries))
+        go memoryStore.RunSweeper(context.Background(), time.Minute)
+        throttleStore = memoryStore
+    }
+
+    // Ключи подписи JWT: PEM файлы из JWT_KEYS_DIR (kid = имя файла). JWT_SECRET
+    // нужен только чтобы принять refresh токены, выданные до перехода на ключи.
+    // Без JWT_KEYS_DIR сервер не запускается: временный ключ разлогинивает всех
+    // при каждом перезапуске, его можно включить только для разработки (JWT_EPHEMERAL_KEYS=true).
+    var jwtKeys *utils.KeySet
+    if keysDir := os.Getenv("JWT_KEYS_DIR"); keysDir != "" {
+        jwtKeys, err = utils.LoadKeySet(keysDir, os.Getenv("JWT_ACTIVE_KID"), os.Getenv("JWT_SECRET"))
+        if err != nil {
+            log.Fatalf("❌ Failed to load JWT keys: %v", err)
+        }
+        log.Printf("🔑 Signing JWT with key %s", jwtKeys.ActiveKeyID())
+    } else if os.Getenv("JWT_EPHEMERAL_KEYS") == "true" {
+        jwtKeys, err = utils.NewEphemeralKeySet(os.Getenv("JWT_SECRET"))
+        if err != nil {
+            log.Fatalf("❌ Failed to generate JWT key: %v", err)
+        }
+        log.Println("⚠️ JWT_EPHEMERAL_KEYS=true, using a temporary signing key: tokens will not survive restart")
+    } else {
+        log.Fatalf("❌ JWT_KEYS_DIR is not set. Create a signing key (see README) or set JWT_EPHEMERAL_KEYS=true for development")
+    }
+
+    // Ключ шифрования секретов TOTP в БД. Секреты, сохраненные без ключа,
+    // продолжают работать и после его включения.
+    var totpCipher *utils.SecretCipher
+    if key := os.Getenv("TOTP_ENCRYPTION_KEY"); key != "" {
+        totpCipher, err = utils.NewSecretCipher(key)
+        if err != nil {
+            log.Fatalf("❌ Invalid TOTP_ENCRYPTION_KEY: %v", err)
+        }
+    } else {
+        log.Println("⚠️ TOTP_ENCRYPTION_KEY is not set, two-factor secrets are stored unencrypted")
+    }
+
+    // Вход через внешних провайдеров: подключаются те, для которых задан client id.
+    // Провайдер возвращает пользователя на фронтенд: {APP_URL}/oauth/{provider}/callback
+    oauthRegistry := services.NewOAuthRegistry()
+    oauthRedirectURL := func(provider string) string {
+        return strings.TrimRight(appURL, "/") + "/oauth/" + provider + "/callback"
+    }
+    if clientID := os.Getenv("OAUTH_YANDEX_CLIENT_ID"); clientID != "" {
+        oauthRegistry.Register(services.NewYandexProvider(clientID, os.Getenv("OAUTH_YANDEX_CLIENT_SECRET"), oauthRedirectURL("yandex")))
+    }
+    if clientID := os.Getenv("OAUTH_VK_CLIENT_ID"); clientID != "" {
+        oauthRegistry.Register(services.NewVKProvider(clientID, os.Getenv("OAUTH_VK_CLIENT_SECRET"), oauthRedirectURL("vk")))
+    }
+    if clientID := os.Getenv("OAUTH_GOOGLE_CLIENT_ID"); clientID != "" {
+        oauthRegistry.Register(services.NewGoogleProvider(clientID, os.Getenv("OAUTH_GOOGLE_CLIENT_SECRET"), oauthRedirectURL("google")))
+    }
+    // Произвольный OIDC провайдер по discovery, например локальный mock сервер
+    if issuer := os.Getenv("OAUTH_OIDC_ISSUER"); issuer != "" {
+        name := getEnv("OAUTH_OIDC_NAME", "oidc")
+        ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+        provider, err := services.DiscoverOIDCProvider(ctx, name, getEnv("OAUTH_OIDC_DISPLAY_NAME", "OpenID Connect"),
+            issuer, os.Getenv("OAUTH_OIDC_CLIENT_ID"), os.Getenv("OAUTH_OIDC_CLIENT_SECRET"), oauthRedirectURL(name))
+        cancel()
+        if err != nil {
+            log.Printf("⚠️ Failed to configure OIDC provider %s: %v", issuer, err)
+        } else {
+            oauthRegistry.Register(provider)
+        }
+    }
+    for _, provider := range oauthRegistry.List() {
+        log.Printf("🔗 OAuth login enabled: %s", provider.Name)
+    }
 
     // Создаем сервисы
-    authService := services.NewAuthService(userRepo, os.Getenv("JWT_SECRET"))
+    authorizer := services.NewAuthorizer(roleRepo)
+    loginThrottler := services.NewLoginThrottler(throttleStore)
+    authService := services.NewAuthService(userRepo, refreshTokenRepo, sessionRepo, userTokenRepo, inviteRepo, mailer, loginThrottler, jwtKeys, appURL)
     //fileService := services.NewFileService("uploads")
     fileService := services.NewFileService("uploads", storageService)
-    materialService := services.NewMaterialService(materialRepo, blockRepo)
+    // Закрытые файлы лежат вне ./uploads и отдаются только через /api/v1/files/:id
+    privateFileService := services.NewPrivateFileService("private", storageService, privateFileRepo, authorizer)
+    blockRegistry := services.NewBlockRegistry(fileService)
+    materialService := services.NewMaterialService(materialRepo, blockRepo, revisionRepo, templateRepo, blockRegistry, authorizer)
+    templateService := services.NewTemplateService(templateRepo, materialRepo)
     catalogService := services.NewCatalogService(catalogRepo)
-    progressService := services.NewProgressService(progressRepo)
-    adminService := services.NewAdminService(adminRepo)
+    formulaService := services.NewFormulaService(16 << 20)
+    gradeService := services.NewGradeService(quizRepo, assignmentRepo, materialRepo, revisionRepo, progressRepo)
+    quizService := services.NewQuizService(quizRepo, materialRepo, revisionRepo, gradeService, authorizer)
+    assignmentService := services.NewAssignmentService(assignmentRepo, materialRepo, revisionRepo, privateFileService, gradeService, authorizer)
+    progressService := services.NewProgressService(progressRepo, gradeService)
+    adminService := services.NewAdminService(adminRepo, auditRepo, authorizer)
+    verificationService := services.NewVerificationService(verificationRepo, userRepo, privateFileService)
+    inviteService := services.NewInviteService(inviteRepo, mailer, appURL)
+    twoFactorService := services.NewTwoFactorService(twoFactorRepo, userRepo, jwtKeys, totpCipher)
+    sessionService := services.NewSessionService(sessionRepo, refreshTokenRepo)
+    oauthService := services.NewOAuthService(oauthRegistry, oauthRepo, userRepo)
+    apiKeyService := services.NewAPIKeyService(apiKeyRepo, userRepo, auditRepo)
+    impersonationService := services.NewImpersonationService(userRepo, auditRepo, authorizer, jwtKeys)
+    accountService := services.NewAccountService(
+        userRepo, refreshTokenRepo, userTokenRepo, auditRepo, accountRepo, materialRepo, blockRepo,
+        verificationRepo, oauthRepo, authorizer, quizService, fileService, privateFileService, mailer, appURL,
+    )
+
+    // Удаляем аккаунты, у которых истек период ожидания
+    if database.DB != nil {
+        go accountService.RunDeletionWorker(context.Background(), time.Hour)
+    }
 
     // Создаем обработчики
-    authHandler := handlers.NewAuthHandler(authService)
-    profileHandler := handlers.NewProfileHandler(authService, userRepo, fileService)
+    authHandler := handlers.NewAuthHandler(authService, twoFactorService)
+    profileHandler := handlers.NewProfileHandler(authService, accountService, userRepo, fileService)
     materialHandler := handlers.NewMaterialHandler(materialService)
+    templateHandler := handlers.NewTemplateHandler(templateService)
     catalogHandler := handlers.NewCatalogHandler(catalogService)
+    formulaHandler := handlers.NewFormulaHandler(formulaService)
     progressHandler := handlers.NewProgressHandler(progressService)
+    quizHandler := handlers.NewQuizHandler(quizService)
+    assignmentHandler := handlers.NewAssignmentHandler(assignmentService)
     adminHandler := handlers.NewAdminHandler(adminService)
     mediaHandler := handlers.NewMediaHandler(fileService)
+    verificationHandler := handlers.NewVerificationHandler(verificationService)
+    fileHandler := handlers.NewFileHandler(privateFileService)
+    inviteHandler := handlers.NewInviteHandler(inviteService)
+    roleHandler := handlers.NewRoleHandler(authorizer)
+    twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService)
+    lockoutHandler := handlers.NewLockoutHandler(loginThrottler)
+    sessionHandler := handlers.NewSessionHandler(sessionService)
+    jwksHandler := handlers.NewJWKSHandler(jwtKeys)
+    oauthHandler := handlers.NewOAuthHandler(oauthService, authService, twoFactorService)
+    apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+    impersonationHandler := handlers.NewImpersonationHandler(impersonationService)
 
     // Настраиваем Gin
     if os.Getenv("GIN_MODE") != "debug" {
@@ -174,10 +361,25 @@ func main() {
     // CORS middleware
     router := gin.Default()
 
+    // IP клиента используется для ограничения попыток входа и запросов к открытым
+    // маршрутам. По умолчанию gin верит X-Forwarded-For от любого клиента, поэтому
+    // заголовок принимается только от прокси из TRUSTED_PROXIES, а без него
+    // IP клиента - это адрес соединения.
+    var trustedProxies []string
+    for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+        if proxy = strings.TrimSpace(proxy); proxy != "" {
+            trustedProxies = append(trustedProxies, proxy)
+        }
+    }
+    if err := router.SetTrustedProxies(trustedProxies); err != nil {
+        log.Fatalf("❌ Invalid TRUSTED_PROXIES: %v", err)
+    }
+
     config := cors.DefaultConfig()
     config.AllowAllOrigins = true
     config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS", "HEAD"}
-    config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization", "Accept", "X-Requested-With"}
+    config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization", "Accept", "X-Requested-With", "X-API-Key", "If-Match"}
+    config.ExposeHeaders = []string{"ETag"}
     config.AllowCredentials = true
     config.MaxAge = 12 * time.Hour
     router.Use(cors.New(config))
@@ -191,39 +393,83 @@ func main() {
         c.JSON(200, gin.H{"routes": routeInfo})
     })
 
-    // Обслуживаем статические файлы (аватары)
-    router.Static("/uploads", "./uploads")
+    // Обслуживаем статические файлы: аватары и медиа материалов.
+    // Документы пользователей сюда не попадают, они отдаются через /api/v1/files/:id
+    router.Static("/uploads/avatars", "./uploads/avatars")
+    router.Static("/uploads/images", "./uploads/images")
+    router.Static("/uploads/videos", "./uploads/videos")
 
     // Routes
     router.GET("/health", handlers.HealthCheck)
+    router.GET("/.well-known/jwks.json", jwksHandler.GetJWKS)
     router.GET("/api/v1/users", handlers.GetUsersTest(database.DB))
 
     auth := router.Group("/api/v1/auth")
     {
         auth.POST("/register", authHandler.Register)
         auth.POST("/login", authHandler.Login)
+        auth.POST("/2fa/verify", authHandler.VerifyTwoFactor)
         auth.POST("/refresh", authHandler.Refresh)
         auth.POST("/logout", authHandler.Logout)
         auth.POST("/forgot-password", authHandler.ForgotPassword)
         auth.POST("/reset-password", authHandler.ResetPassword)
+        auth.POST("/verify-email", authHandler.VerifyEmail)
+        auth.GET("/oauth/providers", oauthHandler.ListProviders)
+        auth.GET("/oauth/:provider/authorize", oauthHandler.Authorize)
+        auth.POST("/oauth/:provider/callback", oauthHandler.Callback)
     }
     // Защищенные эндпоинты (требуют авторизацию)
     protected := router.Group("/api/v1")
-    protected.Use(middleware.AuthMiddleware(authService))
+    protected.Use(middleware.AuthMiddleware(authService, apiKeyService))
+    // Запросы администратора от имени пользователя пишутся в журнал аудита
+    protected.Use(middleware.AuditImpersonation(impersonationService))
     {
+        protected.POST("/auth/logout-all", middleware.DenyImpersonation(), authHandler.LogoutAll)
+        protected.POST("/auth/resend-verification", authHandler.ResendVerification)
+
         protected.GET("/profile", profileHandler.GetProfile)
         protected.PATCH("/profile", profileHandler.UpdateProfile)
+        protected.DELETE("/profile", middleware.DenyImpersonation(), profileHandler.DeleteAccount)
+        protected.GET("/profile/export", middleware.DenyImpersonation(), profileHandler.ExportData)
+        protected.GET("/profile/deletion", profileHandler.GetDeletion)
+        protected.DELETE("/profile/deletion", middleware.DenyImpersonation(), profileHandler.CancelDeletion)
         protected.POST("/profile/avatar", profileHandler.UploadAvatar)
-
-        protected.POST("/materials", materialHandler.CreateMaterial)
+        protected.POST("/profile/password", middleware.DenyImpersonation(), profileHandler.ChangePassword)
+        protected.POST("/profile/email", middleware.DenyImpersonation(), profileHandler.RequestEmailChange)
+        protected.POST("/profile/email/confirm", middleware.DenyImpersonation(), profileHandler.ConfirmEmailChange)
+        protected.GET("/profile/2fa", twoFactorHandler.GetStatus)
+        protected.POST("/profile/2fa/setup", middleware.DenyImpersonation(), twoFactorHandler.Setup)
+        protected.POST("/profile/2fa/confirm", middleware.DenyImpersonation(), twoFactorHandler.Confirm)
+        protected.POST("/profile/2fa/disable", middleware.DenyImpersonation(), twoFactorHandler.Disable)
+        protected.POST("/profile/2fa/recovery-codes", middleware.DenyImpersonation(), twoFactorHandler.RegenerateRecoveryCodes)
+        protected.GET("/profile/sessions", sessionHandler.GetMySessions)
+        protected.DELETE("/profile/sessions/:id", middleware.DenyImpersonation(), sessionHandler.RevokeMySession)
+        protected.GET("/profile/api-keys", apiKeyHandler.ListKeys)
+        protected.POST("/profile/api-keys", middleware.DenyImpersonation(), apiKeyHandler.CreateKey)
+        protected.DELETE("/profile/api-keys/:id", middleware.DenyImpersonation(), apiKeyHandler.RevokeKey)
+
+        protected.POST("/materials", middleware.RequirePermission(authorizer, models.PermMaterialCreate), materialHandler.CreateMaterial)
         protected.GET("/materials/my", materialHandler.GetUserMaterials)
+        protected.GET("/materials/block-schemas", materialHandler.GetBlockSchemas)
+        protected.POST("/materials/animations/preview", materialHandler.PreviewAnimation)
         protected.GET("/materials/:id", materialHandler.GetMaterial)
         protected.PUT("/materials/:id", materialHandler.UpdateMaterial)
-        protected.POST("/materials/:id/publish", materialHandler.PublishMaterial)
+        protected.POST("/materials/:id/publish", middleware.RequireVerifiedEmail(authService), materialHandler.PublishMaterial)
+        protected.POST("/materials/:id/duplicate", middleware.RequirePermission(authorizer, models.PermMaterialCreate), materialHandler.DuplicateMaterial)
+        protected.PUT("/materials/:id/template", materialHandler.SetTemplate)
+        protected.POST("/materials/:id/instantiate", middleware.RequirePermission(authorizer, models.PermMaterialCreate), materialHandler.InstantiateTemplate)
         protected.POST("/materials/:id/blocks", materialHandler.AddBlock)
         protected.PUT("/materials/:id/blocks/:blockId", materialHandler.UpdateBlock)
         protected.DELETE("/materials/:id/blocks/:blockId", materialHandler.DeleteBlock)
         protected.POST("/materials/:id/blocks/reorder", materialHandler.ReorderBlocks)
+        protected.GET("/materials/:id/revisions", materialHandler.ListRevisions)
+        protected.GET("/materials/:id/revisions/:rev", materialHandler.GetRevision)
+        protected.GET("/materials/:id/revisions/:rev/diff", materialHandler.DiffRevisions)
+        protected.POST("/materials/:id/revisions/:rev/restore", materialHandler.RestoreRevision)
+        protected.GET("/materials/:id/quizzes/:blockId/stats", quizHandler.GetStats)
+        protected.POST("/materials/:id/quizzes/:blockId/release", quizHandler.ReleaseResults)
+        protected.GET("/templates", templateHandler.GetGallery)
+        protected.GET("/files/:id", fileHandler.DownloadFile)
 
         protected.POST("/upload/image", mediaHandler.UploadImage)
         protected.POST("/upload/video", mediaHandler.UploadVideo)
@@ -235,15 +481,45 @@ func main() {
             student.GET("/favorites", progressHandler.GetFavorites)
             student.POST("/materials/:id/complete", progressHandler.MarkMaterialComplete)
             student.POST("/materials/:id/favorite", progressHandler.ToggleFavorite)
+            student.POST("/materials/:id/quizzes/:blockId/attempts", quizHandler.SubmitAttempt)
+            student.GET("/materials/:id/quizzes/:blockId/attempts", quizHandler.ListAttempts)
+            student.POST("/materials/:id/assignments/:blockId/submission", assignmentHandler.SubmitWork)
+            student.GET("/materials/:id/assignments/:blockId/submission", assignmentHandler.GetMySubmission)
+            student.GET("/assignments", assignmentHandler.ListMySubmissions)
         }
 
+        teacher := protected.Group("/teacher")
+        {
+            teacher.POST("/verification/applications", verificationHandler.SubmitApplication)
+            teacher.GET("/verification/applications", verificationHandler.GetMyApplications)
+            teacher.GET("/assignments/submissions", assignmentHandler.ListQueue)
+            teacher.POST("/assignments/submissions/:id/review", assignmentHandler.ReviewSubmission)
+        }
+
+        // Доступ к разделам админки определяется правами роли, а не ее названием
         admin := protected.Group("/admin")
-        admin.Use(middleware.AdminMiddleware()) // ← проверка прав администратора
         {
-            admin.GET("/statistics", adminHandler.GetStatistics)
-            admin.GET("/users", adminHandler.GetUsers)
-            admin.POST("/users/:id/block", adminHandler.BlockUser)
-            admin.POST("/subjects", adminHandler.CreateSubject)
+            admin.GET("/statistics", middleware.RequirePermission(authorizer, models.PermStatsView), adminHandler.GetStatistics)
+            admin.GET("/users", middleware.RequirePermission(authorizer, models.PermUserView), adminHandler.GetUsers)
+            admin.POST("/users/:id/block", middleware.RequirePermission(authorizer, models.PermUserBlock), adminHandler.BlockUser)
+            admin.PUT("/users/:id/role", middleware.RequirePermission(authorizer, models.PermRoleManage), adminHandler.ChangeUserRole)
+            admin.GET("/users/:id/sessions", middleware.RequirePermission(authorizer, models.PermUserView), sessionHandler.GetUserSessions)
+            admin.GET("/users/:id/audit", middleware.RequirePermission(authorizer, models.PermUserView), adminHandler.GetUserAudit)
+            admin.POST("/users/:id/impersonate", middleware.RequirePermission(authorizer, models.PermUserImpersonate), impersonationHandler.Impersonate)
+            admin.DELETE("/users/:id/sessions/:sessionId", middleware.RequirePermission(authorizer, models.PermUserBlock), sessionHandler.RevokeUserSession)
+            admin.POST("/subjects", middleware.RequirePermission(authorizer, models.PermSubjectManage), adminHandler.CreateSubject)
+            admin.GET("/teachers/applications", middleware.RequirePermission(authorizer, models.PermTeacherVerify), verificationHandler.ListApplications)
+            admin.POST("/teachers/applications/:id/approve", middleware.RequirePermission(authorizer, models.PermTeacherVerify), verificationHandler.ApproveApplication)
+            admin.POST("/teachers/applications/:id/reject", middleware.RequirePermission(authorizer, models.PermTeacherVerify), verificationHandler.RejectApplication)
+            admin.POST("/invites", middleware.RequirePermission(authorizer, models.PermInviteManage), inviteHandler.CreateInvite)
+            admin.GET("/invites", middleware.RequirePermission(authorizer, models.PermInviteManage), inviteHandler.ListInvites)
+            admin.DELETE("/invites/:id", middleware.RequirePermission(authorizer, models.PermInviteManage), inviteHandler.RevokeInvite)
+            admin.GET("/roles", middleware.RequirePermission(authorizer, models.PermRoleManage), roleHandler.ListRoles)
+            admin.POST("/roles", middleware.RequirePermission(authorizer, models.PermRoleManage), roleHandler.SaveRole)
+            admin.GET("/lockouts", middleware.RequirePermission(authorizer, models.PermUserView), lockoutHandler.ListLockouts)
+            admin.DELETE("/lockouts/:key", middleware.RequirePermission(authorizer, models.PermUserBlock), lockoutHandler.ClearLockout)
+            admin.POST("/templates", middleware.RequirePermission(authorizer, models.PermTemplateManage), templateHandler.SaveGalleryTemplate)
+            admin.DELETE("/templates/:id", middleware.RequirePermission(authorizer, models.PermTemplateManage), templateHandler.RemoveGalleryTemplate)
         }
     }
 
@@ -254,6 +530,14 @@ func main() {
         catalog.GET("/teachers", catalogHandler.SearchTeachers)
     }
 
+    // Отрисовка доступна без авторизации, поэтому число запросов с одного адреса ограничено
+    formulas := router.Group("/api/v1/formulas")
+    formulas.Use(middleware.RateLimit(getEnvAsInt("FORMULA_RATE_LIMIT", 120), time.Minute))
+    {
+        formulas.POST("/render", formulaHandler.RenderFormula)
+        formulas.GET("/render.svg", formulaHandler.RenderFormulaSVG)
+    }
+
     router.GET("/swagger.json", func(c *gin.Context) {
         c.Header("Content-Type", "application/json; charset=utf-8") // ← ДОБАВЬТЕ ЭТУ СТРОЧКУ
         c.File("./docs/swagger.json")
@@ -322,36 +606,102 @@ func main() {
     log.Printf("📊 Database connected successfully")
     log.Printf("🌐 Endpoints:")
     log.Printf("   GET /health")
+    log.Printf("   GET /.well-known/jwks.json")
     log.Printf("   GET /api/v1/users")
     log.Printf("   POST /api/v1/auth/register")
     log.Printf("   POST /api/v1/auth/login")
+    log.Printf("   POST /api/v1/auth/2fa/verify")
     log.Printf("   POST /api/v1/auth/refresh")
     log.Printf("   POST /api/v1/auth/logout")
+    log.Printf("   POST /api/v1/auth/logout-all")
     log.Printf("   POST /api/v1/auth/forgot-password")
     log.Printf("   POST /api/v1/auth/reset-password")
+    log.Printf("   POST /api/v1/auth/verify-email")
+    log.Printf("   GET /api/v1/auth/oauth/providers")
+    log.Printf("   GET /api/v1/auth/oauth/:provider/authorize")
+    log.Printf("   POST /api/v1/auth/oauth/:provider/callback")
+    log.Printf("   POST /api/v1/auth/resend-verification")
     log.Printf("   GET /api/v1/profile")
     log.Printf("   PATCH /api/v1/profile")
+    log.Printf("   DELETE /api/v1/profile")
+    log.Printf("   GET /api/v1/profile/export")
+    log.Printf("   GET /api/v1/profile/deletion")
+    log.Printf("   DELETE /api/v1/profile/deletion")
     log.Printf("   POST /api/v1/profile/avatar")
+    log.Printf("   POST /api/v1/profile/password")
+    log.Printf("   POST /api/v1/profile/email")
+    log.Printf("   POST /api/v1/profile/email/confirm")
+    log.Printf("   GET /api/v1/profile/2fa")
+    log.Printf("   POST /api/v1/profile/2fa/setup")
+    log.Printf("   POST /api/v1/profile/2fa/confirm")
+    log.Printf("   POST /api/v1/profile/2fa/disable")
+    log.Printf("   POST /api/v1/profile/2fa/recovery-codes")
+    log.Printf("   GET /api/v1/profile/sessions")
+    log.Printf("   DELETE /api/v1/profile/sessions/:id")
+    log.Printf("   GET /api/v1/profile/api-keys")
+    log.Printf("   POST /api/v1/profile/api-keys")
+    log.Printf("   DELETE /api/v1/profile/api-keys/:id")
     log.Printf("   POST /api/v1/materials")
     log.Printf("   GET /api/v1/materials")
+    log.Printf("   GET /api/v1/materials/block-schemas")
+    log.Printf("   POST /api/v1/materials/animations/preview")
     log.Printf("   GET /api/v1/materials/:id")
     log.Printf("   PUT /api/v1/materials/:id")
     log.Printf("   POST /api/v1/materials/:id/publish")
+    log.Printf("   POST /api/v1/materials/:id/duplicate")
+    log.Printf("   PUT /api/v1/materials/:id/template")
+    log.Printf("   POST /api/v1/materials/:id/instantiate")
     log.Printf("   POST /api/v1/materials/:id/blocks")
     log.Printf("   PUT /api/v1/materials/:id/blocks/:blockId")
     log.Printf("   DELETE /api/v1/materials/:id/blocks/:blockId")
     log.Printf("   POST /api/v1/materials/:id/blocks/reorder")
+    log.Printf("   GET /api/v1/materials/:id/revisions")
+    log.Printf("   GET /api/v1/materials/:id/revisions/:rev")
+    log.Printf("   GET /api/v1/materials/:id/revisions/:rev/diff")
+    log.Printf("   POST /api/v1/materials/:id/revisions/:rev/restore")
+    log.Printf("   GET /api/v1/materials/:id/quizzes/:blockId/stats")
+    log.Printf("   POST /api/v1/materials/:id/quizzes/:blockId/release")
+    log.Printf("   GET /api/v1/templates")
+    log.Printf("   GET /api/v1/files/:id")
     log.Printf("   GET /api/v1/catalog/materials")
     log.Printf("   GET /api/v1/catalog/subjects")
     log.Printf("   GET /api/v1/catalog/teachers")
+    log.Printf("   POST /api/v1/formulas/render")
+    log.Printf("   GET /api/v1/formulas/render.svg")
     log.Printf("   GET /api/v1/student/progress")
     log.Printf("   GET /api/v1/student/favorites")
     log.Printf("   POST /api/v1/student/materials/:id/complete")
     log.Printf("   POST /api/v1/student/materials/:id/favorite")
+    log.Printf("   POST /api/v1/student/materials/:id/quizzes/:blockId/attempts")
+    log.Printf("   GET /api/v1/student/materials/:id/quizzes/:blockId/attempts")
+    log.Printf("   POST /api/v1/student/materials/:id/assignments/:blockId/submission")
+    log.Printf("   GET /api/v1/student/materials/:id/assignments/:blockId/submission")
+    log.Printf("   GET /api/v1/student/assignments")
     log.Printf("   GET /api/v1/admin/statistics")
     log.Printf("   GET /api/v1/admin/users")
     log.Printf("   POST /api/v1/admin/users/:id/block")
+    log.Printf("   PUT /api/v1/admin/users/:id/role")
+    log.Printf("   GET /api/v1/admin/users/:id/sessions")
+    log.Printf("   GET /api/v1/admin/users/:id/audit")
+    log.Printf("   POST /api/v1/admin/users/:id/impersonate")
+    log.Printf("   DELETE /api/v1/admin/users/:id/sessions/:sessionId")
     log.Printf("   POST /api/v1/admin/subjects")
+    log.Printf("   GET /api/v1/admin/teachers/applications")
+    log.Printf("   POST /api/v1/admin/teachers/applications/:id/approve")
+    log.Printf("   POST /api/v1/admin/teachers/applications/:id/reject")
+    log.Printf("   POST /api/v1/admin/invites")
+    log.Printf("   GET /api/v1/admin/invites")
+    log.Printf("   DELETE /api/v1/admin/invites/:id")
+    log.Printf("   GET /api/v1/admin/roles")
+    log.Printf("   POST /api/v1/admin/roles")
+    log.Printf("   GET /api/v1/admin/lockouts")
+    log.Printf("   DELETE /api/v1/admin/lockouts/:key")
+    log.Printf("   POST /api/v1/admin/templates")
+    log.Printf("   DELETE /api/v1/admin/templates/:id")
+    log.Printf("   POST /api/v1/teacher/verification/applications")
+    log.Printf("   GET /api/v1/teacher/verification/applications")
+    log.Printf("   GET /api/v1/teacher/assignments/submissions")
+    log.Printf("   POST /api/v1/teacher/assignments/submissions/:id/review")
     log.Printf("   POST /api/v1/upload/image")
     log.Printf("   POST /api/v1/upload/video")
     log.Printf("   POST /api/v1/embed/video")
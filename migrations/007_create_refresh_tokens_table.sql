@@ -0,0 +1,15 @@
+-- Таблица refresh токенов (серверное хранилище с ротацией)
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    family_id VARCHAR(36) NOT NULL, -- все токены одной цепочки ротации (один вход)
+    token_hash VARCHAR(64) NOT NULL UNIQUE, -- sha256 от токена, сам токен не храним
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    rotated_at TIMESTAMP WITH TIME ZONE, -- токен уже обменян на новый
+    revoked_at TIMESTAMP WITH TIME ZONE, -- семейство отозвано (logout или повторное использование)
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Индексы
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens(user_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens(family_id);
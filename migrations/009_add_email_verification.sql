@@ -0,0 +1,12 @@
+-- Подтверждение email. Уже существующие пользователи считаются подтвержденными,
+-- поэтому заполняем колонку только в момент ее создания.
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'users' AND column_name = 'email_verified'
+    ) THEN
+        ALTER TABLE users ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT FALSE;
+        UPDATE users SET email_verified = TRUE;
+    END IF;
+END $$;
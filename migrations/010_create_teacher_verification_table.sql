@@ -0,0 +1,20 @@
+-- Заявки преподавателей на верификацию
+CREATE TABLE IF NOT EXISTS teacher_verification_applications (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    message TEXT, -- комментарий преподавателя к заявке
+    documents JSONB NOT NULL DEFAULT '[]', -- загруженные документы (url, имя файла)
+    review_comment TEXT, -- комментарий администратора
+    reviewed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Индексы
+CREATE INDEX IF NOT EXISTS idx_teacher_verification_user_id ON teacher_verification_applications(user_id);
+CREATE INDEX IF NOT EXISTS idx_teacher_verification_status ON teacher_verification_applications(status);
+
+-- У преподавателя может быть только одна заявка на рассмотрении
+CREATE UNIQUE INDEX IF NOT EXISTS idx_teacher_verification_pending
+    ON teacher_verification_applications(user_id) WHERE status = 'pending';
@@ -0,0 +1,13 @@
+-- Приглашения для регистрации с повышенной ролью (администраторы)
+CREATE TABLE IF NOT EXISTS admin_invites (
+    id SERIAL PRIMARY KEY,
+    token_hash VARCHAR(64) NOT NULL UNIQUE, -- sha256 от токена, сам токен не храним
+    email VARCHAR(320), -- если указан, зарегистрироваться можно только с этим email
+    role VARCHAR(20) NOT NULL DEFAULT 'admin',
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    used_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,72 @@
+-- Роли и права доступа (RBAC)
+-- Миграции выполняются при каждом старте, поэтому начальные права заполняются
+-- только при создании таблиц: изменения, сделанные администраторами, не затираются.
+DO $$
+BEGIN
+    IF to_regclass('public.role_permissions') IS NULL THEN
+        CREATE TABLE IF NOT EXISTS roles (
+            name VARCHAR(20) PRIMARY KEY,
+            description VARCHAR(200)
+        );
+
+        CREATE TABLE IF NOT EXISTS permissions (
+            name VARCHAR(50) PRIMARY KEY,
+            description VARCHAR(200)
+        );
+
+        CREATE TABLE role_permissions (
+            role VARCHAR(20) NOT NULL REFERENCES roles(name) ON UPDATE CASCADE ON DELETE CASCADE,
+            permission VARCHAR(50) NOT NULL REFERENCES permissions(name) ON UPDATE CASCADE ON DELETE CASCADE,
+            PRIMARY KEY (role, permission)
+        );
+
+        INSERT INTO roles (name, description) VALUES
+            ('student', 'Ученик'),
+            ('teacher', 'Преподаватель'),
+            ('moderator', 'Модератор контента'),
+            ('admin', 'Администратор')
+        ON CONFLICT (name) DO NOTHING;
+
+        INSERT INTO permissions (name, description) VALUES
+            ('material.create', 'Создание материалов'),
+            ('material.edit.own', 'Редактирование и публикация своих материалов'),
+            ('material.edit.any', 'Редактирование и публикация любых материалов'),
+            ('user.view', 'Просмотр списка пользователей'),
+            ('user.block', 'Блокировка пользователей'),
+            ('subject.manage', 'Управление предметами'),
+            ('stats.view', 'Просмотр статистики платформы'),
+            ('teacher.verify', 'Рассмотрение заявок преподавателей'),
+            ('invite.manage', 'Управление приглашениями администраторов'),
+            ('role.manage', 'Управление ролями и правами')
+        ON CONFLICT (name) DO NOTHING;
+
+        INSERT INTO role_permissions (role, permission) VALUES
+            ('teacher', 'material.create'),
+            ('teacher', 'material.edit.own'),
+            ('moderator', 'material.edit.any'),
+            ('moderator', 'user.view'),
+            ('moderator', 'user.block'),
+            ('admin', 'material.create'),
+            ('admin', 'material.edit.own'),
+            ('admin', 'material.edit.any'),
+            ('admin', 'user.view'),
+            ('admin', 'user.block'),
+            ('admin', 'subject.manage'),
+            ('admin', 'stats.view'),
+            ('admin', 'teacher.verify'),
+            ('admin', 'invite.manage'),
+            ('admin', 'role.manage')
+        ON CONFLICT DO NOTHING;
+    END IF;
+END $$;
+
+-- Роль пользователя теперь ссылается на таблицу ролей вместо фиксированного списка
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'users_role_fkey') THEN
+        ALTER TABLE users ADD CONSTRAINT users_role_fkey
+            FOREIGN KEY (role) REFERENCES roles(name) ON UPDATE CASCADE;
+    END IF;
+END $$;
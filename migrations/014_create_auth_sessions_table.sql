@@ -0,0 +1,13 @@
+-- Сессии (устройства) пользователя. id совпадает с family_id refresh токенов:
+-- сессия активна, пока в ее семействе есть действующий refresh токен.
+CREATE TABLE IF NOT EXISTS auth_sessions (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_agent VARCHAR(512),
+    ip VARCHAR(45),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Индексы
+CREATE INDEX IF NOT EXISTS idx_auth_sessions_user_id ON auth_sessions(user_id);
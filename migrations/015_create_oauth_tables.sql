@@ -0,0 +1,23 @@
+-- Внешние аккаунты (Яндекс ID, VK ID, Google и др.), привязанные к пользователям
+CREATE TABLE IF NOT EXISTS user_identities (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(30) NOT NULL,
+    subject VARCHAR(255) NOT NULL, -- ID пользователя у провайдера
+    email VARCHAR(320),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (provider, subject)
+);
+
+-- Незавершенные авторизации: state и PKCE verifier живут несколько минут
+CREATE TABLE IF NOT EXISTS oauth_states (
+    state_hash VARCHAR(64) PRIMARY KEY, -- sha256 от state
+    provider VARCHAR(30) NOT NULL,
+    code_verifier VARCHAR(128) NOT NULL,
+    role VARCHAR(20) NOT NULL DEFAULT 'student', -- роль, если по итогам входа создается аккаунт
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Индексы
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities(user_id);
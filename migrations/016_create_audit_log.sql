@@ -0,0 +1,18 @@
+-- Журнал действий с аккаунтами (смена пароля, email и т.п.)
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL, -- чей аккаунт затронут
+    actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL, -- кто выполнил действие
+    action VARCHAR(50) NOT NULL,
+    details JSONB NOT NULL DEFAULT '{}',
+    ip VARCHAR(45),
+    user_agent VARCHAR(512),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Новый адрес для токенов смены email
+ALTER TABLE user_tokens ADD COLUMN IF NOT EXISTS new_email VARCHAR(320);
+
+-- Индексы
+CREATE INDEX IF NOT EXISTS idx_audit_log_user_id ON audit_log(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor_id ON audit_log(actor_id, created_at DESC);
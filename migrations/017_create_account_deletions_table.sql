@@ -0,0 +1,14 @@
+-- Запросы на удаление аккаунта. До scheduled_for удаление можно отменить.
+CREATE TABLE IF NOT EXISTS account_deletions (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    materials_action VARCHAR(20) NOT NULL DEFAULT 'archive' CHECK (materials_action IN ('archive', 'transfer')),
+    transfer_to INTEGER REFERENCES users(id) ON DELETE SET NULL, -- новый автор материалов
+    requested_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    scheduled_for TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Обезличенные аккаунты остаются, чтобы сохранить авторство архивных материалов
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+-- Индексы
+CREATE INDEX IF NOT EXISTS idx_account_deletions_scheduled_for ON account_deletions(scheduled_for);
@@ -0,0 +1,13 @@
+-- Право входить под пользователем для поддержки. Выдается администраторам
+-- только при первом появлении права, чтобы не вернуть его после ручного отзыва.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM permissions WHERE name = 'user.impersonate') THEN
+        INSERT INTO permissions (name, description)
+        VALUES ('user.impersonate', 'Вход под пользователем для поддержки');
+
+        INSERT INTO role_permissions (role, permission)
+        VALUES ('admin', 'user.impersonate')
+        ON CONFLICT DO NOTHING;
+    END IF;
+END $$;
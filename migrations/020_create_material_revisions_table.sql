@@ -0,0 +1,27 @@
+-- История изменений материалов: каждая правка сохраняет снимок заголовка и блоков
+CREATE TABLE IF NOT EXISTS material_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    material_id INTEGER NOT NULL REFERENCES materials(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL, -- номер ревизии внутри материала, начиная с 1
+    title VARCHAR(1000) NOT NULL,
+    blocks JSONB NOT NULL DEFAULT '[]',
+    author_id INTEGER REFERENCES users(id) ON DELETE SET NULL, -- кто внес изменение
+    action VARCHAR(30) NOT NULL,
+    restored_from INTEGER, -- номер ревизии, из которой восстановлен материал
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE (material_id, revision)
+);
+
+-- Ревизии неизменяемы: правка снимка задним числом сделала бы историю бесполезной.
+-- Удаление разрешено только каскадом вместе с материалом.
+CREATE OR REPLACE FUNCTION forbid_material_revision_update() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'material revisions are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS material_revisions_immutable ON material_revisions;
+CREATE TRIGGER material_revisions_immutable
+    BEFORE UPDATE ON material_revisions
+    FOR EACH ROW EXECUTE FUNCTION forbid_material_revision_update();
@@ -0,0 +1,37 @@
+-- Опубликованная версия материала - неизменяемая ревизия. Ученики видят ее,
+-- а правки автора копятся в черновике (material_blocks) до следующей публикации.
+ALTER TABLE materials ADD COLUMN IF NOT EXISTS published_revision INTEGER;
+ALTER TABLE materials ADD COLUMN IF NOT EXISTS published_at TIMESTAMP WITH TIME ZONE;
+
+-- Версия материала, которую изучал ученик
+ALTER TABLE material_completions ADD COLUMN IF NOT EXISTS material_revision INTEGER;
+
+-- Уже опубликованные материалы: текущее содержимое становится опубликованной версией
+WITH snapshots AS (
+    INSERT INTO material_revisions (material_id, revision, title, blocks, author_id, action)
+    SELECT m.id,
+           COALESCE((SELECT MAX(r.revision) FROM material_revisions r WHERE r.material_id = m.id), 0) + 1,
+           m.title,
+           COALESCE((
+               SELECT jsonb_agg(jsonb_build_object(
+                   'id', b.block_id, 'type', b.type, 'content', b.content,
+                   'styles', b.styles, 'animation', b.animation, 'position', b.position
+               ) ORDER BY b.position)
+               FROM material_blocks b
+               WHERE b.material_id = m.id
+           ), '[]'::jsonb),
+           m.author_id,
+           'published'
+    FROM materials m
+    WHERE m.status = 'published' AND m.published_revision IS NULL
+    RETURNING material_id, revision
+)
+UPDATE materials m
+SET published_revision = s.revision, published_at = COALESCE(m.updated_at, CURRENT_TIMESTAMP)
+FROM snapshots s
+WHERE m.id = s.material_id;
+
+UPDATE material_completions mc
+SET material_revision = m.published_revision
+FROM materials m
+WHERE mc.material_id = m.id AND mc.material_revision IS NULL AND m.published_revision IS NOT NULL;
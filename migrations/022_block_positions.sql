@@ -0,0 +1,12 @@
+-- Блоки сохраняются по одному, а порядок задается позициями с промежутками:
+-- вставка или перемещение блока меняет одну строку, а не весь материал.
+-- Перенумерация происходит только когда промежуток между соседями исчерпан.
+DO $$
+BEGIN
+    IF (SELECT data_type FROM information_schema.columns
+        WHERE table_name = 'material_blocks' AND column_name = 'position') <> 'bigint' THEN
+        ALTER TABLE material_blocks ALTER COLUMN position TYPE BIGINT;
+    END IF;
+END $$;
+
+ALTER TABLE material_blocks ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP;
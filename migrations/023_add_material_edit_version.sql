@@ -0,0 +1,3 @@
+-- Версия черновика для оптимистичной блокировки: каждое изменение материала
+-- увеличивает ее, а запись с устаревшей версией (If-Match) отклоняется.
+ALTER TABLE materials ADD COLUMN IF NOT EXISTS edit_version INTEGER NOT NULL DEFAULT 1;
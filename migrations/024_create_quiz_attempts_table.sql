@@ -0,0 +1,17 @@
+-- Попытки прохождения тестов. Ответы проверяются на сервере по опубликованной
+-- версии материала, результат хранится вместе с ответами.
+CREATE TABLE IF NOT EXISTS quiz_attempts (
+    id SERIAL PRIMARY KEY,
+    material_id INTEGER NOT NULL REFERENCES materials(id) ON DELETE CASCADE,
+    block_id VARCHAR(100) NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    material_revision INTEGER NOT NULL,
+    answers JSONB NOT NULL,
+    results JSONB NOT NULL,
+    score DECIMAL(8,2) NOT NULL,
+    max_score DECIMAL(8,2) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_quiz_attempts_user ON quiz_attempts(user_id, material_id, block_id);
+CREATE INDEX IF NOT EXISTS idx_quiz_attempts_block ON quiz_attempts(material_id, block_id);
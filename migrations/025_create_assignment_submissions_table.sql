@@ -0,0 +1,24 @@
+-- Ответы учеников на задания с ручной проверкой (блоки assignment).
+-- У ученика одна работа на задание: повторная отправка заменяет ее,
+-- пока преподаватель не выставил оценку.
+CREATE TABLE IF NOT EXISTS assignment_submissions (
+    id SERIAL PRIMARY KEY,
+    material_id INTEGER NOT NULL REFERENCES materials(id) ON DELETE CASCADE,
+    block_id VARCHAR(100) NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    material_revision INTEGER NOT NULL,
+    text TEXT,
+    file_url VARCHAR(500),
+    file_name VARCHAR(255),
+    status VARCHAR(20) NOT NULL DEFAULT 'submitted' CHECK (status IN ('submitted', 'graded', 'returned')),
+    score DECIMAL(8,2),
+    max_score DECIMAL(8,2) NOT NULL,
+    feedback TEXT, -- комментарий преподавателя
+    graded_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    graded_at TIMESTAMP WITH TIME ZONE,
+    submitted_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (material_id, block_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_assignment_submissions_user ON assignment_submissions(user_id);
+CREATE INDEX IF NOT EXISTS idx_assignment_submissions_queue ON assignment_submissions(material_id, status);
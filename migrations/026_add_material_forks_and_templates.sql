@@ -0,0 +1,36 @@
+-- Копии материалов. forked_from - материал-источник, forked_revision - его
+-- опубликованная версия, с которой снята копия. original_author_id - автор
+-- оригинала: сохраняется и у копий копий, и после удаления источника.
+ALTER TABLE materials ADD COLUMN IF NOT EXISTS forked_from INTEGER REFERENCES materials(id) ON DELETE SET NULL;
+ALTER TABLE materials ADD COLUMN IF NOT EXISTS forked_revision INTEGER;
+ALTER TABLE materials ADD COLUMN IF NOT EXISTS original_author_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+
+-- Шаблон: автор разрешает создавать по материалу новые материалы
+ALTER TABLE materials ADD COLUMN IF NOT EXISTS is_template BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_materials_forked_from ON materials(forked_from);
+
+-- Галерея шаблонов, которую ведут администраторы
+CREATE TABLE IF NOT EXISTS template_gallery (
+    material_id INTEGER PRIMARY KEY REFERENCES materials(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL DEFAULT 0,
+    description TEXT,
+    added_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    added_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_template_gallery_position ON template_gallery(position);
+
+-- Право вести галерею шаблонов. Выдается администраторам только при первом
+-- появлении права, чтобы не вернуть его после ручного отзыва.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM permissions WHERE name = 'template.manage') THEN
+        INSERT INTO permissions (name, description)
+        VALUES ('template.manage', 'Ведение галереи шаблонов');
+
+        INSERT INTO role_permissions (role, permission)
+        VALUES ('admin', 'template.manage')
+        ON CONFLICT DO NOTHING;
+    END IF;
+END $$;
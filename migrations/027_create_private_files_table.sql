@@ -0,0 +1,17 @@
+-- Закрытые файлы: документы заявок на верификацию и файлы сданных заданий.
+-- Хранятся вне публичных каталогов и отдаются только через /api/v1/files/{id}
+-- после проверки доступа. id - непрозрачный идентификатор, по нему нельзя
+-- угадать ни владельца, ни путь в хранилище.
+CREATE TABLE IF NOT EXISTS private_files (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(32) NOT NULL,
+    storage VARCHAR(16) NOT NULL, -- local, s3
+    storage_key TEXT NOT NULL,
+    file_name TEXT NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_private_files_user ON private_files(user_id);
@@ -0,0 +1,5 @@
+-- Файлы сданных заданий хранятся как закрытые файлы (private_files).
+-- file_url остается только у работ, сданных до этого.
+ALTER TABLE assignment_submissions ADD COLUMN IF NOT EXISTS file_id VARCHAR(36) REFERENCES private_files(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_assignment_submissions_file ON assignment_submissions(file_id);
@@ -0,0 +1,24 @@
+-- Номер попытки ученика по тесту. Уникальный номер не дает двум одновременным
+-- запросам обойти ограничение числа попыток (maxAttempts в блоке теста).
+ALTER TABLE quiz_attempts ADD COLUMN IF NOT EXISTS attempt_number INTEGER;
+
+UPDATE quiz_attempts a SET attempt_number = n.num
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY user_id, material_id, block_id ORDER BY created_at, id) AS num
+    FROM quiz_attempts
+) n
+WHERE a.id = n.id AND a.attempt_number IS NULL;
+
+ALTER TABLE quiz_attempts ALTER COLUMN attempt_number SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_quiz_attempts_number ON quiz_attempts(user_id, material_id, block_id, attempt_number);
+
+-- Тесты, результаты которых преподаватель открыл ученикам до того,
+-- как у них закончились попытки
+CREATE TABLE IF NOT EXISTS quiz_result_releases (
+    material_id INTEGER NOT NULL REFERENCES materials(id) ON DELETE CASCADE,
+    block_id VARCHAR(100) NOT NULL,
+    released_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    released_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (material_id, block_id)
+);
@@ -0,0 +1,13 @@
+-- Выданные challenge токены второго шага входа. Токен одноразовый:
+-- запись удаляется при успешной проверке кода, повторно предъявить его нельзя
+CREATE TABLE IF NOT EXISTS two_factor_challenges (
+    token_id VARCHAR(64) PRIMARY KEY, -- jti challenge токена
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_two_factor_challenges_expires_at ON two_factor_challenges(expires_at);
+
+-- Секрет TOTP хранится зашифрованным (TOTP_ENCRYPTION_KEY), шифртекст длиннее base32
+ALTER TABLE user_totp ALTER COLUMN secret TYPE TEXT;